@@ -0,0 +1,24 @@
+package config
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+const defaultAlertDedupWindow = 5 * time.Minute
+
+// AlertDedupWindow is how long after the last alert of a patient and diagnosis a new one is grouped into the
+// same incident instead of being notified again. Zero disables the deduplication.
+var AlertDedupWindow time.Duration
+
+// LoadAlertConfig loads the alert deduplication settings from environment variables
+func LoadAlertConfig() {
+	if os.Getenv("ALERT_DEDUP_WINDOW") == "0" {
+		AlertDedupWindow = 0
+	} else {
+		AlertDedupWindow = durationFromEnv("ALERT_DEDUP_WINDOW", defaultAlertDedupWindow)
+	}
+
+	log.Printf("Alert dedup window loaded: %s", AlertDedupWindow)
+}
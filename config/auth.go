@@ -0,0 +1,101 @@
+package config
+
+import (
+	"encoding/base64"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	defaultServiceTokenTTL        = time.Hour
+	defaultAccessTokenTTL         = 15 * time.Minute
+	defaultRefreshTokenTTL        = 7 * 24 * time.Hour
+	defaultTokenPurgeInterval     = time.Hour
+	defaultStreamTokenTTL         = time.Minute
+	defaultJWTSigningAlgorithm    = "RS256"
+	defaultJWTIssuer              = "biometric-data-backend"
+	defaultJWTKeyRotationInterval = 30 * 24 * time.Hour
+	defaultJWTKeyPublishLead      = time.Hour
+	defaultJWTKeyCheckInterval    = 5 * time.Minute
+	// signingKeyEncryptionKeySize is the size of the AES-256 key encrypting the private signing keys
+	signingKeyEncryptionKeySize = 32
+)
+
+var (
+	// ServiceTokenTTL is how long the tokens issued to service accounts are valid
+	ServiceTokenTTL time.Duration
+	// AccessTokenTTL is how long the access tokens issued to users at login or refresh are valid
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token can be left unused before the user has to log in again
+	RefreshTokenTTL time.Duration
+	// StreamTokenTTL is how long the tokens opening the alert stream from a browser are valid
+	StreamTokenTTL time.Duration
+	// TokenPurgeInterval is how often expired refresh tokens and revoked token IDs are deleted
+	TokenPurgeInterval time.Duration
+	// JWTSigningAlgorithm is the algorithm of the new signing keys, RS256 or EdDSA
+	JWTSigningAlgorithm string
+	// JWTIssuer is the issuer (iss) of the tokens, checked when they are verified
+	JWTIssuer string
+	// JWTKeyRotationInterval is how long a signing key signs tokens before it is replaced
+	JWTKeyRotationInterval time.Duration
+	// JWTKeyPublishLead is how long a new signing key is published in the JWKS before it signs tokens,
+	// so the services verifying tokens can fetch it first
+	JWTKeyPublishLead time.Duration
+	// JWTKeyCheckInterval is how often the signing keys are reloaded and checked for rotation
+	JWTKeyCheckInterval time.Duration
+	// JWTKeyRetention is how long a replaced signing key stays published, as long as the tokens it signed live
+	JWTKeyRetention time.Duration
+	// SigningKeyEncryptionKey is the AES-256 key the private signing keys are encrypted with in the database,
+	// nil when SIGNING_KEY_ENCRYPTION_KEY is missing or invalid
+	SigningKeyEncryptionKey []byte
+)
+
+// LoadAuthConfig loads the token settings from environment variables
+func LoadAuthConfig() {
+	ServiceTokenTTL = durationFromEnv("SERVICE_TOKEN_TTL", defaultServiceTokenTTL)
+	AccessTokenTTL = durationFromEnv("ACCESS_TOKEN_TTL", defaultAccessTokenTTL)
+	RefreshTokenTTL = durationFromEnv("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL)
+	StreamTokenTTL = durationFromEnv("STREAM_TOKEN_TTL", defaultStreamTokenTTL)
+	TokenPurgeInterval = durationFromEnv("TOKEN_PURGE_INTERVAL", defaultTokenPurgeInterval)
+
+	JWTSigningAlgorithm = os.Getenv("JWT_SIGNING_ALGORITHM")
+	switch JWTSigningAlgorithm {
+	case "RS256", "EdDSA":
+	case "":
+		JWTSigningAlgorithm = defaultJWTSigningAlgorithm
+	default:
+		log.Printf("Invalid JWT_SIGNING_ALGORITHM %q, using %s", JWTSigningAlgorithm, defaultJWTSigningAlgorithm)
+		JWTSigningAlgorithm = defaultJWTSigningAlgorithm
+	}
+
+	JWTIssuer = os.Getenv("JWT_ISSUER")
+	if JWTIssuer == "" {
+		JWTIssuer = defaultJWTIssuer
+	}
+
+	JWTKeyRotationInterval = durationFromEnv("JWT_KEY_ROTATION_INTERVAL", defaultJWTKeyRotationInterval)
+	JWTKeyPublishLead = durationFromEnv("JWT_KEY_PUBLISH_LEAD", defaultJWTKeyPublishLead)
+	JWTKeyCheckInterval = durationFromEnv("JWT_KEY_CHECK_INTERVAL", defaultJWTKeyCheckInterval)
+	JWTKeyRetention = max(AccessTokenTTL, ServiceTokenTTL)
+	SigningKeyEncryptionKey = signingKeyEncryptionKeyFromEnv()
+
+	log.Printf("Token TTLs loaded: access %s, refresh %s, service %s", AccessTokenTTL, RefreshTokenTTL, ServiceTokenTTL)
+	log.Printf("JWT signing loaded: %s keys rotated every %s", JWTSigningAlgorithm, JWTKeyRotationInterval)
+}
+
+// signingKeyEncryptionKeyFromEnv decodes the base64 encoded SIGNING_KEY_ENCRYPTION_KEY, which must be 32 bytes
+func signingKeyEncryptionKeyFromEnv() []byte {
+	value := os.Getenv("SIGNING_KEY_ENCRYPTION_KEY")
+	if value == "" {
+		log.Println("SIGNING_KEY_ENCRYPTION_KEY is not set")
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(value)
+	if err != nil || len(key) != signingKeyEncryptionKeySize {
+		log.Printf("Invalid SIGNING_KEY_ENCRYPTION_KEY, expected %d base64 encoded bytes", signingKeyEncryptionKeySize)
+		return nil
+	}
+	return key
+}
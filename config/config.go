@@ -53,6 +53,16 @@ func LoadConfig() {
 	utils.ExecuteMigrations()
 	// Load Redis configuration
 	LoadRedisConfig()
+	// Load alert deduplication settings
+	LoadAlertConfig()
+	// Load alert escalation policy
+	LoadEscalationConfig()
+	// Load notification channels
+	LoadNotifierConfig()
+	// Load monitoring device pairing and connectivity settings
+	LoadDeviceConfig()
+	// Load token settings
+	LoadAuthConfig()
 }
 
 // CloseDB ensures the database connection is closed (if necessary)
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	defaultDeviceConnectingTimeout = 2 * time.Minute
+	defaultDeviceSweepInterval     = 30 * time.Second
+	defaultDeviceOfflineThreshold  = 2 * time.Minute
+	defaultDeviceKeyRotationGrace  = 24 * time.Hour
+)
+
+var (
+	// DeviceConnectingTimeout is how long a paired device may stay Connecting without sending readings
+	// before it is unpaired and freed again
+	DeviceConnectingTimeout time.Duration
+	// DeviceSweepInterval is how often devices stuck Connecting or gone silent are looked for
+	DeviceSweepInterval time.Duration
+	// DeviceOfflineThreshold is how long a device may go without a heartbeat or readings before it is offline
+	DeviceOfflineThreshold time.Duration
+	// DeviceKeyRotationGrace is how long a rotated device API key keeps working, so the device can be reconfigured
+	DeviceKeyRotationGrace time.Duration
+)
+
+// LoadDeviceConfig loads the monitoring device pairing, connectivity and credential settings from environment variables
+func LoadDeviceConfig() {
+	DeviceConnectingTimeout = durationFromEnv("DEVICE_CONNECTING_TIMEOUT", defaultDeviceConnectingTimeout)
+	DeviceSweepInterval = durationFromEnv("DEVICE_SWEEP_INTERVAL", defaultDeviceSweepInterval)
+	DeviceOfflineThreshold = durationFromEnv("DEVICE_OFFLINE_THRESHOLD", defaultDeviceOfflineThreshold)
+	DeviceKeyRotationGrace = durationFromEnv("DEVICE_KEY_ROTATION_GRACE", defaultDeviceKeyRotationGrace)
+
+	log.Printf("Device connecting timeout loaded: %s, offline threshold: %s", DeviceConnectingTimeout, DeviceOfflineThreshold)
+}
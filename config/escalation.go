@@ -0,0 +1,73 @@
+package config
+
+import (
+	"biometric-data-backend/models/enum"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// EscalationStep is an action executed when an alert stays unattended for the given time
+type EscalationStep struct {
+	After  time.Duration
+	Action enum.EscalationAction
+}
+
+const (
+	defaultEscalationPolicy   = "2m:renotify,5m:care_team,10m:admins"
+	defaultEscalationInterval = 30 * time.Second
+)
+
+var (
+	EscalationSteps    []EscalationStep
+	EscalationInterval time.Duration
+)
+
+// LoadEscalationConfig loads the escalation policy from environment variables.
+// ESCALATION_POLICY is a comma separated list of <duration>:<action> steps, ordered by duration.
+func LoadEscalationConfig() {
+	policy := os.Getenv("ESCALATION_POLICY")
+	if policy == "" {
+		policy = defaultEscalationPolicy
+	}
+
+	steps, ok := parseEscalationPolicy(policy)
+	if !ok {
+		log.Printf("Invalid ESCALATION_POLICY %q, using default policy", policy)
+		steps, _ = parseEscalationPolicy(defaultEscalationPolicy)
+	}
+	EscalationSteps = steps
+
+	EscalationInterval = durationFromEnv("ESCALATION_CHECK_INTERVAL", defaultEscalationInterval)
+
+	log.Printf("Escalation policy loaded with %d steps", len(EscalationSteps))
+}
+
+func parseEscalationPolicy(policy string) ([]EscalationStep, bool) {
+	var steps []EscalationStep
+	for _, rawStep := range strings.Split(policy, ",") {
+		parts := strings.SplitN(strings.TrimSpace(rawStep), ":", 2)
+		if len(parts) != 2 {
+			return nil, false
+		}
+
+		after, err := time.ParseDuration(parts[0])
+		if err != nil || after < 0 {
+			return nil, false
+		}
+
+		action := enum.EscalationAction(parts[1])
+		if !action.IsValid() {
+			return nil, false
+		}
+
+		// Steps must be ordered so each one happens after the previous
+		if len(steps) > 0 && after <= steps[len(steps)-1].After {
+			return nil, false
+		}
+
+		steps = append(steps, EscalationStep{After: after, Action: action})
+	}
+	return steps, len(steps) > 0
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"biometric-data-backend/models/enum"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseEscalationPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   []EscalationStep
+		wantOk bool
+	}{
+		{
+			name:   "default policy",
+			policy: defaultEscalationPolicy,
+			want: []EscalationStep{
+				{After: 2 * time.Minute, Action: enum.EscalationActionRenotify},
+				{After: 5 * time.Minute, Action: enum.EscalationActionCareTeam},
+				{After: 10 * time.Minute, Action: enum.EscalationActionAdmins},
+			},
+			wantOk: true,
+		},
+		{
+			name:   "spaces around steps",
+			policy: " 30s:renotify , 1m30s:admins",
+			want: []EscalationStep{
+				{After: 30 * time.Second, Action: enum.EscalationActionRenotify},
+				{After: 90 * time.Second, Action: enum.EscalationActionAdmins},
+			},
+			wantOk: true,
+		},
+		{name: "empty", policy: ""},
+		{name: "missing action", policy: "2m"},
+		{name: "unknown action", policy: "2m:page"},
+		{name: "invalid duration", policy: "soon:renotify"},
+		{name: "negative duration", policy: "-1m:renotify"},
+		{name: "unordered steps", policy: "5m:care_team,2m:renotify"},
+		{name: "repeated duration", policy: "2m:renotify,2m:admins"},
+		{name: "trailing comma", policy: "2m:renotify,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseEscalationPolicy(tt.policy)
+			if ok != tt.wantOk {
+				t.Fatalf("ok %v, want %v", ok, tt.wantOk)
+			}
+			if tt.wantOk && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"biometric-data-backend/notifier"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultNotifierChannels    = notifier.ChannelExpo
+	defaultExpoBaseURL         = "https://exp.host"
+	defaultSMTPPort            = "587"
+	defaultOutboxCheckInterval = 5 * time.Second
+	defaultOutboxMaxAttempts   = 8
+	defaultPushReceiptInterval = 5 * time.Minute
+	defaultPushReceiptDelay    = 15 * time.Minute
+)
+
+var (
+	Notifiers           []notifier.Notifier
+	OutboxCheckInterval time.Duration
+	OutboxMaxAttempts   int
+	PushReceiptInterval time.Duration
+	PushReceiptDelay    time.Duration
+)
+
+// LoadNotifierConfig builds the notification channels selected in NOTIFIER_CHANNELS
+// (comma separated list of expo, webhook, email and log) and the outbox settings.
+func LoadNotifierConfig() {
+	channels := os.Getenv("NOTIFIER_CHANNELS")
+	if channels == "" {
+		channels = defaultNotifierChannels
+	}
+
+	Notifiers = nil
+	for _, channel := range strings.Split(channels, ",") {
+		switch strings.TrimSpace(channel) {
+		case notifier.ChannelExpo:
+			baseURL := os.Getenv("EXPO_BASE_URL")
+			if baseURL == "" {
+				baseURL = defaultExpoBaseURL
+			}
+			Notifiers = append(Notifiers, notifier.NewExpoNotifier(baseURL))
+		case notifier.ChannelWebhook:
+			url := os.Getenv("NOTIFIER_WEBHOOK_URL")
+			if url == "" {
+				log.Println("NOTIFIER_WEBHOOK_URL not set, skipping webhook notifier")
+				continue
+			}
+			Notifiers = append(Notifiers, notifier.NewWebhookNotifier(url))
+		case notifier.ChannelEmail:
+			host := os.Getenv("SMTP_HOST")
+			if host == "" {
+				log.Println("SMTP_HOST not set, skipping email notifier")
+				continue
+			}
+			port := os.Getenv("SMTP_PORT")
+			if port == "" {
+				port = defaultSMTPPort
+			}
+			var recipients []string
+			for _, recipient := range strings.Split(os.Getenv("SMTP_TO"), ",") {
+				if recipient = strings.TrimSpace(recipient); recipient != "" {
+					recipients = append(recipients, recipient)
+				}
+			}
+			Notifiers = append(Notifiers, notifier.NewEmailNotifier(
+				host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"), recipients,
+			))
+		case notifier.ChannelLog:
+			Notifiers = append(Notifiers, notifier.NewLogNotifier(os.Getenv("NOTIFIER_LOG_FILE")))
+		default:
+			log.Printf("Unknown notification channel %q, skipping", channel)
+		}
+	}
+
+	if len(Notifiers) == 0 {
+		log.Println("No notification channel configured, notifications will only be logged")
+		Notifiers = append(Notifiers, notifier.NewLogNotifier(""))
+	}
+
+	OutboxCheckInterval = durationFromEnv("OUTBOX_CHECK_INTERVAL", defaultOutboxCheckInterval)
+	PushReceiptInterval = durationFromEnv("PUSH_RECEIPT_CHECK_INTERVAL", defaultPushReceiptInterval)
+	PushReceiptDelay = durationFromEnv("PUSH_RECEIPT_DELAY", defaultPushReceiptDelay)
+
+	OutboxMaxAttempts = defaultOutboxMaxAttempts
+	if maxAttempts := os.Getenv("OUTBOX_MAX_ATTEMPTS"); maxAttempts != "" {
+		parsed, err := strconv.Atoi(maxAttempts)
+		if err != nil || parsed <= 0 {
+			log.Printf("Invalid OUTBOX_MAX_ATTEMPTS %q, using %d", maxAttempts, defaultOutboxMaxAttempts)
+		} else {
+			OutboxMaxAttempts = parsed
+		}
+	}
+
+	log.Printf("Notification channels loaded: %d", len(Notifiers))
+}
+
+// durationFromEnv reads a positive duration from an environment variable, falling back to the given default
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Invalid %s %q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
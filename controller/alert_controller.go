@@ -1,14 +1,21 @@
 package controller
 
 import (
+	"biometric-data-backend/middleware"
 	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/repository"
 	"biometric-data-backend/service"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type AlertController struct {
@@ -30,6 +37,15 @@ func (ac *AlertController) CreateAlert(c *gin.Context) {
 		return
 	}
 
+	// Devices can only report alerts of their own
+	if deviceID, ok := middleware.GetDeviceID(c); ok {
+		if alertDTO.DeviceID != "" && alertDTO.DeviceID != deviceID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "A device can only act for itself"})
+			return
+		}
+		alertDTO.DeviceID = deviceID
+	}
+
 	alertResponse, err := ac.AlertService.CreateAlert(&alertDTO)
 	if err != nil {
 		log.Printf("Failed to create alert: %v", err)
@@ -42,6 +58,11 @@ func (ac *AlertController) CreateAlert(c *gin.Context) {
 		return
 	}
 
+	if alertResponse.Deduplicated {
+		c.JSON(http.StatusOK, alertResponse)
+		return
+	}
+
 	c.JSON(http.StatusCreated, alertResponse) //gin.H{"message": "Alert created successfully", "alert": alertDTO})
 }
 
@@ -69,18 +90,63 @@ func (ac *AlertController) GetAlertByID(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, alert.Version)
 	c.JSON(http.StatusOK, gin.H{"alert": alert})
 }
 
+// alertSearchParams are the query parameters that make GET /alerts a filtered search
+var alertSearchParams = []string{"patient_id", "dni", "device_id", "diagnosis", "status", "attended_by_id", "location", "ward_id", "from", "to", "min_confidence", "sort", "order", "cursor"}
+
 // GetAllAlerts handles retrieving all alerts with optional period filtering and pagination
 func (ac *AlertController) GetAllAlerts(c *gin.Context) {
 	period := c.Query("period")
 	timezone := c.Query("timezone")
+	groupBy := c.Query("group_by")
 
 	var alerts []*dto.AlertDTO
 	var totalCount int
 	var err error
 
+	recentWindow := service.DefaultRecentAlertsWindow
+	if recentHours := c.Query("recent_hours"); recentHours != "" {
+		hours, err := strconv.Atoi(recentHours)
+		if err != nil || hours < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recent_hours: must be a positive number of hours"})
+			return
+		}
+		recentWindow = time.Duration(hours) * time.Hour
+	}
+
+	if groupBy != "" {
+		if groupBy != "incident" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_by: must be 'incident'"})
+			return
+		}
+
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			page = 1
+		}
+
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+		if err != nil || limit < 1 {
+			limit = 10
+		}
+
+		incidents, totalCount, err := ac.AlertService.GetAlertIncidents(page, limit)
+		if err != nil {
+			log.Printf("Error retrieving alert incidents: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alert incidents"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"incidents":  incidents,
+			"totalCount": totalCount,
+		})
+		return
+	}
+
 	if period != "" {
 		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 		if err != nil || page < 1 {
@@ -93,7 +159,7 @@ func (ac *AlertController) GetAllAlerts(c *gin.Context) {
 		}
 
 		// Fetch paginated alerts and total count by period
-		alerts, totalCount, err = ac.AlertService.GetAllAlertsByPeriod(period, page, limit)
+		alerts, totalCount, err = ac.AlertService.GetAllAlertsByPeriod(period, recentWindow, page, limit)
 		if err != nil {
 			log.Printf("Error retrieving alerts by period: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alerts by period"})
@@ -108,6 +174,11 @@ func (ac *AlertController) GetAllAlerts(c *gin.Context) {
 		return
 	}
 
+	if isAlertSearch(c) {
+		ac.searchAlerts(c, recentWindow)
+		return
+	}
+
 	// Handle case without status if needed (currently returns all alerts without pagination)
 	if timezone != "" {
 		// Fetch alerts from today
@@ -121,20 +192,120 @@ func (ac *AlertController) GetAllAlerts(c *gin.Context) {
 			"alerts": alerts,
 		})
 	} else {
-		alerts, err = ac.AlertService.GetAllAlerts()
+		// Only the most recent alerts are answered, along with the cursor of the search to the older ones
+		limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+		page, err := ac.AlertService.GetLatestAlerts(clampPageLimit(limit, err, defaultPageLimit))
 		if err != nil {
 			log.Printf("Error retrieving all alerts: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alerts"})
 			return
 		}
-		totalCount = len(alerts)
 
-		c.JSON(http.StatusOK, gin.H{
-			"alerts":     alerts,
-			"totalCount": totalCount,
-		})
+		response := gin.H{
+			"alerts":     page.Items,
+			"totalCount": *page.Total,
+		}
+		if page.NextCursor != "" {
+			response["next_cursor"] = page.NextCursor
+		}
+		c.JSON(http.StatusOK, response)
+	}
+
+}
+
+// isAlertSearch reports whether the request filters, sorts or pages the alerts with a cursor. A limit or recent_hours
+// alone keeps the previous response of GET /alerts.
+func isAlertSearch(c *gin.Context) bool {
+	for _, param := range alertSearchParams {
+		if c.Query(param) != "" {
+			return true
+		}
+	}
+	_, hasCursor := c.GetQuery("cursor")
+	return hasCursor
+}
+
+// searchAlerts handles the filtered, sorted and cursor paginated alert search
+func (ac *AlertController) searchAlerts(c *gin.Context, recentWindow time.Duration) {
+	filters := dto.AlertFilter{
+		PatientID:    c.Query("patient_id"),
+		DNI:          c.Query("dni"),
+		DeviceID:     c.Query("device_id"),
+		Diagnosis:    c.Query("diagnosis"),
+		AttendedByID: c.Query("attended_by_id"),
+		Location:     c.Query("location"),
+		WardID:       c.Query("ward_id"),
+	}
+	for _, id := range []string{filters.PatientID, filters.AttendedByID, filters.WardID} {
+		if id != "" {
+			if _, err := uuid.Parse(id); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient_id, attended_by_id or ward_id: must be a UUID"})
+				return
+			}
+		}
+	}
+	if status := c.Query("status"); status != "" {
+		filters.Statuses = strings.Split(status, ",")
+	}
+
+	if c.Query("recent_hours") != "" {
+		from := time.Now().UTC().Add(-recentWindow)
+		filters.From = &from
+	}
+	if from := c.Query("from"); from != "" {
+		parsed, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from': must be an RFC3339 timestamp"})
+			return
+		}
+		filters.From = &parsed
+	}
+	if to := c.Query("to"); to != "" {
+		parsed, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to': must be an RFC3339 timestamp"})
+			return
+		}
+		filters.To = &parsed
+	}
+	if minConfidence := c.Query("min_confidence"); minConfidence != "" {
+		parsed, err := strconv.ParseFloat(minConfidence, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid min_confidence: must be a number"})
+			return
+		}
+		filters.MinConfidence = &parsed
+	}
+
+	sort := dto.AlertSort{Field: c.Query("sort"), Descending: true}
+	switch c.DefaultQuery("order", "desc") {
+	case "asc":
+		sort.Descending = false
+	case "desc":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order: must be 'asc' or 'desc'"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit: must be between 1 and " + strconv.Itoa(maxPageLimit)})
+		return
+	}
+
+	result, err := ac.AlertService.SearchAlerts(filters, sort, c.Query("cursor"), limit)
+	if err != nil {
+		log.Printf("Error searching alerts: %v", err)
+		switch {
+		case errors.Is(err, service.ErrInvalidAlertSort), errors.Is(err, service.ErrInvalidAlertStatusFilter), errors.Is(err, service.ErrInvalidAlertCursor):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search alerts"})
+		}
+		return
 	}
 
+	c.JSON(http.StatusOK, result)
 }
 
 // UpdateAlert handles updating an existing alert
@@ -155,10 +326,16 @@ func (ac *AlertController) UpdateAlert(c *gin.Context) {
 		return
 	}
 
-	err = ac.AlertService.UpdateAlert(alertID, &alertDTO)
+	var ok bool
+	if alertDTO.ExpectedVersion, ok = ifMatchVersion(c); !ok {
+		return
+	}
+
+	principal, _ := middleware.GetPrincipal(c)
+	err = ac.AlertService.UpdateAlert(alertID, &alertDTO, principal)
 	if err != nil {
 		log.Printf("Failed to update alert: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert"})
+		writeAlertTransitionError(c, err)
 		return
 	}
 
@@ -185,3 +362,131 @@ func (ac *AlertController) DeleteAlert(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Alert deleted successfully"})
 }
+
+// ClaimAlert handles a doctor taking charge of an alert. Only one doctor can claim an alert: the claim fails with
+// 409 and the current owner if the alert was already claimed.
+func (ac *AlertController) ClaimAlert(c *gin.Context) {
+	ac.transitionAlert(c, enum.AlertStatusAcknowledged)
+}
+
+// AcknowledgeAlert handles a doctor taking charge of an alert, the same as ClaimAlert
+func (ac *AlertController) AcknowledgeAlert(c *gin.Context) {
+	ac.ClaimAlert(c)
+}
+
+// StartAlert handles starting the treatment of an acknowledged alert
+func (ac *AlertController) StartAlert(c *gin.Context) {
+	ac.transitionAlert(c, enum.AlertStatusInProgress)
+}
+
+// ResolveAlert handles closing an alert with its final diagnosis
+func (ac *AlertController) ResolveAlert(c *gin.Context) {
+	ac.transitionAlert(c, enum.AlertStatusResolved)
+}
+
+// MarkAlertFalsePositive handles closing an alert raised without a real condition
+func (ac *AlertController) MarkAlertFalsePositive(c *gin.Context) {
+	ac.transitionAlert(c, enum.AlertStatusFalsePositive)
+}
+
+// EscalateAlert handles escalating a new alert by hand
+func (ac *AlertController) EscalateAlert(c *gin.Context) {
+	ac.transitionAlert(c, enum.AlertStatusEscalated)
+}
+
+// ReleaseAlert handles giving up an acknowledged alert, which becomes New again
+func (ac *AlertController) ReleaseAlert(c *gin.Context) {
+	ac.transitionAlert(c, enum.AlertStatusNew)
+}
+
+// transitionAlert moves the alert of the request to the given status on behalf of the current user
+func (ac *AlertController) transitionAlert(c *gin.Context, to enum.AlertStatus) {
+	id := c.Param("id")
+
+	alertID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	// The body is optional
+	var transitionDTO dto.AlertTransitionDTO
+	if c.Request.ContentLength > 0 && !bindJSON(c, &transitionDTO) {
+		return
+	}
+
+	var ok bool
+	if transitionDTO.ExpectedVersion, ok = ifMatchVersion(c); !ok {
+		return
+	}
+
+	principal, _ := middleware.GetPrincipal(c)
+	if to == enum.AlertStatusAcknowledged {
+		err = ac.AlertService.ClaimAlert(alertID, &transitionDTO, principal)
+	} else {
+		err = ac.AlertService.TransitionAlert(alertID, to, &transitionDTO, principal)
+	}
+	if err != nil {
+		log.Printf("Failed to move alert to %s: %v", to, err)
+		writeAlertTransitionError(c, err)
+		return
+	}
+
+	alert, err := ac.AlertService.GetAlertByID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving alert: %v", err)
+	}
+	if alert != nil {
+		setVersionETag(c, alert.Version)
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert moved to " + string(to), "alert": alert})
+}
+
+// GetAlertEvents handles retrieving the status history of an alert
+func (ac *AlertController) GetAlertEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	alertID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	alertEvents, err := ac.AlertService.GetAlertEvents(alertID)
+	if err != nil {
+		log.Printf("Error retrieving alert events: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alert events"})
+		return
+	}
+
+	if alertEvents == nil {
+		log.Printf("Alert not found with AlertID: %v", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": alertEvents})
+}
+
+// writeAlertTransitionError maps the errors of an alert transition to a response
+func writeAlertTransitionError(c *gin.Context, err error) {
+	var claimedErr *service.AlertClaimedError
+	switch {
+	case errors.As(err, &claimedErr):
+		setVersionETag(c, claimedErr.Version)
+		c.JSON(http.StatusConflict, gin.H{"error": "Alert already claimed", "attended_by": claimedErr.AttendedBy})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+	case errors.Is(err, service.ErrInvalidAlertTransition), errors.Is(err, service.ErrFinalDiagnosisNotAllowed),
+		errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlertDoctorRequired), errors.Is(err, service.ErrFinalDiagnosisRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlertDoctorForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update alert"})
+	}
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"biometric-data-backend/events"
+	"biometric-data-backend/middleware"
+	"biometric-data-backend/service"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const streamHeartbeatInterval = 30 * time.Second
+
+// alertStreamEventTypes are the events pushed to the alert stream clients
+var alertStreamEventTypes = []events.EventType{
+	events.AlertCreated,
+	events.AlertAttended,
+	events.AlertLiberated,
+	events.AlertEscalated,
+	events.AlertStatusChanged,
+	events.AlertRepeated,
+	events.DeviceOffline,
+	events.DeviceOnline,
+}
+
+var errWebSocketOriginNotAllowed = errors.New("origin not allowed")
+
+type AlertStreamController struct {
+	EventBus      *events.Bus
+	TokenService  service.TokenService
+	AllowedOrigin string
+}
+
+// NewAlertStreamController creates the alert stream controller. WebSocket connections are only accepted from the
+// allowed web origin, or from native apps, which send no origin or the X-App-Origin header browsers cannot set.
+func NewAlertStreamController(eventBus *events.Bus, tokenService service.TokenService, allowedOrigin string) *AlertStreamController {
+	return &AlertStreamController{
+		EventBus:      eventBus,
+		TokenService:  tokenService,
+		AllowedOrigin: allowedOrigin,
+	}
+}
+
+// IssueStreamToken handles issuing a short-lived token to open the alert stream from a browser
+func (sc *AlertStreamController) IssueStreamToken(c *gin.Context) {
+	claims, ok := middleware.GetTokenClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	streamToken, err := sc.TokenService.IssueStreamToken(claims)
+	if err != nil {
+		log.Printf("Failed to issue stream token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue stream token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, streamToken)
+}
+
+// StreamAlerts pushes alert events to the client using Server-Sent Events
+func (sc *AlertStreamController) StreamAlerts(c *gin.Context) {
+	stream, unsubscribe := sc.EventBus.Subscribe(alertStreamEventTypes...)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	log.Println("Alert stream (SSE) client connected")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-stream:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", gin.H{"timestamp": time.Now().UTC()})
+			return true
+		}
+	})
+	log.Println("Alert stream (SSE) client disconnected")
+}
+
+// StreamAlertsWebSocket pushes alert events to the client over a WebSocket connection
+func (sc *AlertStreamController) StreamAlertsWebSocket(c *gin.Context) {
+	server := websocket.Server{
+		// CORS does not apply to WebSocket upgrades, so a page of another site could open the socket with the
+		// credentials of the user; the caller itself is authenticated by the JWT
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			if config.Origin == nil || config.Origin.String() == sc.AllowedOrigin || r.Header.Get("X-App-Origin") == "ReactNativeApp" {
+				return nil
+			}
+			log.Printf("Rejected alert stream WebSocket from origin %s", config.Origin)
+			return errWebSocketOriginNotAllowed
+		},
+		Handler: sc.handleWebSocket,
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (sc *AlertStreamController) handleWebSocket(ws *websocket.Conn) {
+	defer func(ws *websocket.Conn) {
+		if err := ws.Close(); err != nil {
+			log.Printf("Error closing alert stream WebSocket: %v", err)
+		}
+	}(ws)
+
+	stream, unsubscribe := sc.EventBus.Subscribe(alertStreamEventTypes...)
+	defer unsubscribe()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	// Incoming messages are ignored; reading only detects when the client goes away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		var discard string
+		for {
+			if err := websocket.Message.Receive(ws, &discard); err != nil {
+				return
+			}
+		}
+	}()
+
+	log.Println("Alert stream (WebSocket) client connected")
+	for {
+		select {
+		case <-closed:
+			log.Println("Alert stream (WebSocket) client disconnected")
+			return
+		case event, ok := <-stream:
+			if !ok {
+				return
+			}
+			if err := websocket.JSON.Send(ws, event); err != nil {
+				log.Printf("Failed to send alert event over WebSocket: %v", err)
+				return
+			}
+		case <-heartbeat.C:
+			heartbeatEvent := gin.H{"type": "heartbeat", "timestamp": time.Now().UTC()}
+			if err := websocket.JSON.Send(ws, heartbeatEvent); err != nil {
+				log.Printf("Failed to send heartbeat over WebSocket: %v", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsController struct {
+	AnalyticsService service.AnalyticsService
+}
+
+func NewAnalyticsController(analyticsService service.AnalyticsService) *AnalyticsController {
+	return &AnalyticsController{
+		AnalyticsService: analyticsService,
+	}
+}
+
+// GetAlertAnalytics handles the alert response-time report over a date range
+func (ac *AnalyticsController) GetAlertAnalytics(c *gin.Context) {
+	query := dto.AlertAnalyticsQuery{
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Timezone: c.Query("timezone"),
+		WardID:   c.Query("ward_id"),
+	}
+
+	analytics, err := ac.AnalyticsService.GetAlertAnalytics(query)
+	if err != nil {
+		log.Printf("Failed to retrieve alert analytics: %v", err)
+		switch {
+		case errors.Is(err, service.ErrInvalidAnalyticsRange), errors.Is(err, service.ErrInvalidAnalyticsTimezone), errors.Is(err, service.ErrInvalidAnalyticsWard):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alert analytics"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
+// GetDiagnosticValidation handles the comparison of the computer diagnosis with the final diagnosis over a date range
+func (ac *AnalyticsController) GetDiagnosticValidation(c *gin.Context) {
+	query := dto.DiagnosticValidationQuery{
+		From:         c.Query("from"),
+		To:           c.Query("to"),
+		Timezone:     c.Query("timezone"),
+		ModelName:    c.Query("model_name"),
+		ModelVersion: c.Query("model_version"),
+	}
+
+	validation, err := ac.AnalyticsService.GetDiagnosticValidation(query)
+	if err != nil {
+		log.Printf("Failed to retrieve diagnostic validation: %v", err)
+		switch {
+		case errors.Is(err, service.ErrInvalidAnalyticsRange), errors.Is(err, service.ErrInvalidAnalyticsTimezone):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve diagnostic validation"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, validation)
+}
@@ -1,26 +1,29 @@
 package controller
 
 import (
+	"biometric-data-backend/middleware"
 	"biometric-data-backend/models/dto"
 	"biometric-data-backend/service"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"log"
 	"net/http"
-	"strconv"
 )
 
 type AuthorizationController struct {
-	UserService service.AuthorizationService
+	UserService  service.AuthorizationService
+	TokenService service.TokenService
 }
 
-func NewAuthorizationController(userService service.AuthorizationService) *AuthorizationController {
+func NewAuthorizationController(userService service.AuthorizationService, tokenService service.TokenService) *AuthorizationController {
 	return &AuthorizationController{
-		UserService: userService,
+		UserService:  userService,
+		TokenService: tokenService,
 	}
 }
 
-// AuthenticateUser handles user login and returns a token if successful
+// AuthenticateUser handles user login and returns an access and a refresh token if successful
 func (uc *AuthorizationController) AuthenticateUser(c *gin.Context) {
 	var loginDTO dto.UserLoginDTO
 	if err := c.ShouldBindJSON(&loginDTO); err != nil {
@@ -29,14 +32,58 @@ func (uc *AuthorizationController) AuthenticateUser(c *gin.Context) {
 		return
 	}
 
-	token, err := uc.UserService.AuthenticateUser(&loginDTO)
+	tokens, err := uc.UserService.AuthenticateUser(&loginDTO)
 	if err != nil {
 		log.Printf("Authentication failed: %v", err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Authentication successful", "token": token})
+	writeAuthTokens(c, "Authentication successful", tokens)
+}
+
+// RefreshTokens handles exchanging a refresh token for a new access and refresh token
+func (uc *AuthorizationController) RefreshTokens(c *gin.Context) {
+	var refreshDTO dto.RefreshTokenDTO
+	if !bindJSON(c, &refreshDTO) {
+		return
+	}
+
+	tokens, err := uc.TokenService.RefreshTokens(refreshDTO.RefreshToken)
+	if err != nil {
+		log.Printf("Failed to refresh tokens: %v", err)
+		if errors.Is(err, service.ErrInvalidRefreshToken) || errors.Is(err, service.ErrRefreshTokenReused) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh tokens"})
+		return
+	}
+
+	writeAuthTokens(c, "Tokens refreshed successfully", tokens)
+}
+
+// Logout handles revoking the token of the caller and its session, or all the sessions of the caller
+func (uc *AuthorizationController) Logout(c *gin.Context) {
+	// The body is optional
+	var logoutDTO dto.LogoutDTO
+	if c.Request.ContentLength > 0 && !bindJSON(c, &logoutDTO) {
+		return
+	}
+
+	claims, ok := middleware.GetTokenClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
+
+	if err := uc.TokenService.Logout(claims, logoutDTO.AllSessions); err != nil {
+		log.Printf("Failed to log out: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 // RegisterUser handles user registration
@@ -73,19 +120,19 @@ func (uc *AuthorizationController) GetUserById(c *gin.Context) {
 }
 
 func (uc *AuthorizationController) GetAllUsers(c *gin.Context) {
+	if request, paginated, ok := cursorPageRequest(c); paginated {
+		if ok {
+			page, err := uc.UserService.GetUsersPage(request)
+			writePage(c, page, err, "Failed to get users")
+		}
+		return
+	}
+
 	var users []*dto.UserDTO
 	var totalCount int
 	var err error
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		limit = 10
-	}
+	page, limit := offsetPageParams(c)
 
 	users, totalCount, err = uc.UserService.GetAllUsers(page, limit)
 	if err != nil {
@@ -136,3 +183,15 @@ func (uc *AuthorizationController) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
+
+func writeAuthTokens(c *gin.Context, message string, tokens *dto.AuthTokensDTO) {
+	// Token responses must not be cached
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"message":       message,
+		"token":         tokens.Token,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+	})
+}
@@ -47,14 +47,17 @@ func (bc *BiometricDataController) GetBiometricDataByID(c *gin.Context) {
 
 // GetAllBiometricData handles retrieving all biometric data
 func (bc *BiometricDataController) GetAllBiometricData(c *gin.Context) {
-	biometrics, err := bc.BiometricDataService.GetAllBiometricData()
-	if err != nil {
-		log.Printf("Error retrieving biometrics: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve biometrics"})
+	request, paginated, ok := cursorPageRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"biometrics": biometrics})
+	page, err := bc.BiometricDataService.GetBiometricDataPage(request)
+	if paginated {
+		writePage(c, page, err, "Failed to retrieve biometrics")
+		return
+	}
+	writeFirstPage(c, "biometrics", page, err, "Failed to retrieve biometrics")
 }
 
 // UpdateBiometricData handles updating an existing biometric record
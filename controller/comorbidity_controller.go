@@ -67,14 +67,17 @@ func (cc *ComorbidityController) GetComorbidityByID(c *gin.Context) {
 
 // GetAllComorbidities handles retrieving all comorbidities
 func (cc *ComorbidityController) GetAllComorbidities(c *gin.Context) {
-	comorbidities, err := cc.ComorbidityService.GetAllComorbidities()
-	if err != nil {
-		log.Printf("Error retrieving comorbidities: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve comorbidities"})
+	request, paginated, ok := cursorPageRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"comorbidities": comorbidities})
+	page, err := cc.ComorbidityService.GetComorbiditiesPage(request)
+	if paginated {
+		writePage(c, page, err, "Failed to retrieve comorbidities")
+		return
+	}
+	writeFirstPage(c, "comorbidities", page, err, "Failed to retrieve comorbidities")
 }
 
 // UpdateComorbidity handles updating an existing comorbidity
@@ -67,14 +67,17 @@ func (cdc *ComputerDiagnosticController) GetComputerDiagnosticByID(c *gin.Contex
 
 // GetAllComputerDiagnostics handles retrieving all computer diagnostics
 func (cdc *ComputerDiagnosticController) GetAllComputerDiagnostics(c *gin.Context) {
-	diagnostics, err := cdc.ComputerDiagnosticService.GetAllComputerDiagnostics()
-	if err != nil {
-		log.Printf("Error retrieving computer diagnostics: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve computer diagnostics"})
+	request, paginated, ok := cursorPageRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"diagnostics": diagnostics})
+	page, err := cdc.ComputerDiagnosticService.GetComputerDiagnosticsPage(request)
+	if paginated {
+		writePage(c, page, err, "Failed to retrieve computer diagnostics")
+		return
+	}
+	writeFirstPage(c, "diagnostics", page, err, "Failed to retrieve computer diagnostics")
 }
 
 // UpdateComputerDiagnostic handles updating an existing computer diagnosis
@@ -1,10 +1,24 @@
 package controller
 
 import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultPageLimit is the page size of the cursor paginated lists when no limit is given
+	defaultPageLimit = 20
+	// defaultOffsetPageLimit is the page size of the lists paginated with page and limit when no limit is given
+	defaultOffsetPageLimit = 10
+	// maxPageLimit bounds the page size of every paginated list
+	maxPageLimit = 100
 )
 
 // Generic function to retrieve a resource by ID (UUID)
@@ -45,3 +59,95 @@ func bindJSON[T any](c *gin.Context, payload *T) bool {
 	}
 	return true
 }
+
+// ifMatchVersion reads the version expected by the client from the If-Match header ("3", "\"3\"" or W/"3").
+// It returns nil when the header is absent, and false after answering 400 when it is malformed.
+func ifMatchVersion(c *gin.Context) (*int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return nil, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil {
+		log.Printf("Invalid If-Match header: %q", header)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header: must be the version of the resource"})
+		return nil, false
+	}
+	return &version, true
+}
+
+// setVersionETag exposes the version of a resource as its ETag, to be sent back in If-Match when updating it
+func setVersionETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// cursorPageRequest reads the cursor pagination parameters of a list: cursor, limit and total=true.
+// Lists answer the page envelope when the cursor parameter is present, empty for the first page, and paginated is
+// true. Without it, the request is for the first page, which the lists answer in their former shape instead of
+// loading the whole table; an invalid limit then falls back to the default or the maximum.
+// It returns false for ok after answering 400 when the limit of a paginated list is invalid.
+func cursorPageRequest(c *gin.Context) (request dto.PageRequest, paginated bool, ok bool) {
+	cursor, paginated := c.GetQuery("cursor")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultPageLimit)))
+	if !paginated {
+		return dto.PageRequest{Limit: clampPageLimit(limit, err, defaultPageLimit)}, false, true
+	}
+
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit: must be between 1 and " + strconv.Itoa(maxPageLimit)})
+		return request, true, false
+	}
+
+	return dto.PageRequest{Cursor: cursor, Limit: limit, WithTotal: c.Query("total") == "true"}, true, true
+}
+
+// offsetPageParams reads the page and limit of the lists paginated with offsets, which also keep their former
+// behaviour. The limit is capped like the cursor pagination.
+func offsetPageParams(c *gin.Context) (page int, limit int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultOffsetPageLimit)))
+	return page, clampPageLimit(limit, err, defaultOffsetPageLimit)
+}
+
+// clampPageLimit returns the given default for a missing or invalid limit and caps it to maxPageLimit
+func clampPageLimit(limit int, err error, defaultLimit int) int {
+	if err != nil || limit < 1 {
+		return defaultLimit
+	}
+	return min(limit, maxPageLimit)
+}
+
+// writePage answers a page of a cursor paginated list, or the error it failed with
+func writePage[T any](c *gin.Context, page *dto.PageDTO[T], err error, errorMessage string) {
+	if err != nil {
+		log.Printf("%s: %v", errorMessage, err)
+		if errors.Is(err, repository.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// writeFirstPage answers the first page of a list in its former shape, the items under the given key, along with
+// the next_cursor to go on with the cursor pagination when there are more items
+func writeFirstPage[T any](c *gin.Context, key string, page *dto.PageDTO[T], err error, errorMessage string) {
+	if err != nil {
+		log.Printf("%s: %v", errorMessage, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+		return
+	}
+
+	response := gin.H{key: page.Items}
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DeviceCredentialController struct {
+	DeviceCredentialService service.DeviceCredentialService
+}
+
+func NewDeviceCredentialController(credentialService service.DeviceCredentialService) *DeviceCredentialController {
+	return &DeviceCredentialController{
+		DeviceCredentialService: credentialService,
+	}
+}
+
+// CreateCredential handles issuing a new API key to a monitoring device
+func (dcc *DeviceCredentialController) CreateCredential(c *gin.Context) {
+	// The body is optional
+	var credentialDTO dto.DeviceCredentialCreateDTO
+	if c.Request.ContentLength > 0 && !bindJSON(c, &credentialDTO) {
+		return
+	}
+
+	credential, err := dcc.DeviceCredentialService.CreateCredential(c.Param("id"), &credentialDTO, currentUserID(c))
+	if err != nil {
+		writeDeviceCredentialError(c, err, "Failed to create device credential")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"credential": credential})
+}
+
+// GetCredentials handles listing the credentials of a monitoring device
+func (dcc *DeviceCredentialController) GetCredentials(c *gin.Context) {
+	credentials, err := dcc.DeviceCredentialService.GetCredentials(c.Param("id"))
+	if err != nil {
+		writeDeviceCredentialError(c, err, "Failed to retrieve device credentials")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+// RotateCredential handles replacing the API key of a monitoring device
+func (dcc *DeviceCredentialController) RotateCredential(c *gin.Context) {
+	credentialID, ok := parseCredentialID(c)
+	if !ok {
+		return
+	}
+
+	credential, err := dcc.DeviceCredentialService.RotateCredential(c.Param("id"), credentialID, currentUserID(c))
+	if err != nil {
+		writeDeviceCredentialError(c, err, "Failed to rotate device credential")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"credential": credential})
+}
+
+// RevokeCredential handles revoking an API key of a monitoring device
+func (dcc *DeviceCredentialController) RevokeCredential(c *gin.Context) {
+	credentialID, ok := parseCredentialID(c)
+	if !ok {
+		return
+	}
+
+	if err := dcc.DeviceCredentialService.RevokeCredential(c.Param("id"), credentialID); err != nil {
+		writeDeviceCredentialError(c, err, "Failed to revoke device credential")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Device credential revoked successfully"})
+}
+
+func parseCredentialID(c *gin.Context) (uuid.UUID, bool) {
+	credentialID, err := uuid.Parse(c.Param("credentialId"))
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return uuid.Nil, false
+	}
+	return credentialID, true
+}
+
+func writeDeviceCredentialError(c *gin.Context, err error, errorMessage string) {
+	log.Printf("%s: %v", errorMessage, err)
+	switch {
+	case errors.Is(err, service.ErrDeviceNotFound), errors.Is(err, service.ErrDeviceCredentialNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDeviceCredentialInactive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+	}
+}
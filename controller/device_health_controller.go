@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type DeviceHealthController struct {
+	DeviceHealthService service.DeviceHealthService
+}
+
+func NewDeviceHealthController(deviceHealthService service.DeviceHealthService) *DeviceHealthController {
+	return &DeviceHealthController{
+		DeviceHealthService: deviceHealthService,
+	}
+}
+
+// RecordHeartbeat handles the periodic heartbeat of a monitoring device
+func (dhc *DeviceHealthController) RecordHeartbeat(c *gin.Context) {
+	// The body is optional, a bare heartbeat only tells the device is alive
+	var heartbeatDTO dto.MonitoringDeviceHeartbeatDTO
+	if c.Request.ContentLength > 0 && !bindJSON(c, &heartbeatDTO) {
+		return
+	}
+
+	device, err := dhc.DeviceHealthService.RecordHeartbeat(c.Param("id"), &heartbeatDTO)
+	if err != nil {
+		log.Printf("Failed to record heartbeat: %v", err)
+		if errors.Is(err, service.ErrDeviceNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record heartbeat"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"device": device})
+}
+
+// GetTechnicalAlerts handles listing the technical alerts, optionally filtered by status, device and type
+func (dhc *DeviceHealthController) GetTechnicalAlerts(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+
+	filters := dto.TechnicalAlertFilter{
+		Status:   c.Query("status"),
+		DeviceID: c.Query("device_id"),
+		Type:     c.Query("type"),
+	}
+	if filters.Status != "" && filters.Status != "open" && filters.Status != "resolved" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status: must be 'open' or 'resolved'"})
+		return
+	}
+	if filters.Type != "" && filters.Type != string(enum.TechnicalAlertDeviceOffline) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type: must be 'device_offline'"})
+		return
+	}
+
+	alerts, totalCount, err := dhc.DeviceHealthService.GetTechnicalAlerts(page, limit, filters)
+	if err != nil {
+		log.Printf("Error retrieving technical alerts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve technical alerts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"technical_alerts": alerts,
+		"totalCount":       totalCount,
+	})
+}
+
+// ResolveTechnicalAlert handles resolving a technical alert by hand
+func (dhc *DeviceHealthController) ResolveTechnicalAlert(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid technical alert ID"})
+		return
+	}
+
+	err = dhc.DeviceHealthService.ResolveTechnicalAlert(id, currentUserID(c))
+	if err != nil {
+		log.Printf("Failed to resolve technical alert: %v", err)
+		switch {
+		case errors.Is(err, service.ErrTechnicalAlertNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrTechnicalAlertResolved):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve technical alert"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Technical alert resolved successfully"})
+}
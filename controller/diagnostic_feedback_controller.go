@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"biometric-data-backend/middleware"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DiagnosticFeedbackController struct {
+	DiagnosticFeedbackService service.DiagnosticFeedbackService
+}
+
+func NewDiagnosticFeedbackController(diagnosticFeedbackService service.DiagnosticFeedbackService) *DiagnosticFeedbackController {
+	return &DiagnosticFeedbackController{
+		DiagnosticFeedbackService: diagnosticFeedbackService,
+	}
+}
+
+// CreateFeedback handles the feedback of the current doctor on the computer diagnosis of an alert
+func (fc *DiagnosticFeedbackController) CreateFeedback(c *gin.Context) {
+	id := c.Param("id")
+
+	alertID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	var feedbackDTO dto.DiagnosticFeedbackCreateDTO
+	if !bindJSON(c, &feedbackDTO) {
+		return
+	}
+
+	var userID *uuid.UUID
+	if currentUserID, ok := middleware.GetUserID(c); ok {
+		userID = &currentUserID
+	}
+
+	feedback, err := fc.DiagnosticFeedbackService.CreateFeedback(alertID, &feedbackDTO, userID)
+	if err != nil {
+		log.Printf("Failed to store diagnostic feedback: %v", err)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		case errors.Is(err, service.ErrCorrectedDiagnosisRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrFeedbackDoctorRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store diagnostic feedback"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, feedback)
+}
+
+// GetFeedbackByAlertID handles retrieving the feedback given on an alert
+func (fc *DiagnosticFeedbackController) GetFeedbackByAlertID(c *gin.Context) {
+	id := c.Param("id")
+
+	alertID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	feedback, err := fc.DiagnosticFeedbackService.GetFeedbackByAlertID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving diagnostic feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve diagnostic feedback"})
+		return
+	}
+
+	if feedback == nil {
+		log.Printf("Alert not found with AlertID: %v", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feedback": feedback})
+}
+
+// ExportTrainingData handles the export of the labeled alerts as JSON lines or CSV
+func (fc *DiagnosticFeedbackController) ExportTrainingData(c *gin.Context) {
+	query := dto.TrainingDataQuery{
+		From:         c.Query("from"),
+		To:           c.Query("to"),
+		Timezone:     c.Query("timezone"),
+		ModelName:    c.Query("model_name"),
+		ModelVersion: c.Query("model_version"),
+		Format:       c.DefaultQuery("format", service.TrainingDataFormatJSONL),
+	}
+
+	contentType := "application/x-ndjson"
+	if query.Format == service.TrainingDataFormatCSV {
+		contentType = "text/csv"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename=training-data."+query.Format)
+
+	err := fc.DiagnosticFeedbackService.ExportTrainingData(query, c.Writer)
+	if err == nil {
+		return
+	}
+
+	log.Printf("Failed to export training data: %v", err)
+	// Once the export started, the status is already sent and the error can only cut the response short
+	if c.Writer.Written() {
+		return
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Disposition")
+	switch {
+	case errors.Is(err, service.ErrInvalidTrainingDataFormat), errors.Is(err, service.ErrInvalidAnalyticsRange), errors.Is(err, service.ErrInvalidAnalyticsTimezone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export training data"})
+	}
+}
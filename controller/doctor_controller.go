@@ -1,10 +1,13 @@
 package controller
 
 import (
+	"biometric-data-backend/middleware"
 	"biometric-data-backend/models/dto"
 	"biometric-data-backend/service"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"log"
 	"net/http"
 )
@@ -109,10 +112,18 @@ func (dc *DoctorController) UpdateDoctorByUserID(c *gin.Context) {
 		return
 	}
 
-	err = dc.DoctorService.UpdateDoctorByUserID(userID, &doctorDTO)
+	principal, _ := middleware.GetPrincipal(c)
+	err = dc.DoctorService.UpdateDoctorByUserID(userID, &doctorDTO, principal)
 	if err != nil {
 		log.Printf("Failed to update doctor: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update doctor"})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		case errors.Is(err, service.ErrDoctorRoleChangeForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update doctor"})
+		}
 		return
 	}
 
@@ -152,14 +163,17 @@ func (dc *DoctorController) GetShortDoctorByID(c *gin.Context) {
 
 // GetAllDoctors handles retrieving all doctors
 func (dc *DoctorController) GetAllDoctors(c *gin.Context) {
-	doctors, err := dc.DoctorService.GetAllDoctors()
-	if err != nil {
-		log.Printf("Error retrieving doctors: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve doctors"})
+	request, paginated, ok := cursorPageRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"doctors": doctors})
+	page, err := dc.DoctorService.GetDoctorsPage(request)
+	if paginated {
+		writePage(c, page, err, "Failed to retrieve doctors")
+		return
+	}
+	writeFirstPage(c, "doctors", page, err, "Failed to retrieve doctors")
 }
 
 // UpdateDoctor handles updating an existing doctor
@@ -180,16 +194,57 @@ func (dc *DoctorController) UpdateDoctor(c *gin.Context) {
 		return
 	}
 
-	err = dc.DoctorService.UpdateDoctor(doctorID, &doctorDTO)
+	principal, _ := middleware.GetPrincipal(c)
+	err = dc.DoctorService.UpdateDoctor(doctorID, &doctorDTO, principal)
 	if err != nil {
 		log.Printf("Failed to update doctor: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update doctor"})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		case errors.Is(err, service.ErrDoctorUpdateForbidden):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update doctor"})
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Doctor updated successfully", "doctor": doctorDTO})
 }
 
+// UpdateDoctorOnCall handles setting whether a doctor is on call
+func (dc *DoctorController) UpdateDoctorOnCall(c *gin.Context) {
+	id := c.Param("id")
+
+	doctorID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid doctor ID"})
+		return
+	}
+
+	var onCallDTO dto.DoctorOnCallUpdateDTO
+	if err := c.ShouldBindJSON(&onCallDTO); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	err = dc.DoctorService.UpdateDoctorOnCall(doctorID, *onCallDTO.OnCall)
+	if err != nil {
+		log.Printf("Failed to update doctor on call: %v", err)
+		switch err.Error() {
+		case "record not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update doctor on call"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Doctor on call updated successfully", "on_call": *onCallDTO.OnCall})
+}
+
 // DeleteDoctor handles deleting a doctor by their DoctorID
 func (dc *DoctorController) DeleteDoctor(c *gin.Context) {
 	id := c.Param("id")
@@ -212,8 +267,14 @@ func (dc *DoctorController) DeleteDoctor(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Doctor deleted successfully"})
 }
 
-// ChangePassword handles changing a doctor's password
+// ChangePassword handles changing the password of the calling doctor
 func (dc *DoctorController) ChangePassword(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
 	var changePasswordDTO dto.ChangePasswordDTO
 	if err := c.ShouldBindJSON(&changePasswordDTO); err != nil {
 		log.Printf("Error binding JSON: %v", err)
@@ -221,10 +282,17 @@ func (dc *DoctorController) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	err := dc.DoctorService.ChangePassword(&changePasswordDTO)
+	err := dc.DoctorService.ChangePassword(userID, &changePasswordDTO)
 	if err != nil {
 		log.Printf("Failed to change password: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Doctor not found"})
+		case errors.Is(err, service.ErrPasswordChangeForbidden), errors.Is(err, service.ErrIncorrectIssuanceDate):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		}
 		return
 	}
 
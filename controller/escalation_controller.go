@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"biometric-data-backend/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type EscalationController struct {
+	EscalationService service.EscalationService
+}
+
+func NewEscalationController(escalationService service.EscalationService) *EscalationController {
+	return &EscalationController{
+		EscalationService: escalationService,
+	}
+}
+
+// GetAlertEscalation handles retrieving the escalation timeline of an alert
+func (ec *EscalationController) GetAlertEscalation(c *gin.Context) {
+	id := c.Param("id")
+
+	alertID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	escalation, err := ec.EscalationService.GetEscalationByAlertID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving escalation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alert escalation"})
+		return
+	}
+
+	if escalation == nil {
+		log.Printf("Escalation not found for AlertID: %v", id)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Alert escalation not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"escalation": escalation})
+}
@@ -1,7 +1,6 @@
 package controller
 
 import (
-	"biometric-data-backend/service"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -13,21 +12,11 @@ func NewAuthController() *AuthController {
 	return &AuthController{}
 }
 
-// GenerateTokenEndpoint generates a JWT token with the given username and roles
+// GenerateTokenEndpoint used to sign a token for any username and roles without authentication. It is kept only
+// to tell the remaining callers where to get their tokens now.
 func (ac *AuthController) GenerateTokenEndpoint(c *gin.Context) {
-	username := c.PostForm("username")
-	roles := c.PostFormArray("roles")
-
-	if username == "" || len(roles) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email and roles are required"})
-		return
-	}
-
-	token, err := service.GenerateToken(username, roles, nil)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	c.JSON(http.StatusGone, gin.H{
+		"error":   "This endpoint has been removed",
+		"message": "Integrations must use a service account and POST /oauth/token, users must log in with POST /authorization/login",
+	})
 }
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/repository"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// uniqueViolationCode is the PostgreSQL error code of a unique constraint violation
+const uniqueViolationCode = "23505"
+
+type LocationController struct {
+	LocationService service.LocationService
+}
+
+func NewLocationController(locationService service.LocationService) *LocationController {
+	return &LocationController{
+		LocationService: locationService,
+	}
+}
+
+// CreateLocation handles the creation of a new building, ward, room or bed
+func (lc *LocationController) CreateLocation(c *gin.Context) {
+	var locationDTO dto.LocationCreateDTO
+	if !bindJSON(c, &locationDTO) {
+		return
+	}
+
+	location, err := lc.LocationService.CreateLocation(&locationDTO)
+	if err != nil {
+		writeLocationError(c, err, "Failed to create location")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Location created successfully", "location": location})
+}
+
+// GetLocationByID handles retrieving a location by its LocationID
+func (lc *LocationController) GetLocationByID(c *gin.Context) {
+	location, err := getByID(c, "id", lc.LocationService.GetLocationByID, "Location not found with LocationID: %v")
+	if err != nil || location == nil {
+		return
+	}
+	setVersionETag(c, location.Version)
+	c.JSON(http.StatusOK, gin.H{"location": location})
+}
+
+// GetLocations handles retrieving the locations, optionally of a type or contained in a location
+func (lc *LocationController) GetLocations(c *gin.Context) {
+	filters := dto.LocationFilter{Type: c.Query("type")}
+	if filters.Type != "" && !enum.LocationType(filters.Type).IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid type: must be building, ward, room or bed"})
+		return
+	}
+	if parentID := c.Query("parent_id"); parentID != "" {
+		parsed, err := uuid.Parse(parentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id: must be a UUID"})
+			return
+		}
+		filters.ParentID = &parsed
+	}
+
+	locations, err := lc.LocationService.GetLocations(filters)
+	if err != nil {
+		log.Printf("Error retrieving locations: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve locations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"locations": locations})
+}
+
+// UpdateLocation handles renaming a location or taking it in or out of service
+func (lc *LocationController) UpdateLocation(c *gin.Context) {
+	locationID, ok := parseLocationID(c)
+	if !ok {
+		return
+	}
+
+	var locationDTO dto.LocationUpdateDTO
+	if !bindJSON(c, &locationDTO) {
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err := lc.LocationService.UpdateLocation(locationID, &locationDTO, expectedVersion)
+	if err != nil {
+		writeLocationError(c, err, "Failed to update location")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location updated successfully", "location": locationDTO})
+}
+
+// DeleteLocation handles deleting a location by its LocationID
+func (lc *LocationController) DeleteLocation(c *gin.Context) {
+	locationID, ok := parseLocationID(c)
+	if !ok {
+		return
+	}
+
+	err := lc.LocationService.DeleteLocation(locationID)
+	if err != nil {
+		writeLocationError(c, err, "Failed to delete location")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Location deleted successfully"})
+}
+
+// GetOccupancy handles retrieving the beds of a location with their status
+func (lc *LocationController) GetOccupancy(c *gin.Context) {
+	occupancy, err := getByID(c, "id", lc.LocationService.GetOccupancy, "Location not found with LocationID: %v")
+	if err != nil || occupancy == nil {
+		return
+	}
+	c.JSON(http.StatusOK, occupancy)
+}
+
+// SetWardStaff handles replacing the doctors assigned to a ward
+func (lc *LocationController) SetWardStaff(c *gin.Context) {
+	locationID, ok := parseLocationID(c)
+	if !ok {
+		return
+	}
+
+	var staffDTO dto.WardStaffUpdateDTO
+	if !bindJSON(c, &staffDTO) {
+		return
+	}
+
+	location, err := lc.LocationService.SetWardStaff(locationID, &staffDTO)
+	if err != nil {
+		writeLocationError(c, err, "Failed to set ward staff")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ward staff updated successfully", "location": location})
+}
+
+// parseLocationID reads the location ID of the route, answering 400 when it is not a UUID
+func parseLocationID(c *gin.Context) (uuid.UUID, bool) {
+	locationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid location ID"})
+		return uuid.Nil, false
+	}
+	return locationID, true
+}
+
+// writeLocationError answers the error a change of a location failed with
+func writeLocationError(c *gin.Context, err error, errorMessage string) {
+	log.Printf("%s: %v", errorMessage, err)
+
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Location not found"})
+	case errors.Is(err, service.ErrInvalidLocationParent), errors.Is(err, service.ErrNotAWard), errors.Is(err, service.ErrUnknownWardStaff):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrLocationHasChildren), errors.Is(err, service.ErrLocationOccupied), errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode:
+		c.JSON(http.StatusConflict, gin.H{"error": "A location with this name already exists in the parent location"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+	}
+}
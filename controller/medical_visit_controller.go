@@ -0,0 +1,137 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MedicalVisitController struct {
+	MedicalVisitService service.MedicalVisitService
+}
+
+func NewMedicalVisitController(medicalVisitService service.MedicalVisitService) *MedicalVisitController {
+	return &MedicalVisitController{
+		MedicalVisitService: medicalVisitService,
+	}
+}
+
+// Admit handles opening a medical visit for a patient
+func (vc *MedicalVisitController) Admit(c *gin.Context) {
+	patientID, ok := parsePatientID(c)
+	if !ok {
+		return
+	}
+
+	var admitDTO dto.MedicalVisitAdmitDTO
+	if !bindJSON(c, &admitDTO) {
+		return
+	}
+
+	visit, err := vc.MedicalVisitService.Admit(patientID, &admitDTO)
+	if err != nil {
+		writeMedicalVisitError(c, err, "Failed to admit patient")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Patient admitted successfully", "visit": visit})
+}
+
+// Discharge handles closing the open medical visit of a patient
+func (vc *MedicalVisitController) Discharge(c *gin.Context) {
+	patientID, ok := parsePatientID(c)
+	if !ok {
+		return
+	}
+
+	var dischargeDTO dto.MedicalVisitDischargeDTO
+	if c.Request.ContentLength != 0 && !bindJSON(c, &dischargeDTO) {
+		return
+	}
+
+	discharge, err := vc.MedicalVisitService.Discharge(patientID, &dischargeDTO)
+	if err != nil {
+		writeMedicalVisitError(c, err, "Failed to discharge patient")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Patient discharged successfully", "visit": discharge.Visit, "released_device_id": discharge.ReleasedDeviceID})
+}
+
+// Transfer handles moving an admitted patient to another location
+func (vc *MedicalVisitController) Transfer(c *gin.Context) {
+	patientID, ok := parsePatientID(c)
+	if !ok {
+		return
+	}
+
+	var transferDTO dto.MedicalVisitTransferCreateDTO
+	if !bindJSON(c, &transferDTO) {
+		return
+	}
+
+	visit, err := vc.MedicalVisitService.Transfer(patientID, &transferDTO)
+	if err != nil {
+		writeMedicalVisitError(c, err, "Failed to transfer patient")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Patient transferred successfully", "visit": visit})
+}
+
+// GetVisitsByPatientID handles retrieving the visit history of a patient
+func (vc *MedicalVisitController) GetVisitsByPatientID(c *gin.Context) {
+	patientID, ok := parsePatientID(c)
+	if !ok {
+		return
+	}
+
+	visits, err := vc.MedicalVisitService.GetVisitsByPatientID(patientID)
+	if err != nil {
+		log.Printf("Error retrieving medical visits: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve medical visits"})
+		return
+	}
+
+	if visits == nil {
+		log.Printf("Patient not found with PatientID: %v", patientID)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"visits": visits})
+}
+
+// parsePatientID reads the patient ID of the route, answering 400 when it is not a UUID
+func parsePatientID(c *gin.Context) (uuid.UUID, bool) {
+	patientID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return uuid.Nil, false
+	}
+	return patientID, true
+}
+
+// writeMedicalVisitError answers the error an admission, discharge or transfer failed with
+func writeMedicalVisitError(c *gin.Context, err error, errorMessage string) {
+	log.Printf("%s: %v", errorMessage, err)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+	case errors.Is(err, service.ErrPatientAlreadyAdmitted), errors.Is(err, service.ErrPatientNotAdmitted),
+		errors.Is(err, service.ErrBedOccupied), errors.Is(err, service.ErrBedOutOfService):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidEntryDate), errors.Is(err, service.ErrInvalidDischargeDate), errors.Is(err, service.ErrSameTransferLocation),
+		errors.Is(err, service.ErrNotABed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+	}
+}
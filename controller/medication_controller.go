@@ -47,14 +47,17 @@ func (mc *MedicationController) GetMedicationByID(c *gin.Context) {
 
 // GetAllMedications handles retrieving all medications
 func (mc *MedicationController) GetAllMedications(c *gin.Context) {
-	medications, err := mc.MedicationService.GetAllMedications()
-	if err != nil {
-		log.Printf("Error retrieving medications: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve medications"})
+	request, paginated, ok := cursorPageRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"medications": medications})
+	page, err := mc.MedicationService.GetMedicationsPage(request)
+	if paginated {
+		writePage(c, page, err, "Failed to retrieve medications")
+		return
+	}
+	writeFirstPage(c, "medications", page, err, "Failed to retrieve medications")
 }
 
 // UpdateMedication handles updating an existing medication
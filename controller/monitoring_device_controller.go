@@ -1,14 +1,16 @@
 package controller
 
 import (
+	"biometric-data-backend/middleware"
 	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
 	"biometric-data-backend/service"
 	"errors"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
@@ -58,6 +60,7 @@ func (mdc *MonitoringDeviceController) GetMonitoringDeviceByID(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, device.Version)
 	c.JSON(http.StatusOK, gin.H{"device": device})
 }
 
@@ -67,21 +70,29 @@ func (mdc *MonitoringDeviceController) GetAllMonitoringDevices(c *gin.Context) {
 	var devices []*dto.MonitoringDeviceDTO
 	var err error
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		limit = 10
-	}
+	page, limit := offsetPageParams(c)
 
 	dni := c.Query("dni")
 	status := c.Query("status") // Add status as a query parameter
 
+	request, paginated, ok := cursorPageRequest(c)
+	if paginated {
+		if ok {
+			page, err := mdc.MonitoringDeviceService.GetMonitoringDevicesPage(request, dto.MonitoringDeviceFilter{DNI: dni, Status: status})
+			writePage(c, page, err, "Failed to retrieve monitoring devices")
+		}
+		return
+	}
+
+	nextCursor := ""
 	if status != "" {
-		devices, totalCount, err = mdc.MonitoringDeviceService.GetAllMonitoringDevicesByStatus(status)
+		// Only the first page of the devices with the status is answered, with the cursor to the next ones
+		request.WithTotal = true
+		var statusPage *dto.PageDTO[dto.MonitoringDeviceDTO]
+		statusPage, err = mdc.MonitoringDeviceService.GetMonitoringDevicesPage(request, dto.MonitoringDeviceFilter{Status: status})
+		if err == nil {
+			devices, totalCount, nextCursor = statusPage.Items, int(*statusPage.Total), statusPage.NextCursor
+		}
 	} else {
 		// Build the filters object
 		filters := dto.MonitoringDeviceFilter{
@@ -97,10 +108,14 @@ func (mdc *MonitoringDeviceController) GetAllMonitoringDevices(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"devices":    devices,
 		"totalCount": totalCount,
-	})
+	}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+	c.JSON(http.StatusOK, response)
 	return
 }
 
@@ -114,7 +129,12 @@ func (mdc *MonitoringDeviceController) UpdateMonitoringDevice(c *gin.Context) {
 		return
 	}
 
-	err := mdc.MonitoringDeviceService.UpdateMonitoringDevice(id, &deviceDTO)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err := mdc.MonitoringDeviceService.UpdateMonitoringDevice(id, &deviceDTO, expectedVersion)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if ok := errors.As(err, &pgErr); ok {
@@ -127,7 +147,7 @@ func (mdc *MonitoringDeviceController) UpdateMonitoringDevice(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
+		writeDevicePairingError(c, err, "Internal Server Error")
 		return
 	}
 
@@ -147,3 +167,110 @@ func (mdc *MonitoringDeviceController) DeleteMonitoringDevice(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Monitoring device deleted successfully"})
 }
+
+// PairDevice handles linking a free monitoring device to a patient
+func (mdc *MonitoringDeviceController) PairDevice(c *gin.Context) {
+	var pairDTO dto.MonitoringDevicePairDTO
+	if !bindJSON(c, &pairDTO) {
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	device, err := mdc.MonitoringDeviceService.PairDevice(c.Param("id"), &pairDTO, currentUserID(c), expectedVersion)
+	if err != nil {
+		writeDevicePairingError(c, err, "Failed to pair monitoring device")
+		return
+	}
+
+	setVersionETag(c, device.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Monitoring device paired successfully", "device": device})
+}
+
+// UnpairDevice handles unlinking a monitoring device from its patient
+func (mdc *MonitoringDeviceController) UnpairDevice(c *gin.Context) {
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	device, err := mdc.MonitoringDeviceService.UnpairDevice(c.Param("id"), currentUserID(c), expectedVersion)
+	if err != nil {
+		writeDevicePairingError(c, err, "Failed to unpair monitoring device")
+		return
+	}
+
+	setVersionETag(c, device.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Monitoring device unpaired successfully", "device": device})
+}
+
+// MarkDeviceUnavailable handles taking a free monitoring device out of use
+func (mdc *MonitoringDeviceController) MarkDeviceUnavailable(c *gin.Context) {
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	device, err := mdc.MonitoringDeviceService.MarkDeviceUnavailable(c.Param("id"), expectedVersion)
+	if err != nil {
+		writeDevicePairingError(c, err, "Failed to mark monitoring device as unavailable")
+		return
+	}
+
+	setVersionETag(c, device.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Monitoring device marked as unavailable", "device": device})
+}
+
+// MarkDeviceAvailable handles putting an unavailable monitoring device back in use
+func (mdc *MonitoringDeviceController) MarkDeviceAvailable(c *gin.Context) {
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	device, err := mdc.MonitoringDeviceService.MarkDeviceAvailable(c.Param("id"), expectedVersion)
+	if err != nil {
+		writeDevicePairingError(c, err, "Failed to mark monitoring device as available")
+		return
+	}
+
+	setVersionETag(c, device.Version)
+	c.JSON(http.StatusOK, gin.H{"message": "Monitoring device marked as available", "device": device})
+}
+
+// GetDeviceAssignments handles retrieving the patients a monitoring device was linked to
+func (mdc *MonitoringDeviceController) GetDeviceAssignments(c *gin.Context) {
+	assignments, err := mdc.MonitoringDeviceService.GetDeviceAssignments(c.Param("id"))
+	if err != nil {
+		writeDevicePairingError(c, err, "Failed to retrieve monitoring device assignments")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"assignments": assignments})
+}
+
+// currentUserID returns the ID of the authenticated user, nil when unknown
+func currentUserID(c *gin.Context) *uuid.UUID {
+	if userID, ok := middleware.GetUserID(c); ok {
+		return &userID
+	}
+	return nil
+}
+
+func writeDevicePairingError(c *gin.Context, err error, errorMessage string) {
+	log.Printf("%s: %v", errorMessage, err)
+	switch {
+	case errors.Is(err, service.ErrDeviceNotFound), errors.Is(err, service.ErrPatientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrVersionConflict), errors.Is(err, service.ErrInvalidDeviceTransition),
+		errors.Is(err, service.ErrPatientAlreadyHasDevice), errors.Is(err, service.ErrDevicePatientMismatch):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrInvalidDeviceStatus), errors.Is(err, service.ErrDevicePatientRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+	}
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"biometric-data-backend/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type NotificationController struct {
+	NotificationService service.NotificationService
+}
+
+func NewNotificationController(notificationService service.NotificationService) *NotificationController {
+	return &NotificationController{
+		NotificationService: notificationService,
+	}
+}
+
+// GetAlertDeliveries handles retrieving the delivery status of the notifications of an alert
+func (nc *NotificationController) GetAlertDeliveries(c *gin.Context) {
+	id := c.Param("id")
+
+	alertID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+		return
+	}
+
+	deliveries, err := nc.NotificationService.GetAlertDeliveries(alertID)
+	if err != nil {
+		log.Printf("Error retrieving alert deliveries: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve alert deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries})
+}
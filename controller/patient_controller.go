@@ -2,7 +2,9 @@ package controller
 
 import (
 	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
 	"biometric-data-backend/service"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"log"
@@ -63,6 +65,7 @@ func (pc *PatientController) GetPatientByID(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, patient.Version)
 	c.JSON(http.StatusOK, gin.H{"patient": patient})
 }
 
@@ -92,15 +95,7 @@ func (pc *PatientController) GetAllPatients(c *gin.Context) {
 	var totalCount int
 	var err error
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		page = 1
-	}
-
-	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
-	if err != nil || limit < 1 {
-		limit = 10
-	}
+	page, limit := offsetPageParams(c)
 
 	// Advanced Filters
 	name := c.Query("name")
@@ -112,6 +107,13 @@ func (pc *PatientController) GetAllPatients(c *gin.Context) {
 	comorbidityName := c.Query("comorbidity")
 	entryDate := c.Query("entry_date")
 	dischargeDate := c.Query("discharge_date")
+	wardID := c.Query("ward_id")
+	if wardID != "" {
+		if _, err := uuid.Parse(wardID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ward_id: must be a UUID"})
+			return
+		}
+	}
 
 	filters := dto.PatientFilter{
 		Name:            name,
@@ -123,6 +125,15 @@ func (pc *PatientController) GetAllPatients(c *gin.Context) {
 		ComorbidityName: comorbidityName,
 		EntryDate:       entryDate,
 		DischargeDate:   dischargeDate,
+		WardID:          wardID,
+	}
+
+	if request, paginated, ok := cursorPageRequest(c); paginated {
+		if ok {
+			page, err := pc.PatientService.GetPatientsPage(request, filters)
+			writePage(c, page, err, "Failed to retrieve patients")
+		}
+		return
 	}
 
 	patients, totalCount, err = pc.PatientService.GetAllPatients(page, limit, filters)
@@ -157,10 +168,20 @@ func (pc *PatientController) UpdatePatient(c *gin.Context) {
 		return
 	}
 
-	err = pc.PatientService.UpdatePatient(patientID, &patientDTO)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = pc.PatientService.UpdatePatient(patientID, &patientDTO, expectedVersion)
 	if err != nil {
 		log.Printf("Failed to update patient: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update patient"})
+		switch {
+		case errors.Is(err, repository.ErrVersionConflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update patient"})
+		}
 		return
 	}
 
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"biometric-data-backend/middleware"
 	"biometric-data-backend/models/dto"
 	"biometric-data-backend/service"
 	"github.com/gin-gonic/gin"
@@ -18,19 +19,50 @@ func NewPhoneController(phoneService service.PhoneService) *PhoneController {
 	}
 }
 
-func (pc *PhoneController) CreatePhone(c *gin.Context) {
+// RegisterPhone registers the push token of a phone against the authenticated user
+func (pc *PhoneController) RegisterPhone(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in token"})
+		return
+	}
+
+	var phoneDTO dto.PhoneCreateDTO
+	if err := c.ShouldBindJSON(&phoneDTO); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	err := pc.PhoneService.RegisterPhone(&phoneDTO, userID)
+	if err != nil {
+		log.Printf("Failed to register phone: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register phone"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Phone registered successfully", "phone": phoneDTO})
+}
+
+// UnregisterPhone stops sending notifications to the push token of the authenticated user, e.g. on logout
+func (pc *PhoneController) UnregisterPhone(c *gin.Context) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found in token"})
+		return
+	}
+
 	var phoneDTO dto.PhoneCreateDTO
 	if err := c.ShouldBindJSON(&phoneDTO); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	err := pc.PhoneService.CreatePhone(&phoneDTO)
+	err := pc.PhoneService.UnregisterPhone(&phoneDTO, userID)
 	if err != nil {
-		log.Printf("Failed to create patient: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create phone"})
+		log.Printf("Failed to unregister phone: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unregister phone"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "Phone created successfully", "phone": phoneDTO})
+	c.JSON(http.StatusOK, gin.H{"message": "Phone unregistered successfully"})
 }
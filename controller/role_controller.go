@@ -67,14 +67,17 @@ func (rc *RoleController) GetRoleByID(c *gin.Context) {
 
 // GetAllRoles handles retrieving all roles
 func (rc *RoleController) GetAllRoles(c *gin.Context) {
-	roles, err := rc.RoleService.GetAllRoles()
-	if err != nil {
-		log.Printf("Error retrieving roles: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve roles"})
+	request, paginated, ok := cursorPageRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"roles": roles})
+	page, err := rc.RoleService.GetRolesPage(request)
+	if paginated {
+		writePage(c, page, err, "Failed to retrieve roles")
+		return
+	}
+	writeFirstPage(c, "roles", page, err, "Failed to retrieve roles")
 }
 
 // UpdateRole handles updating an existing role
@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ServiceAccountController struct {
+	ServiceAccountService service.ServiceAccountService
+}
+
+func NewServiceAccountController(accountService service.ServiceAccountService) *ServiceAccountController {
+	return &ServiceAccountController{
+		ServiceAccountService: accountService,
+	}
+}
+
+// CreateServiceAccount handles the creation of a new service account, the response holds its client secret
+func (sac *ServiceAccountController) CreateServiceAccount(c *gin.Context) {
+	var accountDTO dto.ServiceAccountCreateDTO
+	if !bindJSON(c, &accountDTO) {
+		return
+	}
+
+	account, err := sac.ServiceAccountService.CreateServiceAccount(&accountDTO, currentUserID(c))
+	if err != nil {
+		writeServiceAccountError(c, err, "Failed to create service account")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"service_account": account})
+}
+
+// GetServiceAccounts handles listing the service accounts
+func (sac *ServiceAccountController) GetServiceAccounts(c *gin.Context) {
+	accounts, err := sac.ServiceAccountService.GetServiceAccounts()
+	if err != nil {
+		writeServiceAccountError(c, err, "Failed to retrieve service accounts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_accounts": accounts})
+}
+
+// GetServiceAccountByID handles retrieving a service account by its ServiceAccountID
+func (sac *ServiceAccountController) GetServiceAccountByID(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+
+	account, err := sac.ServiceAccountService.GetServiceAccountByID(id)
+	if err != nil {
+		writeServiceAccountError(c, err, "Failed to retrieve service account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_account": account})
+}
+
+// RotateSecret handles replacing the client secret of a service account
+func (sac *ServiceAccountController) RotateSecret(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+
+	account, err := sac.ServiceAccountService.RotateSecret(id)
+	if err != nil {
+		writeServiceAccountError(c, err, "Failed to rotate service account secret")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"service_account": account})
+}
+
+// RevokeServiceAccount handles revoking a service account and the tokens issued to it
+func (sac *ServiceAccountController) RevokeServiceAccount(c *gin.Context) {
+	id, ok := parseServiceAccountID(c)
+	if !ok {
+		return
+	}
+
+	if err := sac.ServiceAccountService.RevokeServiceAccount(id); err != nil {
+		writeServiceAccountError(c, err, "Failed to revoke service account")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Service account revoked successfully"})
+}
+
+// IssueToken handles the OAuth 2.0 client credentials token request of a service account. The client credentials
+// are read from HTTP Basic authentication or from the form.
+func (sac *ServiceAccountController) IssueToken(c *gin.Context) {
+	request := dto.ServiceTokenRequestDTO{
+		GrantType:    c.PostForm("grant_type"),
+		ClientID:     c.PostForm("client_id"),
+		ClientSecret: c.PostForm("client_secret"),
+		Scope:        c.PostForm("scope"),
+	}
+	if clientID, clientSecret, ok := c.Request.BasicAuth(); ok {
+		request.ClientID = clientID
+		request.ClientSecret = clientSecret
+	}
+
+	// Token responses must not be cached (RFC 6749, section 5.1)
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+
+	token, err := sac.ServiceAccountService.IssueToken(&request)
+	if err != nil {
+		writeTokenError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, token)
+}
+
+func parseServiceAccountID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid service account ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func writeServiceAccountError(c *gin.Context, err error, errorMessage string) {
+	log.Printf("%s: %v", errorMessage, err)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.Is(err, service.ErrServiceAccountNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrServiceAccountRevoked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode:
+		c.JSON(http.StatusConflict, gin.H{"error": "A service account with this name already exists"})
+	case errors.Is(err, service.ErrInvalidServiceAccountScope), errors.Is(err, service.ErrInvalidServiceAccountExpiry):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errorMessage})
+	}
+}
+
+// writeTokenError writes an OAuth 2.0 error response (RFC 6749, section 5.2)
+func writeTokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidClient):
+		c.Header("WWW-Authenticate", `Basic realm="oauth"`)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error(), "error_description": "Client authentication failed"})
+	case errors.Is(err, service.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_description": "The requested scope exceeds the scopes of the service account"})
+	case errors.Is(err, service.ErrUnsupportedGrantType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_description": "Only the client_credentials grant type is supported"})
+	case errors.Is(err, service.ErrInvalidTokenRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "error_description": "grant_type is required"})
+	default:
+		log.Printf("Failed to issue service account token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+	}
+}
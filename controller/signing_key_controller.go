@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SigningKeyController struct {
+	JWTService service.JWTService
+}
+
+func NewSigningKeyController(jwtService service.JWTService) *SigningKeyController {
+	return &SigningKeyController{
+		JWTService: jwtService,
+	}
+}
+
+// GetJWKS handles publishing the public keys the tokens can be verified with
+func (skc *SigningKeyController) GetJWKS(c *gin.Context) {
+	// Verifiers may cache the keys for a while, new keys are published ahead of use
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, skc.JWTService.GetJWKS())
+}
+
+// GetSigningKeys handles listing the signing keys with their status
+func (skc *SigningKeyController) GetSigningKeys(c *gin.Context) {
+	keys, err := skc.JWTService.GetSigningKeys()
+	if err != nil {
+		log.Printf("Failed to retrieve signing keys: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve signing keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signing_keys": keys})
+}
+
+// RotateKey handles replacing the signing key ahead of schedule
+func (skc *SigningKeyController) RotateKey(c *gin.Context) {
+	// The body is optional
+	var rotateDTO dto.SigningKeyRotateDTO
+	if c.Request.ContentLength > 0 && !bindJSON(c, &rotateDTO) {
+		return
+	}
+
+	key, err := skc.JWTService.RotateKey(rotateDTO.Immediate)
+	if err != nil {
+		log.Printf("Failed to rotate signing key: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rotate signing key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"signing_key": key})
+}
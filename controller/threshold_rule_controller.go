@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"log"
+	"net/http"
+)
+
+type ThresholdRuleController struct {
+	ThresholdRuleService service.ThresholdRuleService
+}
+
+func NewThresholdRuleController(thresholdRuleService service.ThresholdRuleService) *ThresholdRuleController {
+	return &ThresholdRuleController{
+		ThresholdRuleService: thresholdRuleService,
+	}
+}
+
+// CreateThresholdRule handles the creation of a new threshold rule
+func (tc *ThresholdRuleController) CreateThresholdRule(c *gin.Context) {
+	var ruleDTO dto.ThresholdRuleCreateDTO
+	if !bindJSON(c, &ruleDTO) {
+		return
+	}
+
+	rule, err := tc.ThresholdRuleService.CreateThresholdRule(&ruleDTO)
+	if err != nil {
+		log.Printf("Failed to create threshold rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create threshold rule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Threshold rule created successfully", "rule": rule})
+}
+
+// GetThresholdRuleByID handles retrieving a threshold rule by its RuleID
+func (tc *ThresholdRuleController) GetThresholdRuleByID(c *gin.Context) {
+	rule, err := getByID(c, "id", tc.ThresholdRuleService.GetThresholdRuleByID, "Threshold rule not found with RuleID: %v")
+	if err != nil || rule == nil {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rule": rule})
+}
+
+// GetAllThresholdRules handles retrieving all threshold rules
+func (tc *ThresholdRuleController) GetAllThresholdRules(c *gin.Context) {
+	rules, err := tc.ThresholdRuleService.GetAllThresholdRules()
+	if err != nil {
+		log.Printf("Error retrieving threshold rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve threshold rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// UpdateThresholdRule handles updating an existing threshold rule
+func (tc *ThresholdRuleController) UpdateThresholdRule(c *gin.Context) {
+	id := c.Param("id")
+
+	ruleID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	var ruleDTO dto.ThresholdRuleUpdateDTO
+	if !bindJSON(c, &ruleDTO) {
+		return
+	}
+
+	err = tc.ThresholdRuleService.UpdateThresholdRule(ruleID, &ruleDTO)
+	if err != nil {
+		log.Printf("Failed to update threshold rule: %v", err)
+		switch err.Error() {
+		case "record not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "Threshold rule not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update threshold rule"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Threshold rule updated successfully", "rule": ruleDTO})
+}
+
+// DeleteThresholdRule handles deleting a threshold rule by its RuleID
+func (tc *ThresholdRuleController) DeleteThresholdRule(c *gin.Context) {
+	id := c.Param("id")
+
+	ruleID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule ID"})
+		return
+	}
+
+	err = tc.ThresholdRuleService.DeleteThresholdRule(ruleID)
+	if err != nil {
+		log.Printf("Failed to delete threshold rule: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete threshold rule"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Threshold rule deleted successfully"})
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/service"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type VitalReadingController struct {
+	VitalReadingService service.VitalReadingService
+}
+
+func NewVitalReadingController(vitalReadingService service.VitalReadingService) *VitalReadingController {
+	return &VitalReadingController{
+		VitalReadingService: vitalReadingService,
+	}
+}
+
+// CreateReadings handles the ingestion of a batch of readings sent by a monitoring device
+func (vc *VitalReadingController) CreateReadings(c *gin.Context) {
+	deviceID := c.Param("id")
+
+	var batchDTO dto.VitalReadingBatchCreateDTO
+	if err := c.ShouldBindJSON(&batchDTO); err != nil {
+		log.Printf("Error binding JSON: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	response, err := vc.VitalReadingService.CreateReadings(deviceID, &batchDTO)
+	if err != nil {
+		log.Printf("Failed to store readings: %v", err)
+		switch {
+		case errors.Is(err, service.ErrDeviceNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Monitoring device not found"})
+		case errors.Is(err, service.ErrDeviceNotInUse), errors.Is(err, service.ErrDeviceWithoutPatient):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrReadingInFuture):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store readings"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// GetPatientVitals handles the bucketed time-series query of the vitals of a patient
+func (vc *VitalReadingController) GetPatientVitals(c *gin.Context) {
+	id := c.Param("id")
+
+	patientID, err := uuid.Parse(id)
+	if err != nil {
+		log.Printf("Invalid UUID: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID"})
+		return
+	}
+
+	query := dto.VitalsQuery{Metric: c.Query("metric")}
+	if from := c.Query("from"); from != "" {
+		if query.From, err = time.Parse(time.RFC3339, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from': must be an RFC3339 timestamp"})
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if query.To, err = time.Parse(time.RFC3339, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to': must be an RFC3339 timestamp"})
+			return
+		}
+	}
+	if bucket := c.Query("bucket"); bucket != "" {
+		if query.Bucket, err = time.ParseDuration(bucket); err != nil || query.Bucket <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'bucket': must be a duration such as 30s, 5m or 1h"})
+			return
+		}
+	}
+
+	vitals, err := vc.VitalReadingService.GetPatientVitals(patientID, query)
+	if err != nil {
+		log.Printf("Failed to retrieve vitals: %v", err)
+		switch {
+		case errors.Is(err, service.ErrPatientNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+		case errors.Is(err, service.ErrInvalidVitalsRange), errors.Is(err, service.ErrInvalidVitalsMetric), errors.Is(err, service.ErrInvalidVitalsBucket):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve vitals"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, vitals)
+}
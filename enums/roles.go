@@ -35,3 +35,12 @@ func MapRoleEnumsToStrings(roles []*RoleEnum) []string {
 	}
 	return result
 }
+
+// IsValid reports whether the role is a known role
+func (r RoleEnum) IsValid() bool {
+	switch r {
+	case Admin, Tester, Doctor, Nurse:
+		return true
+	}
+	return false
+}
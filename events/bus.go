@@ -0,0 +1,105 @@
+package events
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// EventType identifies the kind of event published on the bus
+type EventType string
+
+const (
+	AlertCreated   EventType = "alert.created"
+	AlertAttended  EventType = "alert.attended"
+	AlertLiberated EventType = "alert.liberated"
+	AlertEscalated EventType = "alert.escalated"
+	// AlertStatusChanged is published when an alert moves through its lifecycle (other than attended or liberated)
+	AlertStatusChanged EventType = "alert.status_changed"
+	// AlertRepeated is published when an alert is grouped into an open incident instead of being created
+	AlertRepeated EventType = "alert.repeated"
+	// DeviceOffline is published when an in use device stops reporting, with the technical alert raised
+	DeviceOffline EventType = "device.offline"
+	// DeviceOnline is published when a device reported offline reports again
+	DeviceOnline EventType = "device.online"
+)
+
+// Event is a message published on the bus
+type Event struct {
+	Type      EventType   `json:"type"`
+	Payload   interface{} `json:"payload"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+type subscriber struct {
+	ch    chan Event
+	types map[EventType]bool
+}
+
+// Bus is an in-process publish/subscribe event bus.
+// Publishing never blocks: events are dropped for subscribers whose buffer is full.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[int]*subscriber
+	nextID      int
+	bufferSize  int
+}
+
+// NewBus creates a new instance of Bus
+func NewBus(bufferSize int) *Bus {
+	return &Bus{
+		subscribers: make(map[int]*subscriber),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Subscribe registers a new subscriber for the given event types (all types if none are given).
+// It returns the channel the events are delivered on and a function to cancel the subscription.
+func (b *Bus) Subscribe(types ...EventType) (<-chan Event, func()) {
+	sub := &subscriber{
+		ch:    make(chan Event, b.bufferSize),
+		types: make(map[EventType]bool),
+	}
+	for _, t := range types {
+		sub.types[t] = true
+	}
+
+	b.mu.Lock()
+	id := b.nextID
+	b.nextID++
+	b.subscribers[id] = sub
+	b.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, id)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+	}
+
+	return sub.ch, unsubscribe
+}
+
+// Publish delivers an event to every interested subscriber
+func (b *Bus) Publish(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for id, sub := range b.subscribers {
+		if len(sub.types) > 0 && !sub.types[event.Type] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			log.Printf("Event bus subscriber %d is full, dropping event %s", id, event.Type)
+		}
+	}
+}
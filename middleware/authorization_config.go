@@ -1,14 +1,18 @@
 package middleware
 
 import (
+	"biometric-data-backend/models/dto"
+	"log"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// TokenClaimsKey is the gin context key holding the claims of the token of the authenticated caller
+const TokenClaimsKey = "token_claims"
+
 func RoleAuthorization(requiredRoles []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.Request.Header.Get("Authorization")
@@ -18,49 +22,94 @@ func RoleAuthorization(requiredRoles []string) gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		authorizeToken(c, strings.TrimPrefix(authHeader, "Bearer "), "", requiredRoles)
+	}
+}
 
-		secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
-		if len(secretKey) == 0 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "JWT secret key not set"})
-			c.Abort()
+// StreamAuthorization authorizes the alert stream with the access token of the Authorization header or, for
+// browsers that cannot send headers with EventSource and WebSocket, a stream token in the "token" query parameter
+func StreamAuthorization(requiredRoles []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authHeader := c.Request.Header.Get("Authorization"); authHeader != "" {
+			authorizeToken(c, strings.TrimPrefix(authHeader, "Bearer "), "", requiredRoles)
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return secretKey, nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		streamToken := c.Query("token")
+		if streamToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header missing"})
 			c.Abort()
 			return
 		}
+		authorizeToken(c, streamToken, dto.StreamTokenUse, requiredRoles)
+	}
+}
+
+// authorizeToken verifies a token meant for the given use (empty for access tokens) and lets the request through
+// if it grants one of the required roles
+func authorizeToken(c *gin.Context, tokenString string, use string, requiredRoles []string) {
+	token, err := parseToken(tokenString)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	// Stream tokens are only good for the stream, and access tokens are not stream tokens
+	if tokenUse, _ := claims["use"].(string); tokenUse != use {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userRoles, roleExists := claims["roles"].([]interface{})
-			if !roleExists {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Roles not found in token"})
-				c.Abort()
+	revoked, err := isTokenRevoked(claims)
+	if err != nil {
+		log.Printf("Failed to check token revocation: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate token"})
+		c.Abort()
+		return
+	}
+	if revoked {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token revoked"})
+		c.Abort()
+		return
+	}
+
+	userRoles, roleExists := claims["roles"].([]interface{})
+	if !roleExists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Roles not found in token"})
+		c.Abort()
+		return
+	}
+
+	for _, role := range userRoles {
+		for _, requiredRole := range requiredRoles {
+			if role == requiredRole {
+				c.Set(PrincipalKey, principalFromClaims(claims))
+				c.Set(TokenClaimsKey, claims)
+				c.Next()
 				return
 			}
+		}
+	}
 
-			for _, role := range userRoles {
-				for _, requiredRole := range requiredRoles {
-					if role == requiredRole {
-						c.Next()
-						return
-					}
-				}
-			}
+	c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this resource"})
+	c.Abort()
+}
 
-			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to access this resource"})
-			c.Abort()
-			return
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
-			return
-		}
+// GetTokenClaims returns the claims of the token of the authenticated caller
+func GetTokenClaims(c *gin.Context) (jwt.MapClaims, bool) {
+	value, exists := c.Get(TokenClaimsKey)
+	if !exists {
+		return nil, false
 	}
+	claims, ok := value.(jwt.MapClaims)
+	return claims, ok
 }
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DeviceIDKey is the gin context key holding the DeviceID of the authenticated monitoring device
+	DeviceIDKey = "device_id"
+	// DeviceKeyHeader is the header monitoring devices send their API key in
+	DeviceKeyHeader = "X-Device-Key"
+)
+
+// DeviceAuthenticator resolves the monitoring device an API key belongs to.
+// It reports false when the key is unknown, revoked or expired.
+type DeviceAuthenticator interface {
+	AuthenticateDevice(apiKey string) (string, bool, error)
+}
+
+// DeviceAuthentication only lets through the requests carrying a valid device API key
+func DeviceAuthentication(authenticator DeviceAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey := c.GetHeader(DeviceKeyHeader)
+		if apiKey == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Device key missing"})
+			c.Abort()
+			return
+		}
+
+		deviceID, ok, err := authenticator.AuthenticateDevice(apiKey)
+		if err != nil {
+			log.Printf("Failed to authenticate device: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to authenticate device"})
+			c.Abort()
+			return
+		}
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid device key"})
+			c.Abort()
+			return
+		}
+
+		c.Set(DeviceIDKey, deviceID)
+		c.Next()
+	}
+}
+
+// DeviceOrRoleAuthorization authenticates monitoring devices by their API key, and users by their token and roles.
+// Routes using it must restrict devices to their own data, see DeviceSelf.
+func DeviceOrRoleAuthorization(authenticator DeviceAuthenticator, requiredRoles []string) gin.HandlerFunc {
+	deviceAuthentication := DeviceAuthentication(authenticator)
+	roleAuthorization := RoleAuthorization(requiredRoles)
+	return func(c *gin.Context) {
+		if c.GetHeader(DeviceKeyHeader) != "" {
+			deviceAuthentication(c)
+			return
+		}
+		roleAuthorization(c)
+	}
+}
+
+// DeviceSelf rejects the requests of a monitoring device about another device than itself, identified by the
+// given path parameter. Requests of users go through.
+func DeviceSelf(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if deviceID, ok := GetDeviceID(c); ok && deviceID != c.Param(param) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "A device can only act for itself"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetDeviceID returns the DeviceID of the authenticated monitoring device, if the caller is one
+func GetDeviceID(c *gin.Context) (string, bool) {
+	value, exists := c.Get(DeviceIDKey)
+	if !exists {
+		return "", false
+	}
+	deviceID, ok := value.(string)
+	return deviceID, ok
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"biometric-data-backend/models/dto"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// PrincipalKey is the gin context key holding the dto.Principal of the authenticated caller
+const PrincipalKey = "principal"
+
+// SelfOrAdmin rejects the requests of users about another user than themselves, identified by the given path
+// parameter. Admins can act for any user. It must run after RoleAuthorization.
+func SelfOrAdmin(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+
+		userID, err := uuid.Parse(c.Param(param))
+		if principal.IsAdmin() || (err == nil && principal.IsUser(userID)) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only access your own account"})
+		c.Abort()
+	}
+}
+
+// GetPrincipal returns the authenticated caller, if the request carried a valid token
+func GetPrincipal(c *gin.Context) (*dto.Principal, bool) {
+	value, exists := c.Get(PrincipalKey)
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*dto.Principal)
+	return principal, ok
+}
+
+// GetUserID returns the UserID of the authenticated caller, if the token carried one
+func GetUserID(c *gin.Context) (uuid.UUID, bool) {
+	principal, ok := GetPrincipal(c)
+	if !ok || principal.UserID == nil {
+		return uuid.Nil, false
+	}
+	return *principal.UserID, true
+}
+
+// principalFromClaims builds the caller of a request from the claims of its token
+func principalFromClaims(claims jwt.MapClaims) *dto.Principal {
+	principal := &dto.Principal{
+		UserID:           uuidClaim(claims, "user_id"),
+		DoctorID:         uuidClaim(claims, "doctor_id"),
+		ServiceAccountID: uuidClaim(claims, "service_account_id"),
+	}
+	if roles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range roles {
+			principal.Roles = append(principal.Roles, fmt.Sprint(role))
+		}
+	}
+	return principal
+}
+
+func uuidClaim(claims jwt.MapClaims, claim string) *uuid.UUID {
+	value, ok := claims[claim].(string)
+	if !ok {
+		return nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenRevocationChecker tells whether a token, valid by its signature and expiry, was revoked since it was issued
+type TokenRevocationChecker interface {
+	IsTokenRevoked(claims jwt.MapClaims) (bool, error)
+}
+
+var (
+	revocationCheckersMu sync.RWMutex
+	revocationCheckers   []TokenRevocationChecker
+)
+
+// AddTokenRevocationChecker registers a checker consulted by RoleAuthorization for every token.
+// Checkers are registered once when the routes are set up.
+func AddTokenRevocationChecker(checker TokenRevocationChecker) {
+	revocationCheckersMu.Lock()
+	defer revocationCheckersMu.Unlock()
+	revocationCheckers = append(revocationCheckers, checker)
+}
+
+// isTokenRevoked reports whether any registered checker considers the token revoked
+func isTokenRevoked(claims jwt.MapClaims) (bool, error) {
+	revocationCheckersMu.RLock()
+	defer revocationCheckersMu.RUnlock()
+	for _, checker := range revocationCheckers {
+		revoked, err := checker.IsTokenRevoked(claims)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+	return false, nil
+}
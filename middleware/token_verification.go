@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenVerifier resolves the key a token must be verified with, and the algorithms and issuer it must have
+type TokenVerifier interface {
+	VerificationKey(token *jwt.Token) (interface{}, error)
+	ValidMethods() []string
+	Issuer() string
+}
+
+var (
+	tokenVerifierMu sync.RWMutex
+	tokenVerifier   TokenVerifier
+)
+
+// SetTokenVerifier sets the verifier RoleAuthorization checks every token with.
+// It is set once when the routes are set up, tokens are rejected until then.
+func SetTokenVerifier(verifier TokenVerifier) {
+	tokenVerifierMu.Lock()
+	defer tokenVerifierMu.Unlock()
+	tokenVerifier = verifier
+}
+
+// parseToken parses a token and verifies its signature, algorithm, issuer and expiry
+func parseToken(tokenString string) (*jwt.Token, error) {
+	tokenVerifierMu.RLock()
+	verifier := tokenVerifier
+	tokenVerifierMu.RUnlock()
+	if verifier == nil {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+
+	return jwt.Parse(tokenString, verifier.VerificationKey,
+		jwt.WithValidMethods(verifier.ValidMethods()),
+		jwt.WithIssuer(verifier.Issuer()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+}
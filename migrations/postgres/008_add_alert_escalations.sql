@@ -0,0 +1,34 @@
+-- Create alert_escalations table (current escalation level of each alert)
+CREATE TABLE IF NOT EXISTS alert_escalations (
+                                     alert_id UUID PRIMARY KEY,
+                                     level INT NOT NULL DEFAULT 0,
+                                     next_escalation_at TIMESTAMP,
+                                     stopped_at TIMESTAMP,
+                                     stop_reason VARCHAR(50),
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_alert_escalation
+                                         FOREIGN KEY (alert_id) REFERENCES alerts(alert_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_escalations_next_escalation_at
+    ON alert_escalations (next_escalation_at)
+    WHERE stopped_at IS NULL;
+
+-- Create alert_escalation_steps table (audit of every executed escalation step)
+CREATE TABLE IF NOT EXISTS alert_escalation_steps (
+                                     step_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     alert_id UUID NOT NULL,
+                                     level INT NOT NULL,
+                                     action VARCHAR(50) NOT NULL,
+                                     target_count INT NOT NULL DEFAULT 0,
+                                     notified_devices INT NOT NULL DEFAULT 0,
+                                     error VARCHAR(255),
+                                     executed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     CONSTRAINT fk_alert_escalation_step
+                                         FOREIGN KEY (alert_id) REFERENCES alerts(alert_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_escalation_steps_alert_id
+    ON alert_escalation_steps (alert_id);
@@ -0,0 +1,3 @@
+-- Drop the escalation tables
+DROP TABLE IF EXISTS alert_escalation_steps;
+DROP TABLE IF EXISTS alert_escalations;
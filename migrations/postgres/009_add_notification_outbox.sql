@@ -0,0 +1,21 @@
+-- Create notification_outbox table (notifications pending delivery, one row per channel)
+CREATE TABLE IF NOT EXISTS notification_outbox (
+                                     outbox_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     alert_id UUID,
+                                     channel VARCHAR(50) NOT NULL,
+                                     payload JSONB NOT NULL,
+                                     status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+                                     attempts INT NOT NULL DEFAULT 0,
+                                     next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     last_error VARCHAR(255),
+                                     sent_at TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_notification_outbox_alert
+                                         FOREIGN KEY (alert_id) REFERENCES alerts(alert_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_next_attempt_at
+    ON notification_outbox (next_attempt_at)
+    WHERE status = 'pending';
@@ -0,0 +1,2 @@
+-- Drop the notification_outbox table
+DROP TABLE IF EXISTS notification_outbox;
@@ -0,0 +1,19 @@
+-- Link phones to the user that registered them
+ALTER TABLE phones
+    ADD COLUMN IF NOT EXISTS user_id UUID;
+
+ALTER TABLE phones
+    ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+
+ALTER TABLE phones
+    ADD CONSTRAINT fk_user_phone
+        FOREIGN KEY (user_id)
+            REFERENCES users (user_id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_phones_user_id
+    ON phones (user_id)
+    WHERE active;
+
+-- On-call doctors receive every alert besides the patient's care team
+ALTER TABLE doctors
+    ADD COLUMN IF NOT EXISTS on_call BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,14 @@
+-- Remove the push routing columns
+ALTER TABLE doctors
+    DROP COLUMN IF EXISTS on_call;
+
+DROP INDEX IF EXISTS idx_phones_user_id;
+
+ALTER TABLE phones
+    DROP CONSTRAINT IF EXISTS fk_user_phone;
+
+ALTER TABLE phones
+    DROP COLUMN IF EXISTS active;
+
+ALTER TABLE phones
+    DROP COLUMN IF EXISTS user_id;
@@ -0,0 +1,29 @@
+-- Create push_tickets table (delivery status of every push notification per push token)
+CREATE TABLE IF NOT EXISTS push_tickets (
+                                     push_ticket_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     outbox_id UUID NOT NULL,
+                                     alert_id UUID,
+                                     push_token VARCHAR(255) NOT NULL,
+                                     expo_ticket_id VARCHAR(100),
+                                     status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+                                     error_code VARCHAR(100),
+                                     error_message VARCHAR(255),
+                                     receipt_checked_at TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_push_ticket_outbox
+                                         FOREIGN KEY (outbox_id) REFERENCES notification_outbox(outbox_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_push_ticket_alert
+                                         FOREIGN KEY (alert_id) REFERENCES alerts(alert_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_tickets_alert_id
+    ON push_tickets (alert_id);
+
+CREATE INDEX IF NOT EXISTS idx_push_tickets_pending_receipts
+    ON push_tickets (created_at)
+    WHERE status = 'pending' AND expo_ticket_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_phones_exponent_push_token
+    ON phones (exponent_push_token);
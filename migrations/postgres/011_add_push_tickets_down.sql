@@ -0,0 +1,3 @@
+-- Drop the push_tickets table
+DROP INDEX IF EXISTS idx_phones_exponent_push_token;
+DROP TABLE IF EXISTS push_tickets;
@@ -0,0 +1,21 @@
+-- Create vital_readings table (continuous samples sent by the monitoring devices)
+CREATE TABLE IF NOT EXISTS vital_readings (
+                                     reading_id BIGSERIAL PRIMARY KEY,
+                                     device_id VARCHAR(10) NOT NULL,
+                                     patient_id UUID NOT NULL,
+                                     recorded_at TIMESTAMP NOT NULL,
+                                     o2_saturation DOUBLE PRECISION NOT NULL,
+                                     heart_rate DOUBLE PRECISION NOT NULL,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     CONSTRAINT fk_vital_reading_device
+                                         FOREIGN KEY (device_id) REFERENCES monitoring_devices(device_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_vital_reading_patient
+                                         FOREIGN KEY (patient_id) REFERENCES patients(patient_id) ON DELETE CASCADE
+);
+
+-- A device sends at most one sample per timestamp; retried batches are ignored
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vital_readings_device_recorded_at
+    ON vital_readings (device_id, recorded_at);
+
+CREATE INDEX IF NOT EXISTS idx_vital_readings_patient_recorded_at
+    ON vital_readings (patient_id, recorded_at);
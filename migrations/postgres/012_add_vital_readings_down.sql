@@ -0,0 +1,2 @@
+-- Drop the vital_readings table
+DROP TABLE IF EXISTS vital_readings;
@@ -0,0 +1,38 @@
+-- Create threshold_rules table (rules raising alerts from the incoming vital readings)
+CREATE TABLE IF NOT EXISTS threshold_rules (
+                                     rule_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     name VARCHAR(100) NOT NULL,
+                                     patient_id UUID,
+                                     metric VARCHAR(20) NOT NULL CHECK (metric IN ('o2_saturation', 'heart_rate')),
+                                     operator VARCHAR(2) NOT NULL CHECK (operator IN ('<', '<=', '>', '>=')),
+                                     threshold DOUBLE PRECISION NOT NULL,
+                                     hysteresis DOUBLE PRECISION NOT NULL DEFAULT 0,
+                                     duration_seconds INT NOT NULL DEFAULT 0,
+                                     cooldown_seconds INT NOT NULL DEFAULT 0,
+                                     diagnosis VARCHAR(100) NOT NULL,
+                                     enabled BOOLEAN NOT NULL DEFAULT TRUE,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_threshold_rule_patient
+                                         FOREIGN KEY (patient_id) REFERENCES patients(patient_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_threshold_rules_patient_id
+    ON threshold_rules (patient_id);
+
+-- Record the rule that raised an alert
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS rule_id UUID;
+
+ALTER TABLE alerts
+    ADD CONSTRAINT fk_alert_threshold_rule
+        FOREIGN KEY (rule_id)
+            REFERENCES threshold_rules (rule_id) ON DELETE SET NULL;
+
+-- Default global rules
+INSERT INTO threshold_rules (name, metric, operator, threshold, hysteresis, duration_seconds, cooldown_seconds, diagnosis)
+VALUES ('Desaturación', 'o2_saturation', '<', 90, 2, 30, 300, 'Desaturación de oxígeno');
+
+INSERT INTO threshold_rules (name, metric, operator, threshold, hysteresis, duration_seconds, cooldown_seconds, diagnosis)
+VALUES ('Taquicardia', 'heart_rate', '>', 130, 5, 30, 300, 'Taquicardia');
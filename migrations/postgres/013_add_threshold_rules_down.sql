@@ -0,0 +1,8 @@
+-- Remove the threshold rules
+ALTER TABLE alerts
+    DROP CONSTRAINT IF EXISTS fk_alert_threshold_rule;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS rule_id;
+
+DROP TABLE IF EXISTS threshold_rules;
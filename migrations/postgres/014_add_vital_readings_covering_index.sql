@@ -0,0 +1,5 @@
+-- Cover the vitals time-series query so it is answered from the index alone
+DROP INDEX IF EXISTS idx_vital_readings_patient_recorded_at;
+
+CREATE INDEX IF NOT EXISTS idx_vital_readings_patient_recorded_at
+    ON vital_readings (patient_id, recorded_at) INCLUDE (o2_saturation, heart_rate);
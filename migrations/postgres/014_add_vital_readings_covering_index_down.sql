@@ -0,0 +1,5 @@
+-- Restore the plain patient and timestamp index
+DROP INDEX IF EXISTS idx_vital_readings_patient_recorded_at;
+
+CREATE INDEX IF NOT EXISTS idx_vital_readings_patient_recorded_at
+    ON vital_readings (patient_id, recorded_at);
@@ -0,0 +1,46 @@
+-- Create alert_incidents table (repeated alerts of a patient and diagnosis grouped together)
+CREATE TABLE IF NOT EXISTS alert_incidents (
+                                     incident_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     patient_id UUID NOT NULL,
+                                     diagnosis VARCHAR(100) NOT NULL,
+                                     first_seen_at TIMESTAMP NOT NULL,
+                                     last_seen_at TIMESTAMP NOT NULL,
+                                     alert_count INT NOT NULL DEFAULT 1,
+                                     closed_at TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_alert_incident_patient
+                                         FOREIGN KEY (patient_id) REFERENCES patients(patient_id) ON DELETE CASCADE
+);
+
+-- Open incidents are looked up on every new alert
+CREATE INDEX IF NOT EXISTS idx_alert_incidents_open
+    ON alert_incidents (patient_id, diagnosis, last_seen_at)
+    WHERE closed_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_alert_incidents_last_seen_at
+    ON alert_incidents (last_seen_at);
+
+-- Record the incident an alert belongs to
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS incident_id UUID;
+
+ALTER TABLE alerts
+    ADD CONSTRAINT fk_alert_incident
+        FOREIGN KEY (incident_id)
+            REFERENCES alert_incidents (incident_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_incident_id
+    ON alerts (incident_id);
+
+-- Existing alerts become closed incidents of their own, reusing the alert ID
+INSERT INTO alert_incidents (incident_id, patient_id, diagnosis, first_seen_at, last_seen_at, alert_count, closed_at)
+SELECT a.alert_id, a.patient_id, cd.diagnosis, a.alert_timestamp, a.alert_timestamp, 1, COALESCE(a.attended_timestamp, a.alert_timestamp)
+FROM alerts a
+         JOIN computer_diagnostics cd ON cd.diagnostic_id = a.diagnostic_id
+WHERE a.incident_id IS NULL AND a.patient_id IS NOT NULL AND a.deleted_at IS NULL;
+
+UPDATE alerts
+SET incident_id = alert_id
+WHERE incident_id IS NULL AND alert_id IN (SELECT incident_id FROM alert_incidents);
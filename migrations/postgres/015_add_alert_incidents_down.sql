@@ -0,0 +1,10 @@
+-- Remove the alert incidents
+ALTER TABLE alerts
+    DROP CONSTRAINT IF EXISTS fk_alert_incident;
+
+DROP INDEX IF EXISTS idx_alerts_incident_id;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS incident_id;
+
+DROP TABLE IF EXISTS alert_incidents;
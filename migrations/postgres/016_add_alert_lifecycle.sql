@@ -0,0 +1,37 @@
+-- Explicit alert lifecycle status
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'New';
+
+ALTER TABLE alerts
+    ADD CONSTRAINT chk_alert_status
+        CHECK (status IN ('New', 'Acknowledged', 'In Progress', 'Resolved', 'False Positive', 'Escalated'));
+
+-- Existing alerts get the status their attention fields implied
+UPDATE alerts
+SET status = 'Resolved'
+WHERE attended_by_id IS NOT NULL AND final_diagnosis IS NOT NULL AND final_diagnosis <> '';
+
+UPDATE alerts
+SET status = 'Acknowledged'
+WHERE attended_by_id IS NOT NULL AND status = 'New';
+
+CREATE INDEX IF NOT EXISTS idx_alerts_status
+    ON alerts (status);
+
+-- Create alert_events table (history of the status changes of every alert)
+CREATE TABLE IF NOT EXISTS alert_events (
+                                     event_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     alert_id UUID NOT NULL,
+                                     from_status VARCHAR(20),
+                                     to_status VARCHAR(20) NOT NULL,
+                                     user_id UUID,
+                                     note VARCHAR(500),
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     CONSTRAINT fk_alert_event_alert
+                                         FOREIGN KEY (alert_id) REFERENCES alerts(alert_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_alert_event_user
+                                         FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_events_alert_id
+    ON alert_events (alert_id, created_at);
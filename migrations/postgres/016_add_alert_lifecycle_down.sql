@@ -0,0 +1,10 @@
+-- Remove the alert lifecycle
+DROP TABLE IF EXISTS alert_events;
+
+DROP INDEX IF EXISTS idx_alerts_status;
+
+ALTER TABLE alerts
+    DROP CONSTRAINT IF EXISTS chk_alert_status;
+
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS status;
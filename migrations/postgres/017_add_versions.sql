@@ -0,0 +1,9 @@
+-- Version columns for optimistic locking of concurrent updates
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE patients
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE monitoring_devices
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
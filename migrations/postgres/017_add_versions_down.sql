@@ -0,0 +1,9 @@
+-- Remove the version columns
+ALTER TABLE alerts
+    DROP COLUMN IF EXISTS version;
+
+ALTER TABLE patients
+    DROP COLUMN IF EXISTS version;
+
+ALTER TABLE monitoring_devices
+    DROP COLUMN IF EXISTS version;
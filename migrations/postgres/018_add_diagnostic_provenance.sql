@@ -0,0 +1,11 @@
+-- Model provenance and raw class probabilities of the computer diagnostics
+ALTER TABLE computer_diagnostics
+    ADD COLUMN IF NOT EXISTS model_name VARCHAR(100),
+    ADD COLUMN IF NOT EXISTS model_version VARCHAR(50),
+    ADD COLUMN IF NOT EXISTS input_reference VARCHAR(255),
+    ADD COLUMN IF NOT EXISTS input_window_start TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS input_window_end TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS probabilities JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_computer_diagnostics_model
+    ON computer_diagnostics (model_name, model_version);
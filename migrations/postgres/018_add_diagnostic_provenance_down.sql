@@ -0,0 +1,10 @@
+-- Remove the model provenance of the computer diagnostics
+DROP INDEX IF EXISTS idx_computer_diagnostics_model;
+
+ALTER TABLE computer_diagnostics
+    DROP COLUMN IF EXISTS model_name,
+    DROP COLUMN IF EXISTS model_version,
+    DROP COLUMN IF EXISTS input_reference,
+    DROP COLUMN IF EXISTS input_window_start,
+    DROP COLUMN IF EXISTS input_window_end,
+    DROP COLUMN IF EXISTS probabilities;
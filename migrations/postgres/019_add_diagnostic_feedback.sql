@@ -0,0 +1,21 @@
+-- Create diagnostic_feedback table (opinion of the doctors on the computer diagnosis of the alerts)
+CREATE TABLE IF NOT EXISTS diagnostic_feedback (
+                                     feedback_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     alert_id UUID NOT NULL,
+                                     doctor_id UUID NOT NULL,
+                                     agrees BOOLEAN NOT NULL,
+                                     corrected_diagnosis VARCHAR(100),
+                                     note VARCHAR(1000),
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_diagnostic_feedback_alert
+                                         FOREIGN KEY (alert_id) REFERENCES alerts(alert_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_diagnostic_feedback_doctor
+                                         FOREIGN KEY (doctor_id) REFERENCES doctors(doctor_id) ON DELETE CASCADE,
+                                     CONSTRAINT chk_diagnostic_feedback_correction
+                                         CHECK (agrees OR COALESCE(corrected_diagnosis, '') <> '')
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_diagnostic_feedback_alert_doctor
+    ON diagnostic_feedback (alert_id, doctor_id);
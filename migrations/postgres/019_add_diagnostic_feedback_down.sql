@@ -0,0 +1,2 @@
+-- Remove the feedback of the doctors on the computer diagnosis
+DROP TABLE IF EXISTS diagnostic_feedback;
@@ -0,0 +1,9 @@
+-- Indexes backing the alert search: keyset pagination by timestamp and the most selective filters
+CREATE INDEX IF NOT EXISTS idx_alerts_timestamp_id
+    ON alerts (alert_timestamp, alert_id);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_patient_timestamp
+    ON alerts (patient_id, alert_timestamp);
+
+CREATE INDEX IF NOT EXISTS idx_alerts_attended_by
+    ON alerts (attended_by_id);
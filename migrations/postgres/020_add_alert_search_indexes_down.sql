@@ -0,0 +1,4 @@
+-- Remove the alert search indexes
+DROP INDEX IF EXISTS idx_alerts_timestamp_id;
+DROP INDEX IF EXISTS idx_alerts_patient_timestamp;
+DROP INDEX IF EXISTS idx_alerts_attended_by;
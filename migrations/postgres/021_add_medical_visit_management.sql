@@ -0,0 +1,46 @@
+-- Record the location of the patient during a visit
+ALTER TABLE medical_visits
+    ADD COLUMN IF NOT EXISTS location VARCHAR(100);
+
+UPDATE medical_visits mv
+SET location = p.location
+FROM patients p
+WHERE p.patient_id = mv.patient_id AND mv.discharge_date IS NULL AND mv.location IS NULL;
+
+-- Only the latest open visit of a patient stays open, the older ones end when it started
+UPDATE medical_visits mv
+SET discharge_date = latest.entry_date
+FROM (SELECT DISTINCT ON (patient_id) patient_id, medical_visit_id, entry_date
+      FROM medical_visits
+      WHERE discharge_date IS NULL AND deleted_at IS NULL
+      ORDER BY patient_id, entry_date DESC) latest
+WHERE mv.patient_id = latest.patient_id
+  AND mv.medical_visit_id <> latest.medical_visit_id
+  AND mv.discharge_date IS NULL
+  AND mv.deleted_at IS NULL;
+
+-- A patient has at most one open visit
+CREATE UNIQUE INDEX IF NOT EXISTS idx_medical_visits_open_patient
+    ON medical_visits (patient_id)
+    WHERE discharge_date IS NULL AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_medical_visits_patient_entry_date
+    ON medical_visits (patient_id, entry_date);
+
+-- Create medical_visit_transfers table (moves of an admitted patient between locations)
+CREATE TABLE IF NOT EXISTS medical_visit_transfers (
+                                     transfer_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     medical_visit_id UUID NOT NULL,
+                                     from_location VARCHAR(100),
+                                     to_location VARCHAR(100) NOT NULL,
+                                     reason VARCHAR(255),
+                                     transferred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_medical_visit_transfer_visit
+                                         FOREIGN KEY (medical_visit_id) REFERENCES medical_visits(medical_visit_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_medical_visit_transfers_visit
+    ON medical_visit_transfers (medical_visit_id, transferred_at);
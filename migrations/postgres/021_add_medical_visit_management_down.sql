@@ -0,0 +1,9 @@
+-- Remove the transfers and the open visit constraint of the medical visits
+DROP TABLE IF EXISTS medical_visit_transfers;
+
+DROP INDEX IF EXISTS idx_medical_visits_patient_entry_date;
+
+DROP INDEX IF EXISTS idx_medical_visits_open_patient;
+
+ALTER TABLE medical_visits
+    DROP COLUMN IF EXISTS location;
@@ -0,0 +1,74 @@
+-- Create locations table (building, ward, room and bed hierarchy of the medical center)
+CREATE TABLE IF NOT EXISTS locations (
+                                     location_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     parent_id UUID,
+                                     type VARCHAR(20) NOT NULL,
+                                     name VARCHAR(100) NOT NULL,
+                                     out_of_service BOOLEAN NOT NULL DEFAULT FALSE,
+                                     version INT NOT NULL DEFAULT 1,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_location_parent
+                                         FOREIGN KEY (parent_id) REFERENCES locations(location_id),
+                                     CONSTRAINT chk_location_type
+                                         CHECK (type IN ('building', 'ward', 'room', 'bed')),
+                                     CONSTRAINT chk_location_parent
+                                         CHECK ((type = 'building') = (parent_id IS NULL))
+);
+
+-- Names are unique among the children of a location
+CREATE UNIQUE INDEX IF NOT EXISTS idx_locations_parent_name
+    ON locations (COALESCE(parent_id, '00000000-0000-0000-0000-000000000000'::uuid), name)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_locations_type
+    ON locations (type);
+
+-- Create ward_staff table (doctors notified of the alerts of the patients of a ward)
+CREATE TABLE IF NOT EXISTS ward_staff (
+                                     location_id UUID NOT NULL,
+                                     doctor_id UUID NOT NULL,
+                                     PRIMARY KEY (location_id, doctor_id),
+                                     CONSTRAINT fk_ward_staff_location
+                                         FOREIGN KEY (location_id) REFERENCES locations(location_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_ward_staff_doctor
+                                         FOREIGN KEY (doctor_id) REFERENCES doctors(doctor_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ward_staff_doctor
+    ON ward_staff (doctor_id);
+
+-- Assign a bed to the patient during a visit
+ALTER TABLE medical_visits
+    ADD COLUMN IF NOT EXISTS bed_id UUID;
+
+ALTER TABLE medical_visits
+    ADD CONSTRAINT fk_medical_visit_bed
+        FOREIGN KEY (bed_id) REFERENCES locations(location_id);
+
+-- A bed holds at most one patient with an open visit
+CREATE UNIQUE INDEX IF NOT EXISTS idx_medical_visits_open_bed
+    ON medical_visits (bed_id)
+    WHERE discharge_date IS NULL AND deleted_at IS NULL AND bed_id IS NOT NULL;
+
+ALTER TABLE medical_visit_transfers
+    ADD COLUMN IF NOT EXISTS from_bed_id UUID,
+    ADD COLUMN IF NOT EXISTS to_bed_id UUID;
+
+ALTER TABLE medical_visit_transfers
+    ADD CONSTRAINT fk_medical_visit_transfer_from_bed
+        FOREIGN KEY (from_bed_id) REFERENCES locations(location_id),
+    ADD CONSTRAINT fk_medical_visit_transfer_to_bed
+        FOREIGN KEY (to_bed_id) REFERENCES locations(location_id);
+
+-- Record the ward the patient was in when an alert was raised
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS ward_id UUID;
+
+ALTER TABLE alerts
+    ADD CONSTRAINT fk_alert_ward
+        FOREIGN KEY (ward_id) REFERENCES locations(location_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_ward_timestamp
+    ON alerts (ward_id, alert_timestamp);
@@ -0,0 +1,22 @@
+-- Remove the location hierarchy and the bed and ward assignments
+DROP INDEX IF EXISTS idx_alerts_ward_timestamp;
+
+ALTER TABLE alerts
+    DROP CONSTRAINT IF EXISTS fk_alert_ward,
+    DROP COLUMN IF EXISTS ward_id;
+
+ALTER TABLE medical_visit_transfers
+    DROP CONSTRAINT IF EXISTS fk_medical_visit_transfer_from_bed,
+    DROP CONSTRAINT IF EXISTS fk_medical_visit_transfer_to_bed,
+    DROP COLUMN IF EXISTS from_bed_id,
+    DROP COLUMN IF EXISTS to_bed_id;
+
+DROP INDEX IF EXISTS idx_medical_visits_open_bed;
+
+ALTER TABLE medical_visits
+    DROP CONSTRAINT IF EXISTS fk_medical_visit_bed,
+    DROP COLUMN IF EXISTS bed_id;
+
+DROP TABLE IF EXISTS ward_staff;
+
+DROP TABLE IF EXISTS locations;
@@ -0,0 +1,58 @@
+-- Record when a monitoring device was linked to its patient
+ALTER TABLE monitoring_devices
+    ADD COLUMN IF NOT EXISTS linked_at TIMESTAMP;
+
+-- Free and unavailable devices are not linked to any patient
+UPDATE monitoring_devices
+SET patient_id = NULL, linked_by_id = NULL
+WHERE status IN ('Free', 'Unavailable') AND patient_id IS NOT NULL;
+
+UPDATE monitoring_devices
+SET linked_at = updated_at
+WHERE patient_id IS NOT NULL AND linked_at IS NULL;
+
+ALTER TABLE monitoring_devices
+    ADD CONSTRAINT chk_monitoring_device_patient
+        CHECK ((status IN ('In Use', 'Connecting')) = (patient_id IS NOT NULL));
+
+-- Create device_assignments table (history of the patients a device was linked to)
+CREATE TABLE IF NOT EXISTS device_assignments (
+                                     assignment_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     device_id VARCHAR(10) NOT NULL,
+                                     patient_id UUID NOT NULL,
+                                     linked_by_id UUID,
+                                     linked_by_user_id UUID,
+                                     linked_at TIMESTAMP NOT NULL,
+                                     connected_at TIMESTAMP,
+                                     unlinked_by_user_id UUID,
+                                     unlinked_at TIMESTAMP,
+                                     end_reason VARCHAR(50),
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_device_assignment_device
+                                         FOREIGN KEY (device_id) REFERENCES monitoring_devices(device_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_device_assignment_patient
+                                         FOREIGN KEY (patient_id) REFERENCES patients(patient_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_device_assignment_linked_by
+                                         FOREIGN KEY (linked_by_id) REFERENCES doctors(doctor_id) ON DELETE SET NULL
+);
+
+-- A device and a patient have at most one open assignment
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_assignments_open_device
+    ON device_assignments (device_id)
+    WHERE unlinked_at IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_assignments_open_patient
+    ON device_assignments (patient_id)
+    WHERE unlinked_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_device_assignments_device_linked_at
+    ON device_assignments (device_id, linked_at);
+
+-- Currently linked devices get their open assignment
+INSERT INTO device_assignments (device_id, patient_id, linked_by_id, linked_at, connected_at)
+SELECT d.device_id, d.patient_id, d.linked_by_id, d.linked_at,
+       CASE WHEN d.status = 'In Use' THEN d.linked_at END
+FROM monitoring_devices d
+WHERE d.patient_id IS NOT NULL AND d.deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- Remove the device assignment history
+DROP TABLE IF EXISTS device_assignments;
+
+ALTER TABLE monitoring_devices
+    DROP CONSTRAINT IF EXISTS chk_monitoring_device_patient,
+    DROP COLUMN IF EXISTS linked_at;
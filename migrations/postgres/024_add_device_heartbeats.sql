@@ -0,0 +1,45 @@
+-- Track the connectivity and the last reported state of monitoring devices
+ALTER TABLE monitoring_devices
+    ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS online BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS battery_level SMALLINT,
+    ADD COLUMN IF NOT EXISTS signal_strength INT,
+    ADD COLUMN IF NOT EXISTS firmware_version VARCHAR(50);
+
+ALTER TABLE monitoring_devices
+    ADD CONSTRAINT chk_monitoring_device_battery_level
+        CHECK (battery_level IS NULL OR battery_level BETWEEN 0 AND 100);
+
+-- Existing devices were last seen with their latest reading
+UPDATE monitoring_devices d
+SET last_seen_at = r.recorded_at
+FROM (SELECT device_id, MAX(recorded_at) AS recorded_at FROM vital_readings GROUP BY device_id) r
+WHERE r.device_id = d.device_id AND d.last_seen_at IS NULL;
+
+-- Create technical_alerts table (non-clinical alerts about the monitoring equipment)
+CREATE TABLE IF NOT EXISTS technical_alerts (
+                                     technical_alert_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     device_id VARCHAR(10) NOT NULL,
+                                     patient_id UUID,
+                                     type VARCHAR(50) NOT NULL,
+                                     message VARCHAR(255) NOT NULL,
+                                     last_seen_at TIMESTAMP,
+                                     raised_at TIMESTAMP NOT NULL,
+                                     resolved_at TIMESTAMP,
+                                     resolved_by_id UUID,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_technical_alert_device
+                                         FOREIGN KEY (device_id) REFERENCES monitoring_devices(device_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_technical_alert_patient
+                                         FOREIGN KEY (patient_id) REFERENCES patients(patient_id) ON DELETE SET NULL
+);
+
+-- A device has at most one open technical alert of each type
+CREATE UNIQUE INDEX IF NOT EXISTS idx_technical_alerts_open_device_type
+    ON technical_alerts (device_id, type)
+    WHERE resolved_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_technical_alerts_raised_at
+    ON technical_alerts (raised_at);
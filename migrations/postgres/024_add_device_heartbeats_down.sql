@@ -0,0 +1,10 @@
+-- Remove the device connectivity tracking and the technical alerts
+DROP TABLE IF EXISTS technical_alerts;
+
+ALTER TABLE monitoring_devices
+    DROP CONSTRAINT IF EXISTS chk_monitoring_device_battery_level,
+    DROP COLUMN IF EXISTS last_seen_at,
+    DROP COLUMN IF EXISTS online,
+    DROP COLUMN IF EXISTS battery_level,
+    DROP COLUMN IF EXISTS signal_strength,
+    DROP COLUMN IF EXISTS firmware_version;
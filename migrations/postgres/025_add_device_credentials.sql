@@ -0,0 +1,27 @@
+-- Create device_credentials table (API keys monitoring devices authenticate with)
+CREATE TABLE IF NOT EXISTS device_credentials (
+                                     credential_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     device_id VARCHAR(10) NOT NULL,
+                                     name VARCHAR(100),
+                                     key_prefix VARCHAR(20) NOT NULL,
+                                     key_hash VARCHAR(64) NOT NULL,
+                                     created_by_id UUID,
+                                     last_used_at TIMESTAMP,
+                                     expires_at TIMESTAMP,
+                                     revoked_at TIMESTAMP,
+                                     replaced_by_id UUID,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_device_credential_device
+                                         FOREIGN KEY (device_id) REFERENCES monitoring_devices(device_id) ON DELETE CASCADE,
+                                     CONSTRAINT fk_device_credential_replaced_by
+                                         FOREIGN KEY (replaced_by_id) REFERENCES device_credentials(credential_id) ON DELETE SET NULL
+);
+
+-- Keys are looked up by their public prefix on every device request
+CREATE UNIQUE INDEX IF NOT EXISTS idx_device_credentials_key_prefix
+    ON device_credentials (key_prefix);
+
+CREATE INDEX IF NOT EXISTS idx_device_credentials_device_id
+    ON device_credentials (device_id);
@@ -0,0 +1,2 @@
+-- Remove the device credentials
+DROP TABLE IF EXISTS device_credentials;
@@ -0,0 +1,24 @@
+-- Create service_accounts table (integrations exchanging client credentials for tokens)
+CREATE TABLE IF NOT EXISTS service_accounts (
+                                     service_account_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     name VARCHAR(100) NOT NULL,
+                                     description VARCHAR(255),
+                                     client_id VARCHAR(40) NOT NULL,
+                                     client_secret_hash VARCHAR(255) NOT NULL,
+                                     scopes VARCHAR(255) NOT NULL,
+                                     created_by_id UUID,
+                                     expires_at TIMESTAMP,
+                                     revoked_at TIMESTAMP,
+                                     tokens_valid_after TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     last_token_at TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_service_accounts_client_id
+    ON service_accounts (client_id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_service_accounts_name
+    ON service_accounts (name)
+    WHERE deleted_at IS NULL;
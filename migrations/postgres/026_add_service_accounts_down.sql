@@ -0,0 +1,2 @@
+-- Remove the service accounts
+DROP TABLE IF EXISTS service_accounts;
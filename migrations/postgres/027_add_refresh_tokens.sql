@@ -0,0 +1,42 @@
+-- Bumped to revoke all the access tokens of a user, e.g. on a password or role change
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+
+-- Create refresh_tokens table (rotating refresh tokens, a family is the chain of tokens of one login)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+                                     refresh_token_id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+                                     user_id UUID NOT NULL,
+                                     family_id UUID NOT NULL,
+                                     token_hash VARCHAR(64) NOT NULL,
+                                     expires_at TIMESTAMP NOT NULL,
+                                     used_at TIMESTAMP,
+                                     revoked_at TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT fk_refresh_token_user
+                                         FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash
+    ON refresh_tokens (token_hash);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id
+    ON refresh_tokens (family_id);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id
+    ON refresh_tokens (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_expires_at
+    ON refresh_tokens (expires_at);
+
+-- Create revoked_tokens table (IDs of access tokens revoked before their expiry, e.g. at logout)
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+                                     jti VARCHAR(64) PRIMARY KEY,
+                                     user_id UUID,
+                                     expires_at TIMESTAMP NOT NULL,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at
+    ON revoked_tokens (expires_at);
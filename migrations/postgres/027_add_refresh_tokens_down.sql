@@ -0,0 +1,6 @@
+-- Remove the refresh tokens and the token denylist
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS token_version;
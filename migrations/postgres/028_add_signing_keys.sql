@@ -0,0 +1,17 @@
+-- Create signing_keys table (asymmetric keys signing the tokens, identified by the kid of the token header)
+CREATE TABLE IF NOT EXISTS signing_keys (
+                                     kid VARCHAR(64) PRIMARY KEY,
+                                     algorithm VARCHAR(10) NOT NULL,
+                                     private_key TEXT NOT NULL,
+                                     public_key TEXT NOT NULL,
+                                     activates_at TIMESTAMP NOT NULL,
+                                     expires_at TIMESTAMP,
+                                     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                     deleted_at TIMESTAMP,
+                                     CONSTRAINT chk_signing_key_algorithm CHECK (algorithm IN ('RS256', 'EdDSA'))
+);
+
+-- Published keys are loaded by every instance on startup and then periodically
+CREATE INDEX IF NOT EXISTS idx_signing_keys_expires_at
+    ON signing_keys (expires_at);
@@ -0,0 +1,2 @@
+-- Remove the signing keys
+DROP TABLE IF EXISTS signing_keys;
@@ -0,0 +1,19 @@
+-- Record the monitoring device that raised an alert, so alerts can be filtered by device after it is re-paired
+ALTER TABLE alerts
+    ADD COLUMN IF NOT EXISTS device_id VARCHAR(10);
+
+ALTER TABLE alerts
+    ADD CONSTRAINT fk_alert_device
+        FOREIGN KEY (device_id) REFERENCES monitoring_devices(device_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_alerts_device_timestamp
+    ON alerts (device_id, alert_timestamp);
+
+-- Existing alerts get the device the patient was linked to when they were raised
+UPDATE alerts a
+SET device_id = da.device_id
+FROM device_assignments da
+WHERE a.device_id IS NULL
+  AND da.patient_id = a.patient_id
+  AND da.linked_at <= a.alert_timestamp
+  AND (da.unlinked_at IS NULL OR da.unlinked_at > a.alert_timestamp);
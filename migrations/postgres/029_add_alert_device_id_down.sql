@@ -0,0 +1,6 @@
+-- Remove the device that raised each alert
+DROP INDEX IF EXISTS idx_alerts_device_timestamp;
+
+ALTER TABLE alerts
+    DROP CONSTRAINT IF EXISTS fk_alert_device,
+    DROP COLUMN IF EXISTS device_id;
@@ -0,0 +1,8 @@
+-- A reading may carry a single metric; the metric left out is stored as NULL
+ALTER TABLE vital_readings
+    ALTER COLUMN o2_saturation DROP NOT NULL,
+    ALTER COLUMN heart_rate DROP NOT NULL;
+
+ALTER TABLE vital_readings
+    ADD CONSTRAINT chk_vital_reading_metric
+        CHECK (o2_saturation IS NOT NULL OR heart_rate IS NOT NULL);
@@ -0,0 +1,10 @@
+-- Readings without one of the metrics cannot be kept once both are required again
+ALTER TABLE vital_readings
+    DROP CONSTRAINT IF EXISTS chk_vital_reading_metric;
+
+DELETE FROM vital_readings
+WHERE o2_saturation IS NULL OR heart_rate IS NULL;
+
+ALTER TABLE vital_readings
+    ALTER COLUMN o2_saturation SET NOT NULL,
+    ALTER COLUMN heart_rate SET NOT NULL;
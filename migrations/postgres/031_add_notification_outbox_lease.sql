@@ -0,0 +1,9 @@
+-- Claim outbox entries before delivering them: an entry being sent is 'sending' until its lease expires
+ALTER TABLE notification_outbox
+    ADD COLUMN IF NOT EXISTS lease_expires_at TIMESTAMP,
+    DROP CONSTRAINT IF EXISTS notification_outbox_status_check,
+    ADD CONSTRAINT notification_outbox_status_check CHECK (status IN ('pending', 'sending', 'sent', 'failed'));
+
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_lease_expires_at
+    ON notification_outbox (lease_expires_at)
+    WHERE status = 'sending';
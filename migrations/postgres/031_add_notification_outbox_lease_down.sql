@@ -0,0 +1,9 @@
+-- Remove the delivery leases of the outbox, handing the entries being sent back to the dispatcher
+DROP INDEX IF EXISTS idx_notification_outbox_lease_expires_at;
+
+UPDATE notification_outbox SET status = 'pending' WHERE status = 'sending';
+
+ALTER TABLE notification_outbox
+    DROP CONSTRAINT IF EXISTS notification_outbox_status_check,
+    ADD CONSTRAINT notification_outbox_status_check CHECK (status IN ('pending', 'sent', 'failed')),
+    DROP COLUMN IF EXISTS lease_expires_at;
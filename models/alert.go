@@ -8,16 +8,26 @@ import (
 type Alert struct {
 	BaseModel
 	AttendedTimestamp  *time.Time
-	AlertID            uuid.UUID           `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	AlertTimestamp     time.Time           `gorm:"not null"`
-	AttendedByID       uuid.NullUUID       `gorm:"type:uuid"`
-	AttendedBy         *Doctor             `gorm:"foreignKey:AttendedByID"`
-	FinalDiagnosis     string              `gorm:"size:100;default:null"`
-	PatientID          uuid.UUID           `gorm:"type:uuid;not null"`
-	Patient            *Patient            `gorm:"foreignKey:PatientID;references:PatientID"`
-	BiometricDataID    uuid.UUID           `gorm:"type:uuid;not null"`
-	BiometricData      *BiometricData      `gorm:"foreignKey:BiometricDataID;references:BiometricDataID"`
-	DiagnosticID       uuid.UUID           `gorm:"type:uuid;not null"`
-	ComputerDiagnostic *ComputerDiagnostic `gorm:"foreignKey:DiagnosticID;references:DiagnosticID"`
-	Doctors            []*Doctor           `gorm:"many2many:doctor_alerts"`
+	Status             string                `gorm:"size:20;not null;default:'New';check:status in ('New', 'Acknowledged', 'In Progress', 'Resolved', 'False Positive', 'Escalated')"`
+	AlertID            uuid.UUID             `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	AlertTimestamp     time.Time             `gorm:"not null"`
+	AttendedByID       uuid.NullUUID         `gorm:"type:uuid"`
+	AttendedBy         *Doctor               `gorm:"foreignKey:AttendedByID"`
+	FinalDiagnosis     string                `gorm:"size:100;default:null"`
+	PatientID          uuid.UUID             `gorm:"type:uuid;not null"`
+	Patient            *Patient              `gorm:"foreignKey:PatientID;references:PatientID"`
+	BiometricDataID    uuid.UUID             `gorm:"type:uuid;not null"`
+	BiometricData      *BiometricData        `gorm:"foreignKey:BiometricDataID;references:BiometricDataID"`
+	DiagnosticID       uuid.UUID             `gorm:"type:uuid;not null"`
+	ComputerDiagnostic *ComputerDiagnostic   `gorm:"foreignKey:DiagnosticID;references:DiagnosticID"`
+	Doctors            []*Doctor             `gorm:"many2many:doctor_alerts"`
+	RuleID             *uuid.UUID            `gorm:"type:uuid;default:null"`
+	Rule               *ThresholdRule        `gorm:"foreignKey:RuleID;references:RuleID"`
+	IncidentID         *uuid.UUID            `gorm:"type:uuid;default:null"`
+	Incident           *AlertIncident        `gorm:"foreignKey:IncidentID;references:IncidentID"`
+	Events             []*AlertEvent         `gorm:"foreignKey:AlertID;references:AlertID"`
+	Feedback           []*DiagnosticFeedback `gorm:"foreignKey:AlertID;references:AlertID"`
+	WardID             *uuid.UUID            `gorm:"type:uuid;default:null"`
+	DeviceID           *string               `gorm:"size:10;default:null"`
+	Version            int                   `gorm:"not null;default:1"`
 }
@@ -0,0 +1,14 @@
+package models
+
+// AlertResponseStats aggregates the alerts of a group and the time it took to acknowledge them.
+// Time statistics are nil when no alert of the group was acknowledged.
+type AlertResponseStats struct {
+	GroupKey        string
+	GroupLabel      string
+	Total           int64
+	Acknowledged    int64
+	AvgSecondsToAck *float64
+	P50SecondsToAck *float64
+	P90SecondsToAck *float64
+	P95SecondsToAck *float64
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type AlertEscalation struct {
+	BaseModel
+	AlertID          uuid.UUID  `gorm:"type:uuid;primaryKey"`
+	Alert            *Alert     `gorm:"foreignKey:AlertID;references:AlertID"`
+	Level            int        `gorm:"not null;default:0"`
+	NextEscalationAt *time.Time `gorm:"index"`
+	StoppedAt        *time.Time
+	StopReason       string                 `gorm:"size:50;default:null"`
+	Steps            []*AlertEscalationStep `gorm:"foreignKey:AlertID;references:AlertID"`
+}
+
+type AlertEscalationStep struct {
+	StepID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	AlertID         uuid.UUID `gorm:"type:uuid;not null"`
+	Level           int       `gorm:"not null"`
+	Action          string    `gorm:"size:50;not null"`
+	TargetCount     int       `gorm:"not null;default:0"`
+	NotifiedDevices int       `gorm:"not null;default:0"`
+	Error           string    `gorm:"size:255;default:null"`
+	ExecutedAt      time.Time `gorm:"not null"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AlertEvent records a status change of an alert: who moved it, from which status, to which one and when.
+// Events are append-only; an event without UserID was recorded by the system (creation, escalation).
+type AlertEvent struct {
+	EventID    uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	AlertID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	FromStatus string     `gorm:"size:20;default:null"`
+	ToStatus   string     `gorm:"size:20;not null"`
+	UserID     *uuid.UUID `gorm:"type:uuid;default:null"`
+	User       *User      `gorm:"foreignKey:UserID;references:UserID"`
+	Note       string     `gorm:"size:500;default:null"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AlertIncident groups the repeated alerts raised for the same patient and diagnosis within the dedup window.
+// Only the first alert of an incident is stored and notified; repetitions bump the count and last seen time.
+type AlertIncident struct {
+	BaseModel
+	IncidentID  uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	PatientID   uuid.UUID  `gorm:"type:uuid;not null"`
+	Patient     *Patient   `gorm:"foreignKey:PatientID;references:PatientID"`
+	Diagnosis   string     `gorm:"size:100;not null"`
+	FirstSeenAt time.Time  `gorm:"not null"`
+	LastSeenAt  time.Time  `gorm:"not null"`
+	AlertCount  int        `gorm:"not null;default:1"`
+	ClosedAt    *time.Time `gorm:"default:null"`
+	Alerts      []*Alert   `gorm:"foreignKey:IncidentID;references:IncidentID"`
+}
@@ -5,8 +5,8 @@ import "github.com/google/uuid"
 type BiometricData struct {
 	BaseModel
 	BiometricDataID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	O2Saturation    float64   `gorm:"not null"`
-	HeartRate       float64   `gorm:"not null"`
+	O2Saturation    *float64
+	HeartRate       *float64
 }
 
 func (BiometricData) TableName() string {
@@ -2,11 +2,26 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"time"
 )
 
 type ComputerDiagnostic struct {
 	BaseModel
-	DiagnosticID uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	Diagnosis    string    `gorm:"size:100;not null"`
-	Percentage   float64   `gorm:"type:decimal(4,2)"`
+	DiagnosticID     uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Diagnosis        string    `gorm:"size:100;not null"`
+	Percentage       float64   `gorm:"type:decimal(4,2)"`
+	ModelName        string    `gorm:"size:100;default:null"`
+	ModelVersion     string    `gorm:"size:50;default:null"`
+	InputReference   string    `gorm:"size:255;default:null"`
+	InputWindowStart *time.Time
+	InputWindowEnd   *time.Time
+	// Probabilities holds the raw probability of every class as a JSON object
+	Probabilities string `gorm:"type:jsonb;default:null"`
+}
+
+// DiagnosisConfusionCell counts the alerts whose computer diagnosis was Predicted and whose final diagnosis was Actual
+type DiagnosisConfusionCell struct {
+	Predicted string
+	Actual    string
+	Count     int64
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceAssignment records a period during which a monitoring device was linked to a patient
+type DeviceAssignment struct {
+	BaseModel
+	AssignmentID     uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"assignment_id"`
+	DeviceID         string     `gorm:"size:10;not null" json:"device_id"`
+	PatientID        uuid.UUID  `gorm:"type:uuid;not null" json:"patient_id"`
+	Patient          *Patient   `gorm:"foreignKey:PatientID;references:PatientID"`
+	LinkedByID       *uuid.UUID `gorm:"type:uuid;default:null" json:"linked_by_id"`
+	LinkedBy         *Doctor    `gorm:"foreignKey:LinkedByID"`
+	LinkedByUserID   *uuid.UUID `gorm:"type:uuid;default:null" json:"linked_by_user_id"`
+	LinkedAt         time.Time  `gorm:"not null" json:"linked_at"`
+	ConnectedAt      *time.Time `gorm:"default:null" json:"connected_at"`
+	UnlinkedByUserID *uuid.UUID `gorm:"type:uuid;default:null" json:"unlinked_by_user_id"`
+	UnlinkedAt       *time.Time `gorm:"default:null" json:"unlinked_at"`
+	EndReason        string     `gorm:"size:50;default:null" json:"end_reason"`
+}
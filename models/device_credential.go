@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceCredential is an API key a monitoring device authenticates with. Only the hash of the key is stored,
+// along with its public prefix to look it up.
+type DeviceCredential struct {
+	BaseModel
+	CredentialID uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"credential_id"`
+	DeviceID     string     `gorm:"size:10;not null" json:"device_id"`
+	Name         string     `gorm:"size:100;default:null" json:"name"`
+	KeyPrefix    string     `gorm:"size:20;not null;unique" json:"key_prefix"`
+	KeyHash      string     `gorm:"size:64;not null" json:"-"`
+	CreatedByID  *uuid.UUID `gorm:"type:uuid;default:null" json:"created_by_id"`
+	LastUsedAt   *time.Time `gorm:"default:null" json:"last_used_at"`
+	ExpiresAt    *time.Time `gorm:"default:null" json:"expires_at"`
+	RevokedAt    *time.Time `gorm:"default:null" json:"revoked_at"`
+	ReplacedByID *uuid.UUID `gorm:"type:uuid;default:null" json:"replaced_by_id"`
+}
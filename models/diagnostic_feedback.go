@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// DiagnosticFeedback is the opinion of a doctor on the computer diagnosis of an alert.
+// A doctor gives at most one feedback per alert; giving it again replaces the previous one.
+type DiagnosticFeedback struct {
+	BaseModel
+	FeedbackID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	AlertID            uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_diagnostic_feedback_alert_doctor"`
+	DoctorID           uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_diagnostic_feedback_alert_doctor"`
+	Doctor             *Doctor   `gorm:"foreignKey:DoctorID;references:DoctorID"`
+	Agrees             bool      `gorm:"not null"`
+	CorrectedDiagnosis string    `gorm:"size:100;default:null"`
+	Note               string    `gorm:"size:1000;default:null"`
+}
+
+func (DiagnosticFeedback) TableName() string {
+	return "diagnostic_feedback"
+}
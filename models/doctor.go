@@ -12,6 +12,7 @@ type Doctor struct {
 	IssuanceDate   time.Time  `gorm:"not null" json:"issuance_date" time_format:"2006-01-02"`
 	Name           string     `gorm:"size:100;not null" json:"name"`
 	Specialization string     `gorm:"size:100" json:"specialization"`
+	OnCall         bool       `gorm:"not null;default:false" json:"on_call"`
 	UserID         uuid.UUID  `gorm:"type:uuid;not null; unique"`
 	User           User       `gorm:"foreignKey:UserID;references:UserID"`
 	Alerts         []*Alert   `gorm:"many2many:doctor_alerts" json:"alerts"`
@@ -0,0 +1,145 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"strconv"
+)
+
+// AlertAnalyticsQuery holds the parameters of the alert analytics report.
+// From and To are dates (YYYY-MM-DD) in the time zone, both inclusive. WardID optionally restricts the report
+// to the alerts raised in a ward.
+type AlertAnalyticsQuery struct {
+	From     string
+	To       string
+	Timezone string
+	WardID   string
+}
+
+// TimeToAcknowledgeDTO summarizes the seconds elapsed between an alert being raised and acknowledged
+type TimeToAcknowledgeDTO struct {
+	AvgSeconds *float64 `json:"avg_seconds"`
+	P50Seconds *float64 `json:"p50_seconds"`
+	P90Seconds *float64 `json:"p90_seconds"`
+	P95Seconds *float64 `json:"p95_seconds"`
+}
+
+// AlertResponseStatsDTO holds the alert counts and response times of the whole report
+type AlertResponseStatsDTO struct {
+	Total             int64                `json:"total"`
+	Acknowledged      int64                `json:"acknowledged"`
+	TimeToAcknowledge TimeToAcknowledgeDTO `json:"time_to_acknowledge"`
+}
+
+// AlertDiagnosisStatsDTO holds the alert counts and response times of a diagnosis
+type AlertDiagnosisStatsDTO struct {
+	Diagnosis string `json:"diagnosis"`
+	AlertResponseStatsDTO
+}
+
+// AlertLocationStatsDTO holds the alert counts and response times of a patient location
+type AlertLocationStatsDTO struct {
+	Location string `json:"location"`
+	AlertResponseStatsDTO
+}
+
+// AlertWardStatsDTO holds the alert counts and response times of a ward, empty for patients without bed
+type AlertWardStatsDTO struct {
+	WardID string `json:"ward_id"`
+	Name   string `json:"name"`
+	AlertResponseStatsDTO
+}
+
+// AlertDoctorStatsDTO holds the alert counts and response times of an attending doctor
+type AlertDoctorStatsDTO struct {
+	DoctorID string `json:"doctor_id"`
+	Name     string `json:"name"`
+	AlertResponseStatsDTO
+}
+
+// AlertHourStatsDTO holds the alert counts and response times of an hour of the day
+type AlertHourStatsDTO struct {
+	Hour int `json:"hour"`
+	AlertResponseStatsDTO
+}
+
+// AlertAnalyticsDTO is the alert response-time report over a date range
+type AlertAnalyticsDTO struct {
+	From        string                    `json:"from"`
+	To          string                    `json:"to"`
+	Timezone    string                    `json:"timezone"`
+	Summary     AlertResponseStatsDTO     `json:"summary"`
+	ByDiagnosis []*AlertDiagnosisStatsDTO `json:"by_diagnosis"`
+	ByLocation  []*AlertLocationStatsDTO  `json:"by_location"`
+	ByWard      []*AlertWardStatsDTO      `json:"by_ward"`
+	ByDoctor    []*AlertDoctorStatsDTO    `json:"by_doctor"`
+	ByHourOfDay []*AlertHourStatsDTO      `json:"by_hour_of_day"`
+}
+
+// MapAlertResponseStatsToDTO maps an AlertResponseStats model to an AlertResponseStatsDTO
+func MapAlertResponseStatsToDTO(stats *models.AlertResponseStats) AlertResponseStatsDTO {
+	if stats == nil {
+		return AlertResponseStatsDTO{}
+	}
+	return AlertResponseStatsDTO{
+		Total:        stats.Total,
+		Acknowledged: stats.Acknowledged,
+		TimeToAcknowledge: TimeToAcknowledgeDTO{
+			AvgSeconds: stats.AvgSecondsToAck,
+			P50Seconds: stats.P50SecondsToAck,
+			P90Seconds: stats.P90SecondsToAck,
+			P95Seconds: stats.P95SecondsToAck,
+		},
+	}
+}
+
+// MapAlertResponseStatsToDiagnosisDTOs maps the stats grouped by diagnosis
+func MapAlertResponseStatsToDiagnosisDTOs(stats []*models.AlertResponseStats) []*AlertDiagnosisStatsDTO {
+	dtos := make([]*AlertDiagnosisStatsDTO, 0, len(stats))
+	for _, s := range stats {
+		dtos = append(dtos, &AlertDiagnosisStatsDTO{Diagnosis: s.GroupKey, AlertResponseStatsDTO: MapAlertResponseStatsToDTO(s)})
+	}
+	return dtos
+}
+
+// MapAlertResponseStatsToLocationDTOs maps the stats grouped by patient location
+func MapAlertResponseStatsToLocationDTOs(stats []*models.AlertResponseStats) []*AlertLocationStatsDTO {
+	dtos := make([]*AlertLocationStatsDTO, 0, len(stats))
+	for _, s := range stats {
+		dtos = append(dtos, &AlertLocationStatsDTO{Location: s.GroupKey, AlertResponseStatsDTO: MapAlertResponseStatsToDTO(s)})
+	}
+	return dtos
+}
+
+// MapAlertResponseStatsToWardDTOs maps the stats grouped by ward
+func MapAlertResponseStatsToWardDTOs(stats []*models.AlertResponseStats) []*AlertWardStatsDTO {
+	dtos := make([]*AlertWardStatsDTO, 0, len(stats))
+	for _, s := range stats {
+		dtos = append(dtos, &AlertWardStatsDTO{WardID: s.GroupKey, Name: s.GroupLabel, AlertResponseStatsDTO: MapAlertResponseStatsToDTO(s)})
+	}
+	return dtos
+}
+
+// MapAlertResponseStatsToDoctorDTOs maps the stats grouped by attending doctor
+func MapAlertResponseStatsToDoctorDTOs(stats []*models.AlertResponseStats) []*AlertDoctorStatsDTO {
+	dtos := make([]*AlertDoctorStatsDTO, 0, len(stats))
+	for _, s := range stats {
+		dtos = append(dtos, &AlertDoctorStatsDTO{DoctorID: s.GroupKey, Name: s.GroupLabel, AlertResponseStatsDTO: MapAlertResponseStatsToDTO(s)})
+	}
+	return dtos
+}
+
+// MapAlertResponseStatsToHourDTOs maps the stats grouped by hour of the day, filling the 24 hours including those without alerts
+func MapAlertResponseStatsToHourDTOs(stats []*models.AlertResponseStats) []*AlertHourStatsDTO {
+	dtos := make([]*AlertHourStatsDTO, 24)
+	for hour := range dtos {
+		dtos[hour] = &AlertHourStatsDTO{Hour: hour}
+	}
+	for _, s := range stats {
+		hour, err := strconv.Atoi(s.GroupKey)
+		if err != nil || hour < 0 || hour > 23 {
+			continue
+		}
+		dtos[hour].AlertResponseStatsDTO = MapAlertResponseStatsToDTO(s)
+	}
+	return dtos
+}
@@ -2,6 +2,9 @@ package dto
 
 import (
 	"biometric-data-backend/models"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,18 +18,96 @@ type AlertCreateDTO struct {
 	O2Saturation float64 `json:"o2_saturation"`
 	HeartRate    float64 `json:"heart_rate"`
 	Timezone     string  `json:"timezone"`
+	// Provenance of the computer diagnosis, optional
+	DiagnosticProvenanceDTO
 }
 
 type AlertCreateResponseDTO struct {
-	AlertID string `json:"alert_id,omitempty"`
-	Message string `json:"message"`
+	AlertID      string `json:"alert_id,omitempty"`
+	IncidentID   string `json:"incident_id,omitempty"`
+	Deduplicated bool   `json:"deduplicated,omitempty"`
+	Message      string `json:"message"`
+}
+
+// AlertFilter holds the optional filters of the alert search
+type AlertFilter struct {
+	PatientID     string
+	DNI           string
+	DeviceID      string
+	Diagnosis     string
+	Statuses      []string
+	AttendedByID  string
+	Location      string
+	WardID        string
+	From          *time.Time
+	To            *time.Time
+	MinConfidence *float64
+}
+
+const (
+	AlertSortByTimestamp  = "alert_timestamp"
+	AlertSortByConfidence = "confidence"
+)
+
+// AlertSort is the order of the alert search: the field sorted by and its direction
+type AlertSort struct {
+	Field      string
+	Descending bool
+}
+
+// AlertCursor points at the last alert of a page of the alert search, the next page starts right after it.
+// Value is the sorted field of that alert: its timestamp (RFC3339) or its confidence.
+type AlertCursor struct {
+	Value   string    `json:"v"`
+	AlertID uuid.UUID `json:"id"`
+}
+
+// EncodeAlertCursor encodes a cursor as an opaque URL-safe string
+func EncodeAlertCursor(cursor *AlertCursor) string {
+	encoded, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeAlertCursor decodes a cursor encoded by EncodeAlertCursor
+func DecodeAlertCursor(encoded string) (*AlertCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var cursor AlertCursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// SortValue parses the value of the cursor for the field sorted by
+func (c *AlertCursor) SortValue(field string) (interface{}, error) {
+	if field == AlertSortByConfidence {
+		return strconv.ParseFloat(c.Value, 64)
+	}
+	return time.Parse(time.RFC3339Nano, c.Value)
 }
 
 // AlertUpdateDTO is used for updating an existing alert
 type AlertUpdateDTO struct {
+	// AttendedTimestamp is still accepted but ignored, an alert is attended when the server acknowledges it
 	AttendedTimestamp *time.Time `json:"attended_timestamp"`
 	AttendedByID      uuid.UUID  `json:"attended_by_id"`
 	FinalDiagnosis    string     `json:"final_diagnosis"`
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
+}
+
+// AlertTransitionDTO is the optional body of the alert status transition endpoints
+type AlertTransitionDTO struct {
+	// DoctorID is the doctor attending the alert when acknowledging it, defaults to the doctor of the current user
+	DoctorID *uuid.UUID `json:"doctor_id"`
+	// FinalDiagnosis is required to resolve an alert
+	FinalDiagnosis string `json:"final_diagnosis" binding:"max=100"`
+	Note           string `json:"note" binding:"max=500"`
+	// ExpectedVersion is taken from the If-Match header
+	ExpectedVersion *int `json:"-"`
 }
 
 // AlertDTO is used for retrieving an alert along with related entities
@@ -40,6 +121,10 @@ type AlertDTO struct {
 	BiometricData      *BiometricDataDTO      `json:"biometric_data"`
 	ComputerDiagnostic *ComputerDiagnosticDTO `json:"computer_diagnostic"`
 	Patient            *PatientForAlertDTO    `json:"patient"`
+	RuleID             *uuid.UUID             `json:"rule_id,omitempty"`
+	IncidentID         *uuid.UUID             `json:"incident_id,omitempty"`
+	WardID             *uuid.UUID             `json:"ward_id,omitempty"`
+	Version            int                    `json:"version"`
 }
 
 // MapAlertToDTO maps an Alert model to an AlertDTO
@@ -56,22 +141,24 @@ func MapAlertToDTO(alert *models.Alert) *AlertDTO {
 	}
 
 	attendedTimestamp := ""
-	alertStatus := "Unattended"
 	if alert.AttendedTimestamp != nil {
 		attendedTimestamp = alert.AttendedTimestamp.Format(time.RFC3339)
-		alertStatus = "Attended"
 	}
 
 	return &AlertDTO{
 		AlertID:            alert.AlertID,
 		AlertTimestamp:     alert.AlertTimestamp,
 		AttendedTimestamp:  attendedTimestamp,
-		AlertStatus:        alertStatus,
+		AlertStatus:        alert.Status,
 		AttendedBy:         MapDoctorToDTO(alert.AttendedBy),
 		FinalDiagnosis:     alert.FinalDiagnosis,
 		BiometricData:      MapBiometricDataToDTO(alert.BiometricData),
 		ComputerDiagnostic: MapComputerDiagnosticToDTO(alert.ComputerDiagnostic),
 		Patient:            MapPatientToPatientForAlertDTO(alert.Patient),
+		RuleID:             alert.RuleID,
+		IncidentID:         alert.IncidentID,
+		WardID:             alert.WardID,
+		Version:            alert.Version,
 	}
 }
 
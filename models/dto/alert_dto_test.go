@@ -0,0 +1,81 @@
+package dto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestAlertCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor AlertCursor
+	}{
+		{"timestamp", AlertCursor{Value: "2024-10-01T12:00:00.123456Z", AlertID: uuid.New()}},
+		{"confidence", AlertCursor{Value: "87.5", AlertID: uuid.New()}},
+		{"empty value", AlertCursor{AlertID: uuid.New()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoded, err := DecodeAlertCursor(EncodeAlertCursor(&tt.cursor))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if *decoded != tt.cursor {
+				t.Errorf("got %+v, want %+v", *decoded, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestDecodeAlertCursorRejectsGarbage(t *testing.T) {
+	for _, encoded := range []string{"not base64!", "bm90IGpzb24", "WzEsMl0"} {
+		if _, err := DecodeAlertCursor(encoded); err == nil {
+			t.Errorf("%q: expected an error", encoded)
+		}
+	}
+}
+
+func TestAlertCursorSortValue(t *testing.T) {
+	timestamp := time.Date(2024, 10, 1, 12, 0, 0, 123456000, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		field   string
+		want    interface{}
+		wantErr bool
+	}{
+		{"timestamp", timestamp.Format(time.RFC3339Nano), AlertSortByTimestamp, timestamp, false},
+		{"confidence", "87.5", AlertSortByConfidence, 87.5, false},
+		{"confidence in a timestamp cursor", timestamp.Format(time.RFC3339Nano), AlertSortByConfidence, nil, true},
+		{"timestamp in a confidence cursor", "87.5", AlertSortByTimestamp, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor := &AlertCursor{Value: tt.value}
+			got, err := cursor.SortValue(tt.field)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if gotTime, ok := got.(time.Time); ok {
+				if !gotTime.Equal(tt.want.(time.Time)) {
+					t.Errorf("got %v, want %v", gotTime, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+// AlertEscalationStepDTO is used for retrieving an executed escalation step
+type AlertEscalationStepDTO struct {
+	Level             int       `json:"level"`
+	Action            string    `json:"action"`
+	TargetCount       int       `json:"target_count"`
+	NotifiedDevices   int       `json:"notified_devices"`
+	Error             string    `json:"error,omitempty"`
+	ExecutedAt        time.Time `json:"executed_at"`
+	SecondsAfterAlert float64   `json:"seconds_after_alert"`
+}
+
+// AlertEscalationDTO is used for auditing the escalation of an alert
+type AlertEscalationDTO struct {
+	AlertID           uuid.UUID                 `json:"alert_id"`
+	Level             int                       `json:"level"`
+	NextEscalationAt  *time.Time                `json:"next_escalation_at"`
+	StoppedAt         *time.Time                `json:"stopped_at"`
+	StopReason        string                    `json:"stop_reason"`
+	AlertTimestamp    time.Time                 `json:"alert_timestamp"`
+	AttendedTimestamp *time.Time                `json:"attended_timestamp"`
+	ResponseSeconds   *float64                  `json:"response_seconds"`
+	Steps             []*AlertEscalationStepDTO `json:"steps"`
+}
+
+// MapAlertEscalationToDTO maps an AlertEscalation model to an AlertEscalationDTO
+func MapAlertEscalationToDTO(escalation *models.AlertEscalation) *AlertEscalationDTO {
+	escalationDTO := &AlertEscalationDTO{
+		AlertID:          escalation.AlertID,
+		Level:            escalation.Level,
+		NextEscalationAt: escalation.NextEscalationAt,
+		StoppedAt:        escalation.StoppedAt,
+		StopReason:       escalation.StopReason,
+		Steps:            make([]*AlertEscalationStepDTO, 0),
+	}
+
+	if escalation.Alert != nil {
+		escalationDTO.AlertTimestamp = escalation.Alert.AlertTimestamp
+		escalationDTO.AttendedTimestamp = escalation.Alert.AttendedTimestamp
+		if escalation.Alert.AttendedTimestamp != nil {
+			responseSeconds := escalation.Alert.AttendedTimestamp.Sub(escalation.Alert.AlertTimestamp).Seconds()
+			escalationDTO.ResponseSeconds = &responseSeconds
+		}
+	}
+
+	for _, step := range escalation.Steps {
+		escalationDTO.Steps = append(escalationDTO.Steps, &AlertEscalationStepDTO{
+			Level:             step.Level,
+			Action:            step.Action,
+			TargetCount:       step.TargetCount,
+			NotifiedDevices:   step.NotifiedDevices,
+			Error:             step.Error,
+			ExecutedAt:        step.ExecutedAt,
+			SecondsAfterAlert: step.ExecutedAt.Sub(escalationDTO.AlertTimestamp).Seconds(),
+		})
+	}
+
+	return escalationDTO
+}
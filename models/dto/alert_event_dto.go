@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+// AlertEventDTO is a status change in the history of an alert
+type AlertEventDTO struct {
+	EventID    uuid.UUID  `json:"event_id"`
+	FromStatus string     `json:"from_status"`
+	ToStatus   string     `json:"to_status"`
+	UserID     *uuid.UUID `json:"user_id"`
+	Username   string     `json:"username,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// MapAlertEventToDTO maps an AlertEvent model to an AlertEventDTO
+func MapAlertEventToDTO(alertEvent *models.AlertEvent) *AlertEventDTO {
+	username := ""
+	if alertEvent.User != nil {
+		username = alertEvent.User.Username
+	}
+
+	return &AlertEventDTO{
+		EventID:    alertEvent.EventID,
+		FromStatus: alertEvent.FromStatus,
+		ToStatus:   alertEvent.ToStatus,
+		UserID:     alertEvent.UserID,
+		Username:   username,
+		Note:       alertEvent.Note,
+		CreatedAt:  alertEvent.CreatedAt,
+	}
+}
+
+// MapAlertEventsToDTOs maps a list of AlertEvent models to a list of AlertEventDTOs
+func MapAlertEventsToDTOs(alertEvents []*models.AlertEvent) []*AlertEventDTO {
+	alertEventDTOs := make([]*AlertEventDTO, 0, len(alertEvents))
+	for _, alertEvent := range alertEvents {
+		alertEventDTOs = append(alertEventDTOs, MapAlertEventToDTO(alertEvent))
+	}
+	return alertEventDTOs
+}
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"github.com/google/uuid"
+	"time"
+)
+
+// AlertIncidentDTO is an incident with the alerts grouped into it
+type AlertIncidentDTO struct {
+	IncidentID  uuid.UUID           `json:"incident_id"`
+	Patient     *PatientForAlertDTO `json:"patient"`
+	Diagnosis   string              `json:"diagnosis"`
+	FirstSeenAt time.Time           `json:"first_seen_at"`
+	LastSeenAt  time.Time           `json:"last_seen_at"`
+	AlertCount  int                 `json:"alert_count"`
+	ClosedAt    *time.Time          `json:"closed_at"`
+	Alerts      []*AlertDTO         `json:"alerts"`
+}
+
+// MapAlertIncidentToDTO maps an AlertIncident model to an AlertIncidentDTO
+func MapAlertIncidentToDTO(incident *models.AlertIncident) *AlertIncidentDTO {
+	if incident.Patient == nil {
+		incident.Patient = &models.Patient{}
+	}
+
+	return &AlertIncidentDTO{
+		IncidentID:  incident.IncidentID,
+		Patient:     MapPatientToPatientForAlertDTO(incident.Patient),
+		Diagnosis:   incident.Diagnosis,
+		FirstSeenAt: incident.FirstSeenAt,
+		LastSeenAt:  incident.LastSeenAt,
+		AlertCount:  incident.AlertCount,
+		ClosedAt:    incident.ClosedAt,
+		Alerts:      MapAlertsToDTOs(incident.Alerts),
+	}
+}
+
+// MapAlertIncidentsToDTOs maps a list of AlertIncident models to a list of AlertIncidentDTOs
+func MapAlertIncidentsToDTOs(incidents []*models.AlertIncident) []*AlertIncidentDTO {
+	incidentDTOs := make([]*AlertIncidentDTO, 0, len(incidents))
+	for _, incident := range incidents {
+		incidentDTOs = append(incidentDTOs, MapAlertIncidentToDTO(incident))
+	}
+	return incidentDTOs
+}
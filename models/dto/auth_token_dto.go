@@ -0,0 +1,30 @@
+package dto
+
+// AuthTokensDTO is returned at login and refresh. Token is the access token, the refresh token is exchanged for
+// a new pair once it expires.
+type AuthTokensDTO struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// StreamTokenUse is the "use" claim of the tokens that only open the alert stream
+const StreamTokenUse = "alert_stream"
+
+// StreamTokenDTO is a short-lived token for opening the alert stream from a browser, which cannot send an
+// Authorization header with EventSource or WebSocket
+type StreamTokenDTO struct {
+	Token     string `json:"token"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// RefreshTokenDTO is used for exchanging a refresh token for a new pair of tokens
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LogoutDTO is used for logging out, from the current session only unless AllSessions is set
+type LogoutDTO struct {
+	AllSessions bool `json:"all_sessions"`
+}
@@ -16,10 +16,10 @@ type BiometricDataUpdateDTO struct {
 	HeartRate    float64 `json:"heart_rate"`
 }
 
-// BiometricDataDTO is used for retrieving a biometric record
+// BiometricDataDTO is used for retrieving a biometric record. A metric that was not measured is null.
 type BiometricDataDTO struct {
-	O2Saturation float64 `json:"o2_saturation"`
-	HeartRate    float64 `json:"heart_rate"`
+	O2Saturation *float64 `json:"o2_saturation"`
+	HeartRate    *float64 `json:"heart_rate"`
 }
 
 // MapBiometricDataToDTO maps a BiometricDataData model to a BiometricDataDTO
@@ -2,14 +2,28 @@ package dto
 
 import (
 	"biometric-data-backend/models"
+	"encoding/json"
 	"github.com/google/uuid"
+	"log"
+	"time"
 )
 
+// DiagnosticProvenanceDTO identifies the model that produced a computer diagnosis and the input it was given
+type DiagnosticProvenanceDTO struct {
+	ModelName        string             `json:"model_name,omitempty" binding:"max=100"`
+	ModelVersion     string             `json:"model_version,omitempty" binding:"max=50"`
+	InputReference   string             `json:"input_reference,omitempty" binding:"max=255"`
+	InputWindowStart *time.Time         `json:"input_window_start,omitempty"`
+	InputWindowEnd   *time.Time         `json:"input_window_end,omitempty"`
+	Probabilities    map[string]float64 `json:"probabilities,omitempty" binding:"omitempty,dive,gte=0,lte=1"`
+}
+
 // ComputerDiagnosticCreateDTO is used for creating a new computer diagnosis
 type ComputerDiagnosticCreateDTO struct {
 	AlertID    uuid.UUID `json:"alert_id"`
 	Diagnosis  string    `json:"diagnosis"`
 	Percentage float64   `json:"percentage"`
+	DiagnosticProvenanceDTO
 }
 
 // ComputerDiagnosticUpdateDTO is used for updating an existing computer diagnosis
@@ -17,12 +31,14 @@ type ComputerDiagnosticUpdateDTO struct {
 	AlertID    uuid.UUID `json:"alert_id"`
 	Diagnosis  string    `json:"diagnosis"`
 	Percentage float64   `json:"percentage"`
+	DiagnosticProvenanceDTO
 }
 
 // ComputerDiagnosticDTO is used for retrieving a computer diagnosis
 type ComputerDiagnosticDTO struct {
 	Diagnosis  string  `json:"diagnosis"`
 	Percentage float64 `json:"percentage"`
+	DiagnosticProvenanceDTO
 }
 
 // MapComputerDiagnosticToDTO maps a ComputerDiagnostic model to a ComputerDiagnosisDTO
@@ -30,6 +46,14 @@ func MapComputerDiagnosticToDTO(diagnosis *models.ComputerDiagnostic) *ComputerD
 	return &ComputerDiagnosticDTO{
 		Diagnosis:  diagnosis.Diagnosis,
 		Percentage: diagnosis.Percentage,
+		DiagnosticProvenanceDTO: DiagnosticProvenanceDTO{
+			ModelName:        diagnosis.ModelName,
+			ModelVersion:     diagnosis.ModelVersion,
+			InputReference:   diagnosis.InputReference,
+			InputWindowStart: diagnosis.InputWindowStart,
+			InputWindowEnd:   diagnosis.InputWindowEnd,
+			Probabilities:    unmarshalProbabilities(diagnosis.Probabilities),
+		},
 	}
 }
 
@@ -41,3 +65,34 @@ func MapComputerDiagnosticsToDTOs(diagnoses []*models.ComputerDiagnostic) []*Com
 	}
 	return diagnosisDTOs
 }
+
+// ApplyDiagnosticProvenance copies the provenance of a DiagnosticProvenanceDTO onto a ComputerDiagnostic model
+func ApplyDiagnosticProvenance(diagnosis *models.ComputerDiagnostic, provenance *DiagnosticProvenanceDTO) {
+	diagnosis.ModelName = provenance.ModelName
+	diagnosis.ModelVersion = provenance.ModelVersion
+	diagnosis.InputReference = provenance.InputReference
+	diagnosis.InputWindowStart = provenance.InputWindowStart
+	diagnosis.InputWindowEnd = provenance.InputWindowEnd
+	diagnosis.Probabilities = ""
+	if len(provenance.Probabilities) > 0 {
+		probabilities, err := json.Marshal(provenance.Probabilities)
+		if err != nil {
+			log.Printf("Failed to encode class probabilities: %v", err)
+			return
+		}
+		diagnosis.Probabilities = string(probabilities)
+	}
+}
+
+// unmarshalProbabilities decodes the class probabilities stored as a JSON object, nil when there are none
+func unmarshalProbabilities(probabilities string) map[string]float64 {
+	if probabilities == "" {
+		return nil
+	}
+	var decoded map[string]float64
+	if err := json.Unmarshal([]byte(probabilities), &decoded); err != nil {
+		log.Printf("Failed to decode class probabilities: %v", err)
+		return nil
+	}
+	return decoded
+}
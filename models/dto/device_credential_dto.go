@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeviceCredentialCreateDTO is used for issuing a new API key to a monitoring device
+type DeviceCredentialCreateDTO struct {
+	Name string `json:"name" binding:"max=100"`
+}
+
+// DeviceCredentialDTO is used for retrieving a device credential, without its key
+type DeviceCredentialDTO struct {
+	CredentialID uuid.UUID  `json:"credential_id"`
+	DeviceID     string     `json:"device_id"`
+	Name         string     `json:"name"`
+	KeyPrefix    string     `json:"key_prefix"`
+	CreatedByID  *uuid.UUID `json:"created_by_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id"`
+}
+
+// DeviceCredentialSecretDTO is returned once when a key is issued, the key cannot be retrieved afterwards
+type DeviceCredentialSecretDTO struct {
+	DeviceCredentialDTO
+	APIKey string `json:"api_key"`
+}
+
+// MapDeviceCredentialToDTO maps a DeviceCredential model to a DeviceCredentialDTO
+func MapDeviceCredentialToDTO(credential *models.DeviceCredential) *DeviceCredentialDTO {
+	return &DeviceCredentialDTO{
+		CredentialID: credential.CredentialID,
+		DeviceID:     credential.DeviceID,
+		Name:         credential.Name,
+		KeyPrefix:    credential.KeyPrefix,
+		CreatedByID:  credential.CreatedByID,
+		CreatedAt:    credential.CreatedAt,
+		LastUsedAt:   credential.LastUsedAt,
+		ExpiresAt:    credential.ExpiresAt,
+		RevokedAt:    credential.RevokedAt,
+		ReplacedByID: credential.ReplacedByID,
+	}
+}
+
+// MapDeviceCredentialsToDTOs maps a list of DeviceCredential models to a list of DeviceCredentialDTOs
+func MapDeviceCredentialsToDTOs(credentials []*models.DeviceCredential) []*DeviceCredentialDTO {
+	var credentialDTOs = make([]*DeviceCredentialDTO, 0, len(credentials))
+	for _, credential := range credentials {
+		credentialDTOs = append(credentialDTOs, MapDeviceCredentialToDTO(credential))
+	}
+	return credentialDTOs
+}
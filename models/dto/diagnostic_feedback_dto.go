@@ -0,0 +1,138 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"github.com/google/uuid"
+	"time"
+)
+
+// DiagnosticFeedbackCreateDTO is used for giving feedback on the computer diagnosis of an alert
+type DiagnosticFeedbackCreateDTO struct {
+	Agrees *bool `json:"agrees" binding:"required"`
+	// CorrectedDiagnosis is required when the doctor disagrees with the computer diagnosis
+	CorrectedDiagnosis string `json:"corrected_diagnosis" binding:"max=100"`
+	Note               string `json:"note" binding:"max=1000"`
+}
+
+// DiagnosticFeedbackDTO is used for retrieving the feedback of a doctor on the computer diagnosis of an alert
+type DiagnosticFeedbackDTO struct {
+	FeedbackID         uuid.UUID `json:"feedback_id"`
+	AlertID            uuid.UUID `json:"alert_id"`
+	DoctorID           uuid.UUID `json:"doctor_id"`
+	DoctorName         string    `json:"doctor_name,omitempty"`
+	Agrees             bool      `json:"agrees"`
+	CorrectedDiagnosis string    `json:"corrected_diagnosis,omitempty"`
+	Note               string    `json:"note,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// MapDiagnosticFeedbackToDTO maps a DiagnosticFeedback model to a DiagnosticFeedbackDTO
+func MapDiagnosticFeedbackToDTO(feedback *models.DiagnosticFeedback) *DiagnosticFeedbackDTO {
+	doctorName := ""
+	if feedback.Doctor != nil {
+		doctorName = feedback.Doctor.Name
+	}
+
+	return &DiagnosticFeedbackDTO{
+		FeedbackID:         feedback.FeedbackID,
+		AlertID:            feedback.AlertID,
+		DoctorID:           feedback.DoctorID,
+		DoctorName:         doctorName,
+		Agrees:             feedback.Agrees,
+		CorrectedDiagnosis: feedback.CorrectedDiagnosis,
+		Note:               feedback.Note,
+		CreatedAt:          feedback.CreatedAt,
+		UpdatedAt:          feedback.UpdatedAt,
+	}
+}
+
+// MapDiagnosticFeedbackToDTOs maps a list of DiagnosticFeedback models to a list of DiagnosticFeedbackDTOs
+func MapDiagnosticFeedbackToDTOs(feedback []*models.DiagnosticFeedback) []*DiagnosticFeedbackDTO {
+	feedbackDTOs := make([]*DiagnosticFeedbackDTO, 0, len(feedback))
+	for _, f := range feedback {
+		feedbackDTOs = append(feedbackDTOs, MapDiagnosticFeedbackToDTO(f))
+	}
+	return feedbackDTOs
+}
+
+// TrainingDataQuery holds the parameters of the export of labeled alerts.
+// From and To are dates (YYYY-MM-DD) in the time zone, both inclusive.
+type TrainingDataQuery struct {
+	From         string
+	To           string
+	Timezone     string
+	ModelName    string
+	ModelVersion string
+	Format       string
+}
+
+// TrainingReadingDTO is a vital reading of the input window of a labeled alert
+type TrainingReadingDTO struct {
+	RecordedAt   time.Time `json:"recorded_at"`
+	O2Saturation *float64  `json:"o2_saturation"`
+	HeartRate    *float64  `json:"heart_rate"`
+}
+
+// TrainingSampleDTO is a labeled alert: the vitals of its input window, the computer diagnosis and the label of the doctors.
+// LabelSource is "feedback", "final_diagnosis" or "false_positive".
+type TrainingSampleDTO struct {
+	AlertID        uuid.UUID              `json:"alert_id"`
+	PatientID      uuid.UUID              `json:"patient_id"`
+	AlertTimestamp time.Time              `json:"alert_timestamp"`
+	WindowStart    time.Time              `json:"window_start"`
+	WindowEnd      time.Time              `json:"window_end"`
+	O2Saturation   *float64               `json:"o2_saturation"`
+	HeartRate      *float64               `json:"heart_rate"`
+	Readings       []*TrainingReadingDTO  `json:"readings"`
+	Prediction     *ComputerDiagnosticDTO `json:"prediction"`
+	Label          string                 `json:"label"`
+	LabelSource    string                 `json:"label_source"`
+	DoctorAgrees   *bool                  `json:"doctor_agrees,omitempty"`
+}
+
+// MapAlertToTrainingSample maps a labeled alert and the readings of its input window to a TrainingSampleDTO
+func MapAlertToTrainingSample(alert *models.Alert, windowStart, windowEnd time.Time, readings []*models.VitalReading) *TrainingSampleDTO {
+	sample := &TrainingSampleDTO{
+		AlertID:        alert.AlertID,
+		PatientID:      alert.PatientID,
+		AlertTimestamp: alert.AlertTimestamp.UTC(),
+		WindowStart:    windowStart.UTC(),
+		WindowEnd:      windowEnd.UTC(),
+		Readings:       make([]*TrainingReadingDTO, 0, len(readings)),
+	}
+	if alert.BiometricData != nil {
+		sample.O2Saturation = alert.BiometricData.O2Saturation
+		sample.HeartRate = alert.BiometricData.HeartRate
+	}
+	for _, reading := range readings {
+		sample.Readings = append(sample.Readings, &TrainingReadingDTO{
+			RecordedAt:   reading.RecordedAt.UTC(),
+			O2Saturation: reading.O2Saturation,
+			HeartRate:    reading.HeartRate,
+		})
+	}
+	if alert.ComputerDiagnostic != nil {
+		sample.Prediction = MapComputerDiagnosticToDTO(alert.ComputerDiagnostic)
+	}
+
+	// The most recent feedback wins over the final diagnosis, which wins over a false positive status
+	switch {
+	case len(alert.Feedback) > 0:
+		feedback := alert.Feedback[0]
+		sample.LabelSource = "feedback"
+		sample.DoctorAgrees = &feedback.Agrees
+		sample.Label = feedback.CorrectedDiagnosis
+		if feedback.Agrees && alert.ComputerDiagnostic != nil {
+			sample.Label = alert.ComputerDiagnostic.Diagnosis
+		}
+	case alert.FinalDiagnosis != "":
+		sample.LabelSource = "final_diagnosis"
+		sample.Label = alert.FinalDiagnosis
+	case alert.Status == string(enum.AlertStatusFalsePositive):
+		sample.LabelSource = "false_positive"
+		sample.Label = string(enum.AlertStatusFalsePositive)
+	}
+	return sample
+}
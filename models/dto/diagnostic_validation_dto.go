@@ -0,0 +1,104 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"sort"
+)
+
+// DiagnosticValidationQuery holds the parameters of the computer diagnosis validation report.
+// From and To are dates (YYYY-MM-DD) in the time zone, both inclusive.
+type DiagnosticValidationQuery struct {
+	From         string
+	To           string
+	Timezone     string
+	ModelName    string
+	ModelVersion string
+}
+
+// DiagnosisClassStatsDTO holds how well the computer diagnosis performs for a single diagnosis.
+// Precision and recall are nil when the diagnosis was never predicted or never the final diagnosis.
+type DiagnosisClassStatsDTO struct {
+	Diagnosis     string   `json:"diagnosis"`
+	Predicted     int64    `json:"predicted"`
+	Actual        int64    `json:"actual"`
+	TruePositives int64    `json:"true_positives"`
+	Precision     *float64 `json:"precision"`
+	Recall        *float64 `json:"recall"`
+}
+
+// DiagnosticValidationDTO compares the computer diagnosis of the alerts with their final diagnosis.
+// ConfusionMatrix[i][j] counts the alerts whose final diagnosis is Labels[i] and computer diagnosis is Labels[j].
+type DiagnosticValidationDTO struct {
+	From            string                    `json:"from"`
+	To              string                    `json:"to"`
+	Timezone        string                    `json:"timezone"`
+	ModelName       string                    `json:"model_name,omitempty"`
+	ModelVersion    string                    `json:"model_version,omitempty"`
+	Total           int64                     `json:"total"`
+	Accuracy        *float64                  `json:"accuracy"`
+	Labels          []string                  `json:"labels"`
+	ConfusionMatrix [][]int64                 `json:"confusion_matrix"`
+	Classes         []*DiagnosisClassStatsDTO `json:"classes"`
+}
+
+// MapDiagnosisConfusionToDTO builds the confusion matrix and the per class precision and recall of the counted alerts
+func MapDiagnosisConfusionToDTO(cells []*models.DiagnosisConfusionCell) *DiagnosticValidationDTO {
+	indexes := make(map[string]int)
+	labels := make([]string, 0)
+	for _, cell := range cells {
+		for _, label := range []string{cell.Predicted, cell.Actual} {
+			if _, ok := indexes[label]; !ok {
+				indexes[label] = 0
+				labels = append(labels, label)
+			}
+		}
+	}
+	sort.Strings(labels)
+	for i, label := range labels {
+		indexes[label] = i
+	}
+
+	matrix := make([][]int64, len(labels))
+	for i := range matrix {
+		matrix[i] = make([]int64, len(labels))
+	}
+	classes := make([]*DiagnosisClassStatsDTO, len(labels))
+	for i, label := range labels {
+		classes[i] = &DiagnosisClassStatsDTO{Diagnosis: label}
+	}
+
+	var total, correct int64
+	for _, cell := range cells {
+		predicted, actual := indexes[cell.Predicted], indexes[cell.Actual]
+		matrix[actual][predicted] += cell.Count
+		classes[predicted].Predicted += cell.Count
+		classes[actual].Actual += cell.Count
+		if predicted == actual {
+			classes[predicted].TruePositives += cell.Count
+			correct += cell.Count
+		}
+		total += cell.Count
+	}
+
+	for _, class := range classes {
+		class.Precision = ratio(class.TruePositives, class.Predicted)
+		class.Recall = ratio(class.TruePositives, class.Actual)
+	}
+
+	return &DiagnosticValidationDTO{
+		Total:           total,
+		Accuracy:        ratio(correct, total),
+		Labels:          labels,
+		ConfusionMatrix: matrix,
+		Classes:         classes,
+	}
+}
+
+// ratio divides two counts, nil when the denominator is zero
+func ratio(numerator, denominator int64) *float64 {
+	if denominator == 0 {
+		return nil
+	}
+	value := float64(numerator) / float64(denominator)
+	return &value
+}
@@ -27,6 +27,11 @@ type DoctorUpdateDTO struct {
 	IssuanceDate   string   `json:"issuance_date"`
 }
 
+// DoctorOnCallUpdateDTO is used for setting whether a doctor is on call
+type DoctorOnCallUpdateDTO struct {
+	OnCall *bool `json:"on_call" binding:"required"`
+}
+
 // DoctorDTO is used for retrieving a doctor
 type DoctorDTO struct {
 	DoctorID       uuid.UUID `json:"doctor_id"`
@@ -34,6 +39,7 @@ type DoctorDTO struct {
 	Name           string    `json:"name"`
 	Specialization string    `json:"specialization"`
 	IssuanceDate   string    `json:"issuance_date"`
+	OnCall         bool      `json:"on_call"`
 }
 
 // MapDoctorToDTO maps a Doctor model to a DoctorDTO
@@ -47,6 +53,7 @@ func MapDoctorToDTO(doctor *models.Doctor) *DoctorDTO {
 		Name:           doctor.Name,
 		Specialization: doctor.Specialization,
 		IssuanceDate:   doctor.IssuanceDate.Format("2006-01-02"),
+		OnCall:         doctor.OnCall,
 	}
 }
 
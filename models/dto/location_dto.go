@@ -0,0 +1,148 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+
+	"github.com/google/uuid"
+)
+
+// LocationCreateDTO is used for creating a building, ward, room or bed; every type but buildings needs a parent
+type LocationCreateDTO struct {
+	ParentID     *uuid.UUID `json:"parent_id"`
+	Type         string     `json:"type" binding:"required,oneof=building ward room bed"`
+	Name         string     `json:"name" binding:"required,max=100"`
+	OutOfService bool       `json:"out_of_service"`
+}
+
+// LocationUpdateDTO is used for renaming a location or taking it out of service
+type LocationUpdateDTO struct {
+	Name         string `json:"name" binding:"required,max=100"`
+	OutOfService *bool  `json:"out_of_service"`
+}
+
+// WardStaffUpdateDTO is used for replacing the doctors assigned to a ward
+type WardStaffUpdateDTO struct {
+	DoctorIDs []uuid.UUID `json:"doctor_ids" binding:"required"`
+}
+
+// LocationDTO is used for retrieving a location
+type LocationDTO struct {
+	LocationID   uuid.UUID    `json:"location_id"`
+	ParentID     *uuid.UUID   `json:"parent_id"`
+	Type         string       `json:"type"`
+	Name         string       `json:"name"`
+	OutOfService bool         `json:"out_of_service"`
+	Staff        []*DoctorDTO `json:"staff,omitempty"`
+	Version      int          `json:"version"`
+}
+
+// LocationFilter holds the optional filters of the location list
+type LocationFilter struct {
+	Type     string
+	ParentID *uuid.UUID
+}
+
+// BedOccupancyDTO is a bed with its status and the patient assigned to it
+type BedOccupancyDTO struct {
+	BedID          uuid.UUID  `json:"bed_id"`
+	Name           string     `json:"name"`
+	RoomID         uuid.UUID  `json:"room_id"`
+	RoomName       string     `json:"room_name"`
+	Status         string     `json:"status"`
+	MedicalVisitID *uuid.UUID `json:"medical_visit_id,omitempty"`
+	PatientID      *uuid.UUID `json:"patient_id,omitempty"`
+	PatientName    string     `json:"patient_name,omitempty"`
+}
+
+// LocationOccupancyDTO counts the beds of a location by status
+type LocationOccupancyDTO struct {
+	LocationID   uuid.UUID          `json:"location_id"`
+	Total        int                `json:"total"`
+	Free         int                `json:"free"`
+	Occupied     int                `json:"occupied"`
+	OutOfService int                `json:"out_of_service"`
+	Beds         []*BedOccupancyDTO `json:"beds"`
+}
+
+// MapLocationToDTO maps a Location model to a LocationDTO
+func MapLocationToDTO(location *models.Location) *LocationDTO {
+	var staff []*DoctorDTO
+	if len(location.Staff) > 0 {
+		staff = MapDoctorsToDTOs(location.Staff)
+	}
+	return &LocationDTO{
+		LocationID:   location.LocationID,
+		ParentID:     location.ParentID,
+		Type:         location.Type,
+		Name:         location.Name,
+		OutOfService: location.OutOfService,
+		Staff:        staff,
+		Version:      location.Version,
+	}
+}
+
+// MapLocationsToDTOs maps a list of Location models to a list of LocationDTOs
+func MapLocationsToDTOs(locations []*models.Location) []*LocationDTO {
+	locationDTOs := make([]*LocationDTO, 0)
+	for _, location := range locations {
+		locationDTOs = append(locationDTOs, MapLocationToDTO(location))
+	}
+	return locationDTOs
+}
+
+// MapCreateDTOToLocation maps a LocationCreateDTO to a Location model
+func MapCreateDTOToLocation(dto *LocationCreateDTO) *models.Location {
+	return &models.Location{
+		ParentID:     dto.ParentID,
+		Type:         dto.Type,
+		Name:         dto.Name,
+		OutOfService: dto.OutOfService,
+	}
+}
+
+// MapBedOccupancyToDTO maps the beds of a location and counts them by status
+func MapBedOccupancyToDTO(locationID uuid.UUID, beds []*models.BedOccupancy) *LocationOccupancyDTO {
+	occupancy := &LocationOccupancyDTO{LocationID: locationID, Total: len(beds), Beds: make([]*BedOccupancyDTO, 0, len(beds))}
+	for _, bed := range beds {
+		bedDTO := &BedOccupancyDTO{
+			BedID:          bed.LocationID,
+			Name:           bed.Name,
+			RoomID:         bed.RoomID,
+			RoomName:       bed.RoomName,
+			MedicalVisitID: bed.MedicalVisitID,
+			PatientID:      bed.PatientID,
+		}
+		if bed.PatientName != nil {
+			bedDTO.PatientName = *bed.PatientName
+		}
+
+		// An occupied bed taken out of service stays occupied until the patient leaves it
+		switch {
+		case bed.MedicalVisitID != nil:
+			bedDTO.Status = string(enum.BedStatusOccupied)
+			occupancy.Occupied++
+		case bed.OutOfService:
+			bedDTO.Status = string(enum.BedStatusOutOfService)
+			occupancy.OutOfService++
+		default:
+			bedDTO.Status = string(enum.BedStatusFree)
+			occupancy.Free++
+		}
+		occupancy.Beds = append(occupancy.Beds, bedDTO)
+	}
+	return occupancy
+}
+
+// LocationLabel joins the names of a location and of the locations containing it, from the building down,
+// e.g. "Main / ICU / 101 / A"
+func LocationLabel(ancestors []*models.Location) string {
+	label := ""
+	for i, location := range ancestors {
+		if i > 0 {
+			label += " / "
+		}
+		label += location.Name
+	}
+	return label
+}
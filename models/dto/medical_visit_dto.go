@@ -2,15 +2,61 @@ package dto
 
 import (
 	"biometric-data-backend/models"
+	"github.com/google/uuid"
 	"time"
 )
 
 type MedicalVisitDTO struct {
+	MedicalVisitID uuid.UUID                  `json:"medical_visit_id"`
+	Reason         string                     `json:"reason"`
+	Diagnosis      string                     `json:"diagnosis"`
+	Treatment      string                     `json:"treatment"`
+	Location       string                     `json:"location"`
+	BedID          *uuid.UUID                 `json:"bed_id,omitempty"`
+	EntryDate      *time.Time                 `json:"entry_date"`
+	DischargeDate  string                     `json:"discharge_date"`
+	Transfers      []*MedicalVisitTransferDTO `json:"transfers,omitempty"`
+}
+
+// MedicalVisitAdmitDTO opens a visit for a patient, optionally in a bed. Without bed, the location defaults to the
+// current one of the patient.
+type MedicalVisitAdmitDTO struct {
+	Reason    string     `json:"reason" binding:"required,max=100"`
+	Diagnosis string     `json:"diagnosis" binding:"required,max=100"`
+	Treatment string     `json:"treatment" binding:"max=100"`
+	Location  string     `json:"location" binding:"max=100"`
+	BedID     *uuid.UUID `json:"bed_id"`
+	EntryDate *time.Time `json:"entry_date"`
+}
+
+// MedicalVisitDischargeDTO closes the open visit of a patient, the diagnosis and treatment replace the ones of the admission
+type MedicalVisitDischargeDTO struct {
+	Diagnosis     string     `json:"diagnosis" binding:"max=100"`
+	Treatment     string     `json:"treatment" binding:"max=100"`
+	DischargeDate *time.Time `json:"discharge_date"`
+}
+
+// MedicalVisitTransferCreateDTO moves an admitted patient to another bed, or to a free-text location
+type MedicalVisitTransferCreateDTO struct {
+	Location string     `json:"location" binding:"required_without=BedID,max=100"`
+	BedID    *uuid.UUID `json:"bed_id"`
+	Reason   string     `json:"reason" binding:"max=255"`
+}
+
+type MedicalVisitTransferDTO struct {
+	TransferID    uuid.UUID  `json:"transfer_id"`
+	FromLocation  string     `json:"from_location"`
+	ToLocation    string     `json:"to_location"`
+	FromBedID     *uuid.UUID `json:"from_bed_id,omitempty"`
+	ToBedID       *uuid.UUID `json:"to_bed_id,omitempty"`
 	Reason        string     `json:"reason"`
-	Diagnosis     string     `json:"diagnosis"`
-	Treatment     string     `json:"treatment"`
-	EntryDate     *time.Time `json:"entry_date"`
-	DischargeDate string     `json:"discharge_date"`
+	TransferredAt time.Time  `json:"transferred_at"`
+}
+
+// MedicalVisitDischargeResponseDTO is the discharged visit with the monitoring device released from the patient, if any
+type MedicalVisitDischargeResponseDTO struct {
+	Visit            *MedicalVisitDTO `json:"visit"`
+	ReleasedDeviceID string           `json:"released_device_id,omitempty"`
 }
 
 // MapMedicalVisitToDTO maps a MedicalVisit model to a MedicalVisitDTO
@@ -20,12 +66,36 @@ func MapMedicalVisitToDTO(medicalVisit *models.MedicalVisit) *MedicalVisitDTO {
 		dischargeDate = medicalVisit.DischargeDate.Format(time.RFC3339)
 	}
 	return &MedicalVisitDTO{
-		Reason:        medicalVisit.Reason,
-		Diagnosis:     medicalVisit.Diagnosis,
-		Treatment:     medicalVisit.Treatment,
-		EntryDate:     medicalVisit.EntryDate,
-		DischargeDate: dischargeDate,
+		MedicalVisitID: medicalVisit.MedicalVisitID,
+		Reason:         medicalVisit.Reason,
+		Diagnosis:      medicalVisit.Diagnosis,
+		Treatment:      medicalVisit.Treatment,
+		Location:       medicalVisit.Location,
+		BedID:          medicalVisit.BedID,
+		EntryDate:      medicalVisit.EntryDate,
+		DischargeDate:  dischargeDate,
+		Transfers:      MapMedicalVisitTransfersToDTOs(medicalVisit.Transfers),
+	}
+}
+
+// MapMedicalVisitTransfersToDTOs maps the transfers of a visit, nil when it has none
+func MapMedicalVisitTransfersToDTOs(transfers []*models.MedicalVisitTransfer) []*MedicalVisitTransferDTO {
+	if len(transfers) == 0 {
+		return nil
+	}
+	transferDTOs := make([]*MedicalVisitTransferDTO, 0, len(transfers))
+	for _, transfer := range transfers {
+		transferDTOs = append(transferDTOs, &MedicalVisitTransferDTO{
+			TransferID:    transfer.TransferID,
+			FromLocation:  transfer.FromLocation,
+			ToLocation:    transfer.ToLocation,
+			FromBedID:     transfer.FromBedID,
+			ToBedID:       transfer.ToBedID,
+			Reason:        transfer.Reason,
+			TransferredAt: transfer.TransferredAt,
+		})
 	}
+	return transferDTOs
 }
 
 // MapMedicalVisitsToDTOs maps a slice of MedicalVisit models to a slice of MedicalVisitDTOs
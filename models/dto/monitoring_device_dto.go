@@ -2,6 +2,8 @@ package dto
 
 import (
 	"biometric-data-backend/models"
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -17,16 +19,52 @@ type MonitoringDeviceUpdateDTO struct {
 	LinkedByID *uuid.UUID `json:"linked_by_id"`
 }
 
+// MonitoringDevicePairDTO is used for linking a free monitoring device to a patient.
+// The device is linked by the given doctor, or by the doctor of the current user when omitted.
+type MonitoringDevicePairDTO struct {
+	PatientID uuid.UUID  `json:"patient_id" binding:"required"`
+	DoctorID  *uuid.UUID `json:"doctor_id"`
+}
+
+// MonitoringDeviceHeartbeatDTO is sent periodically by a device to report it is alive and its state
+type MonitoringDeviceHeartbeatDTO struct {
+	BatteryLevel    *int   `json:"battery_level" binding:"omitempty,min=0,max=100"`
+	SignalStrength  *int   `json:"signal_strength"`
+	FirmwareVersion string `json:"firmware_version" binding:"max=50"`
+}
+
 // MonitoringDeviceDTO is used for retrieving a monitoring device
 type MonitoringDeviceDTO struct {
-	DeviceID string               `json:"device_id"`
-	Status   string               `json:"status"`
-	Patient  *PatientForDeviceDTO `json:"patient"`
-	LinkedBy *DoctorDTO           `json:"linked_by"`
+	DeviceID        string               `json:"device_id"`
+	Status          string               `json:"status"`
+	Patient         *PatientForDeviceDTO `json:"patient"`
+	LinkedBy        *DoctorDTO           `json:"linked_by"`
+	LinkedAt        *time.Time           `json:"linked_at"`
+	Online          bool                 `json:"online"`
+	LastSeenAt      *time.Time           `json:"last_seen_at"`
+	BatteryLevel    *int                 `json:"battery_level"`
+	SignalStrength  *int                 `json:"signal_strength"`
+	FirmwareVersion string               `json:"firmware_version"`
+	Version         int                  `json:"version"`
+}
+
+// DeviceAssignmentDTO is used for retrieving the assignment history of a monitoring device
+type DeviceAssignmentDTO struct {
+	AssignmentID     uuid.UUID            `json:"assignment_id"`
+	DeviceID         string               `json:"device_id"`
+	Patient          *PatientForDeviceDTO `json:"patient"`
+	LinkedBy         *DoctorDTO           `json:"linked_by"`
+	LinkedByUserID   *uuid.UUID           `json:"linked_by_user_id"`
+	LinkedAt         time.Time            `json:"linked_at"`
+	ConnectedAt      *time.Time           `json:"connected_at"`
+	UnlinkedByUserID *uuid.UUID           `json:"unlinked_by_user_id"`
+	UnlinkedAt       *time.Time           `json:"unlinked_at"`
+	EndReason        string               `json:"end_reason,omitempty"`
 }
 
 type MonitoringDeviceFilter struct {
-	DNI string `json:"dni"`
+	DNI    string `json:"dni"`
+	Status string `json:"status"`
 }
 
 // MapMonitoringDeviceToDTO maps a MonitoringDevice model to a MonitoringDeviceDTO
@@ -36,10 +74,17 @@ func MapMonitoringDeviceToDTO(device *models.MonitoringDevice) *MonitoringDevice
 	}
 
 	return &MonitoringDeviceDTO{
-		DeviceID: device.DeviceID,
-		Status:   device.Status,
-		Patient:  MapPatientToPatientForDeviceDTO(device.Patient),
-		LinkedBy: MapDoctorToDTO(device.LinkedBy),
+		DeviceID:        device.DeviceID,
+		Status:          device.Status,
+		Patient:         MapPatientToPatientForDeviceDTO(device.Patient),
+		LinkedBy:        MapDoctorToDTO(device.LinkedBy),
+		LinkedAt:        device.LinkedAt,
+		Online:          device.Online,
+		LastSeenAt:      device.LastSeenAt,
+		BatteryLevel:    device.BatteryLevel,
+		SignalStrength:  device.SignalStrength,
+		FirmwareVersion: device.FirmwareVersion,
+		Version:         device.Version,
 	}
 }
 
@@ -51,3 +96,27 @@ func MapMonitoringDevicesToDTOs(devices []*models.MonitoringDevice) []*Monitorin
 	}
 	return deviceDTOs
 }
+
+// MapDeviceAssignmentsToDTOs maps a list of DeviceAssignment models to a list of DeviceAssignmentDTOs
+func MapDeviceAssignmentsToDTOs(assignments []*models.DeviceAssignment) []*DeviceAssignmentDTO {
+	var assignmentDTOs = make([]*DeviceAssignmentDTO, 0, len(assignments))
+	for _, assignment := range assignments {
+		patient := assignment.Patient
+		if patient == nil {
+			patient = &models.Patient{}
+		}
+		assignmentDTOs = append(assignmentDTOs, &DeviceAssignmentDTO{
+			AssignmentID:     assignment.AssignmentID,
+			DeviceID:         assignment.DeviceID,
+			Patient:          MapPatientToPatientForDeviceDTO(patient),
+			LinkedBy:         MapDoctorToDTO(assignment.LinkedBy),
+			LinkedByUserID:   assignment.LinkedByUserID,
+			LinkedAt:         assignment.LinkedAt,
+			ConnectedAt:      assignment.ConnectedAt,
+			UnlinkedByUserID: assignment.UnlinkedByUserID,
+			UnlinkedAt:       assignment.UnlinkedAt,
+			EndReason:        assignment.EndReason,
+		})
+	}
+	return assignmentDTOs
+}
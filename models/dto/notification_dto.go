@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"github.com/google/uuid"
+	"time"
+)
+
+// NotificationOutboxDTO is used for retrieving a notification queued for a channel
+type NotificationOutboxDTO struct {
+	OutboxID  uuid.UUID  `json:"outbox_id"`
+	Channel   string     `json:"channel"`
+	Status    string     `json:"status"`
+	Attempts  int        `json:"attempts"`
+	LastError string     `json:"last_error,omitempty"`
+	QueuedAt  time.Time  `json:"queued_at"`
+	SentAt    *time.Time `json:"sent_at"`
+}
+
+// PushTicketDTO is used for retrieving the delivery status of a push notification to a single phone
+type PushTicketDTO struct {
+	PushTicketID     uuid.UUID  `json:"push_ticket_id"`
+	OutboxID         uuid.UUID  `json:"outbox_id"`
+	UserID           *uuid.UUID `json:"user_id"`
+	PushToken        string     `json:"push_token"`
+	Status           string     `json:"status"`
+	ErrorCode        string     `json:"error_code,omitempty"`
+	ErrorMessage     string     `json:"error_message,omitempty"`
+	SentAt           time.Time  `json:"sent_at"`
+	ReceiptCheckedAt *time.Time `json:"receipt_checked_at"`
+}
+
+// AlertDeliveriesDTO is used for retrieving the delivery status of every notification of an alert
+type AlertDeliveriesDTO struct {
+	AlertID        uuid.UUID                `json:"alert_id"`
+	Notifications  []*NotificationOutboxDTO `json:"notifications"`
+	PushTickets    []*PushTicketDTO         `json:"push_tickets"`
+	DeliveredCount int                      `json:"delivered_count"`
+}
+
+// MapNotificationOutboxToDTO maps a NotificationOutbox model to a NotificationOutboxDTO
+func MapNotificationOutboxToDTO(entry *models.NotificationOutbox) *NotificationOutboxDTO {
+	return &NotificationOutboxDTO{
+		OutboxID:  entry.OutboxID,
+		Channel:   entry.Channel,
+		Status:    entry.Status,
+		Attempts:  entry.Attempts,
+		LastError: entry.LastError,
+		QueuedAt:  entry.CreatedAt,
+		SentAt:    entry.SentAt,
+	}
+}
+
+// MapPushTicketToDTO maps a PushTicket model to a PushTicketDTO
+func MapPushTicketToDTO(ticket *models.PushTicket, userID *uuid.UUID) *PushTicketDTO {
+	return &PushTicketDTO{
+		PushTicketID:     ticket.PushTicketID,
+		OutboxID:         ticket.OutboxID,
+		UserID:           userID,
+		PushToken:        ticket.PushToken,
+		Status:           ticket.Status,
+		ErrorCode:        ticket.ErrorCode,
+		ErrorMessage:     ticket.ErrorMessage,
+		SentAt:           ticket.CreatedAt,
+		ReceiptCheckedAt: ticket.ReceiptCheckedAt,
+	}
+}
+
+// MapAlertDeliveriesToDTO maps the outbox entries and push tickets of an alert to an AlertDeliveriesDTO
+func MapAlertDeliveriesToDTO(alertID uuid.UUID, entries []*models.NotificationOutbox, tickets []*models.PushTicket, userIDs map[string]uuid.UUID) *AlertDeliveriesDTO {
+	deliveries := &AlertDeliveriesDTO{
+		AlertID:       alertID,
+		Notifications: make([]*NotificationOutboxDTO, 0, len(entries)),
+		PushTickets:   make([]*PushTicketDTO, 0, len(tickets)),
+	}
+	for _, entry := range entries {
+		deliveries.Notifications = append(deliveries.Notifications, MapNotificationOutboxToDTO(entry))
+	}
+	for _, ticket := range tickets {
+		var userID *uuid.UUID
+		if id, ok := userIDs[ticket.PushToken]; ok {
+			userID = &id
+		}
+		deliveries.PushTickets = append(deliveries.PushTickets, MapPushTicketToDTO(ticket, userID))
+		if ticket.Status == string(enum.PushTicketStatusDelivered) {
+			deliveries.DeliveredCount++
+		}
+	}
+	return deliveries
+}
@@ -0,0 +1,29 @@
+package dto
+
+// PageRequest asks for a page of a cursor paginated list
+type PageRequest struct {
+	// Cursor is the next_cursor of the previous page, empty for the first page
+	Cursor string
+	Limit  int
+	// WithTotal also counts the items of the whole list, which is slower on large tables
+	WithTotal bool
+}
+
+// PageDTO is the common envelope of the cursor paginated lists
+type PageDTO[T any] struct {
+	Items      []*T   `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int64 `json:"total,omitempty"`
+}
+
+// NewPageDTO wraps a page of items mapped to DTOs in the common envelope
+func NewPageDTO[T any](items []*T, nextCursor string, total *int64) *PageDTO[T] {
+	if items == nil {
+		items = make([]*T, 0)
+	}
+	return &PageDTO[T]{
+		Items:      items,
+		NextCursor: nextCursor,
+		Total:      total,
+	}
+}
@@ -19,6 +19,7 @@ type PatientDTO struct {
 	MedicalStaff       []*DoctorDTO          `json:"medical_staff"`
 	Medications        []*ShortMedicationDTO `json:"medications"`
 	MedicalVisits      []*MedicalVisitDTO    `json:"medical_visits"`
+	Version            int                   `json:"version"`
 }
 
 type PatientFilter struct {
@@ -31,4 +32,5 @@ type PatientFilter struct {
 	ComorbidityName string
 	EntryDate       string
 	DischargeDate   string
+	WardID          string
 }
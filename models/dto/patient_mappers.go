@@ -26,6 +26,7 @@ func MapPatientToDTO(patient *models.Patient) *PatientDTO {
 		MedicalStaff:       MapDoctorsToDTOs(patient.Doctors),
 		Medications:        MapShortMedicationsToDTOs(patient.Medications),
 		MedicalVisits:      MapMedicalVisitsToDTOs(patient.MedicalVisits),
+		Version:            patient.Version,
 	}
 }
 
@@ -3,7 +3,7 @@ package dto
 import "biometric-data-backend/models"
 
 type PhoneCreateDTO struct {
-	ExponentPushToken string `json:"exponent_push_token"`
+	ExponentPushToken string `json:"exponent_push_token" binding:"required"`
 }
 
 func MapCreateDTOToPhone(dto *PhoneCreateDTO) *models.Phone {
@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"biometric-data-backend/enums"
+
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request, as carried by its token. UserID is set for users, DoctorID
+// for users who are doctors, and ServiceAccountID for service accounts.
+type Principal struct {
+	UserID           *uuid.UUID
+	DoctorID         *uuid.UUID
+	ServiceAccountID *uuid.UUID
+	Roles            []string
+}
+
+// HasRole reports whether the caller was granted the given role
+func (p *Principal) HasRole(role enums.RoleEnum) bool {
+	if p == nil {
+		return false
+	}
+	for _, granted := range p.Roles {
+		if granted == string(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin reports whether the caller is an admin
+func (p *Principal) IsAdmin() bool {
+	return p.HasRole(enums.Admin)
+}
+
+// IsUser reports whether the caller is the given user
+func (p *Principal) IsUser(userID uuid.UUID) bool {
+	return p != nil && p.UserID != nil && *p.UserID == userID
+}
@@ -0,0 +1,78 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceAccountCreateDTO is used for creating a service account. Scopes are the roles its tokens are granted.
+type ServiceAccountCreateDTO struct {
+	Name        string     `json:"name" binding:"required,max=100"`
+	Description string     `json:"description" binding:"max=255"`
+	Scopes      []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+}
+
+// ServiceAccountDTO is used for retrieving a service account, without its secret
+type ServiceAccountDTO struct {
+	ServiceAccountID uuid.UUID  `json:"service_account_id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	ClientID         string     `json:"client_id"`
+	Scopes           []string   `json:"scopes"`
+	CreatedByID      *uuid.UUID `json:"created_by_id"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	LastTokenAt      *time.Time `json:"last_token_at"`
+}
+
+// ServiceAccountSecretDTO is returned once when a client secret is issued, the secret cannot be retrieved afterwards
+type ServiceAccountSecretDTO struct {
+	ServiceAccountDTO
+	ClientSecret string `json:"client_secret"`
+}
+
+// ServiceTokenRequestDTO is a client credentials token request (RFC 6749, section 4.4)
+type ServiceTokenRequestDTO struct {
+	GrantType    string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+}
+
+// ServiceTokenDTO is the token issued to a service account, in the OAuth 2.0 format
+type ServiceTokenDTO struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// MapServiceAccountToDTO maps a ServiceAccount model to a ServiceAccountDTO
+func MapServiceAccountToDTO(account *models.ServiceAccount) *ServiceAccountDTO {
+	return &ServiceAccountDTO{
+		ServiceAccountID: account.ServiceAccountID,
+		Name:             account.Name,
+		Description:      account.Description,
+		ClientID:         account.ClientID,
+		Scopes:           strings.Fields(account.Scopes),
+		CreatedByID:      account.CreatedByID,
+		CreatedAt:        account.CreatedAt,
+		ExpiresAt:        account.ExpiresAt,
+		RevokedAt:        account.RevokedAt,
+		LastTokenAt:      account.LastTokenAt,
+	}
+}
+
+// MapServiceAccountsToDTOs maps a list of ServiceAccount models to a list of ServiceAccountDTOs
+func MapServiceAccountsToDTOs(accounts []*models.ServiceAccount) []*ServiceAccountDTO {
+	var accountDTOs = make([]*ServiceAccountDTO, 0, len(accounts))
+	for _, account := range accounts {
+		accountDTOs = append(accountDTOs, MapServiceAccountToDTO(account))
+	}
+	return accountDTOs
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// SigningKeyDTO is used for retrieving a signing key, without its private half.
+// Status is pending, active, retired or expired.
+type SigningKeyDTO struct {
+	KID         string     `json:"kid"`
+	Algorithm   string     `json:"algorithm"`
+	Status      string     `json:"status"`
+	ActivatesAt time.Time  `json:"activates_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// SigningKeyRotateDTO is used for rotating the signing key. An immediate rotation signs with the new key right
+// away and stops accepting the tokens signed with the previous keys, e.g. when a key leaked.
+type SigningKeyRotateDTO struct {
+	Immediate bool `json:"immediate"`
+}
+
+// JWKDTO is a public key in the JSON Web Key format (RFC 7517)
+type JWKDTO struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSDTO is a JSON Web Key Set, the public keys the tokens can be verified with
+type JWKSDTO struct {
+	Keys []JWKDTO `json:"keys"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TechnicalAlertDTO is used for retrieving a technical alert
+type TechnicalAlertDTO struct {
+	TechnicalAlertID uuid.UUID            `json:"technical_alert_id"`
+	DeviceID         string               `json:"device_id"`
+	Patient          *PatientForDeviceDTO `json:"patient"`
+	Type             string               `json:"type"`
+	Message          string               `json:"message"`
+	LastSeenAt       *time.Time           `json:"last_seen_at"`
+	RaisedAt         time.Time            `json:"raised_at"`
+	ResolvedAt       *time.Time           `json:"resolved_at"`
+	ResolvedByID     *uuid.UUID           `json:"resolved_by_id"`
+}
+
+// TechnicalAlertFilter narrows the technical alerts listed.
+// Status is "open" or "resolved", all alerts being listed when empty.
+type TechnicalAlertFilter struct {
+	Status   string
+	DeviceID string
+	Type     string
+}
+
+// MapTechnicalAlertToDTO maps a TechnicalAlert model to a TechnicalAlertDTO
+func MapTechnicalAlertToDTO(alert *models.TechnicalAlert) *TechnicalAlertDTO {
+	var patient *PatientForDeviceDTO
+	if alert.Patient != nil {
+		patient = MapPatientToPatientForDeviceDTO(alert.Patient)
+	}
+
+	return &TechnicalAlertDTO{
+		TechnicalAlertID: alert.TechnicalAlertID,
+		DeviceID:         alert.DeviceID,
+		Patient:          patient,
+		Type:             alert.Type,
+		Message:          alert.Message,
+		LastSeenAt:       alert.LastSeenAt,
+		RaisedAt:         alert.RaisedAt,
+		ResolvedAt:       alert.ResolvedAt,
+		ResolvedByID:     alert.ResolvedByID,
+	}
+}
+
+// MapTechnicalAlertsToDTOs maps a list of TechnicalAlert models to a list of TechnicalAlertDTOs
+func MapTechnicalAlertsToDTOs(alerts []*models.TechnicalAlert) []*TechnicalAlertDTO {
+	var alertDTOs = make([]*TechnicalAlertDTO, 0, len(alerts))
+	for _, alert := range alerts {
+		alertDTOs = append(alertDTOs, MapTechnicalAlertToDTO(alert))
+	}
+	return alertDTOs
+}
@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"github.com/google/uuid"
+)
+
+// ThresholdRuleCreateDTO is used for creating a new threshold rule
+type ThresholdRuleCreateDTO struct {
+	Name            string     `json:"name" binding:"required"`
+	PatientID       *uuid.UUID `json:"patient_id"`
+	Metric          string     `json:"metric" binding:"required,oneof=o2_saturation heart_rate"`
+	Operator        string     `json:"operator" binding:"required,oneof=< <= > >="`
+	Threshold       *float64   `json:"threshold" binding:"required"`
+	Hysteresis      float64    `json:"hysteresis" binding:"gte=0"`
+	DurationSeconds int        `json:"duration_seconds" binding:"gte=0"`
+	CooldownSeconds int        `json:"cooldown_seconds" binding:"gte=0"`
+	Diagnosis       string     `json:"diagnosis" binding:"required"`
+	Enabled         *bool      `json:"enabled"`
+}
+
+// ThresholdRuleUpdateDTO is used for updating an existing threshold rule
+type ThresholdRuleUpdateDTO struct {
+	Name            string     `json:"name" binding:"required"`
+	PatientID       *uuid.UUID `json:"patient_id"`
+	Metric          string     `json:"metric" binding:"required,oneof=o2_saturation heart_rate"`
+	Operator        string     `json:"operator" binding:"required,oneof=< <= > >="`
+	Threshold       *float64   `json:"threshold" binding:"required"`
+	Hysteresis      float64    `json:"hysteresis" binding:"gte=0"`
+	DurationSeconds int        `json:"duration_seconds" binding:"gte=0"`
+	CooldownSeconds int        `json:"cooldown_seconds" binding:"gte=0"`
+	Diagnosis       string     `json:"diagnosis" binding:"required"`
+	Enabled         *bool      `json:"enabled"`
+}
+
+// ThresholdRuleDTO is used for retrieving a threshold rule
+type ThresholdRuleDTO struct {
+	RuleID          uuid.UUID  `json:"rule_id"`
+	Name            string     `json:"name"`
+	PatientID       *uuid.UUID `json:"patient_id"`
+	Metric          string     `json:"metric"`
+	Operator        string     `json:"operator"`
+	Threshold       float64    `json:"threshold"`
+	Hysteresis      float64    `json:"hysteresis"`
+	DurationSeconds int        `json:"duration_seconds"`
+	CooldownSeconds int        `json:"cooldown_seconds"`
+	Diagnosis       string     `json:"diagnosis"`
+	Enabled         bool       `json:"enabled"`
+}
+
+// MapThresholdRuleToDTO maps a ThresholdRule model to a ThresholdRuleDTO
+func MapThresholdRuleToDTO(rule *models.ThresholdRule) *ThresholdRuleDTO {
+	return &ThresholdRuleDTO{
+		RuleID:          rule.RuleID,
+		Name:            rule.Name,
+		PatientID:       rule.PatientID,
+		Metric:          rule.Metric,
+		Operator:        rule.Operator,
+		Threshold:       rule.Threshold,
+		Hysteresis:      rule.Hysteresis,
+		DurationSeconds: rule.DurationSeconds,
+		CooldownSeconds: rule.CooldownSeconds,
+		Diagnosis:       rule.Diagnosis,
+		Enabled:         rule.Enabled,
+	}
+}
+
+// MapThresholdRulesToDTOs maps a list of ThresholdRule models to a list of ThresholdRuleDTOs
+func MapThresholdRulesToDTOs(rules []*models.ThresholdRule) []*ThresholdRuleDTO {
+	ruleDTOs := make([]*ThresholdRuleDTO, 0)
+	for _, rule := range rules {
+		ruleDTOs = append(ruleDTOs, MapThresholdRuleToDTO(rule))
+	}
+	return ruleDTOs
+}
+
+// MapCreateDTOToThresholdRule maps a ThresholdRuleCreateDTO to a ThresholdRule model
+func MapCreateDTOToThresholdRule(dto *ThresholdRuleCreateDTO) *models.ThresholdRule {
+	enabled := true
+	if dto.Enabled != nil {
+		enabled = *dto.Enabled
+	}
+	return &models.ThresholdRule{
+		Name:            dto.Name,
+		PatientID:       dto.PatientID,
+		Metric:          dto.Metric,
+		Operator:        dto.Operator,
+		Threshold:       *dto.Threshold,
+		Hysteresis:      dto.Hysteresis,
+		DurationSeconds: dto.DurationSeconds,
+		CooldownSeconds: dto.CooldownSeconds,
+		Diagnosis:       dto.Diagnosis,
+		Enabled:         enabled,
+	}
+}
+
+// MapUpdateDTOToThresholdRule maps a ThresholdRuleUpdateDTO to a ThresholdRule model
+func MapUpdateDTOToThresholdRule(dto *ThresholdRuleUpdateDTO, rule *models.ThresholdRule) *models.ThresholdRule {
+	rule.Name = dto.Name
+	rule.PatientID = dto.PatientID
+	rule.Metric = dto.Metric
+	rule.Operator = dto.Operator
+	rule.Threshold = *dto.Threshold
+	rule.Hysteresis = dto.Hysteresis
+	rule.DurationSeconds = dto.DurationSeconds
+	rule.CooldownSeconds = dto.CooldownSeconds
+	rule.Diagnosis = dto.Diagnosis
+	if dto.Enabled != nil {
+		rule.Enabled = *dto.Enabled
+	}
+	return rule
+}
@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"github.com/google/uuid"
+	"time"
+)
+
+// VitalReadingCreateDTO is a single sample sent by a monitoring device. A sample carries at least one metric; a
+// metric left out was not measured.
+type VitalReadingCreateDTO struct {
+	RecordedAt   time.Time `json:"recorded_at" binding:"required"`
+	O2Saturation *float64  `json:"o2_saturation" binding:"required_without=HeartRate,omitempty,gte=0,lte=100"`
+	HeartRate    *float64  `json:"heart_rate" binding:"required_without=O2Saturation,omitempty,gte=0,lte=300"`
+}
+
+// VitalReadingBatchCreateDTO is used for ingesting a batch of samples from a monitoring device
+type VitalReadingBatchCreateDTO struct {
+	Readings []*VitalReadingCreateDTO `json:"readings" binding:"required,min=1,max=1000,dive"`
+}
+
+// VitalReadingBatchResponseDTO is returned after ingesting a batch of samples
+type VitalReadingBatchResponseDTO struct {
+	DeviceID  string    `json:"device_id"`
+	PatientID uuid.UUID `json:"patient_id"`
+	Received  int       `json:"received"`
+	Stored    int64     `json:"stored"`
+}
+
+// MapCreateDTOsToVitalReadings maps a batch of VitalReadingCreateDTOs to VitalReading models of a device and patient
+func MapCreateDTOsToVitalReadings(readingDTOs []*VitalReadingCreateDTO, deviceID string, patientID uuid.UUID) []*models.VitalReading {
+	readings := make([]*models.VitalReading, 0, len(readingDTOs))
+	for _, readingDTO := range readingDTOs {
+		readings = append(readings, &models.VitalReading{
+			DeviceID:     deviceID,
+			PatientID:    patientID,
+			RecordedAt:   readingDTO.RecordedAt.UTC(),
+			O2Saturation: readingDTO.O2Saturation,
+			HeartRate:    readingDTO.HeartRate,
+		})
+	}
+	return readings
+}
+
+// VitalsQuery holds the parameters of a patient vitals time-series query
+type VitalsQuery struct {
+	From   time.Time
+	To     time.Time
+	Metric string
+	Bucket time.Duration
+}
+
+// VitalsPointDTO is the aggregate of a metric within a single time bucket
+type VitalsPointDTO struct {
+	Timestamp time.Time `json:"timestamp"`
+	Count     int64     `json:"count"`
+	Min       float64   `json:"min"`
+	Avg       float64   `json:"avg"`
+	Max       float64   `json:"max"`
+}
+
+// VitalsSeriesDTO is the bucketed time series of a single metric
+type VitalsSeriesDTO struct {
+	Metric string            `json:"metric"`
+	Points []*VitalsPointDTO `json:"points"`
+}
+
+// PatientVitalsDTO is returned by the patient vitals time-series query
+type PatientVitalsDTO struct {
+	PatientID     uuid.UUID          `json:"patient_id"`
+	From          time.Time          `json:"from"`
+	To            time.Time          `json:"to"`
+	BucketSeconds int64              `json:"bucket_seconds"`
+	Series        []*VitalsSeriesDTO `json:"series"`
+}
+
+// MapVitalReadingBucketsToSeries maps the aggregated buckets to one series per requested metric
+func MapVitalReadingBucketsToSeries(buckets []*models.VitalReadingBucket, metrics []string) []*VitalsSeriesDTO {
+	series := make([]*VitalsSeriesDTO, 0, len(metrics))
+	for _, metric := range metrics {
+		points := make([]*VitalsPointDTO, 0, len(buckets))
+		for _, bucket := range buckets {
+			point := &VitalsPointDTO{Timestamp: bucket.BucketStart.UTC()}
+			switch metric {
+			case string(enum.RuleMetricO2Saturation):
+				point.Count = bucket.O2SaturationCount
+				point.Min, point.Avg, point.Max = bucket.MinO2Saturation, bucket.AvgO2Saturation, bucket.MaxO2Saturation
+			case string(enum.RuleMetricHeartRate):
+				point.Count = bucket.HeartRateCount
+				point.Min, point.Avg, point.Max = bucket.MinHeartRate, bucket.AvgHeartRate, bucket.MaxHeartRate
+			}
+			// Buckets where the metric was never measured have no point in its series
+			if point.Count == 0 {
+				continue
+			}
+			points = append(points, point)
+		}
+		series = append(series, &VitalsSeriesDTO{Metric: metric, Points: points})
+	}
+	return series
+}
@@ -13,6 +13,181 @@ const (
 	SexMale   Sex = "M"
 	SexFemale Sex = "F"
 
-	AlertStatusAttended   AlertStatus = "Attended"
-	AlertStatusUnattended AlertStatus = "Unattended"
+	AlertStatusNew           AlertStatus = "New"
+	AlertStatusAcknowledged  AlertStatus = "Acknowledged"
+	AlertStatusInProgress    AlertStatus = "In Progress"
+	AlertStatusResolved      AlertStatus = "Resolved"
+	AlertStatusFalsePositive AlertStatus = "False Positive"
+	AlertStatusEscalated     AlertStatus = "Escalated"
+)
+
+// DeviceUnlinkReason tells why a device assignment ended
+type DeviceUnlinkReason string
+
+const (
+	DeviceUnlinkReasonUnpaired   DeviceUnlinkReason = "unpaired"
+	DeviceUnlinkReasonDischarged DeviceUnlinkReason = "discharged"
+	DeviceUnlinkReasonTimeout    DeviceUnlinkReason = "connection_timeout"
+)
+
+// deviceStatusTransitions lists the statuses a monitoring device can move to from each status.
+// A paired device is Connecting until it sends its first readings, and goes back to Free when unpaired.
+var deviceStatusTransitions = map[DeviceStatus][]DeviceStatus{
+	DeviceStatusFree:        {DeviceStatusConnecting, DeviceStatusUnavailable},
+	DeviceStatusConnecting:  {DeviceStatusInUse, DeviceStatusFree},
+	DeviceStatusInUse:       {DeviceStatusFree},
+	DeviceStatusUnavailable: {DeviceStatusFree},
+}
+
+// CanTransitionTo reports whether a device in this status can move to the given status
+func (s DeviceStatus) CanTransitionTo(next DeviceStatus) bool {
+	for _, allowed := range deviceStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid reports whether the status is a known device status
+func (s DeviceStatus) IsValid() bool {
+	switch s {
+	case DeviceStatusInUse, DeviceStatusFree, DeviceStatusUnavailable, DeviceStatusConnecting:
+		return true
+	}
+	return false
+}
+
+// IsLinked reports whether a device in this status is linked to a patient
+func (s DeviceStatus) IsLinked() bool {
+	return s == DeviceStatusInUse || s == DeviceStatusConnecting
+}
+
+// alertStatusTransitions lists the statuses an alert can move to from each status.
+// Acknowledged and In Progress alerts can be released back to New; Resolved and False Positive are final.
+var alertStatusTransitions = map[AlertStatus][]AlertStatus{
+	AlertStatusNew:          {AlertStatusAcknowledged, AlertStatusEscalated, AlertStatusFalsePositive},
+	AlertStatusEscalated:    {AlertStatusAcknowledged, AlertStatusFalsePositive},
+	AlertStatusAcknowledged: {AlertStatusInProgress, AlertStatusResolved, AlertStatusFalsePositive, AlertStatusNew},
+	AlertStatusInProgress:   {AlertStatusResolved, AlertStatusFalsePositive, AlertStatusNew},
+}
+
+// CanTransitionTo reports whether an alert in this status can move to the given status
+func (s AlertStatus) CanTransitionTo(next AlertStatus) bool {
+	for _, allowed := range alertStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid reports whether the status is a known alert status
+func (s AlertStatus) IsValid() bool {
+	switch s {
+	case AlertStatusNew, AlertStatusAcknowledged, AlertStatusInProgress, AlertStatusResolved, AlertStatusFalsePositive, AlertStatusEscalated:
+		return true
+	}
+	return false
+}
+
+// IsFinal reports whether no transition leaves this status
+func (s AlertStatus) IsFinal() bool {
+	return s == AlertStatusResolved || s == AlertStatusFalsePositive
+}
+
+type EscalationAction string
+
+const (
+	EscalationActionRenotify EscalationAction = "renotify"
+	EscalationActionCareTeam EscalationAction = "care_team"
+	EscalationActionAdmins   EscalationAction = "admins"
+)
+
+// IsValid reports whether the action is a known escalation action
+func (a EscalationAction) IsValid() bool {
+	switch a {
+	case EscalationActionRenotify, EscalationActionCareTeam, EscalationActionAdmins:
+		return true
+	}
+	return false
+}
+
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "pending"
+	OutboxStatusSending OutboxStatus = "sending"
+	OutboxStatusSent    OutboxStatus = "sent"
+	OutboxStatusFailed  OutboxStatus = "failed"
+)
+
+type PushTicketStatus string
+
+const (
+	PushTicketStatusPending   PushTicketStatus = "pending"
+	PushTicketStatusDelivered PushTicketStatus = "delivered"
+	PushTicketStatusFailed    PushTicketStatus = "failed"
+)
+
+type RuleMetric string
+
+const (
+	RuleMetricO2Saturation RuleMetric = "o2_saturation"
+	RuleMetricHeartRate    RuleMetric = "heart_rate"
+)
+
+type RuleOperator string
+
+const (
+	RuleOperatorLessThan       RuleOperator = "<"
+	RuleOperatorLessOrEqual    RuleOperator = "<="
+	RuleOperatorGreaterThan    RuleOperator = ">"
+	RuleOperatorGreaterOrEqual RuleOperator = ">="
+)
+
+type LocationType string
+
+const (
+	LocationTypeBuilding LocationType = "building"
+	LocationTypeWard     LocationType = "ward"
+	LocationTypeRoom     LocationType = "room"
+	LocationTypeBed      LocationType = "bed"
+)
+
+// IsValid reports whether the type is a known location type
+func (t LocationType) IsValid() bool {
+	switch t {
+	case LocationTypeBuilding, LocationTypeWard, LocationTypeRoom, LocationTypeBed:
+		return true
+	}
+	return false
+}
+
+// ParentType is the type of the location containing a location of this type; buildings have no parent
+func (t LocationType) ParentType() LocationType {
+	switch t {
+	case LocationTypeWard:
+		return LocationTypeBuilding
+	case LocationTypeRoom:
+		return LocationTypeWard
+	case LocationTypeBed:
+		return LocationTypeRoom
+	}
+	return ""
+}
+
+type BedStatus string
+
+const (
+	BedStatusFree         BedStatus = "Free"
+	BedStatusOccupied     BedStatus = "Occupied"
+	BedStatusOutOfService BedStatus = "Out of Service"
+)
+
+// TechnicalAlertType identifies the equipment problem a technical alert reports
+type TechnicalAlertType string
+
+const (
+	TechnicalAlertDeviceOffline TechnicalAlertType = "device_offline"
 )
@@ -0,0 +1,114 @@
+package enum
+
+import "testing"
+
+var allAlertStatuses = []AlertStatus{
+	AlertStatusNew,
+	AlertStatusAcknowledged,
+	AlertStatusInProgress,
+	AlertStatusResolved,
+	AlertStatusFalsePositive,
+	AlertStatusEscalated,
+}
+
+func TestAlertStatusCanTransitionTo(t *testing.T) {
+	allowed := map[AlertStatus][]AlertStatus{
+		AlertStatusNew:           {AlertStatusAcknowledged, AlertStatusEscalated, AlertStatusFalsePositive},
+		AlertStatusEscalated:     {AlertStatusAcknowledged, AlertStatusFalsePositive},
+		AlertStatusAcknowledged:  {AlertStatusInProgress, AlertStatusResolved, AlertStatusFalsePositive, AlertStatusNew},
+		AlertStatusInProgress:    {AlertStatusResolved, AlertStatusFalsePositive, AlertStatusNew},
+		AlertStatusResolved:      nil,
+		AlertStatusFalsePositive: nil,
+	}
+
+	for _, from := range allAlertStatuses {
+		for _, to := range allAlertStatuses {
+			want := false
+			for _, next := range allowed[from] {
+				if next == to {
+					want = true
+				}
+			}
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: got %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestAlertStatusIsFinal(t *testing.T) {
+	for _, status := range allAlertStatuses {
+		want := status == AlertStatusResolved || status == AlertStatusFalsePositive
+		if got := status.IsFinal(); got != want {
+			t.Errorf("%s: got %v, want %v", status, got, want)
+		}
+		if status.IsFinal() && len(alertStatusTransitions[status]) > 0 {
+			t.Errorf("%s is final but has transitions", status)
+		}
+	}
+}
+
+func TestAlertStatusIsValid(t *testing.T) {
+	tests := []struct {
+		status AlertStatus
+		want   bool
+	}{
+		{AlertStatusNew, true},
+		{AlertStatusEscalated, true},
+		{AlertStatusFalsePositive, true},
+		{"new", false},
+		{"Closed", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.IsValid(); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestDeviceStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from DeviceStatus
+		to   DeviceStatus
+		want bool
+	}{
+		{DeviceStatusFree, DeviceStatusConnecting, true},
+		{DeviceStatusFree, DeviceStatusUnavailable, true},
+		{DeviceStatusFree, DeviceStatusInUse, false},
+		{DeviceStatusConnecting, DeviceStatusInUse, true},
+		{DeviceStatusConnecting, DeviceStatusFree, true},
+		{DeviceStatusConnecting, DeviceStatusUnavailable, false},
+		{DeviceStatusInUse, DeviceStatusFree, true},
+		{DeviceStatusInUse, DeviceStatusConnecting, false},
+		{DeviceStatusInUse, DeviceStatusUnavailable, false},
+		{DeviceStatusUnavailable, DeviceStatusFree, true},
+		{DeviceStatusUnavailable, DeviceStatusInUse, false},
+		{"Unknown", DeviceStatusFree, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s: got %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestDeviceStatusIsLinked(t *testing.T) {
+	tests := []struct {
+		status DeviceStatus
+		want   bool
+	}{
+		{DeviceStatusInUse, true},
+		{DeviceStatusConnecting, true},
+		{DeviceStatusFree, false},
+		{DeviceStatusUnavailable, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.IsLinked(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
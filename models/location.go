@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// Location is a node of the building → ward → room → bed hierarchy of the medical center.
+// Doctors can be assigned to wards as their staff.
+type Location struct {
+	BaseModel
+	LocationID   uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ParentID     *uuid.UUID `gorm:"type:uuid;default:null"`
+	Parent       *Location  `gorm:"foreignKey:ParentID;references:LocationID"`
+	Type         string     `gorm:"size:20;not null;check:type in ('building', 'ward', 'room', 'bed')"`
+	Name         string     `gorm:"size:100;not null"`
+	OutOfService bool       `gorm:"not null;default:false"`
+	Staff        []*Doctor  `gorm:"many2many:ward_staff;foreignKey:LocationID;joinForeignKey:LocationID;References:DoctorID;joinReferences:DoctorID"`
+	Version      int        `gorm:"not null;default:1"`
+}
+
+// BedOccupancy is a bed with the patient of the open visit assigned to it, if any
+type BedOccupancy struct {
+	LocationID     uuid.UUID
+	Name           string
+	RoomID         uuid.UUID
+	RoomName       string
+	OutOfService   bool
+	MedicalVisitID *uuid.UUID
+	PatientID      *uuid.UUID
+	PatientName    *string
+}
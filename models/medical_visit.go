@@ -7,11 +7,15 @@ import (
 
 type MedicalVisit struct {
 	BaseModel
-	MedicalVisitID uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"medical_visit_id"`
-	PatientID      uuid.UUID  `gorm:"type:uuid;not null"`
-	Reason         string     `gorm:"size:100;not null" json:"reason"`
-	Diagnosis      string     `gorm:"size:100;not null" json:"diagnosis"`
-	Treatment      string     `gorm:"size:100" json:"treatment"`
-	EntryDate      *time.Time `json:"entry_date" gorm:"autoCreateTime"`
-	DischargeDate  *time.Time `json:"discharge_date" gorm:"type:date"`
+	MedicalVisitID uuid.UUID               `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"medical_visit_id"`
+	PatientID      uuid.UUID               `gorm:"type:uuid;not null"`
+	Reason         string                  `gorm:"size:100;not null" json:"reason"`
+	Diagnosis      string                  `gorm:"size:100;not null" json:"diagnosis"`
+	Treatment      string                  `gorm:"size:100" json:"treatment"`
+	EntryDate      *time.Time              `json:"entry_date" gorm:"autoCreateTime"`
+	DischargeDate  *time.Time              `json:"discharge_date" gorm:"type:date"`
+	Location       string                  `gorm:"size:100;default:null" json:"location"`
+	BedID          *uuid.UUID              `gorm:"type:uuid;default:null" json:"bed_id"`
+	Bed            *Location               `gorm:"foreignKey:BedID;references:LocationID" json:"bed"`
+	Transfers      []*MedicalVisitTransfer `gorm:"foreignKey:MedicalVisitID;references:MedicalVisitID" json:"transfers"`
 }
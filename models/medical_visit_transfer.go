@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MedicalVisitTransfer records the move of an admitted patient from a location to another during a visit
+type MedicalVisitTransfer struct {
+	BaseModel
+	TransferID     uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"transfer_id"`
+	MedicalVisitID uuid.UUID  `gorm:"type:uuid;not null" json:"medical_visit_id"`
+	FromLocation   string     `gorm:"size:100;default:null" json:"from_location"`
+	ToLocation     string     `gorm:"size:100;not null" json:"to_location"`
+	FromBedID      *uuid.UUID `gorm:"type:uuid;default:null" json:"from_bed_id"`
+	ToBedID        *uuid.UUID `gorm:"type:uuid;default:null" json:"to_bed_id"`
+	Reason         string     `gorm:"size:255;default:null" json:"reason"`
+	TransferredAt  time.Time  `gorm:"not null" json:"transferred_at"`
+}
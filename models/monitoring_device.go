@@ -1,15 +1,24 @@
 package models
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
 type MonitoringDevice struct {
 	BaseModel
-	DeviceID   string     `gorm:"size:10;primaryKey" json:"device_id"`
-	Status     string     `gorm:"size:50;not null;check:status in ('In Use', 'Free', 'Unavailable', 'Connecting')" json:"status"`
-	PatientID  *uuid.UUID `gorm:"type:uuid;unique" json:"patient_id"`
-	Patient    *Patient   `gorm:"foreignKey:PatientID;references:PatientID"`
-	LinkedByID *uuid.UUID `gorm:"type:uuid" json:"linked_by_id"`
-	LinkedBy   *Doctor    `gorm:"foreignKey:LinkedByID"`
+	DeviceID        string     `gorm:"size:10;primaryKey" json:"device_id"`
+	Status          string     `gorm:"size:50;not null;check:status in ('In Use', 'Free', 'Unavailable', 'Connecting')" json:"status"`
+	PatientID       *uuid.UUID `gorm:"type:uuid;unique" json:"patient_id"`
+	Patient         *Patient   `gorm:"foreignKey:PatientID;references:PatientID"`
+	LinkedByID      *uuid.UUID `gorm:"type:uuid" json:"linked_by_id"`
+	LinkedBy        *Doctor    `gorm:"foreignKey:LinkedByID"`
+	LinkedAt        *time.Time `gorm:"default:null" json:"linked_at"`
+	LastSeenAt      *time.Time `gorm:"default:null" json:"last_seen_at"`
+	Online          bool       `gorm:"not null;default:false" json:"online"`
+	BatteryLevel    *int       `gorm:"type:smallint;default:null" json:"battery_level"`
+	SignalStrength  *int       `gorm:"default:null" json:"signal_strength"`
+	FirmwareVersion string     `gorm:"size:50;default:null" json:"firmware_version"`
+	Version         int        `gorm:"not null;default:1" json:"version"`
 }
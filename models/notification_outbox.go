@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// NotificationOutbox is a notification waiting to be delivered through a single channel
+type NotificationOutbox struct {
+	BaseModel
+	OutboxID      uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	AlertID       *uuid.UUID `gorm:"type:uuid;default:null"`
+	Channel       string     `gorm:"size:50;not null"`
+	Payload       string     `gorm:"type:jsonb;not null"`
+	Status        string     `gorm:"size:20;not null;default:'pending';check:status in ('pending', 'sending', 'sent', 'failed')"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"not null"`
+	LastError     string     `gorm:"size:255;default:null"`
+	SentAt        *time.Time
+	// LeaseExpiresAt is when an entry left sending by a dispatcher that stopped can be claimed again
+	LeaseExpiresAt *time.Time
+}
+
+func (NotificationOutbox) TableName() string {
+	return "notification_outbox"
+}
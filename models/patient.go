@@ -20,4 +20,5 @@ type Patient struct {
 	Doctors          []*Doctor         `gorm:"many2many:doctor_patients;foreignKey:PatientID;joinForeignKey:PatientID;References:DoctorID;joinReferences:DoctorID"`
 	MedicalVisits    []*MedicalVisit   `gorm:"foreignKey:PatientID;references:PatientID"`
 	Alerts           []*Alert
+	Version          int `gorm:"not null;default:1"`
 }
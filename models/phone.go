@@ -4,6 +4,9 @@ import "github.com/google/uuid"
 
 type Phone struct {
 	BaseModel
-	PhoneID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	ExponentPushToken string    `gorm:"type:varchar(255);not null"`
+	PhoneID           uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ExponentPushToken string     `gorm:"type:varchar(255);not null"`
+	UserID            *uuid.UUID `gorm:"type:uuid"`
+	User              *User      `gorm:"foreignKey:UserID;references:UserID"`
+	Active            bool       `gorm:"not null;default:true"`
 }
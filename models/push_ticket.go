@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PushTicket tracks the delivery of a push notification to a single push token
+type PushTicket struct {
+	BaseModel
+	PushTicketID     uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	OutboxID         uuid.UUID  `gorm:"type:uuid;not null"`
+	AlertID          *uuid.UUID `gorm:"type:uuid;default:null"`
+	PushToken        string     `gorm:"type:varchar(255);not null"`
+	ExpoTicketID     string     `gorm:"size:100;default:null"`
+	Status           string     `gorm:"size:20;not null;default:'pending';check:status in ('pending', 'delivered', 'failed')"`
+	ErrorCode        string     `gorm:"size:100;default:null"`
+	ErrorMessage     string     `gorm:"size:255;default:null"`
+	ReceiptCheckedAt *time.Time
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a refresh token issued to a user. Only its hash is stored. Every refresh replaces the token
+// with a new one of the same family, so a token used twice reveals it was stolen.
+type RefreshToken struct {
+	BaseModel
+	RefreshTokenID uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"refresh_token_id"`
+	UserID         uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	FamilyID       uuid.UUID  `gorm:"type:uuid;not null" json:"family_id"`
+	TokenHash      string     `gorm:"size:64;not null;unique" json:"-"`
+	ExpiresAt      time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt         *time.Time `gorm:"default:null" json:"used_at"`
+	RevokedAt      *time.Time `gorm:"default:null" json:"revoked_at"`
+}
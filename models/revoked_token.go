@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RevokedToken is the ID (jti) of an access token revoked before its expiry. It is kept until the token expires.
+type RevokedToken struct {
+	JTI       string     `gorm:"column:jti;size:64;primaryKey" json:"jti"`
+	UserID    *uuid.UUID `gorm:"type:uuid;default:null" json:"user_id"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceAccount is an integration authenticating with a client ID and secret instead of a user login.
+// Its tokens carry its fixed scopes, a space separated list as in OAuth 2.0, as roles.
+type ServiceAccount struct {
+	BaseModel
+	ServiceAccountID uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"service_account_id"`
+	Name             string     `gorm:"size:100;not null" json:"name"`
+	Description      string     `gorm:"size:255;default:null" json:"description"`
+	ClientID         string     `gorm:"size:40;not null;unique" json:"client_id"`
+	ClientSecretHash string     `gorm:"size:255;not null" json:"-"`
+	Scopes           string     `gorm:"size:255;not null" json:"scopes"`
+	CreatedByID      *uuid.UUID `gorm:"type:uuid;default:null" json:"created_by_id"`
+	ExpiresAt        *time.Time `gorm:"default:null" json:"expires_at"`
+	RevokedAt        *time.Time `gorm:"default:null" json:"revoked_at"`
+	TokensValidAfter time.Time  `gorm:"not null" json:"tokens_valid_after"`
+	LastTokenAt      *time.Time `gorm:"default:null" json:"last_token_at"`
+}
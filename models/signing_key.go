@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric key signing tokens, both halves PEM encoded and the private one encrypted.
+// A key signs tokens from ActivatesAt until a newer key activates, and is published for verification until ExpiresAt.
+type SigningKey struct {
+	BaseModel
+	KID         string     `gorm:"column:kid;size:64;primaryKey" json:"kid"`
+	Algorithm   string     `gorm:"size:10;not null" json:"algorithm"`
+	PrivateKey  string     `gorm:"type:text;not null" json:"-"`
+	PublicKey   string     `gorm:"type:text;not null" json:"public_key"`
+	ActivatesAt time.Time  `gorm:"not null" json:"activates_at"`
+	ExpiresAt   *time.Time `gorm:"default:null" json:"expires_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TechnicalAlert is a non-clinical alert about the monitoring equipment, e.g. a device that stopped reporting
+type TechnicalAlert struct {
+	BaseModel
+	TechnicalAlertID uuid.UUID         `gorm:"type:uuid;default:uuid_generate_v4();primaryKey" json:"technical_alert_id"`
+	DeviceID         string            `gorm:"size:10;not null" json:"device_id"`
+	Device           *MonitoringDevice `gorm:"foreignKey:DeviceID;references:DeviceID"`
+	PatientID        *uuid.UUID        `gorm:"type:uuid;default:null" json:"patient_id"`
+	Patient          *Patient          `gorm:"foreignKey:PatientID;references:PatientID"`
+	Type             string            `gorm:"size:50;not null" json:"type"`
+	Message          string            `gorm:"size:255;not null" json:"message"`
+	LastSeenAt       *time.Time        `gorm:"default:null" json:"last_seen_at"`
+	RaisedAt         time.Time         `gorm:"not null" json:"raised_at"`
+	ResolvedAt       *time.Time        `gorm:"default:null" json:"resolved_at"`
+	ResolvedByID     *uuid.UUID        `gorm:"type:uuid;default:null" json:"resolved_by_id"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+// ThresholdRule raises an alert when a vital sign of a patient stays beyond a threshold.
+// Rules without a PatientID apply to every patient.
+type ThresholdRule struct {
+	BaseModel
+	RuleID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Name            string     `gorm:"size:100;not null"`
+	PatientID       *uuid.UUID `gorm:"type:uuid;default:null"`
+	Patient         *Patient   `gorm:"foreignKey:PatientID;references:PatientID"`
+	Metric          string     `gorm:"size:20;not null;check:metric in ('o2_saturation', 'heart_rate')"`
+	Operator        string     `gorm:"size:2;not null;check:operator in ('<', '<=', '>', '>=')"`
+	Threshold       float64    `gorm:"not null"`
+	Hysteresis      float64    `gorm:"not null;default:0"`
+	DurationSeconds int        `gorm:"not null;default:0"`
+	CooldownSeconds int        `gorm:"not null;default:0"`
+	Diagnosis       string     `gorm:"size:100;not null"`
+	Enabled         bool       `gorm:"not null;default:true"`
+}
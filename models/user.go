@@ -10,4 +10,6 @@ type User struct {
 	Username string    `gorm:"size:100;unique;not null"`
 	Password string    `gorm:"not null"`
 	Roles    []*Role   `gorm:"many2many:user_roles;joinForeignKey:UserID;joinReferences:RoleID;"`
+	// TokenVersion is only changed by IncrementTokenVersion, never by saving the user
+	TokenVersion int `gorm:"->"`
 }
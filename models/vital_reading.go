@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// VitalReading is a time-stamped sample sent by a monitoring device. A metric the device did not measure is nil.
+// Readings are append-only and high volume, so they don't embed BaseModel.
+type VitalReading struct {
+	ReadingID    int64     `gorm:"primaryKey;autoIncrement"`
+	DeviceID     string    `gorm:"size:10;not null"`
+	PatientID    uuid.UUID `gorm:"type:uuid;not null"`
+	RecordedAt   time.Time `gorm:"not null"`
+	O2Saturation *float64
+	HeartRate    *float64
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+}
+
+// VitalReadingBucket aggregates the readings of a patient recorded within a time bucket. The aggregates of a metric
+// are only meaningful when its count is not zero.
+type VitalReadingBucket struct {
+	BucketStart       time.Time
+	O2SaturationCount int64
+	MinO2Saturation   float64
+	AvgO2Saturation   float64
+	MaxO2Saturation   float64
+	HeartRateCount    int64
+	MinHeartRate      float64
+	AvgHeartRate      float64
+	MaxHeartRate      float64
+}
@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"fmt"
+	"log"
+	"mime"
+	"net/smtp"
+	"strings"
+)
+
+// EmailNotifier sends every notification by email through an SMTP server
+type EmailNotifier struct {
+	addr       string
+	auth       smtp.Auth
+	from       string
+	recipients []string
+}
+
+// NewEmailNotifier creates a new instance of EmailNotifier. Authentication is skipped when username is empty.
+func NewEmailNotifier(host, port, username, password, from string, recipients []string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailNotifier{
+		addr:       host + ":" + port,
+		auth:       auth,
+		from:       from,
+		recipients: recipients,
+	}
+}
+
+func (n *EmailNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *EmailNotifier) Send(notification *Notification) error {
+	if len(n.recipients) == 0 {
+		log.Println("No email recipients configured, skipping email notification")
+		return nil
+	}
+
+	message := "From: " + n.from + "\r\n" +
+		"To: " + strings.Join(n.recipients, ", ") + "\r\n" +
+		"Subject: " + encodeSubject(notification.Title) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=\"UTF-8\"\r\n" +
+		"\r\n" +
+		notification.Body + "\r\n"
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, n.recipients, []byte(message)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Printf("Email notification sent to %d recipients", len(n.recipients))
+	return nil
+}
+
+// encodeSubject makes a notification title safe for the Subject header: line breaks, which would start new headers,
+// are removed and non-ASCII text is encoded
+func encodeSubject(title string) string {
+	title = strings.Join(strings.FieldsFunc(title, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+	return mime.QEncoding.Encode("utf-8", title)
+}
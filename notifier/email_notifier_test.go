@@ -0,0 +1,20 @@
+package notifier
+
+import "testing"
+
+func TestEncodeSubject(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Critical alert", "Critical alert"},
+		{"Alert\r\nBcc: someone@example.com", "Alert Bcc: someone@example.com"},
+		{"Saturación baja", "=?utf-8?q?Saturaci=C3=B3n_baja?="},
+	}
+
+	for _, tt := range tests {
+		if got := encodeSubject(tt.title); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	expoPushPath     = "/--/api/v2/push/send"
+	expoReceiptsPath = "/--/api/v2/push/getReceipts"
+
+	// Limits of the Expo push API per request
+	expoMaxTokensPerRequest   = 100
+	expoMaxReceiptsPerRequest = 1000
+)
+
+// ExpoPushMessage is the payload accepted by the Expo push API
+type ExpoPushMessage struct {
+	To       []string               `json:"to"`
+	Title    string                 `json:"title"`
+	Body     string                 `json:"body"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	Priority string                 `json:"priority"`
+	Sound    string                 `json:"sound"`
+}
+
+type expoDetails struct {
+	Error string `json:"error"`
+}
+
+type expoTicket struct {
+	ID      string      `json:"id"`
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Details expoDetails `json:"details"`
+}
+
+type expoPushResponse struct {
+	Data []expoTicket `json:"data"`
+}
+
+type expoReceipt struct {
+	Status  string      `json:"status"`
+	Message string      `json:"message"`
+	Details expoDetails `json:"details"`
+}
+
+type expoReceiptsResponse struct {
+	Data map[string]expoReceipt `json:"data"`
+}
+
+// ExpoNotifier sends push notifications to the registered phones through the Expo push API
+type ExpoNotifier struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewExpoNotifier creates a new instance of ExpoNotifier for the given Expo base URL (e.g. https://exp.host)
+func NewExpoNotifier(baseURL string) *ExpoNotifier {
+	return &ExpoNotifier{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  newHTTPClient(),
+	}
+}
+
+func (n *ExpoNotifier) Channel() string {
+	return ChannelExpo
+}
+
+func (n *ExpoNotifier) Send(notification *Notification) error {
+	_, err := n.SendWithTickets(notification)
+	return err
+}
+
+// SendWithTickets sends the notification and returns the ticket issued by Expo for every push token. If a batch
+// fails, the tickets of the batches already sent are returned with the error.
+func (n *ExpoNotifier) SendWithTickets(notification *Notification) ([]*PushTicket, error) {
+	if len(notification.PushTokens) == 0 {
+		log.Println("No push tokens registered, skipping Expo notification")
+		return nil, nil
+	}
+
+	tickets := make([]*PushTicket, 0, len(notification.PushTokens))
+	for start := 0; start < len(notification.PushTokens); start += expoMaxTokensPerRequest {
+		end := min(start+expoMaxTokensPerRequest, len(notification.PushTokens))
+		tokens := notification.PushTokens[start:end]
+
+		message := ExpoPushMessage{
+			To:       tokens,
+			Title:    notification.Title,
+			Body:     notification.Body,
+			Data:     notification.Data,
+			Priority: "high",
+			Sound:    "default",
+		}
+
+		var response expoPushResponse
+		if err := n.post(expoPushPath, message, &response); err != nil {
+			return tickets, fmt.Errorf("failed to send notifications: %w", err)
+		}
+		if len(response.Data) != len(tokens) {
+			return tickets, fmt.Errorf("expected %d push tickets, got %d", len(tokens), len(response.Data))
+		}
+
+		// Tickets are returned in the same order as the push tokens
+		for i, ticket := range response.Data {
+			tickets = append(tickets, &PushTicket{
+				PushToken: tokens[i],
+				TicketID:  ticket.ID,
+				Status:    ticket.Status,
+				Message:   ticket.Message,
+				Error:     ticket.Details.Error,
+			})
+		}
+	}
+
+	log.Printf("Expo notification sent to %d devices", len(notification.PushTokens))
+	return tickets, nil
+}
+
+// GetReceipts retrieves the receipts of the given Expo push tickets. Tickets without a receipt yet are omitted.
+func (n *ExpoNotifier) GetReceipts(ticketIDs []string) (map[string]*PushReceipt, error) {
+	receipts := make(map[string]*PushReceipt, len(ticketIDs))
+	for start := 0; start < len(ticketIDs); start += expoMaxReceiptsPerRequest {
+		end := min(start+expoMaxReceiptsPerRequest, len(ticketIDs))
+
+		var response expoReceiptsResponse
+		if err := n.post(expoReceiptsPath, map[string][]string{"ids": ticketIDs[start:end]}, &response); err != nil {
+			return nil, fmt.Errorf("failed to get push receipts: %w", err)
+		}
+
+		for id, receipt := range response.Data {
+			receipts[id] = &PushReceipt{
+				Status:  receipt.Status,
+				Message: receipt.Message,
+				Error:   receipt.Details.Error,
+			}
+		}
+	}
+	return receipts, nil
+}
+
+// post sends a JSON request to the Expo API and decodes the JSON response into result
+func (n *ExpoNotifier) post(path string, body interface{}, result interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.baseURL+path, bytes.NewBuffer(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			log.Printf("Error closing Expo response body: %v", err)
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
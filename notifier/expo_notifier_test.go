@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newPushTokens(count int) []string {
+	tokens := make([]string, count)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("ExponentPushToken[%d]", i)
+	}
+	return tokens
+}
+
+// newExpoTestServer answers every push request with one ticket per token, except for the requests whose number
+// (starting at 1) is in failing, which get a 500
+func newExpoTestServer(t *testing.T, failing map[int]bool) (*httptest.Server, *[]int) {
+	var batchSizes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != expoPushPath {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+
+		var message ExpoPushMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("invalid push message: %v", err)
+		}
+		batchSizes = append(batchSizes, len(message.To))
+		if failing[len(batchSizes)] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		response := expoPushResponse{}
+		for _, token := range message.To {
+			response.Data = append(response.Data, expoTicket{ID: "ticket-" + token, Status: "ok"})
+		}
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			t.Errorf("failed to encode response: %v", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &batchSizes
+}
+
+func TestExpoNotifierSendWithTickets(t *testing.T) {
+	tests := []struct {
+		name        string
+		tokens      int
+		failing     map[int]bool
+		wantBatches []int
+		wantTickets int
+		wantErr     bool
+	}{
+		{name: "no tokens", tokens: 0},
+		{name: "single batch", tokens: 3, wantBatches: []int{3}, wantTickets: 3},
+		{name: "full batch", tokens: 100, wantBatches: []int{100}, wantTickets: 100},
+		{name: "split in batches", tokens: 250, wantBatches: []int{100, 100, 50}, wantTickets: 250},
+		{
+			name:        "failed batch keeps the tickets already issued",
+			tokens:      250,
+			failing:     map[int]bool{2: true},
+			wantBatches: []int{100, 100},
+			wantTickets: 100,
+			wantErr:     true,
+		},
+		{name: "failed first batch", tokens: 3, failing: map[int]bool{1: true}, wantBatches: []int{3}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, batchSizes := newExpoTestServer(t, tt.failing)
+			notifier := NewExpoNotifier(server.URL + "/")
+
+			tokens := newPushTokens(tt.tokens)
+			tickets, err := notifier.SendWithTickets(&Notification{Title: "Alert", Body: "Low O2", PushTokens: tokens})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+
+			if fmt.Sprint(*batchSizes) != fmt.Sprint(tt.wantBatches) {
+				t.Errorf("batches %v, want %v", *batchSizes, tt.wantBatches)
+			}
+			if len(tickets) != tt.wantTickets {
+				t.Fatalf("got %d tickets, want %d", len(tickets), tt.wantTickets)
+			}
+			for i, ticket := range tickets {
+				if ticket.PushToken != tokens[i] || ticket.TicketID != "ticket-"+tokens[i] {
+					t.Errorf("ticket %d: got %s for %s", i, ticket.TicketID, ticket.PushToken)
+				}
+			}
+		})
+	}
+}
+
+func TestExpoNotifierRejectsMissingTickets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(expoPushResponse{Data: []expoTicket{{ID: "only-one", Status: "ok"}}})
+	}))
+	defer server.Close()
+
+	notifier := NewExpoNotifier(server.URL)
+	if _, err := notifier.SendWithTickets(&Notification{PushTokens: newPushTokens(2)}); err == nil {
+		t.Errorf("expected an error when Expo returns fewer tickets than tokens")
+	}
+}
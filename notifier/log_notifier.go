@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes every notification to the application log or, when a file path is given,
+// appends it as a JSON line to that file. It is meant for local development and offline testing.
+type LogNotifier struct {
+	filePath string
+	mu       sync.Mutex
+}
+
+// NewLogNotifier creates a new instance of LogNotifier
+func NewLogNotifier(filePath string) *LogNotifier {
+	return &LogNotifier{
+		filePath: filePath,
+	}
+}
+
+func (n *LogNotifier) Channel() string {
+	return ChannelLog
+}
+
+func (n *LogNotifier) Send(notification *Notification) error {
+	entry := struct {
+		*Notification
+		SentAt time.Time `json:"sent_at"`
+	}{
+		Notification: notification,
+		SentAt:       time.Now().UTC(),
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	if n.filePath == "" {
+		log.Printf("Notification: %s", line)
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open notification file: %w", err)
+	}
+	defer func(file *os.File) {
+		if err := file.Close(); err != nil {
+			log.Printf("Error closing notification file: %v", err)
+		}
+	}(file)
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	return nil
+}
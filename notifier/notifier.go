@@ -0,0 +1,73 @@
+package notifier
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	ChannelExpo    = "expo"
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelLog     = "log"
+)
+
+// httpTimeout bounds every request made by the HTTP based notifiers
+const httpTimeout = 10 * time.Second
+
+// Push ticket and receipt statuses
+const (
+	PushStatusOK    = "ok"
+	PushStatusError = "error"
+)
+
+// ErrorDeviceNotRegistered is reported when a push token is no longer valid and must not be used again
+const ErrorDeviceNotRegistered = "DeviceNotRegistered"
+
+// Notification is the message delivered by every notification channel
+type Notification struct {
+	Title      string                 `json:"title"`
+	Body       string                 `json:"body"`
+	PushTokens []string               `json:"push_tokens,omitempty"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+// PushTicket is the answer of a push service for a single push token
+type PushTicket struct {
+	PushToken string
+	TicketID  string
+	Status    string
+	Message   string
+	Error     string
+}
+
+// PushReceipt is the final delivery outcome of a push ticket
+type PushReceipt struct {
+	Status  string
+	Message string
+	Error   string
+}
+
+// Notifier delivers notifications through a single channel
+type Notifier interface {
+	// Channel returns the name of the channel, used to route outbox entries
+	Channel() string
+	// Send delivers the notification. An error means the delivery can be retried.
+	Send(notification *Notification) error
+}
+
+// TicketNotifier is a Notifier whose deliveries are acknowledged with a ticket per push token. When a delivery fails
+// partway, the tickets of the push tokens already delivered are returned along with the error.
+type TicketNotifier interface {
+	Notifier
+	SendWithTickets(notification *Notification) ([]*PushTicket, error)
+}
+
+// ReceiptChecker retrieves the final delivery outcome of previously issued push tickets
+type ReceiptChecker interface {
+	GetReceipts(ticketIDs []string) (map[string]*PushReceipt, error)
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout}
+}
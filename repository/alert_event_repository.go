@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AlertEventRepository includes specific methods for the AlertEvent entity and embeds BaseRepository
+type AlertEventRepository interface {
+	BaseRepository[models.AlertEvent]
+	GetEventsByAlertID(alertID uuid.UUID) ([]*models.AlertEvent, error)
+}
+
+type alertEventRepository struct {
+	BaseRepository[models.AlertEvent]
+	db *gorm.DB
+}
+
+// NewAlertEventRepository creates a new instance of AlertEventRepository
+func NewAlertEventRepository(db *gorm.DB) AlertEventRepository {
+	baseRepo := NewBaseRepository[models.AlertEvent](db)
+	return &alertEventRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetEventsByAlertID retrieves the status history of an alert, oldest first
+func (r *alertEventRepository) GetEventsByAlertID(alertID uuid.UUID) ([]*models.AlertEvent, error) {
+	var alertEvents []*models.AlertEvent
+	if err := r.db.
+		Preload("User").
+		Where("alert_id = ?", alertID).
+		Order("created_at ASC").
+		Find(&alertEvents).Error; err != nil {
+		return nil, err
+	}
+	return alertEvents, nil
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AlertIncidentRepository includes specific methods for the AlertIncident entity and embeds BaseRepository
+type AlertIncidentRepository interface {
+	BaseRepository[models.AlertIncident]
+	GetOpenIncidentInTransaction(patientID uuid.UUID, diagnosis string, tx *gorm.DB) (*models.AlertIncident, error)
+	RecordOccurrenceInTransaction(incidentID uuid.UUID, seenAt time.Time, tx *gorm.DB) error
+	CloseInTransaction(incidentID uuid.UUID, closedAt time.Time, tx *gorm.DB) error
+	Close(incidentID uuid.UUID, closedAt time.Time) error
+	GetIncidentsPaginated(offset int, limit int) ([]*models.AlertIncident, int64, error)
+}
+
+type alertIncidentRepository struct {
+	BaseRepository[models.AlertIncident]
+	db *gorm.DB
+}
+
+// NewAlertIncidentRepository creates a new instance of AlertIncidentRepository
+func NewAlertIncidentRepository(db *gorm.DB) AlertIncidentRepository {
+	baseRepo := NewBaseRepository[models.AlertIncident](db)
+	return &alertIncidentRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetOpenIncidentInTransaction retrieves the latest open incident of a patient and diagnosis.
+// It takes a transaction-scoped advisory lock on the patient and diagnosis first, so concurrent alerts
+// for the same patient and diagnosis are serialized and cannot open two incidents.
+func (r *alertIncidentRepository) GetOpenIncidentInTransaction(patientID uuid.UUID, diagnosis string, tx *gorm.DB) (*models.AlertIncident, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", patientID.String()+":"+diagnosis).Error; err != nil {
+		return nil, err
+	}
+
+	var incident models.AlertIncident
+	if err := tx.
+		Where("patient_id = ? AND diagnosis = ? AND closed_at IS NULL", patientID, diagnosis).
+		Order("last_seen_at DESC").
+		First(&incident).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// RecordOccurrenceInTransaction counts a repeated alert in an incident
+func (r *alertIncidentRepository) RecordOccurrenceInTransaction(incidentID uuid.UUID, seenAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&models.AlertIncident{}).
+		Where("incident_id = ?", incidentID).
+		Updates(map[string]interface{}{
+			"alert_count":  gorm.Expr("alert_count + 1"),
+			"last_seen_at": seenAt,
+		}).Error
+}
+
+// CloseInTransaction closes an incident so new alerts open a new one
+func (r *alertIncidentRepository) CloseInTransaction(incidentID uuid.UUID, closedAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&models.AlertIncident{}).
+		Where("incident_id = ? AND closed_at IS NULL", incidentID).
+		Update("closed_at", closedAt).Error
+}
+
+// Close closes an incident so new alerts open a new one
+func (r *alertIncidentRepository) Close(incidentID uuid.UUID, closedAt time.Time) error {
+	return r.CloseInTransaction(incidentID, closedAt, r.db)
+}
+
+// GetIncidentsPaginated retrieves incidents with their alerts, most recently seen first
+func (r *alertIncidentRepository) GetIncidentsPaginated(offset int, limit int) ([]*models.AlertIncident, int64, error) {
+	var incidents []*models.AlertIncident
+	var totalCount int64
+
+	if err := r.db.Model(&models.AlertIncident{}).Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := r.db.
+		Preload("Alerts", func(db *gorm.DB) *gorm.DB {
+			return db.Order("alert_timestamp ASC")
+		}).
+		Preload("Alerts.BiometricData").
+		Preload("Alerts.AttendedBy").
+		Preload("Alerts.ComputerDiagnostic").
+		Preload("Patient").
+		Order("last_seen_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&incidents).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return incidents, totalCount, nil
+}
@@ -2,22 +2,31 @@ package repository
 
 import (
 	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
 	"errors"
 	"log"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 // AlertRepository includes specific methods for the Alert entity and embeds BaseRepository
 type AlertRepository interface {
 	BaseRepository[models.Alert]
-	GetRecentAlerts(offset int, limit int) ([]*models.Alert, error)
-	GetPastAlerts(offset int, limit int) ([]*models.Alert, error)
-	CountAlertsByPeriod(period string, count *int64) error
+	GetRecentAlerts(recentWindow time.Duration, offset int, limit int) ([]*models.Alert, error)
+	GetPastAlerts(recentWindow time.Duration, offset int, limit int) ([]*models.Alert, error)
+	CountAlertsByPeriod(period string, recentWindow time.Duration, count *int64) error
+	CountAlerts(count *int64) error
+	SearchAlerts(filters dto.AlertFilter, sort dto.AlertSort, after *dto.AlertCursor, limit int) ([]*models.Alert, error)
 	GetAlertsByTimezone(timezone string) ([]*models.Alert, error)
-	Liberate(alert *models.Alert) error
+	TransitionStatus(alertID uuid.UUID, fromStatus string, version int, updates map[string]interface{}, event *models.AlertEvent) (bool, error)
+	TransitionStatusInTransaction(alertID uuid.UUID, fromStatus string, version int, updates map[string]interface{}, event *models.AlertEvent, tx *gorm.DB) (bool, error)
 	UpdateAlert(alert *models.Alert) error
+	AssignDoctors(alertID uuid.UUID, doctorIDs []uuid.UUID) error
+	AssignDoctorsInTransaction(alertID uuid.UUID, doctorIDs []uuid.UUID, tx *gorm.DB) error
+	GetLatestRuleAlertTimestamp(ruleID uuid.UUID, patientID uuid.UUID) (*time.Time, error)
 }
 
 // alertRepository struct embeds baseRepository for common CRUD operations
@@ -72,8 +81,8 @@ func (r *alertRepository) GetAll() ([]*models.Alert, error) {
 	return alerts, nil
 }
 
-// GetRecentAlerts retrieves recent alerts with pagination (alert_timestamp <= 24 hours).
-func (r *alertRepository) GetRecentAlerts(offset int, limit int) ([]*models.Alert, error) {
+// GetRecentAlerts retrieves recent alerts with pagination (raised within the recent window).
+func (r *alertRepository) GetRecentAlerts(recentWindow time.Duration, offset int, limit int) ([]*models.Alert, error) {
 	var alerts []*models.Alert
 	if err := r.db.Preload("BiometricData").
 		Preload("AttendedBy").
@@ -82,7 +91,7 @@ func (r *alertRepository) GetRecentAlerts(offset int, limit int) ([]*models.Aler
 		Preload("Patient.Medications").
 		Preload("Patient.Doctors").
 		Preload("ComputerDiagnostic").
-		Where("alert_timestamp >= ?", time.Now().UTC().Add(-24*time.Hour)).
+		Where("alert_timestamp >= ?", time.Now().UTC().Add(-recentWindow)).
 		Order("alert_timestamp DESC").
 		Offset(offset).
 		Limit(limit).
@@ -92,8 +101,8 @@ func (r *alertRepository) GetRecentAlerts(offset int, limit int) ([]*models.Aler
 	return alerts, nil
 }
 
-// GetPastAlerts retrieves past alerts with pagination (alert_timestamp > 24 hours).
-func (r *alertRepository) GetPastAlerts(offset int, limit int) ([]*models.Alert, error) {
+// GetPastAlerts retrieves past alerts with pagination (raised before the recent window).
+func (r *alertRepository) GetPastAlerts(recentWindow time.Duration, offset int, limit int) ([]*models.Alert, error) {
 	var alerts []*models.Alert
 	if err := r.db.Preload("BiometricData").
 		Preload("AttendedBy").
@@ -103,7 +112,7 @@ func (r *alertRepository) GetPastAlerts(offset int, limit int) ([]*models.Alert,
 		Preload("Patient.Doctors").
 		Preload("Patient.MonitoringDevice").
 		Preload("ComputerDiagnostic").
-		Where("alert_timestamp < ?", time.Now().UTC().Add(-24*time.Hour)).
+		Where("alert_timestamp < ?", time.Now().UTC().Add(-recentWindow)).
 		Order("alert_timestamp DESC").
 		Offset(offset).
 		Limit(limit).
@@ -143,7 +152,12 @@ func (r *alertRepository) GetAlertsByTimezone(timezone string) ([]*models.Alert,
 	return alerts, nil
 }
 
-func (r *alertRepository) CountAlertsByPeriod(period string, count *int64) error {
+// CountAlerts counts every alert
+func (r *alertRepository) CountAlerts(count *int64) error {
+	return r.db.Model(&models.Alert{}).Count(count).Error
+}
+
+func (r *alertRepository) CountAlertsByPeriod(period string, recentWindow time.Duration, count *int64) error {
 	var condition string
 	if period == "recent" {
 		condition = "alert_timestamp >= ?"
@@ -151,24 +165,195 @@ func (r *alertRepository) CountAlertsByPeriod(period string, count *int64) error
 		condition = "alert_timestamp < ?"
 	}
 
-	cutoffTime := time.Now().UTC().Add(-24 * time.Hour)
+	cutoffTime := time.Now().UTC().Add(-recentWindow)
 
 	return r.db.Model(&models.Alert{}).Where(condition, cutoffTime).Count(count).Error
 }
 
-func (r *alertRepository) Liberate(alert *models.Alert) error {
-	err := r.db.Model(&models.Alert{}).Where("alert_id = ?", alert.AlertID).Update("attended_timestamp", nil).Update("attended_by_id", nil).Update("final_diagnosis", "").Error
-	if err != nil {
+func applyAlertFilters(query *gorm.DB, filters dto.AlertFilter) *gorm.DB {
+	// Basic filters from the alert table
+	if filters.PatientID != "" {
+		query = query.Where("alerts.patient_id = ?", filters.PatientID)
+	}
+	if len(filters.Statuses) > 0 {
+		query = query.Where("alerts.status IN ?", filters.Statuses)
+	}
+	if filters.AttendedByID != "" {
+		query = query.Where("alerts.attended_by_id = ?", filters.AttendedByID)
+	}
+	if filters.WardID != "" {
+		query = query.Where("alerts.ward_id = ?", filters.WardID)
+	}
+	if filters.From != nil {
+		query = query.Where("alerts.alert_timestamp >= ?", filters.From.UTC())
+	}
+	if filters.To != nil {
+		query = query.Where("alerts.alert_timestamp < ?", filters.To.UTC())
+	}
+
+	// Join the patients table to filter by DNI and location
+	if filters.DNI != "" || filters.Location != "" {
+		query = query.Joins("JOIN patients p ON p.patient_id = alerts.patient_id")
+		if filters.DNI != "" {
+			query = query.Where("p.dni = ?", filters.DNI)
+		}
+		if filters.Location != "" {
+			query = query.Where("p.location ILIKE ? ESCAPE '\\'", containsPattern(filters.Location))
+		}
+	}
+
+	// Filter by the device that raised the alert
+	if filters.DeviceID != "" {
+		query = query.Where("alerts.device_id = ?", filters.DeviceID)
+	}
+
+	// Filter by the computer diagnostic, joined by SearchAlerts
+	if filters.Diagnosis != "" {
+		query = query.Where("cd.diagnosis ILIKE ? ESCAPE '\\'", containsPattern(filters.Diagnosis))
+	}
+	if filters.MinConfidence != nil {
+		query = query.Where("cd.percentage >= ?", *filters.MinConfidence)
+	}
+
+	return query
+}
+
+// containsPattern builds a LIKE pattern matching values that contain the given text, with its wildcards escaped
+func containsPattern(text string) string {
+	return "%" + likeEscaper.Replace(text) + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchAlerts retrieves a page of the alerts matching the filters, in the given order, starting after the cursor.
+// Ties are broken by alert ID so that the order is stable between pages.
+func (r *alertRepository) SearchAlerts(filters dto.AlertFilter, sort dto.AlertSort, after *dto.AlertCursor, limit int) ([]*models.Alert, error) {
+	column := "alerts.alert_timestamp"
+	if sort.Field == dto.AlertSortByConfidence {
+		column = "cd.percentage"
+	}
+	direction, comparison := "ASC", ">"
+	if sort.Descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	query := r.db.Model(&models.Alert{}).
+		Joins("JOIN computer_diagnostics cd ON cd.diagnostic_id = alerts.diagnostic_id")
+	query = applyAlertFilters(query, filters)
+
+	if after != nil {
+		value, err := after.SortValue(sort.Field)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("("+column+", alerts.alert_id) "+comparison+" (?, ?)", value, after.AlertID)
+	}
+
+	var alerts []*models.Alert
+	if err := query.
+		Preload("BiometricData").
+		Preload("AttendedBy").
+		Preload("Patient").
+		Preload("Patient.Comorbidities").
+		Preload("Patient.Medications").
+		Preload("Patient.Doctors").
+		Preload("Patient.MonitoringDevice").
+		Preload("ComputerDiagnostic").
+		Order(column + " " + direction).
+		Order("alerts.alert_id " + direction).
+		Limit(limit).
+		Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// TransitionStatus applies a status change to an alert and records its event in a single transaction.
+// See TransitionStatusInTransaction.
+func (r *alertRepository) TransitionStatus(alertID uuid.UUID, fromStatus string, version int, updates map[string]interface{}, event *models.AlertEvent) (bool, error) {
+	var transitioned bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transitioned, err = r.TransitionStatusInTransaction(alertID, fromStatus, version, updates, event, tx)
 		return err
+	})
+	return transitioned, err
+}
+
+// TransitionStatusInTransaction applies the updates (including the new status) to an alert only if it is still in
+// fromStatus at the given version, bumps its version and records the event.
+// It reports false without error when the alert was modified meanwhile.
+func (r *alertRepository) TransitionStatusInTransaction(alertID uuid.UUID, fromStatus string, version int, updates map[string]interface{}, event *models.AlertEvent, tx *gorm.DB) (bool, error) {
+	versionedUpdates := make(map[string]interface{}, len(updates)+1)
+	for column, value := range updates {
+		versionedUpdates[column] = value
 	}
+	versionedUpdates["version"] = gorm.Expr("version + 1")
 
-	return nil
+	result := tx.Model(&models.Alert{}).
+		Where("alert_id = ? AND status = ? AND version = ?", alertID, fromStatus, version).
+		Updates(versionedUpdates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := tx.Create(event).Error; err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
+// UpdateAlert sets the final diagnosis of an alert if its version is still the given one
 func (r *alertRepository) UpdateAlert(alert *models.Alert) error {
-	err := r.db.Save(alert).Error
-	if err != nil {
-		return err
+	result := r.db.Model(&models.Alert{}).
+		Where("alert_id = ? AND version = ?", alert.AlertID, alert.Version).
+		Updates(map[string]interface{}{
+			"final_diagnosis": alert.FinalDiagnosis,
+			"version":         gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
 	}
 	return nil
 }
+
+// AssignDoctors links doctors to an alert (doctor_alerts), skipping the ones already linked.
+func (r *alertRepository) AssignDoctors(alertID uuid.UUID, doctorIDs []uuid.UUID) error {
+	return r.AssignDoctorsInTransaction(alertID, doctorIDs, r.db)
+}
+
+// AssignDoctorsInTransaction links doctors to an alert (doctor_alerts) within a transaction, skipping the ones
+// already linked.
+func (r *alertRepository) AssignDoctorsInTransaction(alertID uuid.UUID, doctorIDs []uuid.UUID, tx *gorm.DB) error {
+	for _, doctorID := range doctorIDs {
+		err := tx.Exec(`INSERT INTO doctor_alerts (doctor_id, alert_id)
+			SELECT ?, ? WHERE NOT EXISTS (SELECT 1 FROM doctor_alerts WHERE doctor_id = ? AND alert_id = ?)`,
+			doctorID, alertID, doctorID, alertID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLatestRuleAlertTimestamp retrieves when a threshold rule last raised an alert for a patient, nil if it never did
+func (r *alertRepository) GetLatestRuleAlertTimestamp(ruleID uuid.UUID, patientID uuid.UUID) (*time.Time, error) {
+	var timestamps []time.Time
+	if err := r.db.Model(&models.Alert{}).
+		Where("rule_id = ? AND patient_id = ?", ruleID, patientID).
+		Order("alert_timestamp DESC").
+		Limit(1).
+		Pluck("alert_timestamp", &timestamps).Error; err != nil {
+		return nil, err
+	}
+	if len(timestamps) == 0 {
+		return nil, nil
+	}
+	return &timestamps[0], nil
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// alertResponseStatsColumns aggregates the alerts of a group; alerts never acknowledged are left out of the times
+const alertResponseStatsColumns = `COUNT(*) AS total,
+	COUNT(alerts.attended_timestamp) AS acknowledged,
+	AVG(EXTRACT(EPOCH FROM alerts.attended_timestamp - alerts.alert_timestamp)) AS avg_seconds_to_ack,
+	PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM alerts.attended_timestamp - alerts.alert_timestamp)) AS p50_seconds_to_ack,
+	PERCENTILE_CONT(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM alerts.attended_timestamp - alerts.alert_timestamp)) AS p90_seconds_to_ack,
+	PERCENTILE_CONT(0.95) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM alerts.attended_timestamp - alerts.alert_timestamp)) AS p95_seconds_to_ack`
+
+// AnalyticsRepository computes reporting aggregates over the alerts raised in [from, to)
+type AnalyticsRepository interface {
+	GetAlertResponseStats(from, to time.Time) (*models.AlertResponseStats, error)
+	GetAlertResponseStatsByDiagnosis(from, to time.Time) ([]*models.AlertResponseStats, error)
+	GetAlertResponseStatsByLocation(from, to time.Time) ([]*models.AlertResponseStats, error)
+	GetAlertResponseStatsByWard(from, to time.Time) ([]*models.AlertResponseStats, error)
+	GetAlertResponseStatsByDoctor(from, to time.Time) ([]*models.AlertResponseStats, error)
+	GetAlertResponseStatsByHourOfDay(from, to time.Time, timezone string) ([]*models.AlertResponseStats, error)
+	GetDiagnosisConfusion(from, to time.Time, modelName, modelVersion string) ([]*models.DiagnosisConfusionCell, error)
+	ForWard(wardID uuid.UUID) AnalyticsRepository
+}
+
+type analyticsRepository struct {
+	db     *gorm.DB
+	wardID *uuid.UUID
+}
+
+// NewAnalyticsRepository creates a new instance of AnalyticsRepository
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// ForWard returns a copy of the repository restricted to the alerts raised while the patient was in a ward
+func (r *analyticsRepository) ForWard(wardID uuid.UUID) AnalyticsRepository {
+	return &analyticsRepository{db: r.db, wardID: &wardID}
+}
+
+// GetAlertResponseStats aggregates all the alerts of the range
+func (r *analyticsRepository) GetAlertResponseStats(from, to time.Time) (*models.AlertResponseStats, error) {
+	var stats models.AlertResponseStats
+	if err := r.alertsInRange(from, to).
+		Select(alertResponseStatsColumns).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetAlertResponseStatsByDiagnosis aggregates the alerts of the range by computer diagnosis
+func (r *analyticsRepository) GetAlertResponseStatsByDiagnosis(from, to time.Time) ([]*models.AlertResponseStats, error) {
+	return r.groupedAlertResponseStats(
+		r.alertsInRange(from, to).
+			Joins("JOIN computer_diagnostics ON computer_diagnostics.diagnostic_id = alerts.diagnostic_id"),
+		"computer_diagnostics.diagnosis", "computer_diagnostics.diagnosis",
+	)
+}
+
+// GetAlertResponseStatsByLocation aggregates the alerts of the range by location of the patient
+func (r *analyticsRepository) GetAlertResponseStatsByLocation(from, to time.Time) ([]*models.AlertResponseStats, error) {
+	return r.groupedAlertResponseStats(
+		r.alertsInRange(from, to).
+			Joins("JOIN patients ON patients.patient_id = alerts.patient_id"),
+		"COALESCE(patients.location, '')", "COALESCE(patients.location, '')",
+	)
+}
+
+// GetAlertResponseStatsByWard aggregates the alerts of the range by ward of the patient when they were raised,
+// alerts of patients without bed are grouped under an empty key
+func (r *analyticsRepository) GetAlertResponseStatsByWard(from, to time.Time) ([]*models.AlertResponseStats, error) {
+	return r.groupedAlertResponseStats(
+		r.alertsInRange(from, to).
+			Joins("LEFT JOIN locations wards ON wards.location_id = alerts.ward_id"),
+		"COALESCE(alerts.ward_id::text, '')", "COALESCE(wards.name, '')",
+	)
+}
+
+// GetAlertResponseStatsByDoctor aggregates the acknowledged alerts of the range by attending doctor
+func (r *analyticsRepository) GetAlertResponseStatsByDoctor(from, to time.Time) ([]*models.AlertResponseStats, error) {
+	return r.groupedAlertResponseStats(
+		r.alertsInRange(from, to).
+			Joins("JOIN doctors ON doctors.doctor_id = alerts.attended_by_id"),
+		"doctors.doctor_id::text", "doctors.name",
+	)
+}
+
+// GetAlertResponseStatsByHourOfDay aggregates the alerts of the range by hour of the day (0-23) in the given time zone
+func (r *analyticsRepository) GetAlertResponseStatsByHourOfDay(from, to time.Time, timezone string) ([]*models.AlertResponseStats, error) {
+	var stats []*models.AlertResponseStats
+	if err := r.alertsInRange(from, to).
+		Select("EXTRACT(HOUR FROM alerts.alert_timestamp AT TIME ZONE 'UTC' AT TIME ZONE ?)::int::text AS group_key, '' AS group_label, "+alertResponseStatsColumns, timezone).
+		Group("group_key").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// GetDiagnosisConfusion counts the alerts of the range by computer diagnosis and final diagnosis, optionally for a
+// single model name and version. Alerts without final diagnosis are left out unless they were marked as false
+// positives, which count as the "False Positive" final diagnosis.
+func (r *analyticsRepository) GetDiagnosisConfusion(from, to time.Time, modelName, modelVersion string) ([]*models.DiagnosisConfusionCell, error) {
+	falsePositive := string(enum.AlertStatusFalsePositive)
+	query := r.alertsInRange(from, to).
+		Joins("JOIN computer_diagnostics ON computer_diagnostics.diagnostic_id = alerts.diagnostic_id").
+		Where("COALESCE(alerts.final_diagnosis, '') <> '' OR alerts.status = ?", falsePositive)
+	if modelName != "" {
+		query = query.Where("computer_diagnostics.model_name = ?", modelName)
+	}
+	if modelVersion != "" {
+		query = query.Where("computer_diagnostics.model_version = ?", modelVersion)
+	}
+
+	var cells []*models.DiagnosisConfusionCell
+	if err := query.
+		Select("computer_diagnostics.diagnosis AS predicted, COALESCE(NULLIF(alerts.final_diagnosis, ''), ?) AS actual, COUNT(*) AS count", falsePositive).
+		Group("predicted").
+		Group("actual").
+		Scan(&cells).Error; err != nil {
+		return nil, err
+	}
+	return cells, nil
+}
+
+// alertsInRange selects the alerts raised in [from, to), both in UTC, in the ward of the repository if any
+func (r *analyticsRepository) alertsInRange(from, to time.Time) *gorm.DB {
+	query := r.db.Table("alerts").
+		Where("alerts.deleted_at IS NULL AND alerts.alert_timestamp >= ? AND alerts.alert_timestamp < ?", from.UTC(), to.UTC())
+	if r.wardID != nil {
+		query = query.Where("alerts.ward_id = ?", *r.wardID)
+	}
+	return query
+}
+
+// groupedAlertResponseStats aggregates the alerts of a query by a key expression, largest groups first
+func (r *analyticsRepository) groupedAlertResponseStats(query *gorm.DB, keyExpr string, labelExpr string) ([]*models.AlertResponseStats, error) {
+	var stats []*models.AlertResponseStats
+	if err := query.
+		Select(keyExpr + " AS group_key, " + labelExpr + " AS group_label, " + alertResponseStatsColumns).
+		Group(keyExpr).
+		Group(labelExpr).
+		Order("total DESC").
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
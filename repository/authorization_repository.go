@@ -2,6 +2,7 @@ package repository
 
 import (
 	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -10,10 +11,13 @@ type AuthorizationRepository interface {
 	BaseRepository[models.User]
 	GetUserByUsername(email string) (*models.User, error)
 	GetUserByID(id uuid.UUID) (*models.User, error)
+	GetUsersPage(request dto.PageRequest) (*Page[models.User], error)
 	GetAllUsers(offset int, limit int) ([]*models.User, error)
 	CountAllUsers() (int64, error)
 	DeleteUserAndUserRoles(id uuid.UUID) error
 	UpdateUserRoles(user *models.User, roles []*models.Role) error
+	GetUserIDsByRole(roleName string) ([]uuid.UUID, error)
+	IncrementTokenVersion(id uuid.UUID) error
 }
 
 type authorizationRepository struct {
@@ -69,6 +73,11 @@ func (r *authorizationRepository) GetAllUsers(offset int, limit int) ([]*models.
 	return users, nil
 }
 
+// GetUsersPage retrieves a cursor paginated page of the users with their roles
+func (r *authorizationRepository) GetUsersPage(request dto.PageRequest) (*Page[models.User], error) {
+	return r.GetPage(request, []string{"Roles"})
+}
+
 func (r *authorizationRepository) CountAllUsers() (int64, error) {
 	var count int64
 	if err := r.db.Model(&models.User{}).Count(&count).Error; err != nil {
@@ -114,3 +123,21 @@ func (r *authorizationRepository) UpdateUserRoles(user *models.User, roles []*mo
 	// Commit the transaction
 	return tx.Commit().Error
 }
+
+// GetUserIDsByRole retrieves the IDs of the users that have the given role.
+func (r *authorizationRepository) GetUserIDsByRole(roleName string) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	if err := r.db.Model(&models.User{}).
+		Joins("JOIN user_roles ON user_roles.user_id = users.user_id").
+		Joins("JOIN roles ON roles.role_id = user_roles.role_id").
+		Where("roles.role_name = ?", roleName).
+		Pluck("users.user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// IncrementTokenVersion bumps the token version of a user, revoking the access tokens issued before
+func (r *authorizationRepository) IncrementTokenVersion(id uuid.UUID) error {
+	return r.db.Exec("UPDATE users SET token_version = token_version + 1 WHERE user_id = ?", id).Error
+}
@@ -1,18 +1,24 @@
 package repository
 
 import (
+	"biometric-data-backend/models/dto"
 	"errors"
 	"gorm.io/gorm"
 )
 
+// ErrVersionConflict is returned when a versioned update finds the record modified since it was read
+var ErrVersionConflict = errors.New("version conflict: the record was modified by another request")
+
 type BaseRepository[T any] interface {
 	Create(entity *T) error
 	CreateInTransaction(entity *T, tx *gorm.DB) error
 	GetByID(id interface{}, primaryKey string) (*T, error)
 	GetByIDs(ids []interface{}, primaryKey string) ([]*T, error)
 	GetAll() ([]*T, error)
+	GetPage(request dto.PageRequest, preloads []string, filters ...func(*gorm.DB) *gorm.DB) (*Page[T], error)
 	Update(entity *T, primaryKey string, id interface{}) error
 	UpdateInTransaction(entity *T, primaryKey string, id interface{}, tx *gorm.DB) error
+	UpdateWithVersion(entity *T, primaryKey string, id interface{}, version int, columns ...string) error
 	Delete(id interface{}, primaryKey string) error
 	DeleteInTransaction(id interface{}, primaryKey string, tx *gorm.DB) error
 	BeginTransaction() *gorm.DB
@@ -74,6 +80,11 @@ func (r *baseRepository[T]) GetAll() ([]*T, error) {
 	return entities, nil
 }
 
+// GetPage retrieves a page of the records matching the filters, in a stable order (ignoring logically deleted data)
+func (r *baseRepository[T]) GetPage(request dto.PageRequest, preloads []string, filters ...func(*gorm.DB) *gorm.DB) (*Page[T], error) {
+	return paginate[T](r.db, request, preloads, filters...)
+}
+
 // Update an existing record without transaction
 func (r *baseRepository[T]) Update(entity *T, primaryKey string, id interface{}) error {
 	return r.UpdateInTransaction(entity, primaryKey, id, r.db) // Reuse transaction method
@@ -87,6 +98,25 @@ func (r *baseRepository[T]) UpdateInTransaction(entity *T, primaryKey string, id
 	return nil
 }
 
+// UpdateWithVersion updates a record only if its version is still the given one (optimistic locking).
+// The entity must carry the next version (version + 1). When columns are given only those are updated,
+// zero values included; otherwise zero values are skipped like in Update.
+func (r *baseRepository[T]) UpdateWithVersion(entity *T, primaryKey string, id interface{}, version int, columns ...string) error {
+	query := r.db.Model(entity).Where(primaryKey+" = ? AND version = ?", id, version)
+	if len(columns) > 0 {
+		query = query.Select(append(columns, "version"))
+	}
+
+	result := query.Updates(entity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
 // Delete a record by its primary key without transaction
 func (r *baseRepository[T]) Delete(id interface{}, primaryKey string) error {
 	return r.DeleteInTransaction(id, primaryKey, r.db) // Reuse transaction method
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeviceAssignmentRepository includes specific methods for the DeviceAssignment entity and embeds BaseRepository
+type DeviceAssignmentRepository interface {
+	BaseRepository[models.DeviceAssignment]
+	MarkConnectedInTransaction(deviceID string, connectedAt time.Time, tx *gorm.DB) error
+	CloseOpenAssignmentInTransaction(deviceID string, unlinkedAt time.Time, userID *uuid.UUID, reason enum.DeviceUnlinkReason, tx *gorm.DB) error
+	GetAssignmentsByDeviceID(deviceID string) ([]*models.DeviceAssignment, error)
+}
+
+type deviceAssignmentRepository struct {
+	BaseRepository[models.DeviceAssignment]
+	db *gorm.DB
+}
+
+// NewDeviceAssignmentRepository creates a new instance of DeviceAssignmentRepository
+func NewDeviceAssignmentRepository(db *gorm.DB) DeviceAssignmentRepository {
+	baseRepo := NewBaseRepository[models.DeviceAssignment](db)
+	return &deviceAssignmentRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// MarkConnectedInTransaction records when the device of the open assignment sent its first readings
+func (r *deviceAssignmentRepository) MarkConnectedInTransaction(deviceID string, connectedAt time.Time, tx *gorm.DB) error {
+	return markDeviceAssignmentConnected(tx, deviceID, connectedAt)
+}
+
+// CloseOpenAssignmentInTransaction ends the open assignment of a device, if any
+func (r *deviceAssignmentRepository) CloseOpenAssignmentInTransaction(deviceID string, unlinkedAt time.Time, userID *uuid.UUID, reason enum.DeviceUnlinkReason, tx *gorm.DB) error {
+	return closeOpenDeviceAssignment(tx, deviceID, unlinkedAt, userID, reason)
+}
+
+// GetAssignmentsByDeviceID retrieves the assignments of a device with their patient and doctor, most recent first
+func (r *deviceAssignmentRepository) GetAssignmentsByDeviceID(deviceID string) ([]*models.DeviceAssignment, error) {
+	var assignments []*models.DeviceAssignment
+	if err := r.db.
+		Preload("Patient").
+		Preload("LinkedBy").
+		Where("device_id = ?", deviceID).
+		Order("linked_at DESC").
+		Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// markDeviceAssignmentConnected records when the device of the open assignment sent its first readings
+func markDeviceAssignmentConnected(tx *gorm.DB, deviceID string, connectedAt time.Time) error {
+	return tx.Model(&models.DeviceAssignment{}).
+		Where("device_id = ? AND unlinked_at IS NULL AND connected_at IS NULL", deviceID).
+		Update("connected_at", connectedAt).Error
+}
+
+// closeOpenDeviceAssignment ends the open assignment of a device, shared by the repositories freeing devices
+func closeOpenDeviceAssignment(tx *gorm.DB, deviceID string, unlinkedAt time.Time, userID *uuid.UUID, reason enum.DeviceUnlinkReason) error {
+	return tx.Model(&models.DeviceAssignment{}).
+		Where("device_id = ? AND unlinked_at IS NULL", deviceID).
+		Updates(map[string]interface{}{
+			"unlinked_at":         unlinkedAt,
+			"unlinked_by_user_id": userID,
+			"end_reason":          string(reason),
+		}).Error
+}
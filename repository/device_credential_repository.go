@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeviceCredentialRepository includes specific methods for the DeviceCredential entity and embeds BaseRepository
+type DeviceCredentialRepository interface {
+	BaseRepository[models.DeviceCredential]
+	GetByKeyPrefix(keyPrefix string) (*models.DeviceCredential, error)
+	GetCredentialsByDeviceID(deviceID string) ([]*models.DeviceCredential, error)
+	GetDeviceCredentialInTransaction(deviceID string, credentialID uuid.UUID, tx *gorm.DB) (*models.DeviceCredential, error)
+	ExpireInTransaction(credentialID uuid.UUID, expiresAt time.Time, replacedByID *uuid.UUID, tx *gorm.DB) error
+	Revoke(credentialID uuid.UUID, revokedAt time.Time) error
+	TouchLastUsed(credentialID uuid.UUID, usedAt time.Time, notUsedSince time.Time) error
+}
+
+type deviceCredentialRepository struct {
+	BaseRepository[models.DeviceCredential]
+	db *gorm.DB
+}
+
+// NewDeviceCredentialRepository creates a new instance of DeviceCredentialRepository
+func NewDeviceCredentialRepository(db *gorm.DB) DeviceCredentialRepository {
+	baseRepo := NewBaseRepository[models.DeviceCredential](db)
+	return &deviceCredentialRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetByKeyPrefix retrieves the credential of a key by its public prefix
+func (r *deviceCredentialRepository) GetByKeyPrefix(keyPrefix string) (*models.DeviceCredential, error) {
+	var credential models.DeviceCredential
+	if err := r.db.Where("key_prefix = ?", keyPrefix).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// GetCredentialsByDeviceID retrieves the credentials of a device, most recent first
+func (r *deviceCredentialRepository) GetCredentialsByDeviceID(deviceID string) ([]*models.DeviceCredential, error) {
+	var credentials []*models.DeviceCredential
+	if err := r.db.Where("device_id = ?", deviceID).Order("created_at DESC").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// GetDeviceCredentialInTransaction retrieves a credential of a device inside a transaction.
+// It takes a transaction-scoped advisory lock on the credential first, so it cannot be rotated twice at once.
+func (r *deviceCredentialRepository) GetDeviceCredentialInTransaction(deviceID string, credentialID uuid.UUID, tx *gorm.DB) (*models.DeviceCredential, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "device_credential:"+credentialID.String()).Error; err != nil {
+		return nil, err
+	}
+
+	var credential models.DeviceCredential
+	if err := tx.Where("credential_id = ? AND device_id = ?", credentialID, deviceID).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+// ExpireInTransaction makes a credential expire at the given time, recording the credential replacing it
+func (r *deviceCredentialRepository) ExpireInTransaction(credentialID uuid.UUID, expiresAt time.Time, replacedByID *uuid.UUID, tx *gorm.DB) error {
+	return tx.Model(&models.DeviceCredential{}).
+		Where("credential_id = ?", credentialID).
+		Updates(map[string]interface{}{
+			"expires_at":     expiresAt,
+			"replaced_by_id": replacedByID,
+		}).Error
+}
+
+// Revoke revokes a credential right away
+func (r *deviceCredentialRepository) Revoke(credentialID uuid.UUID, revokedAt time.Time) error {
+	return r.db.Model(&models.DeviceCredential{}).
+		Where("credential_id = ? AND revoked_at IS NULL", credentialID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// TouchLastUsed records the use of a credential, unless it was already recorded since the given time
+func (r *deviceCredentialRepository) TouchLastUsed(credentialID uuid.UUID, usedAt time.Time, notUsedSince time.Time) error {
+	return r.db.Model(&models.DeviceCredential{}).
+		Where("credential_id = ? AND (last_used_at IS NULL OR last_used_at < ?)", credentialID, notUsedSince).
+		UpdateColumn("last_used_at", usedAt).Error
+}
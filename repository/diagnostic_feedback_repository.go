@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DiagnosticFeedbackRepository includes specific methods for the DiagnosticFeedback entity and embeds BaseRepository
+type DiagnosticFeedbackRepository interface {
+	BaseRepository[models.DiagnosticFeedback]
+	Upsert(feedback *models.DiagnosticFeedback) error
+	GetFeedbackByAlertID(alertID uuid.UUID) ([]*models.DiagnosticFeedback, error)
+	GetLabeledAlertsInBatches(from, to time.Time, modelName, modelVersion string, batchSize int, process func([]*models.Alert) error) error
+}
+
+type diagnosticFeedbackRepository struct {
+	BaseRepository[models.DiagnosticFeedback]
+	db *gorm.DB
+}
+
+// NewDiagnosticFeedbackRepository creates a new instance of DiagnosticFeedbackRepository
+func NewDiagnosticFeedbackRepository(db *gorm.DB) DiagnosticFeedbackRepository {
+	baseRepo := NewBaseRepository[models.DiagnosticFeedback](db)
+	return &diagnosticFeedbackRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// Upsert stores the feedback of a doctor on an alert, replacing the one the doctor gave before
+func (r *diagnosticFeedbackRepository) Upsert(feedback *models.DiagnosticFeedback) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "alert_id"}, {Name: "doctor_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"agrees", "corrected_diagnosis", "note", "updated_at", "deleted_at"}),
+		}).
+		Create(feedback).Error
+}
+
+// GetFeedbackByAlertID retrieves the feedback given on an alert, most recent first
+func (r *diagnosticFeedbackRepository) GetFeedbackByAlertID(alertID uuid.UUID) ([]*models.DiagnosticFeedback, error) {
+	var feedback []*models.DiagnosticFeedback
+	if err := r.db.
+		Preload("Doctor").
+		Where("alert_id = ?", alertID).
+		Order("updated_at DESC").
+		Find(&feedback).Error; err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// GetLabeledAlertsInBatches walks the alerts raised in [from, to) that have a label, i.e. feedback from a doctor,
+// a final diagnosis or a false positive status, with their computer diagnostic, biometric data and feedback.
+// The computer diagnostics can be restricted to a model name and version.
+func (r *diagnosticFeedbackRepository) GetLabeledAlertsInBatches(from, to time.Time, modelName, modelVersion string, batchSize int, process func([]*models.Alert) error) error {
+	query := r.db.
+		Preload("ComputerDiagnostic").
+		Preload("BiometricData").
+		Preload("Feedback", func(db *gorm.DB) *gorm.DB {
+			return db.Order("updated_at DESC")
+		}).
+		Joins("JOIN computer_diagnostics ON computer_diagnostics.diagnostic_id = alerts.diagnostic_id").
+		Where("alerts.alert_timestamp >= ? AND alerts.alert_timestamp < ?", from.UTC(), to.UTC()).
+		Where("COALESCE(alerts.final_diagnosis, '') <> '' OR alerts.status = ? OR EXISTS (SELECT 1 FROM diagnostic_feedback WHERE diagnostic_feedback.alert_id = alerts.alert_id AND diagnostic_feedback.deleted_at IS NULL)",
+			string(enum.AlertStatusFalsePositive))
+	if modelName != "" {
+		query = query.Where("computer_diagnostics.model_name = ?", modelName)
+	}
+	if modelVersion != "" {
+		query = query.Where("computer_diagnostics.model_version = ?", modelVersion)
+	}
+
+	var alerts []*models.Alert
+	return query.FindInBatches(&alerts, batchSize, func(tx *gorm.DB, batch int) error {
+		return process(alerts)
+	}).Error
+}
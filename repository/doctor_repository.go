@@ -14,6 +14,10 @@ type DoctorRepository interface {
 	GetDoctorsByIDs(ids []uuid.UUID) ([]*models.Doctor, error)
 	GetDoctorByDNI(dni string) (*models.Doctor, error)
 	GetDoctorsByAlertID(alertID uuid.UUID) ([]*models.Doctor, error)
+	GetDoctorsByPatientID(patientID uuid.UUID) ([]*models.Doctor, error)
+	GetOnCallDoctors() ([]*models.Doctor, error)
+	GetWardStaffByPatientID(patientID uuid.UUID) ([]*models.Doctor, error)
+	UpdateOnCall(id uuid.UUID, onCall bool) error
 }
 
 // doctorRepository struct embeds the baseRepository for common CRUD operations
@@ -69,3 +73,48 @@ func (r *doctorRepository) GetDoctorsByAlertID(alertID uuid.UUID) ([]*models.Doc
 	}
 	return doctors, nil
 }
+
+// GetDoctorsByPatientID retrieves the doctors assigned to a specific patient.
+func (r *doctorRepository) GetDoctorsByPatientID(patientID uuid.UUID) ([]*models.Doctor, error) {
+	var doctors []*models.Doctor
+	if err := r.db.Joins("JOIN doctor_patients ON doctor_patients.doctor_id = doctors.doctor_id").
+		Where("doctor_patients.patient_id = ?", patientID).Find(&doctors).Error; err != nil {
+		return nil, err
+	}
+	return doctors, nil
+}
+
+// GetOnCallDoctors retrieves the doctors that are currently on call.
+func (r *doctorRepository) GetOnCallDoctors() ([]*models.Doctor, error) {
+	var doctors []*models.Doctor
+	if err := r.db.Where("on_call = ?", true).Find(&doctors).Error; err != nil {
+		return nil, err
+	}
+	return doctors, nil
+}
+
+// UpdateOnCall sets whether a doctor is on call.
+func (r *doctorRepository) UpdateOnCall(id uuid.UUID, onCall bool) error {
+	result := r.db.Model(&models.Doctor{}).Where("doctor_id = ?", id).Update("on_call", onCall)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetWardStaffByPatientID retrieves the staff of the ward of the bed assigned to the open visit of a patient
+func (r *doctorRepository) GetWardStaffByPatientID(patientID uuid.UUID) ([]*models.Doctor, error) {
+	var doctors []*models.Doctor
+	if err := r.db.Joins("JOIN ward_staff ON ward_staff.doctor_id = doctors.doctor_id").
+		Joins("JOIN locations room ON room.parent_id = ward_staff.location_id").
+		Joins("JOIN locations bed ON bed.parent_id = room.location_id").
+		Joins("JOIN medical_visits mv ON mv.bed_id = bed.location_id AND mv.discharge_date IS NULL AND mv.deleted_at IS NULL").
+		Where("mv.patient_id = ?", patientID).
+		Find(&doctors).Error; err != nil {
+		return nil, err
+	}
+	return doctors, nil
+}
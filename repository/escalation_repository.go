@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EscalationRepository includes specific methods for the AlertEscalation entity and embeds BaseRepository
+type EscalationRepository interface {
+	BaseRepository[models.AlertEscalation]
+	GetEscalationByAlertID(alertID uuid.UUID) (*models.AlertEscalation, error)
+	GetDueEscalationsInTransaction(now time.Time, limit int, tx *gorm.DB) ([]*models.AlertEscalation, error)
+	RecordStepInTransaction(escalation *models.AlertEscalation, step *models.AlertEscalationStep, tx *gorm.DB) error
+	Stop(alertID uuid.UUID, reason string) error
+	Resume(alertID uuid.UUID, nextEscalationAt *time.Time) error
+	Postpone(alertID uuid.UUID, nextEscalationAt time.Time) error
+}
+
+type escalationRepository struct {
+	BaseRepository[models.AlertEscalation]
+	db *gorm.DB
+}
+
+// NewEscalationRepository creates a new instance of EscalationRepository
+func NewEscalationRepository(db *gorm.DB) EscalationRepository {
+	baseRepo := NewBaseRepository[models.AlertEscalation](db)
+	return &escalationRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetEscalationByAlertID retrieves the escalation of an alert along with its executed steps.
+func (r *escalationRepository) GetEscalationByAlertID(alertID uuid.UUID) (*models.AlertEscalation, error) {
+	var escalation models.AlertEscalation
+	if err := r.db.
+		Preload("Alert").
+		Preload("Steps", func(db *gorm.DB) *gorm.DB {
+			return db.Order("executed_at ASC")
+		}).
+		Where("alert_id = ?", alertID).First(&escalation).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &escalation, nil
+}
+
+// GetDueEscalationsInTransaction locks and retrieves the escalations of unacknowledged alerts whose next step is due.
+// Rows locked by another scheduler instance are skipped. Only the escalation rows are locked, the joined alerts stay
+// free to be claimed and linked to doctors.
+func (r *escalationRepository) GetDueEscalationsInTransaction(now time.Time, limit int, tx *gorm.DB) ([]*models.AlertEscalation, error) {
+	var escalations []*models.AlertEscalation
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "alert_escalations"}, Options: "SKIP LOCKED"}).
+		Joins("JOIN alerts ON alerts.alert_id = alert_escalations.alert_id").
+		Where("alert_escalations.stopped_at IS NULL").
+		Where("alert_escalations.next_escalation_at <= ?", now).
+		Where("alerts.status IN ? AND alerts.deleted_at IS NULL", []string{string(enum.AlertStatusNew), string(enum.AlertStatusEscalated)}).
+		Order("alert_escalations.next_escalation_at ASC").
+		Limit(limit).
+		Find(&escalations).Error; err != nil {
+		return nil, err
+	}
+	return escalations, nil
+}
+
+// RecordStepInTransaction stores an executed step and advances the escalation to its next level.
+func (r *escalationRepository) RecordStepInTransaction(escalation *models.AlertEscalation, step *models.AlertEscalationStep, tx *gorm.DB) error {
+	if err := tx.Create(step).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.AlertEscalation{}).
+		Where("alert_id = ?", escalation.AlertID).
+		Updates(map[string]interface{}{
+			"level":              escalation.Level,
+			"next_escalation_at": escalation.NextEscalationAt,
+			"stopped_at":         escalation.StoppedAt,
+			"stop_reason":        escalation.StopReason,
+		}).Error
+}
+
+// Stop ends the escalation of an alert. Escalations already stopped are left untouched.
+func (r *escalationRepository) Stop(alertID uuid.UUID, reason string) error {
+	return r.db.Model(&models.AlertEscalation{}).
+		Where("alert_id = ? AND stopped_at IS NULL", alertID).
+		Updates(map[string]interface{}{
+			"stopped_at":         time.Now().UTC(),
+			"stop_reason":        reason,
+			"next_escalation_at": nil,
+		}).Error
+}
+
+// Resume restarts a stopped escalation from its first level.
+func (r *escalationRepository) Resume(alertID uuid.UUID, nextEscalationAt *time.Time) error {
+	return r.db.Model(&models.AlertEscalation{}).
+		Where("alert_id = ?", alertID).
+		Updates(map[string]interface{}{
+			"level":              0,
+			"stopped_at":         nil,
+			"stop_reason":        nil,
+			"next_escalation_at": nextEscalationAt,
+		}).Error
+}
+
+// Postpone moves the next step of a running escalation to a later time, leaving its level untouched.
+func (r *escalationRepository) Postpone(alertID uuid.UUID, nextEscalationAt time.Time) error {
+	return r.db.Model(&models.AlertEscalation{}).
+		Where("alert_id = ? AND stopped_at IS NULL", alertID).
+		Update("next_escalation_at", nextEscalationAt).Error
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LocationRepository includes specific methods for the Location entity and embeds BaseRepository
+type LocationRepository interface {
+	BaseRepository[models.Location]
+	GetLocations(filters dto.LocationFilter) ([]*models.Location, error)
+	GetAncestors(id uuid.UUID) ([]*models.Location, error)
+	CountChildren(id uuid.UUID) (int64, error)
+	GetBedOccupancy(id uuid.UUID) ([]*models.BedOccupancy, error)
+	GetCurrentWardID(patientID uuid.UUID) (*uuid.UUID, error)
+	SetWardStaff(wardID uuid.UUID, doctors []*models.Doctor) error
+}
+
+type locationRepository struct {
+	BaseRepository[models.Location]
+	db *gorm.DB
+}
+
+// NewLocationRepository creates a new instance of LocationRepository
+func NewLocationRepository(db *gorm.DB) LocationRepository {
+	baseRepo := NewBaseRepository[models.Location](db)
+	return &locationRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetByID retrieves a location with the staff of the ward
+func (r *locationRepository) GetByID(id interface{}, primaryKey string) (*models.Location, error) {
+	var location models.Location
+	if err := r.db.
+		Preload("Staff").
+		Where(primaryKey+" = ?", id).
+		First(&location).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &location, nil
+}
+
+// GetLocations retrieves the locations of a type and/or the children of a location, by name
+func (r *locationRepository) GetLocations(filters dto.LocationFilter) ([]*models.Location, error) {
+	query := r.db.Preload("Staff")
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+	if filters.ParentID != nil {
+		query = query.Where("parent_id = ?", *filters.ParentID)
+	}
+
+	var locations []*models.Location
+	if err := query.Order("name ASC").Find(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// GetAncestors retrieves a location and the locations containing it, from the building down to the location itself
+func (r *locationRepository) GetAncestors(id uuid.UUID) ([]*models.Location, error) {
+	var locations []*models.Location
+	if err := r.db.Raw(`WITH RECURSIVE ancestors AS (
+			SELECT locations.*, 0 AS depth FROM locations WHERE location_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT parent.*, ancestors.depth + 1 FROM locations parent
+			JOIN ancestors ON parent.location_id = ancestors.parent_id
+			WHERE parent.deleted_at IS NULL
+		)
+		SELECT * FROM ancestors ORDER BY depth DESC`, id).
+		Scan(&locations).Error; err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// CountChildren counts the locations directly contained in a location
+func (r *locationRepository) CountChildren(id uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Location{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetBedOccupancy retrieves the beds contained in a location, or the bed itself, with the patient of the open
+// visit assigned to each of them, by room and bed name
+func (r *locationRepository) GetBedOccupancy(id uuid.UUID) ([]*models.BedOccupancy, error) {
+	var beds []*models.BedOccupancy
+	if err := r.db.Raw(`WITH RECURSIVE descendants AS (
+			SELECT location_id FROM locations WHERE location_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT child.location_id FROM locations child
+			JOIN descendants ON child.parent_id = descendants.location_id
+			WHERE child.deleted_at IS NULL
+		)
+		SELECT bed.location_id, bed.name, room.location_id AS room_id, room.name AS room_name, bed.out_of_service,
+			mv.medical_visit_id, p.patient_id, p.name AS patient_name
+		FROM descendants
+		JOIN locations bed ON bed.location_id = descendants.location_id AND bed.type = ?
+		JOIN locations room ON room.location_id = bed.parent_id
+		LEFT JOIN medical_visits mv ON mv.bed_id = bed.location_id AND mv.discharge_date IS NULL AND mv.deleted_at IS NULL
+		LEFT JOIN patients p ON p.patient_id = mv.patient_id
+		ORDER BY room.name, bed.name`, id, string(enum.LocationTypeBed)).
+		Scan(&beds).Error; err != nil {
+		return nil, err
+	}
+	return beds, nil
+}
+
+// GetCurrentWardID retrieves the ward of the bed assigned to the open visit of a patient, nil when the patient
+// has no open visit or no bed
+func (r *locationRepository) GetCurrentWardID(patientID uuid.UUID) (*uuid.UUID, error) {
+	var wardIDs []uuid.UUID
+	if err := r.db.Table("medical_visits mv").
+		Joins("JOIN locations bed ON bed.location_id = mv.bed_id").
+		Joins("JOIN locations room ON room.location_id = bed.parent_id").
+		Where("mv.patient_id = ? AND mv.discharge_date IS NULL AND mv.deleted_at IS NULL", patientID).
+		Limit(1).
+		Pluck("room.parent_id", &wardIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(wardIDs) == 0 {
+		return nil, nil
+	}
+	return &wardIDs[0], nil
+}
+
+// SetWardStaff replaces the doctors assigned to a ward
+func (r *locationRepository) SetWardStaff(wardID uuid.UUID, doctors []*models.Doctor) error {
+	return r.db.Model(&models.Location{LocationID: wardID}).Association("Staff").Replace(doctors)
+}
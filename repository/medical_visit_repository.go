@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MedicalVisitRepository includes specific methods for the MedicalVisit entity and embeds BaseRepository
+type MedicalVisitRepository interface {
+	BaseRepository[models.MedicalVisit]
+	GetOpenVisitInTransaction(patientID uuid.UUID, tx *gorm.DB) (*models.MedicalVisit, error)
+	GetOpenVisitByBedInTransaction(bedID uuid.UUID, tx *gorm.DB) (*models.MedicalVisit, error)
+	GetVisitsByPatientID(patientID uuid.UUID) ([]*models.MedicalVisit, error)
+	DischargeInTransaction(visit *models.MedicalVisit, tx *gorm.DB) error
+	TransferInTransaction(visit *models.MedicalVisit, transfer *models.MedicalVisitTransfer, tx *gorm.DB) error
+	SetPatientLocationInTransaction(patientID uuid.UUID, location string, tx *gorm.DB) error
+	ReleasePatientDeviceInTransaction(patientID uuid.UUID, tx *gorm.DB) (string, error)
+}
+
+type medicalVisitRepository struct {
+	BaseRepository[models.MedicalVisit]
+	db *gorm.DB
+}
+
+// NewMedicalVisitRepository creates a new instance of MedicalVisitRepository
+func NewMedicalVisitRepository(db *gorm.DB) MedicalVisitRepository {
+	baseRepo := NewBaseRepository[models.MedicalVisit](db)
+	return &medicalVisitRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetOpenVisitInTransaction retrieves the visit of a patient that has not been discharged yet.
+// It takes a transaction-scoped advisory lock on the patient first, so concurrent admissions, discharges
+// and transfers of the same patient are serialized.
+func (r *medicalVisitRepository) GetOpenVisitInTransaction(patientID uuid.UUID, tx *gorm.DB) (*models.MedicalVisit, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "medical_visit:"+patientID.String()).Error; err != nil {
+		return nil, err
+	}
+
+	var visit models.MedicalVisit
+	if err := tx.
+		Where("patient_id = ? AND discharge_date IS NULL", patientID).
+		Order("entry_date DESC").
+		First(&visit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// GetOpenVisitByBedInTransaction retrieves the visit not discharged yet that is assigned to a bed.
+// It takes a transaction-scoped advisory lock on the bed first, so two patients cannot be assigned to it at once.
+func (r *medicalVisitRepository) GetOpenVisitByBedInTransaction(bedID uuid.UUID, tx *gorm.DB) (*models.MedicalVisit, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "bed:"+bedID.String()).Error; err != nil {
+		return nil, err
+	}
+
+	var visit models.MedicalVisit
+	if err := tx.
+		Where("bed_id = ? AND discharge_date IS NULL", bedID).
+		First(&visit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// GetVisitsByPatientID retrieves the visits of a patient with their transfers, most recent first
+func (r *medicalVisitRepository) GetVisitsByPatientID(patientID uuid.UUID) ([]*models.MedicalVisit, error) {
+	var visits []*models.MedicalVisit
+	if err := r.db.
+		Preload("Transfers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("transferred_at ASC")
+		}).
+		Where("patient_id = ?", patientID).
+		Order("entry_date DESC").
+		Find(&visits).Error; err != nil {
+		return nil, err
+	}
+	return visits, nil
+}
+
+// DischargeInTransaction closes an open visit with its discharge date and final diagnosis and treatment
+func (r *medicalVisitRepository) DischargeInTransaction(visit *models.MedicalVisit, tx *gorm.DB) error {
+	result := tx.Model(&models.MedicalVisit{}).
+		Where("medical_visit_id = ? AND discharge_date IS NULL", visit.MedicalVisitID).
+		Updates(map[string]interface{}{
+			"discharge_date": visit.DischargeDate,
+			"diagnosis":      visit.Diagnosis,
+			"treatment":      visit.Treatment,
+		})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// TransferInTransaction moves the visit to the destination of the transfer and records the transfer
+func (r *medicalVisitRepository) TransferInTransaction(visit *models.MedicalVisit, transfer *models.MedicalVisitTransfer, tx *gorm.DB) error {
+	if err := tx.Model(&models.MedicalVisit{}).
+		Where("medical_visit_id = ?", visit.MedicalVisitID).
+		Updates(map[string]interface{}{
+			"location": transfer.ToLocation,
+			"bed_id":   transfer.ToBedID,
+		}).Error; err != nil {
+		return err
+	}
+	transfer.MedicalVisitID = visit.MedicalVisitID
+	return tx.Create(transfer).Error
+}
+
+// SetPatientLocationInTransaction changes the current location of a patient, cleared when empty, and bumps its version
+func (r *medicalVisitRepository) SetPatientLocationInTransaction(patientID uuid.UUID, location string, tx *gorm.DB) error {
+	var value interface{}
+	if location != "" {
+		value = location
+	}
+	return tx.Model(&models.Patient{}).
+		Where("patient_id = ?", patientID).
+		Updates(map[string]interface{}{
+			"location": value,
+			"version":  gorm.Expr("version + 1"),
+		}).Error
+}
+
+// ReleasePatientDeviceInTransaction frees the monitoring device linked to a patient, bumps its version and ends
+// its assignment. It returns the ID of the released device, empty when the patient had none.
+func (r *medicalVisitRepository) ReleasePatientDeviceInTransaction(patientID uuid.UUID, tx *gorm.DB) (string, error) {
+	var device models.MonitoringDevice
+	if err := tx.Where("patient_id = ?", patientID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if err := tx.Model(&models.MonitoringDevice{}).
+		Where("device_id = ?", device.DeviceID).
+		Updates(map[string]interface{}{
+			"status":       string(enum.DeviceStatusFree),
+			"patient_id":   nil,
+			"linked_by_id": nil,
+			"linked_at":    nil,
+			"version":      gorm.Expr("version + 1"),
+		}).Error; err != nil {
+		return "", err
+	}
+
+	if err := closeOpenDeviceAssignment(tx, device.DeviceID, time.Now().UTC(), nil, enum.DeviceUnlinkReasonDischarged); err != nil {
+		return "", err
+	}
+	return device.DeviceID, nil
+}
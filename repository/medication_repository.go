@@ -2,6 +2,7 @@ package repository
 
 import (
 	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
 	"errors"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -11,6 +12,7 @@ type MedicationRepository interface {
 	CreateMedication(medication *models.Medication) error
 	GetMedicationByID(id uuid.UUID) (*models.Medication, error)
 	GetAllMedications() ([]*models.Medication, error)
+	GetMedicationsPage(request dto.PageRequest) (*Page[models.Medication], error)
 	UpdateMedication(medication *models.Medication) error
 	DeleteMedication(id uuid.UUID) error
 }
@@ -52,6 +54,11 @@ func (r *medicationRepository) GetAllMedications() ([]*models.Medication, error)
 	return medications, nil
 }
 
+// GetMedicationsPage retrieves a cursor paginated page of the medications
+func (r *medicationRepository) GetMedicationsPage(request dto.PageRequest) (*Page[models.Medication], error) {
+	return paginate[models.Medication](r.db, request, nil)
+}
+
 // UpdateMedication updates an existing medication record in the database.
 func (r *medicationRepository) UpdateMedication(medication *models.Medication) error {
 	if err := r.db.Save(medication).Error; err != nil {
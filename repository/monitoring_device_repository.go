@@ -3,6 +3,11 @@ package repository
 import (
 	"biometric-data-backend/models"
 	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -12,8 +17,17 @@ type MonitoringDeviceRepository interface {
 	GetAllMonitoringDevices(offset int, limit int, filters dto.MonitoringDeviceFilter) ([]*models.MonitoringDevice, error)
 	GetDevicesByStatus(status string) ([]*models.MonitoringDevice, error)
 	CountAllMonitoringDevices(filters dto.MonitoringDeviceFilter) (int64, error)
+	GetMonitoringDevicesPage(request dto.PageRequest, filters dto.MonitoringDeviceFilter) (*Page[models.MonitoringDevice], error)
 	UpdateMonitoringDevice(monitoringDevice *models.MonitoringDevice) error
+	UpdateMonitoringDeviceInTransaction(monitoringDevice *models.MonitoringDevice, tx *gorm.DB) error
 	DeleteMonitoringDevice(id string) error
+	BeginTransaction() *gorm.DB
+	GetMonitoringDeviceInTransaction(id string, tx *gorm.DB) (*models.MonitoringDevice, error)
+	GetDeviceByPatientIDInTransaction(patientID uuid.UUID, tx *gorm.DB) (*models.MonitoringDevice, error)
+	GetStaleConnectingDevices(linkedBefore time.Time, limit int) ([]*models.MonitoringDevice, error)
+	MarkConnected(deviceID string, connectedAt time.Time) (bool, error)
+	UpdateTelemetryInTransaction(monitoringDevice *models.MonitoringDevice, tx *gorm.DB) error
+	GetSilentDevices(seenBefore time.Time, limit int) ([]*models.MonitoringDevice, error)
 }
 
 type monitoringDeviceRepository struct {
@@ -79,6 +93,20 @@ func (r *monitoringDeviceRepository) GetDevicesByStatus(status string) ([]*model
 	return devices, nil
 }
 
+// GetMonitoringDevicesPage retrieves a cursor paginated page of the devices matching the filters
+func (r *monitoringDeviceRepository) GetMonitoringDevicesPage(request dto.PageRequest, filters dto.MonitoringDeviceFilter) (*Page[models.MonitoringDevice], error) {
+	return paginate[models.MonitoringDevice](r.db, request,
+		[]string{"Patient", "LinkedBy"},
+		func(query *gorm.DB) *gorm.DB {
+			query = applyMonitoringDeviceFilters(query, filters)
+			if filters.Status != "" {
+				query = query.Where("monitoring_devices.status = ?", filters.Status)
+			}
+			return query
+		},
+	)
+}
+
 func (r *monitoringDeviceRepository) CountAllMonitoringDevices(filters dto.MonitoringDeviceFilter) (int64, error) {
 	var totalCount int64
 
@@ -93,10 +121,28 @@ func (r *monitoringDeviceRepository) CountAllMonitoringDevices(filters dto.Monit
 	return totalCount, nil
 }
 
-// UpdateMonitoringDevice updates an existing monitoringDevice record in the database.
+// UpdateMonitoringDevice updates the status and links of a monitoringDevice record if its version is still the one
+// it was read with, and bumps the version. It returns ErrVersionConflict otherwise.
 func (r *monitoringDeviceRepository) UpdateMonitoringDevice(monitoringDevice *models.MonitoringDevice) error {
-	if err := r.db.Save(monitoringDevice).Error; err != nil {
-		return err
+	return r.UpdateMonitoringDeviceInTransaction(monitoringDevice, r.db)
+}
+
+// UpdateMonitoringDeviceInTransaction updates the status and links of a monitoringDevice record inside a transaction
+// if its version is still the one it was read with, and bumps the version. It returns ErrVersionConflict otherwise.
+func (r *monitoringDeviceRepository) UpdateMonitoringDeviceInTransaction(monitoringDevice *models.MonitoringDevice, tx *gorm.DB) error {
+	version := monitoringDevice.Version
+	monitoringDevice.Version = version + 1
+
+	result := tx.Model(monitoringDevice).
+		Where("device_id = ? AND version = ?", monitoringDevice.DeviceID, version).
+		Select("status", "patient_id", "linked_by_id", "linked_at", "version").
+		Updates(monitoringDevice)
+	if result.Error == nil && result.RowsAffected == 0 {
+		result.Error = ErrVersionConflict
+	}
+	if result.Error != nil {
+		monitoringDevice.Version = version
+		return result.Error
 	}
 	return nil
 }
@@ -108,3 +154,103 @@ func (r *monitoringDeviceRepository) DeleteMonitoringDevice(id string) error {
 	}
 	return nil
 }
+
+// BeginTransaction starts a new transaction
+func (r *monitoringDeviceRepository) BeginTransaction() *gorm.DB {
+	return r.db.Begin()
+}
+
+// GetMonitoringDeviceInTransaction retrieves a monitoringDevice by its MonitoringDeviceID inside a transaction.
+// It takes a transaction-scoped advisory lock on the device first, so concurrent pairings and status changes
+// of the same device are serialized.
+func (r *monitoringDeviceRepository) GetMonitoringDeviceInTransaction(id string, tx *gorm.DB) (*models.MonitoringDevice, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "monitoring_device:"+id).Error; err != nil {
+		return nil, err
+	}
+
+	var device models.MonitoringDevice
+	if err := tx.Where("device_id = ?", id).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+// GetDeviceByPatientIDInTransaction retrieves the device linked to a patient inside a transaction.
+// It takes a transaction-scoped advisory lock on the patient first, so a patient cannot be paired with two devices at once.
+func (r *monitoringDeviceRepository) GetDeviceByPatientIDInTransaction(patientID uuid.UUID, tx *gorm.DB) (*models.MonitoringDevice, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "monitoring_device:patient:"+patientID.String()).Error; err != nil {
+		return nil, err
+	}
+
+	var device models.MonitoringDevice
+	if err := tx.Where("patient_id = ?", patientID).First(&device).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &device, nil
+}
+
+// GetStaleConnectingDevices retrieves the devices still connecting that were linked before the given time, oldest first
+func (r *monitoringDeviceRepository) GetStaleConnectingDevices(linkedBefore time.Time, limit int) ([]*models.MonitoringDevice, error) {
+	var devices []*models.MonitoringDevice
+	if err := r.db.
+		Where("status = ? AND linked_at < ?", string(enum.DeviceStatusConnecting), linkedBefore).
+		Order("linked_at ASC").
+		Limit(limit).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// MarkConnected moves a connecting device to In Use and records when its assignment connected.
+// It reports whether the device was connecting.
+func (r *monitoringDeviceRepository) MarkConnected(deviceID string, connectedAt time.Time) (bool, error) {
+	connected := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.MonitoringDevice{}).
+			Where("device_id = ? AND status = ?", deviceID, string(enum.DeviceStatusConnecting)).
+			Updates(map[string]interface{}{
+				"status":  string(enum.DeviceStatusInUse),
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		connected = true
+
+		return markDeviceAssignmentConnected(tx, deviceID, connectedAt)
+	})
+	if err != nil {
+		return false, err
+	}
+	return connected, nil
+}
+
+// UpdateTelemetryInTransaction saves the connectivity and reported state of a device. Being reported by the device
+// itself, it does not bump the version of the device.
+func (r *monitoringDeviceRepository) UpdateTelemetryInTransaction(monitoringDevice *models.MonitoringDevice, tx *gorm.DB) error {
+	return tx.Model(monitoringDevice).
+		Where("device_id = ?", monitoringDevice.DeviceID).
+		Select("last_seen_at", "online", "battery_level", "signal_strength", "firmware_version").
+		Updates(monitoringDevice).Error
+}
+
+// GetSilentDevices retrieves the devices not seen since the given time that are still online, or that are in use
+// without an open offline technical alert, oldest first
+func (r *monitoringDeviceRepository) GetSilentDevices(seenBefore time.Time, limit int) ([]*models.MonitoringDevice, error) {
+	var devices []*models.MonitoringDevice
+	if err := r.db.
+		Where("(online AND last_seen_at < ?) OR (status = ? AND COALESCE(last_seen_at, linked_at, updated_at) < ? AND NOT EXISTS ("+
+			"SELECT 1 FROM technical_alerts WHERE technical_alerts.device_id = monitoring_devices.device_id "+
+			"AND technical_alerts.type = ? AND technical_alerts.resolved_at IS NULL AND technical_alerts.deleted_at IS NULL))",
+			seenBefore, string(enum.DeviceStatusInUse), seenBefore, string(enum.TechnicalAlertDeviceOffline)).
+		Order("last_seen_at ASC NULLS FIRST").
+		Limit(limit).
+		Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
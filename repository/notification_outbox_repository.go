@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationOutboxRepository includes specific methods for the NotificationOutbox entity and embeds BaseRepository
+type NotificationOutboxRepository interface {
+	BaseRepository[models.NotificationOutbox]
+	GetDueInTransaction(now time.Time, limit int, tx *gorm.DB) ([]*models.NotificationOutbox, error)
+	ClaimInTransaction(entries []*models.NotificationOutbox, leaseExpiresAt time.Time, tx *gorm.DB) error
+	RecordAttemptInTransaction(entry *models.NotificationOutbox, tx *gorm.DB) (bool, error)
+	GetOutboxByAlertID(alertID uuid.UUID) ([]*models.NotificationOutbox, error)
+}
+
+type notificationOutboxRepository struct {
+	BaseRepository[models.NotificationOutbox]
+	db *gorm.DB
+}
+
+// NewNotificationOutboxRepository creates a new instance of NotificationOutboxRepository
+func NewNotificationOutboxRepository(db *gorm.DB) NotificationOutboxRepository {
+	baseRepo := NewBaseRepository[models.NotificationOutbox](db)
+	return &notificationOutboxRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetDueInTransaction locks and retrieves the pending notifications whose next attempt is due, along with the ones
+// whose delivery lease expired. Rows locked by another dispatcher instance are skipped.
+func (r *notificationOutboxRepository) GetDueInTransaction(now time.Time, limit int, tx *gorm.DB) ([]*models.NotificationOutbox, error) {
+	var entries []*models.NotificationOutbox
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND lease_expires_at <= ?)",
+			enum.OutboxStatusPending, now, enum.OutboxStatusSending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// ClaimInTransaction marks the given entries as being sent until the lease expires, so no other dispatcher picks
+// them up while they are delivered outside of any transaction
+func (r *notificationOutboxRepository) ClaimInTransaction(entries []*models.NotificationOutbox, leaseExpiresAt time.Time, tx *gorm.DB) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	outboxIDs := make([]uuid.UUID, 0, len(entries))
+	for _, entry := range entries {
+		outboxIDs = append(outboxIDs, entry.OutboxID)
+	}
+	return tx.Model(&models.NotificationOutbox{}).
+		Where("outbox_id IN ?", outboxIDs).
+		Updates(map[string]interface{}{
+			"status":           enum.OutboxStatusSending,
+			"lease_expires_at": leaseExpiresAt,
+		}).Error
+}
+
+// RecordAttemptInTransaction stores the outcome of a delivery attempt, along with the payload left to deliver, and
+// releases the claim of the entry. It returns false when the entry was no longer claimed.
+func (r *notificationOutboxRepository) RecordAttemptInTransaction(entry *models.NotificationOutbox, tx *gorm.DB) (bool, error) {
+	result := tx.Model(&models.NotificationOutbox{}).
+		Where("outbox_id = ? AND status = ?", entry.OutboxID, enum.OutboxStatusSending).
+		Updates(map[string]interface{}{
+			"payload":          entry.Payload,
+			"status":           entry.Status,
+			"attempts":         entry.Attempts,
+			"next_attempt_at":  entry.NextAttemptAt,
+			"last_error":       entry.LastError,
+			"sent_at":          entry.SentAt,
+			"lease_expires_at": nil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// GetOutboxByAlertID retrieves the notifications queued for an alert
+func (r *notificationOutboxRepository) GetOutboxByAlertID(alertID uuid.UUID) ([]*models.NotificationOutbox, error) {
+	var entries []*models.NotificationOutbox
+	if err := r.db.Where("alert_id = ?", alertID).Order("created_at ASC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
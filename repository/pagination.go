@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"biometric-data-backend/models/dto"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidCursor is returned when a page cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page is a page of a cursor paginated list. NextCursor is empty on the last page and Total is only set when requested.
+type Page[T any] struct {
+	Items      []*T
+	NextCursor string
+	Total      *int64
+}
+
+// paginate reads a page of the records of T matching the filters using keyset pagination. Records are ordered by
+// creation time when the table has a created_at column, then by primary key, so the order is stable and records
+// created while paging are neither skipped nor repeated. Preloads are only applied to the returned items.
+func paginate[T any](db *gorm.DB, request dto.PageRequest, preloads []string, filters ...func(*gorm.DB) *gorm.DB) (*Page[T], error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	keys := pageKeys(stmt.Schema)
+
+	page := &Page[T]{}
+	if request.WithTotal {
+		var total int64
+		if err := db.Model(new(T)).Scopes(filters...).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	query := db.Model(new(T)).Scopes(filters...)
+	columns := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		column := clause.Column{Table: clause.CurrentTable, Name: key.DBName}
+		columns = append(columns, column)
+		query = query.Order(clause.OrderByColumn{Column: column})
+	}
+
+	if request.Cursor != "" {
+		values, err := decodePageCursor(request.Cursor, len(keys))
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+		query = query.Where("("+placeholders+") > ("+placeholders+")", append(columns, values...)...)
+	}
+
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+
+	// One more item tells whether there is a next page
+	var items []*T
+	if err := query.Limit(request.Limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(items) > request.Limit {
+		items = items[:request.Limit]
+		last := reflect.ValueOf(items[len(items)-1]).Elem()
+		values := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			value, _ := key.ValueOf(context.Background(), last)
+			values = append(values, value)
+		}
+		cursor, err := encodePageCursor(values)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = cursor
+	}
+	page.Items = items
+	return page, nil
+}
+
+// pageKeys returns the columns the records of a table are ordered by: created_at, if any, and the primary key
+func pageKeys(s *schema.Schema) []*schema.Field {
+	keys := make([]*schema.Field, 0, 2)
+	if createdAt := s.LookUpField("created_at"); createdAt != nil {
+		keys = append(keys, createdAt)
+	}
+	return append(keys, s.PrimaryFields...)
+}
+
+// encodePageCursor encodes the key values of the last item of a page as an opaque URL-safe string
+func encodePageCursor(values []interface{}) (string, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// decodePageCursor decodes a cursor encoded by encodePageCursor holding the given number of key values
+func decodePageCursor(cursor string, keys int) ([]interface{}, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	if err := json.Unmarshal(decoded, &values); err != nil {
+		return nil, err
+	}
+	if len(values) != keys {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
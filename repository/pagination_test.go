@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+	}{
+		{"created_at and uuid", []interface{}{"2024-10-01T12:00:00.123456Z", "0b7c6a56-8d0c-4bb2-9b8e-1f6c2c3c9d11"}},
+		{"string key", []interface{}{"DEV-001"}},
+		{"numeric key", []interface{}{float64(42)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := encodePageCursor(tt.values)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			decoded, err := decodePageCursor(cursor, len(tt.values))
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.values) {
+				t.Errorf("got %v, want %v", decoded, tt.values)
+			}
+		})
+	}
+}
+
+func TestDecodePageCursorRejectsInvalidCursors(t *testing.T) {
+	twoKeys, err := encodePageCursor([]interface{}{"2024-10-01T12:00:00Z", "id"})
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+		keys   int
+	}{
+		{"not base64", "not a cursor!", 2},
+		{"not json", "bm90IGpzb24", 2},
+		{"not a list", "eyJhIjoxfQ", 1},
+		{"fewer keys", twoKeys, 3},
+		{"more keys", twoKeys, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodePageCursor(tt.cursor, tt.keys); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+
+	if _, err := decodePageCursor(twoKeys, 3); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("got %v, want ErrInvalidCursor", err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +15,8 @@ type PatientRepository interface {
 	BaseRepository[models.Patient]
 	GetPatientByDNI(dni string) (*models.Patient, error)
 	GetAllPaginatedWithFilters(offset int, limit int, filters dto.PatientFilter) ([]*models.Patient, int64, error)
+	GetPatientsPage(request dto.PageRequest, filters dto.PatientFilter) (*Page[models.Patient], error)
+	ExistsByID(id uuid.UUID) (bool, error)
 }
 
 type patientRepository struct {
@@ -128,6 +131,14 @@ func applyPatientFilters(query *gorm.DB, filters dto.PatientFilter) *gorm.DB {
 			Where("DATE(mv2.discharge_date) = ? AND mv2.discharge_date = (SELECT MAX(mv3.discharge_date) FROM medical_visits mv3 WHERE mv3.patient_id = patients.patient_id AND mv3.discharge_date IS NOT NULL)", filters.DischargeDate)
 	}
 
+	// Filter by the ward of the bed assigned to the open visit of the patient
+	if filters.WardID != "" {
+		query = query.Where(`EXISTS (SELECT 1 FROM medical_visits wmv
+			JOIN locations bed ON bed.location_id = wmv.bed_id
+			JOIN locations room ON room.location_id = bed.parent_id
+			WHERE wmv.patient_id = patients.patient_id AND wmv.discharge_date IS NULL AND wmv.deleted_at IS NULL AND room.parent_id = ?)`, filters.WardID)
+	}
+
 	return query
 }
 
@@ -162,3 +173,22 @@ func (r *patientRepository) GetAllPaginatedWithFilters(offset int, limit int, fi
 
 	return patients, totalCount, nil
 }
+
+// ExistsByID checks whether a patient exists without loading its relationships
+func (r *patientRepository) ExistsByID(id uuid.UUID) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.Patient{}).Where("patient_id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetPatientsPage retrieves a cursor paginated page of the patients matching the filters
+func (r *patientRepository) GetPatientsPage(request dto.PageRequest, filters dto.PatientFilter) (*Page[models.Patient], error) {
+	return r.GetPage(request,
+		[]string{"Comorbidities", "Medications", "Doctors", "Alerts", "MedicalVisits", "MonitoringDevice"},
+		func(query *gorm.DB) *gorm.DB {
+			return applyPatientFilters(query, filters)
+		},
+	)
+}
@@ -2,13 +2,22 @@ package repository
 
 import (
 	"biometric-data-backend/models"
+	"errors"
+
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type PhoneRepository interface {
 	BaseRepository[models.Phone]
 	ExistsByExponentPushToken(token string) (bool, error)
+	GetByExponentPushToken(token string) (*models.Phone, error)
 	GetPushTokens() ([]string, error)
+	GetPushTokensByUserIDs(userIDs []uuid.UUID) ([]string, error)
+	Register(phone *models.Phone) error
+	Unregister(token string, userID uuid.UUID) (bool, error)
+	DeactivateByExponentPushTokens(tokens []string) error
+	GetUserIDsByExponentPushTokens(tokens []string) (map[string]uuid.UUID, error)
 }
 
 type phoneRepository struct {
@@ -32,9 +41,22 @@ func (r *phoneRepository) ExistsByExponentPushToken(token string) (bool, error)
 	return count > 0, nil
 }
 
+// GetByExponentPushToken retrieves the phone registered with the given push token
+func (r *phoneRepository) GetByExponentPushToken(token string) (*models.Phone, error) {
+	var phone models.Phone
+	if err := r.db.Where("exponent_push_token = ?", token).First(&phone).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &phone, nil
+}
+
+// GetPushTokens retrieves the push tokens of every active phone
 func (r *phoneRepository) GetPushTokens() ([]string, error) {
 	var phones []models.Phone
-	if err := r.db.Model(&models.Phone{}).Find(&phones).Error; err != nil {
+	if err := r.db.Model(&models.Phone{}).Where("active = ?", true).Find(&phones).Error; err != nil {
 		return nil, err
 	}
 
@@ -51,3 +73,76 @@ func (r *phoneRepository) GetPushTokens() ([]string, error) {
 	}
 	return tokens, nil
 }
+
+// GetPushTokensByUserIDs retrieves the push tokens of the active phones registered by the given users
+func (r *phoneRepository) GetPushTokensByUserIDs(userIDs []uuid.UUID) ([]string, error) {
+	tokens := make([]string, 0)
+	if len(userIDs) == 0 {
+		return tokens, nil
+	}
+
+	if err := r.db.Model(&models.Phone{}).
+		Distinct("exponent_push_token").
+		Where("user_id IN (?) AND active = ? AND exponent_push_token <> ''", userIDs, true).
+		Pluck("exponent_push_token", &tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Register links a push token to a user, taking over the token if it was registered by someone else
+func (r *phoneRepository) Register(phone *models.Phone) error {
+	result := r.db.Model(&models.Phone{}).
+		Where("exponent_push_token = ?", phone.ExponentPushToken).
+		Updates(map[string]interface{}{
+			"user_id": phone.UserID,
+			"active":  true,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	phone.Active = true
+	return r.db.Create(phone).Error
+}
+
+// Unregister deactivates the push token of a user. It reports whether a phone was deactivated.
+func (r *phoneRepository) Unregister(token string, userID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Phone{}).
+		Where("exponent_push_token = ? AND user_id = ? AND active = ?", token, userID, true).
+		Update("active", false)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeactivateByExponentPushTokens stops sending notifications to push tokens that are no longer valid
+func (r *phoneRepository) DeactivateByExponentPushTokens(tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.Phone{}).
+		Where("exponent_push_token IN (?)", tokens).
+		Update("active", false).Error
+}
+
+// GetUserIDsByExponentPushTokens maps push tokens to the users that registered them
+func (r *phoneRepository) GetUserIDsByExponentPushTokens(tokens []string) (map[string]uuid.UUID, error) {
+	userIDs := make(map[string]uuid.UUID)
+	if len(tokens) == 0 {
+		return userIDs, nil
+	}
+
+	var phones []models.Phone
+	if err := r.db.Where("exponent_push_token IN (?) AND user_id IS NOT NULL", tokens).Find(&phones).Error; err != nil {
+		return nil, err
+	}
+	for _, phone := range phones {
+		userIDs[phone.ExponentPushToken] = *phone.UserID
+	}
+	return userIDs, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PushTicketRepository includes specific methods for the PushTicket entity and embeds BaseRepository
+type PushTicketRepository interface {
+	BaseRepository[models.PushTicket]
+	CreateBatchInTransaction(tickets []*models.PushTicket, tx *gorm.DB) error
+	GetPendingReceiptsInTransaction(issuedBefore time.Time, limit int, tx *gorm.DB) ([]*models.PushTicket, error)
+	UpdateReceiptInTransaction(ticket *models.PushTicket, tx *gorm.DB) error
+	GetPushTicketsByAlertID(alertID uuid.UUID) ([]*models.PushTicket, error)
+}
+
+type pushTicketRepository struct {
+	BaseRepository[models.PushTicket]
+	db *gorm.DB
+}
+
+// NewPushTicketRepository creates a new instance of PushTicketRepository
+func NewPushTicketRepository(db *gorm.DB) PushTicketRepository {
+	baseRepo := NewBaseRepository[models.PushTicket](db)
+	return &pushTicketRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// CreateBatchInTransaction stores the tickets of a delivered push notification
+func (r *pushTicketRepository) CreateBatchInTransaction(tickets []*models.PushTicket, tx *gorm.DB) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+	return tx.Create(&tickets).Error
+}
+
+// GetPendingReceiptsInTransaction locks and retrieves the tickets issued before the given time whose receipt is still unknown.
+// Rows locked by another poller instance are skipped.
+func (r *pushTicketRepository) GetPendingReceiptsInTransaction(issuedBefore time.Time, limit int, tx *gorm.DB) ([]*models.PushTicket, error) {
+	var tickets []*models.PushTicket
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("status = ? AND expo_ticket_id IS NOT NULL", enum.PushTicketStatusPending).
+		Where("created_at <= ?", issuedBefore).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&tickets).Error; err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
+
+// UpdateReceiptInTransaction stores the receipt outcome of a ticket
+func (r *pushTicketRepository) UpdateReceiptInTransaction(ticket *models.PushTicket, tx *gorm.DB) error {
+	return tx.Model(&models.PushTicket{}).
+		Where("push_ticket_id = ?", ticket.PushTicketID).
+		Updates(map[string]interface{}{
+			"status":             ticket.Status,
+			"error_code":         ticket.ErrorCode,
+			"error_message":      ticket.ErrorMessage,
+			"receipt_checked_at": ticket.ReceiptCheckedAt,
+		}).Error
+}
+
+// GetPushTicketsByAlertID retrieves the push tickets issued for the notifications of an alert
+func (r *pushTicketRepository) GetPushTicketsByAlertID(alertID uuid.UUID) ([]*models.PushTicket, error) {
+	var tickets []*models.PushTicket
+	if err := r.db.Where("alert_id = ?", alertID).Order("created_at ASC").Find(&tickets).Error; err != nil {
+		return nil, err
+	}
+	return tickets, nil
+}
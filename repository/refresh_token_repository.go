@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository includes specific methods for the RefreshToken entity and embeds BaseRepository
+type RefreshTokenRepository interface {
+	BaseRepository[models.RefreshToken]
+	GetByTokenHashInTransaction(tokenHash string, tx *gorm.DB) (*models.RefreshToken, error)
+	MarkUsedInTransaction(id uuid.UUID, usedAt time.Time, tx *gorm.DB) error
+	RevokeFamilyInTransaction(familyID uuid.UUID, revokedAt time.Time, tx *gorm.DB) error
+	RevokeFamily(familyID uuid.UUID, revokedAt time.Time) error
+	RevokeUserTokens(userID uuid.UUID, revokedAt time.Time) error
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type refreshTokenRepository struct {
+	BaseRepository[models.RefreshToken]
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	baseRepo := NewBaseRepository[models.RefreshToken](db)
+	return &refreshTokenRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetByTokenHashInTransaction retrieves a refresh token by its hash, nil when it does not exist.
+// It takes a transaction-scoped advisory lock on the token first, so concurrent refreshes with the same token
+// are serialized and only the first one can use it.
+func (r *refreshTokenRepository) GetByTokenHashInTransaction(tokenHash string, tx *gorm.DB) (*models.RefreshToken, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "refresh_token:"+tokenHash).Error; err != nil {
+		return nil, err
+	}
+
+	var token models.RefreshToken
+	if err := tx.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsedInTransaction records that a refresh token was exchanged, it cannot be used again
+func (r *refreshTokenRepository) MarkUsedInTransaction(id uuid.UUID, usedAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("refresh_token_id = ?", id).
+		Update("used_at", usedAt).Error
+}
+
+// RevokeFamilyInTransaction revokes every refresh token of a family
+func (r *refreshTokenRepository) RevokeFamilyInTransaction(familyID uuid.UUID, revokedAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeFamily revokes every refresh token of a family
+func (r *refreshTokenRepository) RevokeFamily(familyID uuid.UUID, revokedAt time.Time) error {
+	return r.RevokeFamilyInTransaction(familyID, revokedAt, r.db)
+}
+
+// RevokeUserTokens revokes every refresh token of a user
+func (r *refreshTokenRepository) RevokeUserTokens(userID uuid.UUID, revokedAt time.Time) error {
+	return r.db.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", revokedAt).Error
+}
+
+// DeleteExpired permanently deletes the refresh tokens expired before the given time
+func (r *refreshTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Unscoped().Where("expires_at < ?", before).Delete(&models.RefreshToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenRepository includes specific methods for the RevokedToken entity and embeds BaseRepository
+type RevokedTokenRepository interface {
+	BaseRepository[models.RevokedToken]
+	Revoke(token *models.RevokedToken) error
+	IsRevoked(jti string) (bool, error)
+	DeleteExpired(before time.Time) (int64, error)
+}
+
+type revokedTokenRepository struct {
+	BaseRepository[models.RevokedToken]
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository creates a new instance of RevokedTokenRepository
+func NewRevokedTokenRepository(db *gorm.DB) RevokedTokenRepository {
+	baseRepo := NewBaseRepository[models.RevokedToken](db)
+	return &revokedTokenRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// Revoke adds a token ID to the denylist, revoking it twice does nothing
+func (r *revokedTokenRepository) Revoke(token *models.RevokedToken) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(token).Error
+}
+
+// IsRevoked reports whether a token ID is in the denylist
+func (r *revokedTokenRepository) IsRevoked(jti string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// DeleteExpired deletes the token IDs whose tokens expired before the given time, they are rejected anyway
+func (r *revokedTokenRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at < ?", before).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
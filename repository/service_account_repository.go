@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ServiceAccountRepository includes specific methods for the ServiceAccount entity and embeds BaseRepository
+type ServiceAccountRepository interface {
+	BaseRepository[models.ServiceAccount]
+	GetByClientID(clientID string) (*models.ServiceAccount, error)
+	GetServiceAccounts() ([]*models.ServiceAccount, error)
+	UpdateSecret(id uuid.UUID, clientSecretHash string, tokensValidAfter time.Time) error
+	Revoke(id uuid.UUID, revokedAt time.Time) error
+	TouchLastToken(id uuid.UUID, issuedAt time.Time) error
+}
+
+type serviceAccountRepository struct {
+	BaseRepository[models.ServiceAccount]
+	db *gorm.DB
+}
+
+// NewServiceAccountRepository creates a new instance of ServiceAccountRepository
+func NewServiceAccountRepository(db *gorm.DB) ServiceAccountRepository {
+	baseRepo := NewBaseRepository[models.ServiceAccount](db)
+	return &serviceAccountRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetByClientID retrieves a service account by its client ID
+func (r *serviceAccountRepository) GetByClientID(clientID string) (*models.ServiceAccount, error) {
+	var account models.ServiceAccount
+	if err := r.db.Where("client_id = ?", clientID).First(&account).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &account, nil
+}
+
+// GetServiceAccounts retrieves all the service accounts, most recent first
+func (r *serviceAccountRepository) GetServiceAccounts() ([]*models.ServiceAccount, error) {
+	var accounts []*models.ServiceAccount
+	if err := r.db.Order("created_at DESC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// UpdateSecret replaces the client secret of a service account, invalidating the tokens issued before
+func (r *serviceAccountRepository) UpdateSecret(id uuid.UUID, clientSecretHash string, tokensValidAfter time.Time) error {
+	return r.db.Model(&models.ServiceAccount{}).
+		Where("service_account_id = ?", id).
+		Updates(map[string]interface{}{
+			"client_secret_hash": clientSecretHash,
+			"tokens_valid_after": tokensValidAfter,
+		}).Error
+}
+
+// Revoke revokes a service account and the tokens issued to it
+func (r *serviceAccountRepository) Revoke(id uuid.UUID, revokedAt time.Time) error {
+	return r.db.Model(&models.ServiceAccount{}).
+		Where("service_account_id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at":         revokedAt,
+			"tokens_valid_after": revokedAt,
+		}).Error
+}
+
+// TouchLastToken records when a token was last issued to a service account
+func (r *serviceAccountRepository) TouchLastToken(id uuid.UUID, issuedAt time.Time) error {
+	return r.db.Model(&models.ServiceAccount{}).
+		Where("service_account_id = ?", id).
+		UpdateColumn("last_token_at", issuedAt).Error
+}
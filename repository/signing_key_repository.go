@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SigningKeyRepository includes specific methods for the SigningKey entity and embeds BaseRepository
+type SigningKeyRepository interface {
+	BaseRepository[models.SigningKey]
+	GetPublishedKeys(now time.Time) ([]*models.SigningKey, error)
+	GetSigningKeys() ([]*models.SigningKey, error)
+	GetLatestKeyInTransaction(tx *gorm.DB) (*models.SigningKey, error)
+	ExpireKeysInTransaction(exceptKID string, expiresAt time.Time, tx *gorm.DB) error
+	UpdatePrivateKey(kid string, privateKey string) error
+}
+
+type signingKeyRepository struct {
+	BaseRepository[models.SigningKey]
+	db *gorm.DB
+}
+
+// NewSigningKeyRepository creates a new instance of SigningKeyRepository
+func NewSigningKeyRepository(db *gorm.DB) SigningKeyRepository {
+	baseRepo := NewBaseRepository[models.SigningKey](db)
+	return &signingKeyRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetPublishedKeys retrieves the keys not expired yet, whether they sign tokens or are only used to verify them
+func (r *signingKeyRepository) GetPublishedKeys(now time.Time) ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	if err := r.db.
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("activates_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetSigningKeys retrieves all the keys, most recent first
+func (r *signingKeyRepository) GetSigningKeys() ([]*models.SigningKey, error) {
+	var keys []*models.SigningKey
+	if err := r.db.Order("activates_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// GetLatestKeyInTransaction retrieves the most recent key, nil when there is none.
+// It takes a transaction-scoped advisory lock first, so instances starting or rotating keys at the same time
+// are serialized and only one of them creates a key.
+func (r *signingKeyRepository) GetLatestKeyInTransaction(tx *gorm.DB) (*models.SigningKey, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "signing_keys").Error; err != nil {
+		return nil, err
+	}
+
+	var key models.SigningKey
+	if err := tx.Order("activates_at DESC").First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ExpireKeysInTransaction sets when the keys other than the given one stop being published, unless they
+// already expire earlier
+func (r *signingKeyRepository) ExpireKeysInTransaction(exceptKID string, expiresAt time.Time, tx *gorm.DB) error {
+	return tx.Model(&models.SigningKey{}).
+		Where("kid <> ? AND (expires_at IS NULL OR expires_at > ?)", exceptKID, expiresAt).
+		Update("expires_at", expiresAt).Error
+}
+
+// UpdatePrivateKey replaces the stored private half of a key
+func (r *signingKeyRepository) UpdatePrivateKey(kid string, privateKey string) error {
+	return r.db.Model(&models.SigningKey{}).
+		Where("kid = ?", kid).
+		Update("private_key", privateKey).Error
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TechnicalAlertRepository includes specific methods for the TechnicalAlert entity and embeds BaseRepository
+type TechnicalAlertRepository interface {
+	BaseRepository[models.TechnicalAlert]
+	GetTechnicalAlertByID(id uuid.UUID) (*models.TechnicalAlert, error)
+	GetOpenAlertInTransaction(deviceID string, alertType string, tx *gorm.DB) (*models.TechnicalAlert, error)
+	ResolveOpenAlertsInTransaction(deviceID string, alertType string, resolvedAt time.Time, tx *gorm.DB) (int64, error)
+	Resolve(id uuid.UUID, resolvedAt time.Time, resolvedByID *uuid.UUID) (bool, error)
+	GetTechnicalAlertsPaginated(offset int, limit int, filters dto.TechnicalAlertFilter) ([]*models.TechnicalAlert, int64, error)
+}
+
+type technicalAlertRepository struct {
+	BaseRepository[models.TechnicalAlert]
+	db *gorm.DB
+}
+
+// NewTechnicalAlertRepository creates a new instance of TechnicalAlertRepository
+func NewTechnicalAlertRepository(db *gorm.DB) TechnicalAlertRepository {
+	baseRepo := NewBaseRepository[models.TechnicalAlert](db)
+	return &technicalAlertRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetTechnicalAlertByID retrieves a technical alert with its patient
+func (r *technicalAlertRepository) GetTechnicalAlertByID(id uuid.UUID) (*models.TechnicalAlert, error) {
+	var alert models.TechnicalAlert
+	if err := r.db.Preload("Patient").Where("technical_alert_id = ?", id).First(&alert).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// GetOpenAlertInTransaction retrieves the unresolved technical alert of a device and type
+func (r *technicalAlertRepository) GetOpenAlertInTransaction(deviceID string, alertType string, tx *gorm.DB) (*models.TechnicalAlert, error) {
+	var alert models.TechnicalAlert
+	if err := tx.Where("device_id = ? AND type = ? AND resolved_at IS NULL", deviceID, alertType).First(&alert).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// ResolveOpenAlertsInTransaction resolves the unresolved technical alerts of a device and type, returning how many were
+func (r *technicalAlertRepository) ResolveOpenAlertsInTransaction(deviceID string, alertType string, resolvedAt time.Time, tx *gorm.DB) (int64, error) {
+	result := tx.Model(&models.TechnicalAlert{}).
+		Where("device_id = ? AND type = ? AND resolved_at IS NULL", deviceID, alertType).
+		Update("resolved_at", resolvedAt)
+	return result.RowsAffected, result.Error
+}
+
+// Resolve resolves a technical alert by hand. It reports whether the alert was still open.
+func (r *technicalAlertRepository) Resolve(id uuid.UUID, resolvedAt time.Time, resolvedByID *uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.TechnicalAlert{}).
+		Where("technical_alert_id = ? AND resolved_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"resolved_at":    resolvedAt,
+			"resolved_by_id": resolvedByID,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetTechnicalAlertsPaginated retrieves the technical alerts matching the filters with their patient, most recent first
+func (r *technicalAlertRepository) GetTechnicalAlertsPaginated(offset int, limit int, filters dto.TechnicalAlertFilter) ([]*models.TechnicalAlert, int64, error) {
+	var alerts []*models.TechnicalAlert
+	var totalCount int64
+
+	query := r.db.Model(&models.TechnicalAlert{})
+	switch filters.Status {
+	case "open":
+		query = query.Where("resolved_at IS NULL")
+	case "resolved":
+		query = query.Where("resolved_at IS NOT NULL")
+	}
+	if filters.DeviceID != "" {
+		query = query.Where("device_id = ?", filters.DeviceID)
+	}
+	if filters.Type != "" {
+		query = query.Where("type = ?", filters.Type)
+	}
+
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.
+		Preload("Patient").
+		Order("raised_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return alerts, totalCount, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ThresholdRuleRepository includes specific methods for the ThresholdRule entity and embeds BaseRepository
+type ThresholdRuleRepository interface {
+	BaseRepository[models.ThresholdRule]
+	GetEnabledRules() ([]*models.ThresholdRule, error)
+	UpdateRule(rule *models.ThresholdRule) error
+}
+
+type thresholdRuleRepository struct {
+	BaseRepository[models.ThresholdRule]
+	db *gorm.DB
+}
+
+// NewThresholdRuleRepository creates a new instance of ThresholdRuleRepository
+func NewThresholdRuleRepository(db *gorm.DB) ThresholdRuleRepository {
+	baseRepo := NewBaseRepository[models.ThresholdRule](db)
+	return &thresholdRuleRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// GetEnabledRules retrieves every enabled rule, global and patient specific
+func (r *thresholdRuleRepository) GetEnabledRules() ([]*models.ThresholdRule, error) {
+	var rules []*models.ThresholdRule
+	if err := r.db.Where("enabled = ?", true).Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// UpdateRule updates every field of a rule, including zero values such as a disabled rule or a zero hysteresis
+func (r *thresholdRuleRepository) UpdateRule(rule *models.ThresholdRule) error {
+	return r.db.Model(rule).
+		Select("name", "patient_id", "metric", "operator", "threshold", "hysteresis", "duration_seconds", "cooldown_seconds", "diagnosis", "enabled").
+		Where("rule_id = ?", rule.RuleID).
+		Updates(rule).Error
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"biometric-data-backend/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const vitalReadingInsertBatchSize = 500
+
+// VitalReadingRepository includes specific methods for the VitalReading entity and embeds BaseRepository
+type VitalReadingRepository interface {
+	BaseRepository[models.VitalReading]
+	CreateReadings(readings []*models.VitalReading) (int64, error)
+	GetBuckets(patientID uuid.UUID, from, to time.Time, bucket time.Duration) ([]*models.VitalReadingBucket, error)
+	GetReadings(patientID uuid.UUID, from, to time.Time) ([]*models.VitalReading, error)
+}
+
+type vitalReadingRepository struct {
+	BaseRepository[models.VitalReading]
+	db *gorm.DB
+}
+
+// NewVitalReadingRepository creates a new instance of VitalReadingRepository
+func NewVitalReadingRepository(db *gorm.DB) VitalReadingRepository {
+	baseRepo := NewBaseRepository[models.VitalReading](db)
+	return &vitalReadingRepository{
+		BaseRepository: baseRepo,
+		db:             db,
+	}
+}
+
+// CreateReadings stores a batch of readings, skipping the ones already stored for the same device and timestamp.
+// It returns the number of stored readings.
+func (r *vitalReadingRepository) CreateReadings(readings []*models.VitalReading) (int64, error) {
+	result := r.db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		CreateInBatches(readings, vitalReadingInsertBatchSize)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// GetBuckets aggregates the readings of a patient recorded in [from, to) into buckets of the given size.
+// Buckets are aligned to the Unix epoch and buckets without readings are omitted.
+func (r *vitalReadingRepository) GetBuckets(patientID uuid.UUID, from, to time.Time, bucket time.Duration) ([]*models.VitalReadingBucket, error) {
+	bucketSeconds := int64(bucket / time.Second)
+
+	var buckets []*models.VitalReadingBucket
+	err := r.db.
+		Model(&models.VitalReading{}).
+		Select(`to_timestamp(floor(extract(epoch FROM recorded_at) / ?) * ?) AT TIME ZONE 'UTC' AS bucket_start,
+			COUNT(o2_saturation) AS o2_saturation_count,
+			COALESCE(MIN(o2_saturation), 0) AS min_o2_saturation,
+			COALESCE(AVG(o2_saturation), 0) AS avg_o2_saturation,
+			COALESCE(MAX(o2_saturation), 0) AS max_o2_saturation,
+			COUNT(heart_rate) AS heart_rate_count,
+			COALESCE(MIN(heart_rate), 0) AS min_heart_rate,
+			COALESCE(AVG(heart_rate), 0) AS avg_heart_rate,
+			COALESCE(MAX(heart_rate), 0) AS max_heart_rate`, bucketSeconds, bucketSeconds).
+		Where("patient_id = ? AND recorded_at >= ? AND recorded_at < ?", patientID, from.UTC(), to.UTC()).
+		Group("bucket_start").
+		Order("bucket_start").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// GetReadings retrieves the readings of a patient recorded in [from, to], oldest first
+func (r *vitalReadingRepository) GetReadings(patientID uuid.UUID, from, to time.Time) ([]*models.VitalReading, error) {
+	var readings []*models.VitalReading
+	if err := r.db.
+		Where("patient_id = ? AND recorded_at >= ? AND recorded_at <= ?", patientID, from.UTC(), to.UTC()).
+		Order("recorded_at ASC").
+		Find(&readings).Error; err != nil {
+		return nil, err
+	}
+	return readings, nil
+}
@@ -4,6 +4,7 @@ import (
 	"biometric-data-backend/config"
 	"biometric-data-backend/controller"
 	"biometric-data-backend/enums"
+	"biometric-data-backend/events"
 	"biometric-data-backend/middleware"
 	"biometric-data-backend/redis"
 	"biometric-data-backend/repository"
@@ -30,18 +31,30 @@ const (
 	RolesResource               = "roles"
 	AuthorizationResource       = "authorization"
 	PhoneResource               = "phones"
+	ThresholdRulesResource      = "threshold-rules"
+	AnalyticsResource           = "analytics"
+	LocationsResource           = "locations"
+	TechnicalAlertsResource     = "technical-alerts"
+	ServiceAccountsResource     = "service-accounts"
+	SigningKeysResource         = "signing-keys"
 )
 
+// AllowedOrigin returns the web origin allowed to call the API from a browser
+func AllowedOrigin() string {
+	allowedOrigin := os.Getenv("ALLOWED_ORIGIN")
+	if allowedOrigin == "" {
+		allowedOrigin = "http://localhost:3000"
+	}
+	return allowedOrigin
+}
+
 func CORSMiddleware() gin.HandlerFunc {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("No .env file found, using default configuration")
 	}
 
-	allowedOrigin := os.Getenv("ALLOWED_ORIGIN")
-	if allowedOrigin == "" {
-		allowedOrigin = "http://localhost:3000"
-	}
+	allowedOrigin := AllowedOrigin()
 
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
@@ -54,7 +67,8 @@ func CORSMiddleware() gin.HandlerFunc {
 		}
 
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-App-Origin")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization, X-App-Origin, If-Match")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "ETag")
 
 		if c.Request.Method == http.MethodOptions {
 			c.AbortWithStatus(http.StatusNoContent)
@@ -80,14 +94,50 @@ func registerCrudRoutesWithMiddleware(router *gin.Engine, resource string, creat
 func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 	// Initialize Redis cache manager
 	cacheManager := redis.NewCacheManager(config.RedisClient, 5*time.Minute)
+	// Initialize the in-process event bus
+	eventBus := events.NewBus(64)
 	// Apply CORS middleware to the router
 	router.Use(CORSMiddleware())
 	// JWT Auth
 	authController := controller.NewAuthController()
 
-	// Register JWT auth routes
+	// Signing keys, every token is signed and verified with them
+	signingKeyRepo := repository.NewSigningKeyRepository(db)
+	jwtService := service.NewJWTService(signingKeyRepo, config.JWTSigningAlgorithm, config.JWTIssuer, config.JWTKeyRotationInterval, config.JWTKeyPublishLead, config.JWTKeyRetention, config.JWTKeyCheckInterval, config.SigningKeyEncryptionKey)
+	if err := jwtService.EnsureSigningKey(); err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+	middleware.SetTokenVerifier(jwtService)
+	go jwtService.RunKeyRotation()
+	signingKeyController := controller.NewSigningKeyController(jwtService)
+
+	// Register signing key routes
+	router.GET("/.well-known/jwks.json", signingKeyController.GetJWKS)
+	signingKeys := router.Group("/" + SigningKeysResource)
+	signingKeys.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)))
+	signingKeys.GET("", signingKeyController.GetSigningKeys)
+	signingKeys.POST("/rotate", signingKeyController.RotateKey)
+
+	// Register JWT auth routes, the open token endpoint is gone in favor of service accounts
 	router.POST("/generate-token", authController.GenerateTokenEndpoint)
 
+	// Service accounts
+	serviceAccountRepo := repository.NewServiceAccountRepository(db)
+	serviceAccountService := service.NewServiceAccountService(serviceAccountRepo, jwtService, cacheManager, config.ServiceTokenTTL)
+	serviceAccountController := controller.NewServiceAccountController(serviceAccountService)
+	// Tokens of revoked service accounts are rejected by the role authorization
+	middleware.AddTokenRevocationChecker(serviceAccountService)
+
+	// Register service account routes
+	router.POST("/oauth/token", serviceAccountController.IssueToken)
+	serviceAccounts := router.Group("/" + ServiceAccountsResource)
+	serviceAccounts.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)))
+	serviceAccounts.POST("", serviceAccountController.CreateServiceAccount)
+	serviceAccounts.GET("", serviceAccountController.GetServiceAccounts)
+	serviceAccounts.GET("/:id", serviceAccountController.GetServiceAccountByID)
+	serviceAccounts.POST("/:id/rotate-secret", serviceAccountController.RotateSecret)
+	serviceAccounts.DELETE("/:id", serviceAccountController.RevokeServiceAccount)
+
 	// Role
 	roleRepo := repository.NewRoleRepository(db)
 	roleService := service.NewRoleService(roleRepo, cacheManager)
@@ -109,11 +159,20 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 
 	// Authorization
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo, roleRepo)
-	authorizationController := controller.NewAuthorizationController(userService)
+	doctorRepo := repository.NewDoctorRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	revokedTokenRepo := repository.NewRevokedTokenRepository(db)
+	tokenService := service.NewTokenService(refreshTokenRepo, revokedTokenRepo, userRepo, doctorRepo, jwtService, cacheManager, config.AccessTokenTTL, config.RefreshTokenTTL, config.StreamTokenTTL, config.TokenPurgeInterval)
+	userService := service.NewUserService(userRepo, roleRepo, tokenService)
+	authorizationController := controller.NewAuthorizationController(userService, tokenService)
+	// Revoked tokens and tokens of users whose password or roles changed are rejected by the role authorization
+	middleware.AddTokenRevocationChecker(tokenService)
 
 	// Register authorization routes
 	router.POST("/"+AuthorizationResource+"/login", authorizationController.AuthenticateUser)
+	router.POST("/"+AuthorizationResource+"/refresh", authorizationController.RefreshTokens)
+	router.POST("/"+AuthorizationResource+"/logout", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor, enums.Nurse, enums.Tester)), authorizationController.Logout)
+	go tokenService.RunPurge()
 
 	// Register authorization routes with middleware
 	registerCrudRoutesWithMiddleware(
@@ -128,8 +187,7 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 	)
 
 	// Doctor
-	doctorRepo := repository.NewDoctorRepository(db)
-	doctorService := service.NewDoctorService(doctorRepo, userRepo, roleRepo, userService, cacheManager)
+	doctorService := service.NewDoctorService(doctorRepo, userRepo, roleRepo, userService, tokenService, cacheManager)
 	doctorController := controller.NewDoctorController(doctorService)
 
 	// Register doctor routes
@@ -146,11 +204,18 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 	// Additional doctor-specific route
 	router.GET("/"+DoctorsResource+"/:id/short", doctorController.GetShortDoctorByID)
 	router.GET("/"+DoctorsResource+"/alertID/:alertID", doctorController.GetDoctorsByAlertID)
-	router.GET("/"+DoctorsResource+"/userID/:userID", doctorController.GetDoctorByUserID)
-	router.PATCH("/"+DoctorsResource+"/userID/:userID", doctorController.UpdateDoctorByUserID)
+	// Users can only read and edit their own doctor profile, admins any of them
+	doctorsByUser := router.Group("/" + DoctorsResource + "/userID/:userID")
+	doctorsByUser.Use(
+		middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor, enums.Nurse, enums.Tester)),
+		middleware.SelfOrAdmin("userID"),
+	)
+	doctorsByUser.GET("", doctorController.GetDoctorByUserID)
+	doctorsByUser.PATCH("", doctorController.UpdateDoctorByUserID)
+	router.PATCH("/"+DoctorsResource+"/:id/on-call", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), doctorController.UpdateDoctorOnCall)
 
 	// Change password for doctor
-	router.PATCH("/"+AuthorizationResource+"/change-password", doctorController.ChangePassword)
+	router.PATCH("/"+AuthorizationResource+"/change-password", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor, enums.Nurse, enums.Tester)), doctorController.ChangePassword)
 	// Patient
 	patientRepo := repository.NewPatientRepository(db)
 	patientService := service.NewPatientService(patientRepo, cacheManager)
@@ -170,6 +235,21 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 	// Additional patient-specific route
 	router.GET("/"+PatientsResource+"/dni/:dni", patientController.GetPatientByDNI)
 
+	// Location
+	locationRepo := repository.NewLocationRepository(db)
+	locationService := service.NewLocationService(locationRepo, doctorRepo)
+	locationController := controller.NewLocationController(locationService)
+
+	// Register location routes, managed by admins
+	locations := router.Group("/" + LocationsResource)
+	locations.GET("", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), locationController.GetLocations)
+	locations.GET("/:id", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), locationController.GetLocationByID)
+	locations.GET("/:id/occupancy", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), locationController.GetOccupancy)
+	locations.POST("", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), locationController.CreateLocation)
+	locations.PATCH("/:id", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), locationController.UpdateLocation)
+	locations.DELETE("/:id", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), locationController.DeleteLocation)
+	locations.PUT("/:id/staff", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), locationController.SetWardStaff)
+
 	// Comorbidity
 	comorbidityRepo := repository.NewComorbidityRepository(db)
 	comorbidityService := service.NewComorbidityService(comorbidityRepo, cacheManager)
@@ -240,9 +320,13 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 
 	// MonitoringDevice
 	monitoringDeviceRepo := repository.NewMonitoringDeviceRepository(db)
-	monitoringDeviceService := service.NewMonitoringDeviceService(monitoringDeviceRepo, cacheManager)
+	deviceAssignmentRepo := repository.NewDeviceAssignmentRepository(db)
+	monitoringDeviceService := service.NewMonitoringDeviceService(monitoringDeviceRepo, deviceAssignmentRepo, patientRepo, doctorRepo, cacheManager, config.DeviceConnectingTimeout, config.DeviceSweepInterval)
 	monitoringDeviceController := controller.NewMonitoringDeviceController(monitoringDeviceService)
 
+	// Free the paired devices that never connect in the background
+	go monitoringDeviceService.RunConnectingSweeper()
+
 	// Register monitoring device routes
 	registerCrudRoutesWithMiddleware(
 		router,
@@ -255,28 +339,165 @@ func RegisterRoutes(router *gin.Engine, db *gorm.DB) {
 		enums.ToStringArray(enums.Admin, enums.Doctor),
 	)
 
+	// Register monitoring device pairing routes
+	devicePairing := router.Group("/" + MonitoringDevicesResource + "/:id")
+	devicePairing.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	devicePairing.POST("/pair", monitoringDeviceController.PairDevice)
+	devicePairing.POST("/unpair", monitoringDeviceController.UnpairDevice)
+	devicePairing.POST("/unavailable", monitoringDeviceController.MarkDeviceUnavailable)
+	devicePairing.POST("/available", monitoringDeviceController.MarkDeviceAvailable)
+	devicePairing.GET("/assignments", monitoringDeviceController.GetDeviceAssignments)
+
+	// Device credentials
+	deviceCredentialRepo := repository.NewDeviceCredentialRepository(db)
+	deviceCredentialService := service.NewDeviceCredentialService(deviceCredentialRepo, monitoringDeviceRepo, config.DeviceKeyRotationGrace)
+	deviceCredentialController := controller.NewDeviceCredentialController(deviceCredentialService)
+
+	// Register device credential routes
+	deviceCredentials := router.Group("/" + MonitoringDevicesResource + "/:id/credentials")
+	deviceCredentials.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)))
+	deviceCredentials.POST("", deviceCredentialController.CreateCredential)
+	deviceCredentials.GET("", deviceCredentialController.GetCredentials)
+	deviceCredentials.POST("/:credentialId/rotate", deviceCredentialController.RotateCredential)
+	deviceCredentials.DELETE("/:credentialId", deviceCredentialController.RevokeCredential)
+
+	// Device health
+	technicalAlertRepo := repository.NewTechnicalAlertRepository(db)
+	deviceHealthService := service.NewDeviceHealthService(monitoringDeviceRepo, technicalAlertRepo, cacheManager, eventBus, config.DeviceOfflineThreshold, config.DeviceSweepInterval)
+	deviceHealthController := controller.NewDeviceHealthController(deviceHealthService)
+
+	// Mark the devices that stop reporting as offline in the background
+	go deviceHealthService.RunWatchdog()
+
+	// Register device health routes
+	router.POST("/"+MonitoringDevicesResource+"/:id/heartbeat", middleware.DeviceOrRoleAuthorization(deviceCredentialService, enums.ToStringArray(enums.Admin, enums.Doctor)), middleware.DeviceSelf("id"), deviceHealthController.RecordHeartbeat)
+	technicalAlerts := router.Group("/" + TechnicalAlertsResource)
+	technicalAlerts.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	technicalAlerts.GET("", deviceHealthController.GetTechnicalAlerts)
+	technicalAlerts.POST("/:id/resolve", deviceHealthController.ResolveTechnicalAlert)
+
 	// Phone
 	phoneRepo := repository.NewPhoneRepository(db)
-	phoneService := service.NewPhoneService(phoneRepo)
+	phoneService := service.NewPhoneService(phoneRepo, doctorRepo)
 	phoneController := controller.NewPhoneController(phoneService)
 
 	// Register phone routes
-	router.POST("/"+PhoneResource, phoneController.CreatePhone)
+	phones := router.Group("/" + PhoneResource)
+	phones.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	phones.POST("", phoneController.RegisterPhone)
+	phones.DELETE("", phoneController.UnregisterPhone)
+
+	// Notification
+	notificationOutboxRepo := repository.NewNotificationOutboxRepository(db)
+	pushTicketRepo := repository.NewPushTicketRepository(db)
+	notificationService := service.NewNotificationService(notificationOutboxRepo, pushTicketRepo, phoneRepo, config.Notifiers, config.OutboxCheckInterval, config.OutboxMaxAttempts, config.PushReceiptInterval, config.PushReceiptDelay)
+	notificationController := controller.NewNotificationController(notificationService)
+
+	// Deliver the queued notifications and check their push receipts in the background
+	go notificationService.RunDispatcher()
+	go notificationService.RunReceiptPoller()
 
 	// Alert
 	alertRepo := repository.NewAlertRepository(db)
-	alertService := service.NewAlertService(alertRepo, biometricRepo, computerDiagnosticRepo, doctorRepo, monitoringDeviceRepo, phoneRepo, patientRepo, cacheManager)
+	alertIncidentRepo := repository.NewAlertIncidentRepository(db)
+	alertEventRepo := repository.NewAlertEventRepository(db)
+	escalationRepo := repository.NewEscalationRepository(db)
+	escalationService := service.NewEscalationService(escalationRepo, alertRepo, doctorRepo, userRepo, phoneService, notificationService, eventBus, config.EscalationSteps, config.EscalationInterval)
+	alertService := service.NewAlertService(alertRepo, alertIncidentRepo, alertEventRepo, biometricRepo, computerDiagnosticRepo, doctorRepo, monitoringDeviceRepo, locationRepo, phoneService, patientRepo, escalationService, notificationService, cacheManager, eventBus, config.AlertDedupWindow)
 	alertController := controller.NewAlertController(alertService)
-
-	// Register alert routes
+	alertStreamController := controller.NewAlertStreamController(eventBus, tokenService, AllowedOrigin())
+	escalationController := controller.NewEscalationController(escalationService)
+
+	// Execute the escalation steps of unattended alerts in the background
+	go escalationService.RunScheduler()
+
+	// Register alert routes, devices can report alerts with their API key
+	router.POST("/"+AlertsResource, middleware.DeviceOrRoleAuthorization(deviceCredentialService, enums.ToStringArray(enums.Admin, enums.Doctor)), alertController.CreateAlert)
+	alerts := router.Group("/" + AlertsResource)
+	alerts.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	alerts.GET("/:id", alertController.GetAlertByID)
+	alerts.GET("", alertController.GetAllAlerts)
+	alerts.PATCH("/:id", alertController.UpdateAlert)
+	alerts.DELETE("/:id", alertController.DeleteAlert)
+
+	// Register alert lifecycle routes
+	alertLifecycle := router.Group("/" + AlertsResource + "/:id")
+	alertLifecycle.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	alertLifecycle.POST("/claim", alertController.ClaimAlert)
+	alertLifecycle.POST("/acknowledge", alertController.AcknowledgeAlert)
+	alertLifecycle.POST("/start", alertController.StartAlert)
+	alertLifecycle.POST("/resolve", alertController.ResolveAlert)
+	alertLifecycle.POST("/false-positive", alertController.MarkAlertFalsePositive)
+	alertLifecycle.POST("/escalate", alertController.EscalateAlert)
+	alertLifecycle.POST("/release", alertController.ReleaseAlert)
+	alertLifecycle.GET("/events", alertController.GetAlertEvents)
+
+	// Register real-time alert stream routes, browsers open them with a stream token in the query
+	router.POST("/"+AlertsResource+"/stream/token", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), alertStreamController.IssueStreamToken)
+	alertStream := router.Group("/" + AlertsResource + "/stream")
+	alertStream.Use(middleware.StreamAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	alertStream.GET("", alertStreamController.StreamAlerts)
+	alertStream.GET("/ws", alertStreamController.StreamAlertsWebSocket)
+
+	// Register alert escalation routes
+	router.GET("/"+AlertsResource+"/:id/escalation", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), escalationController.GetAlertEscalation)
+	router.GET("/"+AlertsResource+"/:id/deliveries", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), notificationController.GetAlertDeliveries)
+
+	// Threshold rule
+	thresholdRuleRepo := repository.NewThresholdRuleRepository(db)
+	vitalReadingRepo := repository.NewVitalReadingRepository(db)
+	thresholdRuleService := service.NewThresholdRuleService(thresholdRuleRepo, alertRepo, vitalReadingRepo, alertService)
+	thresholdRuleController := controller.NewThresholdRuleController(thresholdRuleService)
+
+	// Register threshold rule routes
 	registerCrudRoutesWithMiddleware(
 		router,
-		AlertsResource,
-		alertController.CreateAlert,
-		alertController.GetAlertByID,
-		alertController.GetAllAlerts,
-		alertController.UpdateAlert,
-		alertController.DeleteAlert,
+		ThresholdRulesResource,
+		thresholdRuleController.CreateThresholdRule,
+		thresholdRuleController.GetThresholdRuleByID,
+		thresholdRuleController.GetAllThresholdRules,
+		thresholdRuleController.UpdateThresholdRule,
+		thresholdRuleController.DeleteThresholdRule,
 		enums.ToStringArray(enums.Admin, enums.Doctor),
 	)
+
+	// Vital readings
+	vitalReadingService := service.NewVitalReadingService(vitalReadingRepo, monitoringDeviceRepo, monitoringDeviceService, deviceHealthService, patientRepo, thresholdRuleService)
+	vitalReadingController := controller.NewVitalReadingController(vitalReadingService)
+
+	// Register vital reading ingestion routes
+	router.POST("/"+MonitoringDevicesResource+"/:id/readings", middleware.DeviceOrRoleAuthorization(deviceCredentialService, enums.ToStringArray(enums.Admin, enums.Doctor)), middleware.DeviceSelf("id"), vitalReadingController.CreateReadings)
+	router.GET("/"+PatientsResource+"/:id/vitals", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), vitalReadingController.GetPatientVitals)
+
+	// Analytics
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	analyticsService := service.NewAnalyticsService(analyticsRepo)
+	analyticsController := controller.NewAnalyticsController(analyticsService)
+
+	// Register analytics routes
+	router.GET("/"+AnalyticsResource+"/alerts", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), analyticsController.GetAlertAnalytics)
+	router.GET("/"+AnalyticsResource+"/diagnostics", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)), analyticsController.GetDiagnosticValidation)
+
+	// Diagnostic feedback
+	diagnosticFeedbackRepo := repository.NewDiagnosticFeedbackRepository(db)
+	diagnosticFeedbackService := service.NewDiagnosticFeedbackService(diagnosticFeedbackRepo, alertRepo, doctorRepo, vitalReadingRepo)
+	diagnosticFeedbackController := controller.NewDiagnosticFeedbackController(diagnosticFeedbackService)
+
+	// Register diagnostic feedback routes
+	alertLifecycle.POST("/feedback", diagnosticFeedbackController.CreateFeedback)
+	alertLifecycle.GET("/feedback", diagnosticFeedbackController.GetFeedbackByAlertID)
+	router.GET("/"+AnalyticsResource+"/diagnostics/training-data", middleware.RoleAuthorization(enums.ToStringArray(enums.Admin)), diagnosticFeedbackController.ExportTrainingData)
+
+	// Medical visits
+	medicalVisitRepo := repository.NewMedicalVisitRepository(db)
+	medicalVisitService := service.NewMedicalVisitService(medicalVisitRepo, patientRepo, locationRepo, cacheManager)
+	medicalVisitController := controller.NewMedicalVisitController(medicalVisitService)
+
+	// Register medical visit routes
+	patientVisits := router.Group("/" + PatientsResource + "/:id/visits")
+	patientVisits.Use(middleware.RoleAuthorization(enums.ToStringArray(enums.Admin, enums.Doctor)))
+	patientVisits.GET("", medicalVisitController.GetVisitsByPatientID)
+	patientVisits.POST("/admit", medicalVisitController.Admit)
+	patientVisits.POST("/discharge", medicalVisitController.Discharge)
+	patientVisits.POST("/transfer", medicalVisitController.Transfer)
 }
@@ -1,15 +1,18 @@
 package service
 
 import (
+	"biometric-data-backend/events"
 	"biometric-data-backend/models"
 	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/notifier"
 	"biometric-data-backend/redis"
 	"biometric-data-backend/repository"
-	"biometric-data-backend/utils"
 	"context"
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,50 +20,180 @@ import (
 	"gorm.io/gorm"
 )
 
+// DefaultRecentAlertsWindow is how far back alerts count as recent when no window is given
+const DefaultRecentAlertsWindow = 24 * time.Hour
+
+var (
+	ErrInvalidAlertTransition   = errors.New("invalid alert status transition")
+	ErrAlertDoctorRequired      = errors.New("a doctor is required to acknowledge the alert")
+	ErrAlertDoctorForbidden     = errors.New("only admins can acknowledge an alert on behalf of another doctor")
+	ErrFinalDiagnosisRequired   = errors.New("a final diagnosis is required to resolve the alert")
+	ErrFinalDiagnosisNotAllowed = errors.New("the final diagnosis can only be set on a resolved or false positive alert, resolve it instead")
+	ErrInvalidAlertSort         = errors.New("invalid sort: must be 'alert_timestamp' or 'confidence'")
+	ErrInvalidAlertStatusFilter = errors.New("invalid status: must be New, Acknowledged, In Progress, Resolved, False Positive or Escalated")
+	ErrInvalidAlertCursor       = errors.New("invalid cursor")
+)
+
+// AlertClaimedError is returned when claiming an alert already attended by a doctor
+type AlertClaimedError struct {
+	AttendedBy *dto.DoctorDTO
+	Version    int
+}
+
+func (e *AlertClaimedError) Error() string {
+	return "alert already claimed"
+}
+
+// escalationStopReasons are the escalation stop reasons of the statuses that end an escalation
+var escalationStopReasons = map[enum.AlertStatus]string{
+	enum.AlertStatusAcknowledged:  escalationStopReasonAttended,
+	enum.AlertStatusResolved:      escalationStopReasonResolved,
+	enum.AlertStatusFalsePositive: escalationStopReasonFalsePositive,
+}
+
 type AlertService interface {
 	CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCreateResponseDTO, error)
+	CreateAlertFromRule(rule *models.ThresholdRule, deviceID string, o2Saturation *float64, heartRate *float64) (*dto.AlertCreateResponseDTO, error)
 	GetAlertByID(id uuid.UUID) (*dto.AlertDTO, error)
 	GetAllAlerts() ([]*dto.AlertDTO, error)
-	UpdateAlert(id uuid.UUID, alertDTO *dto.AlertUpdateDTO) error
+	GetLatestAlerts(limit int) (*dto.PageDTO[dto.AlertDTO], error)
+	UpdateAlert(id uuid.UUID, alertDTO *dto.AlertUpdateDTO, principal *dto.Principal) error
+	TransitionAlert(id uuid.UUID, to enum.AlertStatus, transitionDTO *dto.AlertTransitionDTO, principal *dto.Principal) error
+	ClaimAlert(id uuid.UUID, transitionDTO *dto.AlertTransitionDTO, principal *dto.Principal) error
+	GetAlertEvents(id uuid.UUID) ([]*dto.AlertEventDTO, error)
 	DeleteAlert(id uuid.UUID) error
-	GetAllAlertsByPeriod(period string, page int, limit int) ([]*dto.AlertDTO, int, error)
+	GetAllAlertsByPeriod(period string, recentWindow time.Duration, page int, limit int) ([]*dto.AlertDTO, int, error)
+	SearchAlerts(filters dto.AlertFilter, sort dto.AlertSort, cursor string, limit int) (*dto.PageDTO[dto.AlertDTO], error)
 	GetAllAlertsByTimezone(timezone string) ([]*dto.AlertDTO, error)
+	GetAlertIncidents(page int, limit int) ([]*dto.AlertIncidentDTO, int, error)
 }
 
 type alertService struct {
 	alertRepo              repository.AlertRepository
+	incidentRepo           repository.AlertIncidentRepository
+	alertEventRepo         repository.AlertEventRepository
 	patientRepo            repository.PatientRepository
 	biometricRepo          repository.BiometricDataRepository
 	computerDiagnosticRepo repository.ComputerDiagnosticRepository
 	doctorRepo             repository.DoctorRepository
 	monitoringDeviceRepo   repository.MonitoringDeviceRepository
-	phoneRepo              repository.PhoneRepository
+	locationRepo           repository.LocationRepository
+	phoneService           PhoneService
+	escalationService      EscalationService
+	notificationService    NotificationService
 	cache                  *redis.CacheManager
+	eventBus               *events.Bus
+	dedupWindow            time.Duration
 }
 
 func NewAlertService(
 	alertRepo repository.AlertRepository,
+	incidentRepo repository.AlertIncidentRepository,
+	alertEventRepo repository.AlertEventRepository,
 	biometricRepo repository.BiometricDataRepository,
 	computerDiagnosticRepo repository.ComputerDiagnosticRepository,
 	doctorRepo repository.DoctorRepository,
 	monitoringDeviceRepo repository.MonitoringDeviceRepository,
-	phoneRepo repository.PhoneRepository,
+	locationRepo repository.LocationRepository,
+	phoneService PhoneService,
 	patientRepo repository.PatientRepository,
+	escalationService EscalationService,
+	notificationService NotificationService,
 	cache *redis.CacheManager,
+	eventBus *events.Bus,
+	dedupWindow time.Duration,
 ) AlertService {
 	return &alertService{
 		alertRepo:              alertRepo,
+		incidentRepo:           incidentRepo,
+		alertEventRepo:         alertEventRepo,
 		biometricRepo:          biometricRepo,
 		computerDiagnosticRepo: computerDiagnosticRepo,
 		doctorRepo:             doctorRepo,
 		monitoringDeviceRepo:   monitoringDeviceRepo,
-		phoneRepo:              phoneRepo,
+		locationRepo:           locationRepo,
+		phoneService:           phoneService,
 		patientRepo:            patientRepo,
+		escalationService:      escalationService,
+		notificationService:    notificationService,
 		cache:                  cache,
+		eventBus:               eventBus,
+		dedupWindow:            dedupWindow,
 	}
 }
 
+// alertInput holds the data needed to raise an alert, whatever its source
+type alertInput struct {
+	Device       *models.MonitoringDevice
+	Diagnosis    string
+	Percentage   float64
+	O2Saturation *float64
+	HeartRate    *float64
+	Timestamp    time.Time
+	RuleID       *uuid.UUID
+	Provenance   *dto.DiagnosticProvenanceDTO
+}
+
 func (s *alertService) CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCreateResponseDTO, error) {
+	device, err := s.monitoringDeviceRepo.GetMonitoringDeviceByID(alertDTO.DeviceID)
+	if err != nil {
+		log.Printf("Device not found: %v", err)
+		return &dto.AlertCreateResponseDTO{Message: "Device not found"}, err
+	}
+
+	if device.Status != "In Use" {
+		log.Printf("Device is not in use")
+		return &dto.AlertCreateResponseDTO{Message: "Device is not in use"}, errors.New("device is not in use")
+	}
+
+	location, err := time.LoadLocation(alertDTO.Timezone)
+	if err != nil {
+		fmt.Printf("Invalid timezone provided (%s), defaulting to UTC: %v\n", alertDTO.Timezone, err)
+		location = time.UTC
+	}
+
+	localTime := time.Now().In(location)
+	utcTime := localTime.UTC()
+
+	return s.raiseAlert(&alertInput{
+		Device:       device,
+		Diagnosis:    alertDTO.Diagnosis,
+		Percentage:   alertDTO.Percentage,
+		O2Saturation: &alertDTO.O2Saturation,
+		HeartRate:    &alertDTO.HeartRate,
+		Timestamp:    utcTime,
+		Provenance:   &alertDTO.DiagnosticProvenanceDTO,
+	})
+}
+
+// CreateAlertFromRule raises an alert for a threshold rule that fired on the readings of a device. A metric missing
+// from the reading is left empty in the alert.
+func (s *alertService) CreateAlertFromRule(rule *models.ThresholdRule, deviceID string, o2Saturation *float64, heartRate *float64) (*dto.AlertCreateResponseDTO, error) {
+	device, err := s.monitoringDeviceRepo.GetMonitoringDeviceByID(deviceID)
+	if err != nil {
+		log.Printf("Device not found: %v", err)
+		return &dto.AlertCreateResponseDTO{Message: "Device not found"}, err
+	}
+
+	return s.raiseAlert(&alertInput{
+		Device:       device,
+		Diagnosis:    rule.Diagnosis,
+		O2Saturation: o2Saturation,
+		HeartRate:    heartRate,
+		Timestamp:    time.Now().UTC(),
+		RuleID:       &rule.RuleID,
+	})
+}
+
+// raiseAlert stores an alert with its biometric data and diagnostic, starts its escalation and queues its notifications.
+// An alert repeating the diagnosis of an open incident of the patient within the dedup window is only counted in that incident.
+func (s *alertService) raiseAlert(input *alertInput) (*dto.AlertCreateResponseDTO, error) {
+	device := input.Device
+	if device.PatientID == nil {
+		log.Printf("Device %s is not linked to a patient", device.DeviceID)
+		return &dto.AlertCreateResponseDTO{Message: "Device is not linked to a patient"}, errors.New("device is not linked to a patient")
+	}
+
 	tx := s.alertRepo.BeginTransaction()
 	if tx.Error != nil {
 		log.Printf("Failed to start transaction: %v", tx.Error)
@@ -77,23 +210,35 @@ func (s *alertService) CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCrea
 		}
 	}()
 
-	device, err := s.monitoringDeviceRepo.GetMonitoringDeviceByID(alertDTO.DeviceID)
-	if err != nil {
-		log.Printf("Device not found: %v", err)
-		return &dto.AlertCreateResponseDTO{Message: "Device not found"}, err
-	}
+	if s.dedupWindow > 0 {
+		incident, err := s.incidentRepo.GetOpenIncidentInTransaction(*device.PatientID, input.Diagnosis, tx)
+		if err != nil {
+			log.Printf("Failed to fetch open alert incident: %v", err)
+			tx.Rollback()
+			return &dto.AlertCreateResponseDTO{Message: "Failed to fetch open alert incident"}, err
+		}
 
-	if device.Status != "In Use" {
-		log.Printf("Device is not in use")
-		return &dto.AlertCreateResponseDTO{Message: "Device is not in use"}, errors.New("device is not in use")
+		if incident != nil {
+			if input.Timestamp.Sub(incident.LastSeenAt) <= s.dedupWindow {
+				return s.recordRepeatedAlert(incident, input.Timestamp, tx)
+			}
+
+			// The incident went quiet for longer than the window, the new alert opens a new one
+			err = s.incidentRepo.CloseInTransaction(incident.IncidentID, incident.LastSeenAt, tx)
+			if err != nil {
+				log.Printf("Failed to close alert incident: %v", err)
+				tx.Rollback()
+				return &dto.AlertCreateResponseDTO{Message: "Failed to close alert incident"}, err
+			}
+		}
 	}
 
 	biometricData := &models.BiometricData{
-		O2Saturation: alertDTO.O2Saturation,
-		HeartRate:    alertDTO.HeartRate,
+		O2Saturation: input.O2Saturation,
+		HeartRate:    input.HeartRate,
 	}
 
-	err = s.biometricRepo.CreateInTransaction(biometricData, tx)
+	err := s.biometricRepo.CreateInTransaction(biometricData, tx)
 	if err != nil {
 		log.Printf("Failed to create biometric data: %v", err)
 		tx.Rollback()
@@ -101,8 +246,11 @@ func (s *alertService) CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCrea
 	}
 
 	computerDiagnostic := &models.ComputerDiagnostic{
-		Diagnosis:  alertDTO.Diagnosis,
-		Percentage: alertDTO.Percentage,
+		Diagnosis:  input.Diagnosis,
+		Percentage: input.Percentage,
+	}
+	if input.Provenance != nil {
+		dto.ApplyDiagnosticProvenance(computerDiagnostic, input.Provenance)
 	}
 
 	err = s.computerDiagnosticRepo.CreateInTransaction(computerDiagnostic, tx)
@@ -113,23 +261,38 @@ func (s *alertService) CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCrea
 	}
 
 	patient, err := s.patientRepo.GetByID(device.PatientID, "patient_id")
-	if err != nil {
+	if err != nil || patient == nil {
 		log.Printf("Failed to fetch patient information: %v", err)
 		tx.Rollback()
+		if err == nil {
+			err = gorm.ErrRecordNotFound
+		}
 		return &dto.AlertCreateResponseDTO{Message: "Failed to fetch patient information"}, err
 	}
 
-	location, err := time.LoadLocation(alertDTO.Timezone)
+	// The ward is only used for filtering and reporting, an alert is still raised without it
+	wardID, err := s.locationRepo.GetCurrentWardID(patient.PatientID)
 	if err != nil {
-		fmt.Printf("Invalid timezone provided (%s), defaulting to UTC: %v\n", alertDTO.Timezone, err)
-		location = time.UTC
+		log.Printf("Failed to fetch the ward of the patient: %v", err)
 	}
 
-	localTime := time.Now().In(location)
-	utcTime := localTime.UTC()
+	incident := &models.AlertIncident{
+		PatientID:   patient.PatientID,
+		Diagnosis:   input.Diagnosis,
+		FirstSeenAt: input.Timestamp,
+		LastSeenAt:  input.Timestamp,
+		AlertCount:  1,
+	}
+
+	err = s.incidentRepo.CreateInTransaction(incident, tx)
+	if err != nil {
+		log.Printf("Failed to create alert incident: %v", err)
+		tx.Rollback()
+		return &dto.AlertCreateResponseDTO{Message: "Failed to create alert incident"}, err
+	}
 
 	alert := &models.Alert{
-		AlertTimestamp:     utcTime,
+		AlertTimestamp:     input.Timestamp,
 		AttendedTimestamp:  nil,
 		AttendedBy:         nil,
 		BiometricDataID:    biometricData.BiometricDataID,
@@ -137,6 +300,11 @@ func (s *alertService) CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCrea
 		DiagnosticID:       computerDiagnostic.DiagnosticID,
 		ComputerDiagnostic: computerDiagnostic,
 		PatientID:          patient.PatientID,
+		Status:             string(enum.AlertStatusNew),
+		RuleID:             input.RuleID,
+		IncidentID:         &incident.IncidentID,
+		WardID:             wardID,
+		DeviceID:           &device.DeviceID,
 	}
 
 	err = s.alertRepo.CreateInTransaction(alert, tx)
@@ -146,34 +314,94 @@ func (s *alertService) CreateAlert(alertDTO *dto.AlertCreateDTO) (*dto.AlertCrea
 		return &dto.AlertCreateResponseDTO{Message: "Failed to create alert"}, err
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
-		return &dto.AlertCreateResponseDTO{Message: "Failed to commit transaction"}, err
+	err = s.alertEventRepo.CreateInTransaction(&models.AlertEvent{
+		AlertID:  alert.AlertID,
+		ToStatus: alert.Status,
+	}, tx)
+	if err != nil {
+		log.Printf("Failed to record alert creation: %v", err)
+		tx.Rollback()
+		return &dto.AlertCreateResponseDTO{Message: "Failed to record alert creation"}, err
 	}
 
-	pushTokens, err := s.phoneRepo.GetPushTokens()
-	if len(pushTokens) != 0 {
-		notificationTitle := "Alerta Crítica: " + computerDiagnostic.Diagnosis
-		notificationBody := "Paciente: " + patient.Name + ", Ubicación: " + patient.Location
+	err = s.escalationService.StartEscalationInTransaction(alert, tx)
+	if err != nil {
+		tx.Rollback()
+		return &dto.AlertCreateResponseDTO{Message: "Failed to start alert escalation"}, err
+	}
 
-		err = utils.SendExponentPushNotifications(pushTokens, notificationTitle, notificationBody)
-		if err != nil {
-			return nil, err
-		}
+	pushTokens, err := s.phoneService.GetAlertPushTokens(patient.PatientID)
+	if err != nil {
+		// The alert is still created; channels other than push notifications can deliver it
+		log.Printf("Failed to fetch push tokens: %v", err)
+	}
+
+	notification := &notifier.Notification{
+		Title:      "Alerta Crítica: " + computerDiagnostic.Diagnosis,
+		Body:       "Paciente: " + patient.Name + ", Ubicación: " + patient.Location,
+		PushTokens: pushTokens,
+		Data:       map[string]interface{}{"alert_id": alert.AlertID},
+	}
+
+	err = s.notificationService.EnqueueInTransaction(notification, &alert.AlertID, tx)
+	if err != nil {
+		tx.Rollback()
+		return &dto.AlertCreateResponseDTO{Message: "Failed to enqueue alert notification"}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		return &dto.AlertCreateResponseDTO{Message: "Failed to commit transaction"}, err
 	}
 
 	alertResponse := &dto.AlertCreateResponseDTO{
-		AlertID: alert.AlertID.String(),
-		Message: "Alert created successfully",
+		AlertID:    alert.AlertID.String(),
+		IncidentID: incident.IncidentID.String(),
+		Message:    "Alert created successfully",
 	}
 
 	// Invalidate relevant caches
 	_ = s.cache.Delete(context.Background(), "alerts:all")
 
+	s.publishAlertEvent(events.AlertCreated, alert.AlertID)
+
 	log.Printf("Alert created successfully with AlertID: %s", alert.AlertID)
 	return alertResponse, nil
 }
 
+// recordRepeatedAlert counts a repeated alert in its open incident and commits the transaction.
+// No alert, notification or escalation is created for it.
+func (s *alertService) recordRepeatedAlert(incident *models.AlertIncident, seenAt time.Time, tx *gorm.DB) (*dto.AlertCreateResponseDTO, error) {
+	err := s.incidentRepo.RecordOccurrenceInTransaction(incident.IncidentID, seenAt, tx)
+	if err != nil {
+		log.Printf("Failed to record repeated alert: %v", err)
+		tx.Rollback()
+		return &dto.AlertCreateResponseDTO{Message: "Failed to record repeated alert"}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		return &dto.AlertCreateResponseDTO{Message: "Failed to commit transaction"}, err
+	}
+
+	incident.AlertCount++
+	incident.LastSeenAt = seenAt
+
+	if s.eventBus != nil {
+		s.eventBus.Publish(events.Event{
+			Type:    events.AlertRepeated,
+			Payload: dto.MapAlertIncidentToDTO(incident),
+		})
+	}
+
+	log.Printf("Repeated alert grouped into IncidentID: %s (count %d)", incident.IncidentID, incident.AlertCount)
+	return &dto.AlertCreateResponseDTO{
+		IncidentID:   incident.IncidentID.String(),
+		Deduplicated: true,
+		Message:      "Alert grouped into open incident",
+	}, nil
+}
+
 func (s *alertService) GetAlertByID(id uuid.UUID) (*dto.AlertDTO, error) {
 	var alert dto.AlertDTO
 
@@ -226,74 +454,262 @@ func (s *alertService) GetAllAlerts() ([]*dto.AlertDTO, error) {
 	return alerts, nil
 }
 
-func (s *alertService) UpdateAlert(id uuid.UUID, alertDTO *dto.AlertUpdateDTO) error {
-
-	alert, err := s.alertRepo.GetByID(id, "alert_id")
+// GetLatestAlerts returns the most recent alerts, with the total number of alerts and the cursor of the alert search
+// to the older ones
+func (s *alertService) GetLatestAlerts(limit int) (*dto.PageDTO[dto.AlertDTO], error) {
+	page, err := s.SearchAlerts(dto.AlertFilter{}, dto.AlertSort{Descending: true}, "", limit)
 	if err != nil {
-		log.Printf("Error retrieving alert: %v", err)
-		return err
+		return nil, err
 	}
-	if alert == nil {
-		log.Printf("Alert not found with AlertID: %v", id)
-		return gorm.ErrRecordNotFound
+
+	var total int64
+	if err := s.alertRepo.CountAlerts(&total); err != nil {
+		log.Printf("Error counting alerts: %v", err)
+		return nil, err
 	}
+	page.Total = &total
+	return page, nil
+}
 
+// UpdateAlert corrects the final diagnosis of a resolved or false positive alert, or acknowledges (attended_by_id set) or releases
+// (attended_by_id empty) it through the alert lifecycle on behalf of the caller
+func (s *alertService) UpdateAlert(id uuid.UUID, alertDTO *dto.AlertUpdateDTO, principal *dto.Principal) error {
 	if alertDTO.FinalDiagnosis != "" {
+		alert, err := s.alertRepo.GetByID(id, "alert_id")
+		if err != nil {
+			log.Printf("Error retrieving alert: %v", err)
+			return err
+		}
+		if alert == nil {
+			log.Printf("Alert not found with AlertID: %v", id)
+			return gorm.ErrRecordNotFound
+		}
+
+		if alertDTO.ExpectedVersion != nil && *alertDTO.ExpectedVersion != alert.Version {
+			return repository.ErrVersionConflict
+		}
+		// An open alert gets its final diagnosis when it is resolved
+		status := enum.AlertStatus(alert.Status)
+		if status != enum.AlertStatusResolved && status != enum.AlertStatusFalsePositive {
+			log.Printf("Rejected final diagnosis of AlertID %s in status %s", id, status)
+			return ErrFinalDiagnosisNotAllowed
+		}
+
 		alert.FinalDiagnosis = alertDTO.FinalDiagnosis
-		err := s.alertRepo.UpdateAlert(alert)
+		err = s.alertRepo.UpdateAlert(alert)
 		if err != nil {
 			log.Printf("Failed to update alert: %v", err)
 			return err
 		}
+		_ = s.cache.Delete(context.Background(), "alert:"+id.String(), "alerts:all")
 		return nil
 	}
 
 	if alertDTO.AttendedByID == uuid.Nil {
-		err := s.alertRepo.Liberate(alert)
+		return s.TransitionAlert(id, enum.AlertStatusNew, &dto.AlertTransitionDTO{ExpectedVersion: alertDTO.ExpectedVersion}, principal)
+	}
+
+	// The claim is stamped with the server clock, whatever attended_timestamp the client sent
+	return s.ClaimAlert(id, &dto.AlertTransitionDTO{
+		DoctorID:        &alertDTO.AttendedByID,
+		ExpectedVersion: alertDTO.ExpectedVersion,
+	}, principal)
+}
+
+// ClaimAlert acknowledges an alert on behalf of a doctor. The claim succeeds atomically only if the alert is still
+// unclaimed (and at the expected version, if given); otherwise an AlertClaimedError reports the current owner.
+func (s *alertService) ClaimAlert(id uuid.UUID, transitionDTO *dto.AlertTransitionDTO, principal *dto.Principal) error {
+	err := s.TransitionAlert(id, enum.AlertStatusAcknowledged, transitionDTO, principal)
+	if err == nil || !(errors.Is(err, ErrInvalidAlertTransition) || errors.Is(err, repository.ErrVersionConflict)) {
+		return err
+	}
+
+	alert, getErr := s.alertRepo.GetByID(id, "alert_id")
+	if getErr != nil || alert == nil || !alert.AttendedByID.Valid {
+		return err
+	}
+
+	log.Printf("AlertID %s already claimed by DoctorID %s", id, alert.AttendedByID.UUID)
+	return &AlertClaimedError{
+		AttendedBy: dto.MapDoctorToDTO(alert.AttendedBy),
+		Version:    alert.Version,
+	}
+}
+
+// TransitionAlert moves an alert to a new status of its lifecycle and records who did it.
+// Acknowledging attends the alert and stops its escalation, releasing it back to New clears the attention and
+// resumes the escalation, and resolving it requires a final diagnosis.
+func (s *alertService) TransitionAlert(id uuid.UUID, to enum.AlertStatus, transitionDTO *dto.AlertTransitionDTO, principal *dto.Principal) error {
+	alert, err := s.alertRepo.GetByID(id, "alert_id")
+	if err != nil {
+		log.Printf("Error retrieving alert: %v", err)
+		return err
+	}
+	if alert == nil {
+		log.Printf("Alert not found with AlertID: %v", id)
+		return gorm.ErrRecordNotFound
+	}
+
+	if transitionDTO.ExpectedVersion != nil && *transitionDTO.ExpectedVersion != alert.Version {
+		log.Printf("Rejected transition of AlertID %s: version %d expected, found %d", id, *transitionDTO.ExpectedVersion, alert.Version)
+		return repository.ErrVersionConflict
+	}
+
+	from := enum.AlertStatus(alert.Status)
+	if !from.CanTransitionTo(to) {
+		log.Printf("Rejected transition of AlertID %s from %s to %s", id, from, to)
+		return fmt.Errorf("%w: %s to %s", ErrInvalidAlertTransition, from, to)
+	}
+
+	now := time.Now().UTC()
+	updates := map[string]interface{}{"status": string(to)}
+	switch to {
+	case enum.AlertStatusAcknowledged:
+		doctorID, err := s.resolveAttendingDoctor(transitionDTO.DoctorID, principal)
 		if err != nil {
-			log.Printf("Failed to liberate alert: %v", err)
 			return err
 		}
+		// Stamped with the server clock so the time to acknowledge stays consistent
+		updates["attended_by_id"] = doctorID
+		updates["attended_timestamp"] = now
+	case enum.AlertStatusNew:
+		updates["attended_by_id"] = nil
+		updates["attended_timestamp"] = nil
+		updates["final_diagnosis"] = nil
+	case enum.AlertStatusResolved:
+		if transitionDTO.FinalDiagnosis == "" {
+			return ErrFinalDiagnosisRequired
+		}
+		updates["final_diagnosis"] = transitionDTO.FinalDiagnosis
+	case enum.AlertStatusFalsePositive:
+		if transitionDTO.FinalDiagnosis != "" {
+			updates["final_diagnosis"] = transitionDTO.FinalDiagnosis
+		}
+	}
 
-		_ = s.cache.Delete(context.Background(), "alert:"+id.String(), "alerts:all")
-		return nil
+	var userID *uuid.UUID
+	if principal != nil {
+		userID = principal.UserID
+	}
+	alertEvent := &models.AlertEvent{
+		AlertID:    id,
+		FromStatus: string(from),
+		ToStatus:   string(to),
+		UserID:     userID,
+		Note:       transitionDTO.Note,
 	}
 
-	// Update AttendedByID if provided
-	if alertDTO.AttendedByID != uuid.Nil {
-		alert.AttendedByID = uuid.NullUUID{
-			UUID:  alertDTO.AttendedByID,
-			Valid: true,
+	transitioned, err := s.alertRepo.TransitionStatus(id, string(from), alert.Version, updates, alertEvent)
+	if err != nil {
+		log.Printf("Failed to transition alert: %v", err)
+		return err
+	}
+	if !transitioned {
+		// Another request modified the alert since it was read
+		log.Printf("AlertID %s modified concurrently, transition to %s rejected", id, to)
+		return repository.ErrVersionConflict
+	}
+	log.Printf("AlertID %s moved from %s to %s", id, from, to)
+
+	switch to {
+	case enum.AlertStatusAcknowledged, enum.AlertStatusResolved, enum.AlertStatusFalsePositive:
+		// A handled alert must not be escalated any further, and a new alert for the same diagnosis must be notified again
+		_ = s.escalationService.StopEscalation(id, escalationStopReasons[to])
+		if alert.IncidentID != nil {
+			if err := s.incidentRepo.Close(*alert.IncidentID, now); err != nil {
+				log.Printf("Failed to close alert incident: %v", err)
+			}
 		}
+	case enum.AlertStatusNew:
+		_ = s.escalationService.ResumeEscalation(id)
 	}
 
-	// // Only allow updates to other fields if AttendedByID is set
-	if alert.AttendedByID.Valid {
-		utcTimestamp := alertDTO.AttendedTimestamp.UTC()
-		alert.AttendedTimestamp = &utcTimestamp
+	_ = s.cache.Delete(context.Background(), "alert:"+id.String(), "alerts:all")
+
+	switch to {
+	case enum.AlertStatusAcknowledged:
+		s.publishAlertEvent(events.AlertAttended, id)
+	case enum.AlertStatusNew:
+		s.publishAlertEvent(events.AlertLiberated, id)
+	default:
+		s.publishAlertEvent(events.AlertStatusChanged, id)
 	}
+	return nil
+}
 
-	updateAlert := &models.Alert{
-		AlertTimestamp:     alert.AlertTimestamp,
-		AttendedTimestamp:  alert.AttendedTimestamp,
-		AttendedByID:       uuid.NullUUID{UUID: alertDTO.AttendedByID, Valid: true},
-		BiometricDataID:    alert.BiometricDataID,
-		BiometricData:      alert.BiometricData,
-		DiagnosticID:       alert.DiagnosticID,
-		ComputerDiagnostic: alert.ComputerDiagnostic,
-		PatientID:          alert.PatientID,
+// resolveAttendingDoctor returns the doctor acknowledging an alert: the doctor of the caller, or the requested one.
+// Only admins can acknowledge on behalf of another doctor; without a caller the requested doctor is used as is.
+func (s *alertService) resolveAttendingDoctor(requestedID *uuid.UUID, principal *dto.Principal) (uuid.UUID, error) {
+	if requestedID != nil && *requestedID == uuid.Nil {
+		requestedID = nil
+	}
+	if principal == nil {
+		if requestedID == nil {
+			return uuid.Nil, ErrAlertDoctorRequired
+		}
+		return *requestedID, nil
 	}
 
-	err = s.alertRepo.Update(updateAlert, "alert_id", id)
+	callerID, err := s.callerDoctorID(principal)
 	if err != nil {
-		log.Printf("Failed to update alert: %v", err)
-		return err
+		return uuid.Nil, err
 	}
-	log.Println("Alert updated successfully with AlertID:", alert.AlertID)
 
-	// Invalidate cache for updated alert and all alerts
-	_ = s.cache.Delete(context.Background(), "alert:"+id.String(), "alerts:all")
-	return nil
+	switch {
+	case requestedID != nil && (callerID == nil || *requestedID != *callerID):
+		if !principal.IsAdmin() {
+			log.Printf("Rejected acknowledgement on behalf of DoctorID %s by a non-admin", requestedID)
+			return uuid.Nil, ErrAlertDoctorForbidden
+		}
+		return *requestedID, nil
+	case callerID != nil:
+		return *callerID, nil
+	default:
+		return uuid.Nil, ErrAlertDoctorRequired
+	}
+}
+
+// callerDoctorID returns the doctor of the caller, from its token or, for tokens issued without it, from its user
+func (s *alertService) callerDoctorID(principal *dto.Principal) (*uuid.UUID, error) {
+	if principal.DoctorID != nil {
+		return principal.DoctorID, nil
+	}
+	if principal.UserID == nil {
+		return nil, nil
+	}
+
+	doctor, err := s.doctorRepo.GetDoctorByUserID(*principal.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Failed to fetch doctor of UserID %s: %v", principal.UserID, err)
+		return nil, err
+	}
+	if doctor == nil {
+		return nil, nil
+	}
+	return &doctor.DoctorID, nil
+}
+
+// GetAlertEvents returns the status history of an alert
+func (s *alertService) GetAlertEvents(id uuid.UUID) ([]*dto.AlertEventDTO, error) {
+	alert, err := s.alertRepo.GetByID(id, "alert_id")
+	if err != nil {
+		log.Printf("Error retrieving alert: %v", err)
+		return nil, err
+	}
+	if alert == nil {
+		log.Println("No alert found with AlertID:", id)
+		return nil, nil
+	}
+
+	alertEvents, err := s.alertEventRepo.GetEventsByAlertID(id)
+	if err != nil {
+		log.Printf("Error retrieving alert events: %v", err)
+		return nil, err
+	}
+	return dto.MapAlertEventsToDTOs(alertEvents), nil
 }
 
 func (s *alertService) DeleteAlert(id uuid.UUID) error {
@@ -314,9 +730,10 @@ func (s *alertService) DeleteAlert(id uuid.UUID) error {
 	return nil
 }
 
-func (s *alertService) GetAllAlertsByPeriod(period string, page int, limit int) ([]*dto.AlertDTO, int, error) {
+// GetAllAlertsByPeriod returns a page of the alerts raised within the recent window ("recent") or before it ("past")
+func (s *alertService) GetAllAlertsByPeriod(period string, recentWindow time.Duration, page int, limit int) ([]*dto.AlertDTO, int, error) {
 	period = strings.ToLower(period)
-	log.Printf("Fetching alerts with period: %s, page: %d, limit: %d", period, page, limit)
+	log.Printf("Fetching alerts with period: %s, recent window: %s, page: %d, limit: %d", period, recentWindow, page, limit)
 
 	offset := (page - 1) * limit
 	var alerts []*models.Alert
@@ -340,14 +757,14 @@ func (s *alertService) GetAllAlertsByPeriod(period string, page int, limit int)
 	// Fetch data based on period
 	switch period {
 	case "recent":
-		err = s.alertRepo.CountAlertsByPeriod("recent", &totalCount)
+		err = s.alertRepo.CountAlertsByPeriod("recent", recentWindow, &totalCount)
 		if err == nil {
-			alerts, err = s.alertRepo.GetRecentAlerts(offset, limit)
+			alerts, err = s.alertRepo.GetRecentAlerts(recentWindow, offset, limit)
 		}
 	case "past":
-		err = s.alertRepo.CountAlertsByPeriod("past", &totalCount)
+		err = s.alertRepo.CountAlertsByPeriod("past", recentWindow, &totalCount)
 		if err == nil {
-			alerts, err = s.alertRepo.GetPastAlerts(offset, limit)
+			alerts, err = s.alertRepo.GetPastAlerts(recentWindow, offset, limit)
 		}
 	default:
 		log.Printf("Invalid period: %s", period)
@@ -388,3 +805,87 @@ func (s *alertService) GetAllAlertsByTimezone(timezone string) ([]*dto.AlertDTO,
 	log.Printf("Alerts fetched successfully for timezone: %s, count: %d", timezone, len(alerts))
 	return alerts, nil
 }
+
+// SearchAlerts returns a page of the alerts matching the filters in the given order. The page starts after the
+// cursor returned with the previous page, or at the beginning when the cursor is empty.
+func (s *alertService) SearchAlerts(filters dto.AlertFilter, sort dto.AlertSort, cursor string, limit int) (*dto.PageDTO[dto.AlertDTO], error) {
+	if sort.Field == "" {
+		sort.Field = dto.AlertSortByTimestamp
+	}
+	if sort.Field != dto.AlertSortByTimestamp && sort.Field != dto.AlertSortByConfidence {
+		return nil, ErrInvalidAlertSort
+	}
+	for _, status := range filters.Statuses {
+		if !enum.AlertStatus(status).IsValid() {
+			return nil, ErrInvalidAlertStatusFilter
+		}
+	}
+
+	var after *dto.AlertCursor
+	if cursor != "" {
+		var err error
+		if after, err = dto.DecodeAlertCursor(cursor); err != nil {
+			return nil, ErrInvalidAlertCursor
+		}
+		if _, err := after.SortValue(sort.Field); err != nil {
+			return nil, ErrInvalidAlertCursor
+		}
+	}
+
+	log.Printf("Searching alerts with filters: %+v, sort: %+v, limit: %d", filters, sort, limit)
+	// One more alert tells whether there is a next page
+	alerts, err := s.alertRepo.SearchAlerts(filters, sort, after, limit+1)
+	if err != nil {
+		log.Printf("Error searching alerts: %v", err)
+		return nil, err
+	}
+
+	nextCursor := ""
+	if len(alerts) > limit {
+		alerts = alerts[:limit]
+		last := alerts[len(alerts)-1]
+		next := &dto.AlertCursor{AlertID: last.AlertID, Value: last.AlertTimestamp.UTC().Format(time.RFC3339Nano)}
+		if sort.Field == dto.AlertSortByConfidence {
+			next.Value = "0"
+			if last.ComputerDiagnostic != nil {
+				next.Value = strconv.FormatFloat(last.ComputerDiagnostic.Percentage, 'f', -1, 64)
+			}
+		}
+		nextCursor = dto.EncodeAlertCursor(next)
+	}
+	return dto.NewPageDTO(dto.MapAlertsToDTOs(alerts), nextCursor, nil), nil
+}
+
+// GetAlertIncidents returns the alerts grouped by incident, most recently seen first
+func (s *alertService) GetAlertIncidents(page int, limit int) ([]*dto.AlertIncidentDTO, int, error) {
+	log.Printf("Fetching alert incidents, page: %d, limit: %d", page, limit)
+
+	offset := (page - 1) * limit
+	incidents, totalCount, err := s.incidentRepo.GetIncidentsPaginated(offset, limit)
+	if err != nil {
+		log.Printf("Error retrieving alert incidents: %v", err)
+		return nil, 0, err
+	}
+
+	return dto.MapAlertIncidentsToDTOs(incidents), int(totalCount), nil
+}
+
+// publishAlertEvent publishes the current state of an alert on the event bus
+func (s *alertService) publishAlertEvent(eventType events.EventType, id uuid.UUID) {
+	if s.eventBus == nil {
+		return
+	}
+
+	var payload interface{} = map[string]interface{}{"alert_id": id}
+	alert, err := s.alertRepo.GetByID(id, "alert_id")
+	if err != nil {
+		log.Printf("Failed to load alert %s for event %s: %v", id, eventType, err)
+	} else if alert != nil {
+		payload = dto.MapAlertToDTO(alert)
+	}
+
+	s.eventBus.Publish(events.Event{
+		Type:    eventType,
+		Payload: payload,
+	})
+}
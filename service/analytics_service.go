@@ -0,0 +1,160 @@
+package service
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// analyticsDateLayout is the layout of the dates of an analytics report
+	analyticsDateLayout = "2006-01-02"
+	// maxAnalyticsDays bounds the number of days of a single analytics report
+	maxAnalyticsDays = 366
+)
+
+var (
+	ErrInvalidAnalyticsRange    = errors.New("invalid date range: 'from' and 'to' must be dates (YYYY-MM-DD), 'from' not after 'to', spanning at most 366 days")
+	ErrInvalidAnalyticsTimezone = errors.New("invalid timezone: must be an IANA time zone such as America/Lima")
+	ErrInvalidAnalyticsWard     = errors.New("invalid ward_id: must be a UUID")
+)
+
+type AnalyticsService interface {
+	GetAlertAnalytics(query dto.AlertAnalyticsQuery) (*dto.AlertAnalyticsDTO, error)
+	GetDiagnosticValidation(query dto.DiagnosticValidationQuery) (*dto.DiagnosticValidationDTO, error)
+}
+
+type analyticsService struct {
+	repo repository.AnalyticsRepository
+}
+
+func NewAnalyticsService(repo repository.AnalyticsRepository) AnalyticsService {
+	return &analyticsService{repo: repo}
+}
+
+// GetAlertAnalytics reports the alert counts and time to acknowledge over a date range, overall and by diagnosis,
+// patient location, ward, attending doctor and hour of the day, optionally for a single ward. Dates and hours are
+// taken in the requested time zone, defaulting to UTC; the range defaults to the current month.
+func (s *analyticsService) GetAlertAnalytics(query dto.AlertAnalyticsQuery) (*dto.AlertAnalyticsDTO, error) {
+	fromDate, toDate, location, err := analyticsDateRange(query.From, query.To, query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.repo
+	if query.WardID != "" {
+		wardID, err := uuid.Parse(query.WardID)
+		if err != nil {
+			return nil, ErrInvalidAnalyticsWard
+		}
+		repo = repo.ForWard(wardID)
+	}
+
+	// The last day is inclusive
+	from, to := fromDate, toDate.AddDate(0, 0, 1)
+
+	log.Printf("Computing alert analytics from %s to %s in %s", from, to, location)
+	summary, err := repo.GetAlertResponseStats(from, to)
+	if err != nil {
+		log.Printf("Failed to compute alert summary: %v", err)
+		return nil, err
+	}
+	byDiagnosis, err := repo.GetAlertResponseStatsByDiagnosis(from, to)
+	if err != nil {
+		log.Printf("Failed to compute alert stats by diagnosis: %v", err)
+		return nil, err
+	}
+	byLocation, err := repo.GetAlertResponseStatsByLocation(from, to)
+	if err != nil {
+		log.Printf("Failed to compute alert stats by location: %v", err)
+		return nil, err
+	}
+	byWard, err := repo.GetAlertResponseStatsByWard(from, to)
+	if err != nil {
+		log.Printf("Failed to compute alert stats by ward: %v", err)
+		return nil, err
+	}
+	byDoctor, err := repo.GetAlertResponseStatsByDoctor(from, to)
+	if err != nil {
+		log.Printf("Failed to compute alert stats by doctor: %v", err)
+		return nil, err
+	}
+	byHour, err := repo.GetAlertResponseStatsByHourOfDay(from, to, location.String())
+	if err != nil {
+		log.Printf("Failed to compute alert stats by hour of day: %v", err)
+		return nil, err
+	}
+
+	return &dto.AlertAnalyticsDTO{
+		From:        fromDate.Format(analyticsDateLayout),
+		To:          toDate.Format(analyticsDateLayout),
+		Timezone:    location.String(),
+		Summary:     dto.MapAlertResponseStatsToDTO(summary),
+		ByDiagnosis: dto.MapAlertResponseStatsToDiagnosisDTOs(byDiagnosis),
+		ByLocation:  dto.MapAlertResponseStatsToLocationDTOs(byLocation),
+		ByWard:      dto.MapAlertResponseStatsToWardDTOs(byWard),
+		ByDoctor:    dto.MapAlertResponseStatsToDoctorDTOs(byDoctor),
+		ByHourOfDay: dto.MapAlertResponseStatsToHourDTOs(byHour),
+	}, nil
+}
+
+// GetDiagnosticValidation compares the computer diagnosis of the alerts raised over a date range with the final
+// diagnosis given by the doctors, optionally for a single model name and version
+func (s *analyticsService) GetDiagnosticValidation(query dto.DiagnosticValidationQuery) (*dto.DiagnosticValidationDTO, error) {
+	fromDate, toDate, location, err := analyticsDateRange(query.From, query.To, query.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last day is inclusive
+	from, to := fromDate, toDate.AddDate(0, 0, 1)
+
+	log.Printf("Computing diagnostic validation from %s to %s in %s", from, to, location)
+	cells, err := s.repo.GetDiagnosisConfusion(from, to, query.ModelName, query.ModelVersion)
+	if err != nil {
+		log.Printf("Failed to compute diagnosis confusion: %v", err)
+		return nil, err
+	}
+
+	validation := dto.MapDiagnosisConfusionToDTO(cells)
+	validation.From = fromDate.Format(analyticsDateLayout)
+	validation.To = toDate.Format(analyticsDateLayout)
+	validation.Timezone = location.String()
+	validation.ModelName = query.ModelName
+	validation.ModelVersion = query.ModelVersion
+	return validation, nil
+}
+
+// analyticsDateRange parses the first and last dates of a report in its time zone (UTC by default), defaulting to the current month
+func analyticsDateRange(fromParam, toParam, timezone string) (time.Time, time.Time, *time.Location, error) {
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	// "Local" is the zone of the server and unknown to the database
+	location, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "Local" {
+		return time.Time{}, time.Time{}, nil, ErrInvalidAnalyticsTimezone
+	}
+
+	now := time.Now().In(location)
+	fromDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, location)
+	toDate := fromDate.AddDate(0, 1, -1)
+	if fromParam != "" {
+		if fromDate, err = time.ParseInLocation(analyticsDateLayout, fromParam, location); err != nil {
+			return time.Time{}, time.Time{}, nil, ErrInvalidAnalyticsRange
+		}
+	}
+	if toParam != "" {
+		if toDate, err = time.ParseInLocation(analyticsDateLayout, toParam, location); err != nil {
+			return time.Time{}, time.Time{}, nil, ErrInvalidAnalyticsRange
+		}
+	}
+	if toDate.Before(fromDate) || toDate.Sub(fromDate) >= maxAnalyticsDays*24*time.Hour {
+		return time.Time{}, time.Time{}, nil, ErrInvalidAnalyticsRange
+	}
+	return fromDate, toDate, location, nil
+}
@@ -14,22 +14,25 @@ import (
 type AuthorizationService interface {
 	RegisterUser(userDTO *dto.UserRegisterDTO) (*uuid.UUID, error)
 	RegisterUserInTransaction(userDTO *dto.UserRegisterDTO, tx *gorm.DB) (*uuid.UUID, error)
-	AuthenticateUser(loginDTO *dto.UserLoginDTO) (string, error)
+	AuthenticateUser(loginDTO *dto.UserLoginDTO) (*dto.AuthTokensDTO, error)
 	GetUserById(id uuid.UUID) (*dto.UserDTO, error)
 	GetAllUsers(page int, limit int) ([]*dto.UserDTO, int, error)
+	GetUsersPage(request dto.PageRequest) (*dto.PageDTO[dto.UserDTO], error)
 	UpdateUser(id uuid.UUID, userDTO *dto.UserUpdateDTO) error
 	DeleteUser(id uuid.UUID) error
 }
 
 type userService struct {
-	repo     repository.AuthorizationRepository
-	roleRepo repository.RoleRepository
+	repo         repository.AuthorizationRepository
+	roleRepo     repository.RoleRepository
+	tokenService TokenService
 }
 
-func NewUserService(repo repository.AuthorizationRepository, roleRepo repository.RoleRepository) AuthorizationService {
+func NewUserService(repo repository.AuthorizationRepository, roleRepo repository.RoleRepository, tokenService TokenService) AuthorizationService {
 	return &userService{
-		repo:     repo,
-		roleRepo: roleRepo,
+		repo:         repo,
+		roleRepo:     roleRepo,
+		tokenService: tokenService,
 	}
 }
 
@@ -101,33 +104,29 @@ func (s *userService) RegisterUserInTransaction(userDTO *dto.UserRegisterDTO, tx
 	return &user.UserID, nil
 }
 
-// AuthenticateUser handles user authentication
-func (s *userService) AuthenticateUser(loginDTO *dto.UserLoginDTO) (string, error) {
+// AuthenticateUser handles user authentication and starts a session with an access and a refresh token
+func (s *userService) AuthenticateUser(loginDTO *dto.UserLoginDTO) (*dto.AuthTokensDTO, error) {
 	user, err := s.repo.GetUserByUsername(loginDTO.Username)
 	if err != nil {
 		log.Printf("Error fetching user: %v", err)
-		return "", err
+		return nil, err
 	}
 
 	// Compare the provided password with the stored hashed password
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(loginDTO.Password))
 	if err != nil {
 		log.Println("Incorrect password for user:", loginDTO.Username)
-		return "", errors.New("incorrect password")
+		return nil, errors.New("incorrect password")
 	}
 
-	// Generate JWT token with user information
-	token, err := GenerateToken(user.Username, dto.MapRolesToNames(user.Roles), map[string]interface{}{
-		"user_id": user.UserID,
-	})
-
+	// Generate the tokens with user information
+	tokens, err := s.tokenService.IssueTokens(user)
 	if err != nil {
-		log.Printf("Failed to generate token: %v", err)
-		return "", err
+		return nil, err
 	}
 
 	log.Println("User authenticated successfully:", loginDTO.Username)
-	return token, nil
+	return tokens, nil
 }
 
 func (s *userService) GetUserById(id uuid.UUID) (*dto.UserDTO, error) {
@@ -218,6 +217,13 @@ func (s *userService) UpdateUser(id uuid.UUID, userDTO *dto.UserUpdateDTO) error
 		return err
 	}
 
+	// Tokens issued with the previous password or roles must not stay valid
+	if userDTO.Password != "" || len(userDTO.Roles) > 0 {
+		if err := s.tokenService.RevokeUserTokens(id); err != nil {
+			return err
+		}
+	}
+
 	log.Println("User updated successfully with UserID:", user.UserID)
 	return nil
 }
@@ -231,6 +237,21 @@ func (s *userService) DeleteUser(id uuid.UUID) error {
 		return err
 	}
 
+	// The tokens of a deleted user are rejected already, the refresh tokens are revoked for good measure
+	if err := s.tokenService.RevokeUserTokens(id); err != nil {
+		return err
+	}
+
 	log.Println("User deleted successfully with UserID:", id)
 	return nil
 }
+
+// GetUsersPage returns a cursor paginated page of the users
+func (s *userService) GetUsersPage(request dto.PageRequest) (*dto.PageDTO[dto.UserDTO], error) {
+	page, err := s.repo.GetUsersPage(request)
+	if err != nil {
+		log.Printf("Error retrieving page of users: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapUsersToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
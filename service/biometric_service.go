@@ -16,6 +16,7 @@ type BiometricDataService interface {
 	CreateBiometricData(biometricDTO *dto.BiometricDataCreateDTO) error
 	GetBiometricDataByID(id uuid.UUID) (*dto.BiometricDataDTO, error)
 	GetAllBiometricData() ([]*dto.BiometricDataDTO, error)
+	GetBiometricDataPage(request dto.PageRequest) (*dto.PageDTO[dto.BiometricDataDTO], error)
 	UpdateBiometricData(id uuid.UUID, biometricDTO *dto.BiometricDataUpdateDTO) error
 	DeleteBiometricData(id uuid.UUID) error
 }
@@ -31,8 +32,8 @@ func NewBiometricDataService(repo repository.BiometricDataRepository, cache *red
 
 func (s *biometricService) CreateBiometricData(biometricDTO *dto.BiometricDataCreateDTO) error {
 	biometric := &models.BiometricData{
-		O2Saturation: biometricDTO.O2Saturation,
-		HeartRate:    biometricDTO.HeartRate,
+		O2Saturation: &biometricDTO.O2Saturation,
+		HeartRate:    &biometricDTO.HeartRate,
 	}
 
 	err := s.repo.Create(biometric)
@@ -132,8 +133,8 @@ func (s *biometricService) UpdateBiometricData(id uuid.UUID, biometricDTO *dto.B
 	}
 
 	// Update biometric data
-	biometric.O2Saturation = biometricDTO.O2Saturation
-	biometric.HeartRate = biometricDTO.HeartRate
+	biometric.O2Saturation = &biometricDTO.O2Saturation
+	biometric.HeartRate = &biometricDTO.HeartRate
 
 	err = s.repo.Update(biometric, "biometric_data_id", id)
 	if err != nil {
@@ -164,3 +165,13 @@ func (s *biometricService) DeleteBiometricData(id uuid.UUID) error {
 	_ = s.cache.Delete(context.Background(), "biometric_data:"+id.String(), "biometric_data:all")
 	return nil
 }
+
+// GetBiometricDataPage returns a cursor paginated page of the biometric data
+func (s *biometricService) GetBiometricDataPage(request dto.PageRequest) (*dto.PageDTO[dto.BiometricDataDTO], error) {
+	page, err := s.repo.GetPage(request, nil)
+	if err != nil {
+		log.Printf("Error retrieving page of biometric data: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapBiometricDataToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
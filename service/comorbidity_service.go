@@ -15,6 +15,7 @@ type ComorbidityService interface {
 	CreateComorbidity(comorbidityDTO *dto.ComorbidityCreateDTO) error
 	GetComorbidityByID(id uuid.UUID) (*dto.ComorbidityDTO, error)
 	GetAllComorbidities() ([]*dto.ComorbidityDTO, error)
+	GetComorbiditiesPage(request dto.PageRequest) (*dto.PageDTO[dto.ComorbidityDTO], error)
 	UpdateComorbidity(id uuid.UUID, comorbidityDTO *dto.ComorbidityUpdateDTO) error
 	DeleteComorbidity(id uuid.UUID) error
 }
@@ -145,3 +146,13 @@ func (s *comorbidityService) DeleteComorbidity(id uuid.UUID) error {
 	_ = s.cache.Delete(context.Background(), "comorbidity:"+id.String(), "comorbidities:all")
 	return nil
 }
+
+// GetComorbiditiesPage returns a cursor paginated page of the comorbidities
+func (s *comorbidityService) GetComorbiditiesPage(request dto.PageRequest) (*dto.PageDTO[dto.ComorbidityDTO], error) {
+	page, err := s.repo.GetPage(request, nil)
+	if err != nil {
+		log.Printf("Error retrieving page of comorbidities: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapComorbiditiesToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
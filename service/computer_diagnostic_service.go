@@ -16,6 +16,7 @@ type ComputerDiagnosticService interface {
 	CreateComputerDiagnostic(diagnosisDTO *dto.ComputerDiagnosticCreateDTO) error
 	GetComputerDiagnosticByID(id uuid.UUID) (*dto.ComputerDiagnosticDTO, error)
 	GetAllComputerDiagnostics() ([]*dto.ComputerDiagnosticDTO, error)
+	GetComputerDiagnosticsPage(request dto.PageRequest) (*dto.PageDTO[dto.ComputerDiagnosticDTO], error)
 	UpdateComputerDiagnostic(id uuid.UUID, diagnosisDTO *dto.ComputerDiagnosticUpdateDTO) error
 	DeleteComputerDiagnostic(id uuid.UUID) error
 }
@@ -34,6 +35,7 @@ func (s *computerDiagnosticService) CreateComputerDiagnostic(diagnosisDTO *dto.C
 		Diagnosis:  diagnosisDTO.Diagnosis,
 		Percentage: diagnosisDTO.Percentage,
 	}
+	dto.ApplyDiagnosticProvenance(diagnosis, &diagnosisDTO.DiagnosticProvenanceDTO)
 
 	err := s.repo.Create(diagnosis)
 	if err != nil {
@@ -129,6 +131,7 @@ func (s *computerDiagnosticService) UpdateComputerDiagnostic(id uuid.UUID, diagn
 
 	diagnosis.Diagnosis = diagnosisDTO.Diagnosis
 	diagnosis.Percentage = diagnosisDTO.Percentage
+	dto.ApplyDiagnosticProvenance(diagnosis, &diagnosisDTO.DiagnosticProvenanceDTO)
 
 	err = s.repo.Update(diagnosis, "diagnostic_id", id)
 	if err != nil {
@@ -159,3 +162,13 @@ func (s *computerDiagnosticService) DeleteComputerDiagnostic(id uuid.UUID) error
 	_ = s.cache.Delete(context.Background(), "computer_diagnostic:"+id.String(), "computer_diagnostics:all")
 	return nil
 }
+
+// GetComputerDiagnosticsPage returns a cursor paginated page of the computer diagnostics
+func (s *computerDiagnosticService) GetComputerDiagnosticsPage(request dto.PageRequest) (*dto.PageDTO[dto.ComputerDiagnosticDTO], error) {
+	page, err := s.repo.GetPage(request, nil)
+	if err != nil {
+		log.Printf("Error retrieving page of computer diagnostics: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapComputerDiagnosticsToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
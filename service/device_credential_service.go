@@ -0,0 +1,238 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// deviceKeyPrefix starts every device API key, so leaked keys are easy to recognize
+	deviceKeyPrefix = "dk_"
+	// deviceKeyLastUsedPrecision bounds how often the last use of a key is written
+	deviceKeyLastUsedPrecision = time.Minute
+)
+
+var (
+	ErrDeviceCredentialNotFound = errors.New("device credential not found")
+	ErrDeviceCredentialInactive = errors.New("device credential is revoked, expired or already rotated")
+)
+
+type DeviceCredentialService interface {
+	CreateCredential(deviceID string, credentialDTO *dto.DeviceCredentialCreateDTO, userID *uuid.UUID) (*dto.DeviceCredentialSecretDTO, error)
+	GetCredentials(deviceID string) ([]*dto.DeviceCredentialDTO, error)
+	RotateCredential(deviceID string, credentialID uuid.UUID, userID *uuid.UUID) (*dto.DeviceCredentialSecretDTO, error)
+	RevokeCredential(deviceID string, credentialID uuid.UUID) error
+	AuthenticateDevice(apiKey string) (string, bool, error)
+}
+
+type deviceCredentialService struct {
+	repo          repository.DeviceCredentialRepository
+	deviceRepo    repository.MonitoringDeviceRepository
+	rotationGrace time.Duration
+}
+
+func NewDeviceCredentialService(repo repository.DeviceCredentialRepository, deviceRepo repository.MonitoringDeviceRepository, rotationGrace time.Duration) DeviceCredentialService {
+	return &deviceCredentialService{
+		repo:          repo,
+		deviceRepo:    deviceRepo,
+		rotationGrace: rotationGrace,
+	}
+}
+
+// CreateCredential issues a new API key to a device. The key is only returned here.
+func (s *deviceCredentialService) CreateCredential(deviceID string, credentialDTO *dto.DeviceCredentialCreateDTO, userID *uuid.UUID) (*dto.DeviceCredentialSecretDTO, error) {
+	if err := s.checkDeviceExists(deviceID); err != nil {
+		return nil, err
+	}
+
+	credential, apiKey, err := newDeviceCredential(deviceID, credentialDTO.Name, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(credential); err != nil {
+		log.Printf("Failed to create credential of DeviceID %s: %v", deviceID, err)
+		return nil, err
+	}
+	log.Printf("Credential %s issued to DeviceID %s", credential.KeyPrefix, deviceID)
+
+	return &dto.DeviceCredentialSecretDTO{DeviceCredentialDTO: *dto.MapDeviceCredentialToDTO(credential), APIKey: apiKey}, nil
+}
+
+// GetCredentials returns the credentials of a device, without their keys
+func (s *deviceCredentialService) GetCredentials(deviceID string) ([]*dto.DeviceCredentialDTO, error) {
+	if err := s.checkDeviceExists(deviceID); err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.repo.GetCredentialsByDeviceID(deviceID)
+	if err != nil {
+		log.Printf("Error retrieving credentials of DeviceID %s: %v", deviceID, err)
+		return nil, err
+	}
+	return dto.MapDeviceCredentialsToDTOs(credentials), nil
+}
+
+// RotateCredential issues a new API key replacing the given one, which keeps working during the rotation grace
+// period so the device can be reconfigured
+func (s *deviceCredentialService) RotateCredential(deviceID string, credentialID uuid.UUID, userID *uuid.UUID) (*dto.DeviceCredentialSecretDTO, error) {
+	tx := s.repo.BeginTransaction()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	current, err := s.repo.GetDeviceCredentialInTransaction(deviceID, credentialID, tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error retrieving credential: %v", err)
+		return nil, err
+	}
+	if current == nil {
+		tx.Rollback()
+		return nil, ErrDeviceCredentialNotFound
+	}
+
+	now := time.Now().UTC()
+	if !isCredentialActive(current, now) || current.ReplacedByID != nil {
+		tx.Rollback()
+		return nil, ErrDeviceCredentialInactive
+	}
+
+	replacement, apiKey, err := newDeviceCredential(deviceID, current.Name, userID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := s.repo.CreateInTransaction(replacement, tx); err != nil {
+		tx.Rollback()
+		log.Printf("Failed to create credential of DeviceID %s: %v", deviceID, err)
+		return nil, err
+	}
+
+	expiresAt := now.Add(s.rotationGrace)
+	if current.ExpiresAt != nil && current.ExpiresAt.Before(expiresAt) {
+		expiresAt = *current.ExpiresAt
+	}
+	if err := s.repo.ExpireInTransaction(current.CredentialID, expiresAt, &replacement.CredentialID, tx); err != nil {
+		tx.Rollback()
+		log.Printf("Failed to expire credential %s: %v", current.KeyPrefix, err)
+		return nil, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	log.Printf("Credential %s of DeviceID %s rotated to %s", current.KeyPrefix, deviceID, replacement.KeyPrefix)
+
+	return &dto.DeviceCredentialSecretDTO{DeviceCredentialDTO: *dto.MapDeviceCredentialToDTO(replacement), APIKey: apiKey}, nil
+}
+
+// RevokeCredential revokes a credential right away. Revoking a revoked credential does nothing.
+func (s *deviceCredentialService) RevokeCredential(deviceID string, credentialID uuid.UUID) error {
+	credential, err := s.repo.GetByID(credentialID, "credential_id")
+	if err != nil {
+		log.Printf("Error retrieving credential: %v", err)
+		return err
+	}
+	if credential == nil || credential.DeviceID != deviceID {
+		return ErrDeviceCredentialNotFound
+	}
+
+	if err := s.repo.Revoke(credentialID, time.Now().UTC()); err != nil {
+		log.Printf("Failed to revoke credential %s: %v", credential.KeyPrefix, err)
+		return err
+	}
+	log.Printf("Credential %s of DeviceID %s revoked", credential.KeyPrefix, deviceID)
+	return nil
+}
+
+// AuthenticateDevice returns the device an API key belongs to. It reports false when the key is unknown,
+// revoked or expired.
+func (s *deviceCredentialService) AuthenticateDevice(apiKey string) (string, bool, error) {
+	keyPrefix, _, found := strings.Cut(apiKey, ".")
+	if !found || !strings.HasPrefix(keyPrefix, deviceKeyPrefix) {
+		return "", false, nil
+	}
+
+	credential, err := s.repo.GetByKeyPrefix(keyPrefix)
+	if err != nil {
+		log.Printf("Error retrieving credential: %v", err)
+		return "", false, err
+	}
+	if credential == nil {
+		return "", false, nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashDeviceKey(apiKey)), []byte(credential.KeyHash)) != 1 {
+		return "", false, nil
+	}
+
+	now := time.Now().UTC()
+	if !isCredentialActive(credential, now) {
+		return "", false, nil
+	}
+
+	if err := s.repo.TouchLastUsed(credential.CredentialID, now, now.Add(-deviceKeyLastUsedPrecision)); err != nil {
+		log.Printf("Failed to record use of credential %s: %v", credential.KeyPrefix, err)
+	}
+	return credential.DeviceID, true, nil
+}
+
+func (s *deviceCredentialService) checkDeviceExists(deviceID string) error {
+	if _, err := s.deviceRepo.GetMonitoringDeviceByID(deviceID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrDeviceNotFound
+		}
+		log.Printf("Error retrieving monitoring device: %v", err)
+		return err
+	}
+	return nil
+}
+
+// newDeviceCredential generates a random API key of the form dk_<prefix>.<secret> and its credential
+func newDeviceCredential(deviceID string, name string, userID *uuid.UUID) (*models.DeviceCredential, string, error) {
+	prefix := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	keyPrefix := deviceKeyPrefix + hex.EncodeToString(prefix)
+	apiKey := keyPrefix + "." + base64.RawURLEncoding.EncodeToString(secret)
+
+	return &models.DeviceCredential{
+		DeviceID:    deviceID,
+		Name:        name,
+		KeyPrefix:   keyPrefix,
+		KeyHash:     hashDeviceKey(apiKey),
+		CreatedByID: userID,
+	}, apiKey, nil
+}
+
+// hashDeviceKey hashes an API key for storage. Keys are long random strings, so a fast hash is enough.
+func hashDeviceKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func isCredentialActive(credential *models.DeviceCredential, now time.Time) bool {
+	if credential.RevokedAt != nil {
+		return false
+	}
+	return credential.ExpiresAt == nil || credential.ExpiresAt.After(now)
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"biometric-data-backend/events"
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/redis"
+	"biometric-data-backend/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// deviceWatchdogBatchSize bounds the number of silent devices handled on each check
+const deviceWatchdogBatchSize = 100
+
+var (
+	ErrTechnicalAlertNotFound = errors.New("technical alert not found")
+	ErrTechnicalAlertResolved = errors.New("technical alert is already resolved")
+)
+
+type DeviceHealthService interface {
+	RecordHeartbeat(deviceID string, heartbeatDTO *dto.MonitoringDeviceHeartbeatDTO) (*dto.MonitoringDeviceDTO, error)
+	RecordActivity(deviceID string) error
+	GetTechnicalAlerts(page int, limit int, filters dto.TechnicalAlertFilter) ([]*dto.TechnicalAlertDTO, int, error)
+	ResolveTechnicalAlert(id uuid.UUID, userID *uuid.UUID) error
+	RunWatchdog()
+}
+
+type deviceHealthService struct {
+	deviceRepo         repository.MonitoringDeviceRepository
+	technicalAlertRepo repository.TechnicalAlertRepository
+	cache              *redis.CacheManager
+	eventBus           *events.Bus
+	offlineThreshold   time.Duration
+	interval           time.Duration
+}
+
+func NewDeviceHealthService(
+	deviceRepo repository.MonitoringDeviceRepository,
+	technicalAlertRepo repository.TechnicalAlertRepository,
+	cache *redis.CacheManager,
+	eventBus *events.Bus,
+	offlineThreshold time.Duration,
+	interval time.Duration,
+) DeviceHealthService {
+	return &deviceHealthService{
+		deviceRepo:         deviceRepo,
+		technicalAlertRepo: technicalAlertRepo,
+		cache:              cache,
+		eventBus:           eventBus,
+		offlineThreshold:   offlineThreshold,
+		interval:           interval,
+	}
+}
+
+// RecordHeartbeat marks a device as seen now with the state it reports
+func (s *deviceHealthService) RecordHeartbeat(deviceID string, heartbeatDTO *dto.MonitoringDeviceHeartbeatDTO) (*dto.MonitoringDeviceDTO, error) {
+	device, err := s.recordSeen(deviceID, heartbeatDTO)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapMonitoringDeviceToDTO(device), nil
+}
+
+// RecordActivity marks a device as seen now, e.g. when it sends readings
+func (s *deviceHealthService) RecordActivity(deviceID string) error {
+	_, err := s.recordSeen(deviceID, nil)
+	return err
+}
+
+// recordSeen marks a device as seen now and online. A device coming back online has its offline alerts resolved.
+func (s *deviceHealthService) recordSeen(deviceID string, heartbeatDTO *dto.MonitoringDeviceHeartbeatDTO) (*models.MonitoringDevice, error) {
+	tx := s.deviceRepo.BeginTransaction()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	device, err := s.deviceRepo.GetMonitoringDeviceInTransaction(deviceID, tx)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		log.Printf("Failed to fetch monitoring device: %v", err)
+		return nil, err
+	}
+
+	wasOnline := device.Online
+	previous := *device
+
+	now := time.Now().UTC()
+	device.LastSeenAt = &now
+	device.Online = true
+	if heartbeatDTO != nil {
+		if heartbeatDTO.BatteryLevel != nil {
+			device.BatteryLevel = heartbeatDTO.BatteryLevel
+		}
+		if heartbeatDTO.SignalStrength != nil {
+			device.SignalStrength = heartbeatDTO.SignalStrength
+		}
+		if heartbeatDTO.FirmwareVersion != "" {
+			device.FirmwareVersion = heartbeatDTO.FirmwareVersion
+		}
+	}
+
+	if err := s.deviceRepo.UpdateTelemetryInTransaction(device, tx); err != nil {
+		tx.Rollback()
+		log.Printf("Failed to record activity of DeviceID %s: %v", deviceID, err)
+		return nil, err
+	}
+
+	var resolved int64
+	if !wasOnline {
+		resolved, err = s.technicalAlertRepo.ResolveOpenAlertsInTransaction(deviceID, string(enum.TechnicalAlertDeviceOffline), now, tx)
+		if err != nil {
+			tx.Rollback()
+			log.Printf("Failed to resolve offline alerts of DeviceID %s: %v", deviceID, err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	// Heartbeats are frequent, so the cached device is only dropped when its displayed state changes
+	if !wasOnline || !sameInt(previous.BatteryLevel, device.BatteryLevel) || previous.FirmwareVersion != device.FirmwareVersion {
+		s.invalidateDevice(device)
+	}
+	if !wasOnline {
+		log.Println("Monitoring device back online with DeviceID:", deviceID)
+	}
+	if resolved > 0 {
+		s.publishDeviceEvent(events.DeviceOnline, dto.MapMonitoringDeviceToDTO(device))
+	}
+	return device, nil
+}
+
+// GetTechnicalAlerts returns the technical alerts matching the filters, most recent first
+func (s *deviceHealthService) GetTechnicalAlerts(page int, limit int, filters dto.TechnicalAlertFilter) ([]*dto.TechnicalAlertDTO, int, error) {
+	offset := (page - 1) * limit
+	alerts, totalCount, err := s.technicalAlertRepo.GetTechnicalAlertsPaginated(offset, limit, filters)
+	if err != nil {
+		log.Printf("Error retrieving technical alerts: %v", err)
+		return nil, 0, err
+	}
+	return dto.MapTechnicalAlertsToDTOs(alerts), int(totalCount), nil
+}
+
+// ResolveTechnicalAlert resolves a technical alert by hand, e.g. once the device was replaced
+func (s *deviceHealthService) ResolveTechnicalAlert(id uuid.UUID, userID *uuid.UUID) error {
+	alert, err := s.technicalAlertRepo.GetTechnicalAlertByID(id)
+	if err != nil {
+		log.Printf("Error retrieving technical alert: %v", err)
+		return err
+	}
+	if alert == nil {
+		return ErrTechnicalAlertNotFound
+	}
+
+	resolved, err := s.technicalAlertRepo.Resolve(id, time.Now().UTC(), userID)
+	if err != nil {
+		log.Printf("Failed to resolve technical alert: %v", err)
+		return err
+	}
+	if !resolved {
+		return ErrTechnicalAlertResolved
+	}
+	log.Println("Technical alert resolved with TechnicalAlertID:", id)
+	return nil
+}
+
+// RunWatchdog periodically marks the devices that stopped reporting as offline, raising a technical alert for
+// the ones in use
+func (s *deviceHealthService) RunWatchdog() {
+	log.Printf("Device watchdog started, checking every %s", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkSilentDevices()
+	}
+}
+
+func (s *deviceHealthService) checkSilentDevices() {
+	seenBefore := time.Now().UTC().Add(-s.offlineThreshold)
+	devices, err := s.deviceRepo.GetSilentDevices(seenBefore, deviceWatchdogBatchSize)
+	if err != nil {
+		log.Printf("Failed to fetch silent devices: %v", err)
+		return
+	}
+
+	for _, device := range devices {
+		if err := s.markOffline(device.DeviceID, seenBefore); err != nil {
+			log.Printf("Failed to mark DeviceID %s as offline: %v", device.DeviceID, err)
+		}
+	}
+}
+
+// markOffline marks a device not seen since the given time as offline and, if it is in use, raises a technical alert
+func (s *deviceHealthService) markOffline(deviceID string, seenBefore time.Time) error {
+	tx := s.deviceRepo.BeginTransaction()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	device, err := s.deviceRepo.GetMonitoringDeviceInTransaction(deviceID, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// The device may have reported since it was fetched
+	if lastSeen := deviceLastSeen(device); !lastSeen.Before(seenBefore) {
+		tx.Rollback()
+		return nil
+	}
+
+	wasOnline := device.Online
+	device.Online = false
+	if err := s.deviceRepo.UpdateTelemetryInTransaction(device, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var raised *models.TechnicalAlert
+	if device.Status == string(enum.DeviceStatusInUse) {
+		open, err := s.technicalAlertRepo.GetOpenAlertInTransaction(deviceID, string(enum.TechnicalAlertDeviceOffline), tx)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		if open == nil {
+			raised = &models.TechnicalAlert{
+				DeviceID:   deviceID,
+				PatientID:  device.PatientID,
+				Type:       string(enum.TechnicalAlertDeviceOffline),
+				Message:    offlineMessage(device),
+				LastSeenAt: device.LastSeenAt,
+				RaisedAt:   time.Now().UTC(),
+			}
+			if err := s.technicalAlertRepo.CreateInTransaction(raised, tx); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	if wasOnline {
+		s.invalidateDevice(device)
+		log.Println("Monitoring device offline with DeviceID:", deviceID)
+	}
+	if raised != nil {
+		log.Printf("Technical alert raised for DeviceID %s: %s", deviceID, raised.Message)
+		s.publishTechnicalAlert(raised)
+	}
+	return nil
+}
+
+func (s *deviceHealthService) invalidateDevice(device *models.MonitoringDevice) {
+	_ = s.cache.Delete(context.Background(), "monitoring_device:"+device.DeviceID, "monitoring_devices:all",
+		"monitoring_devices:status:"+device.Status)
+}
+
+// publishTechnicalAlert publishes a raised technical alert with its patient on the event bus
+func (s *deviceHealthService) publishTechnicalAlert(alert *models.TechnicalAlert) {
+	var payload interface{} = dto.MapTechnicalAlertToDTO(alert)
+	loaded, err := s.technicalAlertRepo.GetTechnicalAlertByID(alert.TechnicalAlertID)
+	if err != nil {
+		log.Printf("Failed to load technical alert %s for event: %v", alert.TechnicalAlertID, err)
+	} else if loaded != nil {
+		payload = dto.MapTechnicalAlertToDTO(loaded)
+	}
+	s.publishDeviceEvent(events.DeviceOffline, payload)
+}
+
+func (s *deviceHealthService) publishDeviceEvent(eventType events.EventType, payload interface{}) {
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.Publish(events.Event{
+		Type:    eventType,
+		Payload: payload,
+	})
+}
+
+// deviceLastSeen returns when a device last reported, falling back to when it was linked or last changed
+func deviceLastSeen(device *models.MonitoringDevice) time.Time {
+	switch {
+	case device.LastSeenAt != nil:
+		return *device.LastSeenAt
+	case device.LinkedAt != nil:
+		return *device.LinkedAt
+	}
+	return device.UpdatedAt
+}
+
+func offlineMessage(device *models.MonitoringDevice) string {
+	if device.LastSeenAt == nil {
+		return fmt.Sprintf("Device %s has not reported since it was linked", device.DeviceID)
+	}
+	return fmt.Sprintf("Device %s has not reported since %s", device.DeviceID, device.LastSeenAt.Format(time.RFC3339))
+}
+
+// sameInt reports whether two optional numbers are equal, a missing number only being equal to another missing one
+func sameInt(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultTrainingWindow is the input window exported before the alert when its diagnostic has none
+	defaultTrainingWindow = time.Minute
+	// trainingDataBatchSize is the number of alerts loaded at once while exporting
+	trainingDataBatchSize = 200
+)
+
+const (
+	TrainingDataFormatJSONL = "jsonl"
+	TrainingDataFormatCSV   = "csv"
+)
+
+var (
+	ErrFeedbackDoctorRequired     = errors.New("feedback must be given by a doctor")
+	ErrCorrectedDiagnosisRequired = errors.New("a corrected diagnosis is required when disagreeing with the computer diagnosis")
+	ErrInvalidTrainingDataFormat  = errors.New("invalid format: must be jsonl or csv")
+)
+
+// trainingDataCSVHeader are the columns of the CSV export, the last three belong to a single reading
+var trainingDataCSVHeader = []string{
+	"alert_id", "patient_id", "alert_timestamp", "window_start", "window_end", "alert_o2_saturation", "alert_heart_rate",
+	"model_name", "model_version", "predicted_diagnosis", "percentage", "probabilities", "label", "label_source", "doctor_agrees",
+	"recorded_at", "o2_saturation", "heart_rate",
+}
+
+type DiagnosticFeedbackService interface {
+	CreateFeedback(alertID uuid.UUID, feedbackDTO *dto.DiagnosticFeedbackCreateDTO, userID *uuid.UUID) (*dto.DiagnosticFeedbackDTO, error)
+	GetFeedbackByAlertID(alertID uuid.UUID) ([]*dto.DiagnosticFeedbackDTO, error)
+	ExportTrainingData(query dto.TrainingDataQuery, w io.Writer) error
+}
+
+type diagnosticFeedbackService struct {
+	repo             repository.DiagnosticFeedbackRepository
+	alertRepo        repository.AlertRepository
+	doctorRepo       repository.DoctorRepository
+	vitalReadingRepo repository.VitalReadingRepository
+}
+
+func NewDiagnosticFeedbackService(repo repository.DiagnosticFeedbackRepository, alertRepo repository.AlertRepository, doctorRepo repository.DoctorRepository, vitalReadingRepo repository.VitalReadingRepository) DiagnosticFeedbackService {
+	return &diagnosticFeedbackService{
+		repo:             repo,
+		alertRepo:        alertRepo,
+		doctorRepo:       doctorRepo,
+		vitalReadingRepo: vitalReadingRepo,
+	}
+}
+
+// CreateFeedback records whether the doctor of the current user agrees with the computer diagnosis of an alert.
+// Giving feedback again on the same alert replaces the previous one.
+func (s *diagnosticFeedbackService) CreateFeedback(alertID uuid.UUID, feedbackDTO *dto.DiagnosticFeedbackCreateDTO, userID *uuid.UUID) (*dto.DiagnosticFeedbackDTO, error) {
+	agrees := *feedbackDTO.Agrees
+	if !agrees && feedbackDTO.CorrectedDiagnosis == "" {
+		return nil, ErrCorrectedDiagnosisRequired
+	}
+
+	alert, err := s.alertRepo.GetByID(alertID, "alert_id")
+	if err != nil {
+		log.Printf("Error retrieving alert: %v", err)
+		return nil, err
+	}
+	if alert == nil {
+		log.Println("No alert found with AlertID:", alertID)
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	if userID == nil {
+		return nil, ErrFeedbackDoctorRequired
+	}
+	doctor, err := s.doctorRepo.GetDoctorByUserID(*userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrFeedbackDoctorRequired
+		}
+		log.Printf("Failed to fetch doctor of UserID %s: %v", userID, err)
+		return nil, err
+	}
+	if doctor == nil {
+		return nil, ErrFeedbackDoctorRequired
+	}
+
+	feedback := &models.DiagnosticFeedback{
+		AlertID:  alertID,
+		DoctorID: doctor.DoctorID,
+		Agrees:   agrees,
+		Note:     feedbackDTO.Note,
+	}
+	if !agrees {
+		feedback.CorrectedDiagnosis = feedbackDTO.CorrectedDiagnosis
+	}
+
+	if err := s.repo.Upsert(feedback); err != nil {
+		log.Printf("Failed to store diagnostic feedback: %v", err)
+		return nil, err
+	}
+	log.Printf("Diagnostic feedback stored for AlertID %s by DoctorID %s", alertID, doctor.DoctorID)
+
+	feedback.Doctor = doctor
+	return dto.MapDiagnosticFeedbackToDTO(feedback), nil
+}
+
+// GetFeedbackByAlertID returns the feedback given on an alert, nil if the alert does not exist
+func (s *diagnosticFeedbackService) GetFeedbackByAlertID(alertID uuid.UUID) ([]*dto.DiagnosticFeedbackDTO, error) {
+	alert, err := s.alertRepo.GetByID(alertID, "alert_id")
+	if err != nil {
+		log.Printf("Error retrieving alert: %v", err)
+		return nil, err
+	}
+	if alert == nil {
+		log.Println("No alert found with AlertID:", alertID)
+		return nil, nil
+	}
+
+	feedback, err := s.repo.GetFeedbackByAlertID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving diagnostic feedback: %v", err)
+		return nil, err
+	}
+	return dto.MapDiagnosticFeedbackToDTOs(feedback), nil
+}
+
+// ExportTrainingData writes the labeled alerts raised over a date range to w, as JSON lines (one alert per line) or
+// as CSV (one row per reading of the input window). Invalid parameters are reported before anything is written.
+func (s *diagnosticFeedbackService) ExportTrainingData(query dto.TrainingDataQuery, w io.Writer) error {
+	format := query.Format
+	if format == "" {
+		format = TrainingDataFormatJSONL
+	}
+	if format != TrainingDataFormatJSONL && format != TrainingDataFormatCSV {
+		return ErrInvalidTrainingDataFormat
+	}
+
+	fromDate, toDate, location, err := analyticsDateRange(query.From, query.To, query.Timezone)
+	if err != nil {
+		return err
+	}
+
+	// The last day is inclusive
+	from, to := fromDate, toDate.AddDate(0, 0, 1)
+
+	var writeSample func(sample *dto.TrainingSampleDTO) error
+	var csvWriter *csv.Writer
+	if format == TrainingDataFormatCSV {
+		csvWriter = csv.NewWriter(w)
+		if err := csvWriter.Write(trainingDataCSVHeader); err != nil {
+			return err
+		}
+		writeSample = func(sample *dto.TrainingSampleDTO) error {
+			return writeTrainingSampleCSV(csvWriter, sample)
+		}
+	} else {
+		encoder := json.NewEncoder(w)
+		writeSample = func(sample *dto.TrainingSampleDTO) error {
+			return encoder.Encode(sample)
+		}
+	}
+
+	log.Printf("Exporting %s training data from %s to %s in %s", format, from, to, location)
+	exported := 0
+	err = s.repo.GetLabeledAlertsInBatches(from, to, query.ModelName, query.ModelVersion, trainingDataBatchSize, func(alerts []*models.Alert) error {
+		for _, alert := range alerts {
+			windowStart, windowEnd := trainingWindow(alert)
+			readings, err := s.vitalReadingRepo.GetReadings(alert.PatientID, windowStart, windowEnd)
+			if err != nil {
+				log.Printf("Failed to fetch readings of AlertID %s: %v", alert.AlertID, err)
+				return err
+			}
+			if err := writeSample(dto.MapAlertToTrainingSample(alert, windowStart, windowEnd, readings)); err != nil {
+				return err
+			}
+			exported++
+		}
+		if csvWriter != nil {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to export training data: %v", err)
+		return err
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Exported %d labeled alerts", exported)
+	return nil
+}
+
+// trainingWindow returns the input window of the computer diagnosis of an alert, or the minute before the alert
+func trainingWindow(alert *models.Alert) (time.Time, time.Time) {
+	if diagnostic := alert.ComputerDiagnostic; diagnostic != nil && diagnostic.InputWindowStart != nil && diagnostic.InputWindowEnd != nil {
+		return *diagnostic.InputWindowStart, *diagnostic.InputWindowEnd
+	}
+	return alert.AlertTimestamp.Add(-defaultTrainingWindow), alert.AlertTimestamp
+}
+
+// writeTrainingSampleCSV writes a labeled alert as one row per reading, or a single row without reading when it has none
+func writeTrainingSampleCSV(w *csv.Writer, sample *dto.TrainingSampleDTO) error {
+	var modelName, modelVersion, predicted, percentage, probabilities, agrees string
+	if prediction := sample.Prediction; prediction != nil {
+		modelName, modelVersion, predicted = prediction.ModelName, prediction.ModelVersion, prediction.Diagnosis
+		percentage = strconv.FormatFloat(prediction.Percentage, 'f', -1, 64)
+		if len(prediction.Probabilities) > 0 {
+			encoded, err := json.Marshal(prediction.Probabilities)
+			if err != nil {
+				return err
+			}
+			probabilities = string(encoded)
+		}
+	}
+	if sample.DoctorAgrees != nil {
+		agrees = strconv.FormatBool(*sample.DoctorAgrees)
+	}
+
+	row := []string{
+		sample.AlertID.String(),
+		sample.PatientID.String(),
+		sample.AlertTimestamp.Format(time.RFC3339),
+		sample.WindowStart.Format(time.RFC3339),
+		sample.WindowEnd.Format(time.RFC3339),
+		formatOptionalFloat(sample.O2Saturation),
+		formatOptionalFloat(sample.HeartRate),
+		modelName,
+		modelVersion,
+		predicted,
+		percentage,
+		probabilities,
+		sample.Label,
+		sample.LabelSource,
+		agrees,
+	}
+
+	if len(sample.Readings) == 0 {
+		return w.Write(append(row, "", "", ""))
+	}
+	for _, reading := range sample.Readings {
+		readingRow := append(append([]string{}, row...),
+			reading.RecordedAt.Format(time.RFC3339Nano),
+			formatOptionalFloat(reading.O2Saturation),
+			formatOptionalFloat(reading.HeartRate),
+		)
+		if err := w.Write(readingRow); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatOptionalFloat formats a metric for the CSV export, empty when it was not measured
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"biometric-data-backend/models"
 	"biometric-data-backend/models/dto"
 	"biometric-data-backend/redis"
 	"biometric-data-backend/repository"
@@ -13,25 +14,35 @@ import (
 	"time"
 )
 
+var (
+	ErrIncorrectIssuanceDate     = errors.New("incorrect issuance date")
+	ErrPasswordChangeForbidden   = errors.New("you can only change your own password")
+	ErrDoctorRoleChangeForbidden = errors.New("only admins can change the roles of a doctor")
+	ErrDoctorUpdateForbidden     = errors.New("you can only update your own doctor profile")
+)
+
 type DoctorService interface {
 	CreateDoctor(doctorDTO *dto.DoctorCreateDTO) error
 	GetDoctorByID(id uuid.UUID) (*dto.DoctorDTO, error)
 	GetDoctorByUserID(userID uuid.UUID) (*dto.DoctorDTO, error)
-	UpdateDoctorByUserID(userID uuid.UUID, doctorDTO *dto.DoctorUpdateDTO) error
+	UpdateDoctorByUserID(userID uuid.UUID, doctorDTO *dto.DoctorUpdateDTO, principal *dto.Principal) error
 	GetDoctorsByAlertID(alertID uuid.UUID) ([]*dto.DoctorDTO, error)
 	GetShortDoctorByID(id uuid.UUID) (*dto.DoctorDTO, error)
 	GetAllDoctors() ([]*dto.DoctorDTO, error)
-	UpdateDoctor(id uuid.UUID, doctorDTO *dto.DoctorUpdateDTO) error
+	GetDoctorsPage(request dto.PageRequest) (*dto.PageDTO[dto.DoctorDTO], error)
+	UpdateDoctor(id uuid.UUID, doctorDTO *dto.DoctorUpdateDTO, principal *dto.Principal) error
+	UpdateDoctorOnCall(id uuid.UUID, onCall bool) error
 	DeleteDoctor(id uuid.UUID) error
-	ChangePassword(changePasswordDTO *dto.ChangePasswordDTO) error
+	ChangePassword(userID uuid.UUID, changePasswordDTO *dto.ChangePasswordDTO) error
 }
 
 type doctorService struct {
-	repo        repository.DoctorRepository
-	authRepo    repository.AuthorizationRepository
-	roleRepo    repository.RoleRepository
-	authService AuthorizationService
-	cache       *redis.CacheManager
+	repo         repository.DoctorRepository
+	authRepo     repository.AuthorizationRepository
+	roleRepo     repository.RoleRepository
+	authService  AuthorizationService
+	tokenService TokenService
+	cache        *redis.CacheManager
 }
 
 func NewDoctorService(
@@ -39,14 +50,16 @@ func NewDoctorService(
 	authRepo repository.AuthorizationRepository,
 	roleRepo repository.RoleRepository,
 	authService AuthorizationService,
+	tokenService TokenService,
 	cache *redis.CacheManager,
 ) DoctorService {
 	return &doctorService{
-		repo:        repo,
-		authRepo:    authRepo,
-		roleRepo:    roleRepo,
-		authService: authService,
-		cache:       cache,
+		repo:         repo,
+		authRepo:     authRepo,
+		roleRepo:     roleRepo,
+		authService:  authService,
+		tokenService: tokenService,
+		cache:        cache,
 	}
 }
 
@@ -158,7 +171,7 @@ func (s *doctorService) GetAllDoctors() ([]*dto.DoctorDTO, error) {
 }
 
 // UpdateDoctor updates a doctor and invalidates the cache
-func (s *doctorService) UpdateDoctor(id uuid.UUID, doctorDTO *dto.DoctorUpdateDTO) error {
+func (s *doctorService) UpdateDoctor(id uuid.UUID, doctorDTO *dto.DoctorUpdateDTO, principal *dto.Principal) error {
 	log.Println("Updating doctor with DoctorID:", id)
 
 	doctor, err := s.repo.GetByID(id, "doctor_id")
@@ -170,6 +183,12 @@ func (s *doctorService) UpdateDoctor(id uuid.UUID, doctorDTO *dto.DoctorUpdateDT
 		return gorm.ErrRecordNotFound
 	}
 
+	// Doctors can only edit their own profile, admins any of them
+	if !principal.IsAdmin() && !isCallerDoctor(principal, doctor) {
+		log.Printf("Rejected update of DoctorID %v by another user", id)
+		return ErrDoctorUpdateForbidden
+	}
+
 	doctor = dto.MapUpdateDTOToDoctor(doctorDTO, doctor)
 
 	err = s.repo.Update(doctor, "doctor_id", id)
@@ -185,6 +204,23 @@ func (s *doctorService) UpdateDoctor(id uuid.UUID, doctorDTO *dto.DoctorUpdateDT
 	return nil
 }
 
+// UpdateDoctorOnCall sets whether a doctor is on call and invalidates the cache
+func (s *doctorService) UpdateDoctorOnCall(id uuid.UUID, onCall bool) error {
+	log.Printf("Setting on call to %t for DoctorID: %s", onCall, id)
+
+	err := s.repo.UpdateOnCall(id, onCall)
+	if err != nil {
+		log.Printf("Failed to update doctor on call: %v", err)
+		return err
+	}
+
+	// Invalidate cache for the updated doctor and all doctors
+	_ = s.cache.Delete(context.Background(), "doctor:"+id.String(), "doctors:all")
+
+	log.Println("Doctor on call updated successfully with DoctorID:", id)
+	return nil
+}
+
 // DeleteDoctor deletes a doctor and invalidates the cache
 func (s *doctorService) DeleteDoctor(id uuid.UUID) error {
 	log.Println("Deleting doctor with DoctorID:", id)
@@ -221,8 +257,8 @@ func (s *doctorService) GetDoctorByUserID(userID uuid.UUID) (*dto.DoctorDTO, err
 	return dto.MapDoctorToDTO(doctor), nil
 }
 
-// UpdateDoctorByUserID updates a doctor by UserID
-func (s *doctorService) UpdateDoctorByUserID(userID uuid.UUID, doctorDTO *dto.DoctorUpdateDTO) error {
+// UpdateDoctorByUserID updates a doctor by UserID on behalf of the caller. Only admins can change the roles.
+func (s *doctorService) UpdateDoctorByUserID(userID uuid.UUID, doctorDTO *dto.DoctorUpdateDTO, principal *dto.Principal) error {
 	log.Println("Updating doctor with UserID:", userID)
 
 	// Start a transaction
@@ -279,6 +315,13 @@ func (s *doctorService) UpdateDoctorByUserID(userID uuid.UUID, doctorDTO *dto.Do
 		return gorm.ErrRecordNotFound
 	}
 
+	// Doctors editing their own profile cannot grant themselves roles
+	if len(doctorDTO.Roles) > 0 && !principal.IsAdmin() && !sameRoles(user.Roles, doctorDTO.Roles) {
+		log.Printf("Rejected role change of UserID %v by a non-admin", userID)
+		tx.Rollback()
+		return ErrDoctorRoleChangeForbidden
+	}
+
 	// Update user details
 	user.Username = doctorDTO.DNI
 	if doctorDTO.Password != "" {
@@ -321,6 +364,13 @@ func (s *doctorService) UpdateDoctorByUserID(userID uuid.UUID, doctorDTO *dto.Do
 		return err
 	}
 
+	// Tokens issued with the previous password or roles must not stay valid
+	if doctorDTO.Password != "" || len(doctorDTO.Roles) > 0 {
+		if err := s.tokenService.RevokeUserTokens(userID); err != nil {
+			return err
+		}
+	}
+
 	log.Println("Doctor and associated user updated successfully with UserID:", userID)
 	return nil
 }
@@ -353,33 +403,47 @@ func (s *doctorService) GetShortDoctorByID(id uuid.UUID) (*dto.DoctorDTO, error)
 	return dto.MapDoctorToDTO(doctor), nil
 }
 
-// ChangePassword handles user password change
-func (s *doctorService) ChangePassword(changePasswordDTO *dto.ChangePasswordDTO) error {
-	log.Println("Changing password for user with DNI:", changePasswordDTO.DNI)
+// ChangePassword changes the password of the given user, who must be a doctor. The DNI is optional and, when
+// given, must be the caller's own.
+func (s *doctorService) ChangePassword(userID uuid.UUID, changePasswordDTO *dto.ChangePasswordDTO) error {
+	log.Println("Changing password for user with UserID:", userID)
 
 	// Fetch the doctor
-	doctor, err := s.repo.GetDoctorByDNI(changePasswordDTO.DNI)
+	doctor, err := s.repo.GetDoctorByUserID(userID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Doctor not found with UserID: %v", userID)
+			return gorm.ErrRecordNotFound
+		}
 		log.Printf("Error fetching doctor: %v", err)
 		return err
 	}
 	if doctor == nil {
-		log.Printf("Doctor not found with DNI: %v", changePasswordDTO.DNI)
+		log.Printf("Doctor not found with UserID: %v", userID)
 		return gorm.ErrRecordNotFound
 	}
 
+	if changePasswordDTO.DNI != "" && changePasswordDTO.DNI != doctor.DNI {
+		log.Printf("Rejected password change of UserID %v for another DNI", userID)
+		return ErrPasswordChangeForbidden
+	}
+
 	parseDate, err := time.Parse("2006-01-02", changePasswordDTO.IssuanceDate)
-	if !doctor.IssuanceDate.Equal(parseDate) {
-		log.Println("Incorrect issuance date for user with DNI:", changePasswordDTO.DNI)
-		return errors.New("incorrect issuance date")
+	if err != nil || !doctor.IssuanceDate.Equal(parseDate) {
+		log.Println("Incorrect issuance date for user with UserID:", userID)
+		return ErrIncorrectIssuanceDate
 	}
 
 	// Fetch the user by ID
-	user, err := s.authRepo.GetByID(doctor.User.UserID, "user_id")
+	user, err := s.authRepo.GetByID(userID, "user_id")
 	if err != nil {
 		log.Printf("Error fetching user: %v", err)
 		return err
 	}
+	if user == nil {
+		log.Printf("User not found with UserID: %v", userID)
+		return gorm.ErrRecordNotFound
+	}
 
 	// Hash the new password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(changePasswordDTO.NewPassword), bcrypt.DefaultCost)
@@ -396,6 +460,49 @@ func (s *doctorService) ChangePassword(changePasswordDTO *dto.ChangePasswordDTO)
 		return err
 	}
 
+	// Log out every session opened with the previous password
+	if err := s.tokenService.RevokeUserTokens(user.UserID); err != nil {
+		return err
+	}
+
 	log.Println("Password changed successfully for user ID:", user.UserID)
 	return nil
 }
+
+// isCallerDoctor reports whether the doctor is the caller, by the doctor of its token or, for tokens issued
+// without it, by its user
+func isCallerDoctor(principal *dto.Principal, doctor *models.Doctor) bool {
+	if principal == nil {
+		return false
+	}
+	if principal.DoctorID != nil {
+		return *principal.DoctorID == doctor.DoctorID
+	}
+	return principal.IsUser(doctor.UserID)
+}
+
+// sameRoles reports whether the requested role names are exactly the roles the user already has
+func sameRoles(current []*models.Role, requested []string) bool {
+	granted := make(map[string]bool, len(current))
+	for _, role := range current {
+		granted[string(role.RoleName)] = true
+	}
+	seen := make(map[string]bool, len(requested))
+	for _, name := range requested {
+		if !granted[name] {
+			return false
+		}
+		seen[name] = true
+	}
+	return len(seen) == len(granted)
+}
+
+// GetDoctorsPage returns a cursor paginated page of the doctors
+func (s *doctorService) GetDoctorsPage(request dto.PageRequest) (*dto.PageDTO[dto.DoctorDTO], error) {
+	page, err := s.repo.GetPage(request, nil)
+	if err != nil {
+		log.Printf("Error retrieving page of doctors: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapDoctorsToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"biometric-data-backend/config"
+	"biometric-data-backend/enums"
+	"biometric-data-backend/events"
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/notifier"
+	"biometric-data-backend/repository"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// escalationBatchSize is the maximum number of escalation steps executed on every check
+	escalationBatchSize = 50
+	// escalationRetryDelay is how long a failed escalation step waits before it is retried
+	escalationRetryDelay = time.Minute
+
+	escalationStopReasonAttended      = "attended"
+	escalationStopReasonResolved      = "resolved"
+	escalationStopReasonFalsePositive = "false_positive"
+	escalationStopReasonDone          = "completed"
+	escalationStopReasonStatusChanged = "status_changed"
+	// escalationErrorNoReachableTarget is recorded on a step whose targets have no registered phone
+	escalationErrorNoReachableTarget = "no reachable target"
+)
+
+type EscalationService interface {
+	StartEscalationInTransaction(alert *models.Alert, tx *gorm.DB) error
+	StopEscalation(alertID uuid.UUID, reason string) error
+	ResumeEscalation(alertID uuid.UUID) error
+	GetEscalationByAlertID(alertID uuid.UUID) (*dto.AlertEscalationDTO, error)
+	RunScheduler()
+}
+
+type escalationService struct {
+	repo                repository.EscalationRepository
+	alertRepo           repository.AlertRepository
+	doctorRepo          repository.DoctorRepository
+	authRepo            repository.AuthorizationRepository
+	phoneService        PhoneService
+	notificationService NotificationService
+	eventBus            *events.Bus
+	steps               []config.EscalationStep
+	interval            time.Duration
+}
+
+func NewEscalationService(
+	repo repository.EscalationRepository,
+	alertRepo repository.AlertRepository,
+	doctorRepo repository.DoctorRepository,
+	authRepo repository.AuthorizationRepository,
+	phoneService PhoneService,
+	notificationService NotificationService,
+	eventBus *events.Bus,
+	steps []config.EscalationStep,
+	interval time.Duration,
+) EscalationService {
+	return &escalationService{
+		repo:                repo,
+		alertRepo:           alertRepo,
+		doctorRepo:          doctorRepo,
+		authRepo:            authRepo,
+		phoneService:        phoneService,
+		notificationService: notificationService,
+		eventBus:            eventBus,
+		steps:               steps,
+		interval:            interval,
+	}
+}
+
+// StartEscalationInTransaction schedules the first escalation step of a newly created alert
+func (s *escalationService) StartEscalationInTransaction(alert *models.Alert, tx *gorm.DB) error {
+	if len(s.steps) == 0 {
+		return nil
+	}
+
+	nextEscalationAt := alert.AlertTimestamp.Add(s.steps[0].After)
+	escalation := &models.AlertEscalation{
+		AlertID:          alert.AlertID,
+		Level:            0,
+		NextEscalationAt: &nextEscalationAt,
+	}
+
+	if err := s.repo.CreateInTransaction(escalation, tx); err != nil {
+		log.Printf("Failed to start escalation for AlertID %s: %v", alert.AlertID, err)
+		return err
+	}
+	return nil
+}
+
+// StopEscalation stops any further escalation step of an alert
+func (s *escalationService) StopEscalation(alertID uuid.UUID, reason string) error {
+	if err := s.repo.Stop(alertID, reason); err != nil {
+		log.Printf("Failed to stop escalation for AlertID %s: %v", alertID, err)
+		return err
+	}
+	log.Printf("Escalation stopped for AlertID %s (%s)", alertID, reason)
+	return nil
+}
+
+// ResumeEscalation restarts the escalation policy of an alert that became unattended again
+func (s *escalationService) ResumeEscalation(alertID uuid.UUID) error {
+	if len(s.steps) == 0 {
+		return nil
+	}
+
+	nextEscalationAt := time.Now().UTC().Add(s.steps[0].After)
+	if err := s.repo.Resume(alertID, &nextEscalationAt); err != nil {
+		log.Printf("Failed to resume escalation for AlertID %s: %v", alertID, err)
+		return err
+	}
+	log.Printf("Escalation resumed for AlertID %s", alertID)
+	return nil
+}
+
+// GetEscalationByAlertID retrieves the escalation status and executed steps of an alert
+func (s *escalationService) GetEscalationByAlertID(alertID uuid.UUID) (*dto.AlertEscalationDTO, error) {
+	escalation, err := s.repo.GetEscalationByAlertID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving escalation: %v", err)
+		return nil, err
+	}
+	if escalation == nil {
+		log.Println("No escalation found for AlertID:", alertID)
+		return nil, nil
+	}
+
+	return dto.MapAlertEscalationToDTO(escalation), nil
+}
+
+// RunScheduler periodically executes the escalation steps that are due. It blocks forever.
+func (s *escalationService) RunScheduler() {
+	if len(s.steps) == 0 {
+		log.Println("No escalation steps configured. Escalation scheduler disabled.")
+		return
+	}
+
+	log.Printf("Escalation scheduler started, checking every %s", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.processDueEscalations()
+	}
+}
+
+// processDueEscalations executes the due escalation steps one by one, each in its own transaction, so an
+// escalation that keeps failing does not hold back the others
+func (s *escalationService) processDueEscalations() {
+	now := time.Now().UTC()
+	for range escalationBatchSize {
+		if !s.processNextEscalation(now) {
+			return
+		}
+	}
+}
+
+// processNextEscalation locks and executes the most overdue escalation. It returns false when there is nothing
+// left to process or the escalations cannot be fetched.
+func (s *escalationService) processNextEscalation(now time.Time) bool {
+	tx := s.repo.BeginTransaction()
+	if tx.Error != nil {
+		log.Printf("Failed to start escalation transaction: %v", tx.Error)
+		return false
+	}
+
+	escalations, err := s.repo.GetDueEscalationsInTransaction(now, 1, tx)
+	if err != nil {
+		log.Printf("Failed to fetch due escalations: %v", err)
+		tx.Rollback()
+		return false
+	}
+	if len(escalations) == 0 {
+		tx.Rollback()
+		return false
+	}
+
+	escalation := escalations[0]
+	event, err := s.executeStep(escalation, tx)
+	if err != nil {
+		log.Printf("Failed to execute escalation step for AlertID %s: %v", escalation.AlertID, err)
+		tx.Rollback()
+		s.postponeEscalation(escalation.AlertID, now)
+		return true
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Failed to commit escalation of AlertID %s: %v", escalation.AlertID, err)
+		s.postponeEscalation(escalation.AlertID, now)
+		return true
+	}
+
+	// Only the escalations committed are announced on the stream
+	if event != nil && s.eventBus != nil {
+		s.eventBus.Publish(*event)
+	}
+	return true
+}
+
+// postponeEscalation retries a failed escalation step later, letting the other due escalations go first
+func (s *escalationService) postponeEscalation(alertID uuid.UUID, now time.Time) {
+	if err := s.repo.Postpone(alertID, now.Add(escalationRetryDelay)); err != nil {
+		log.Printf("Failed to postpone escalation of AlertID %s: %v", alertID, err)
+	}
+}
+
+// executeStep executes the due step of an escalation. It returns the alert.escalated event to publish once the
+// transaction is committed, nil when the escalation stopped instead.
+func (s *escalationService) executeStep(escalation *models.AlertEscalation, tx *gorm.DB) (*events.Event, error) {
+	now := time.Now().UTC()
+	step := &models.AlertEscalationStep{
+		AlertID:    escalation.AlertID,
+		Level:      escalation.Level,
+		ExecutedAt: now,
+	}
+
+	if escalation.Level >= len(s.steps) {
+		// The policy changed since this escalation was scheduled
+		escalation.NextEscalationAt = nil
+		escalation.StoppedAt = &now
+		escalation.StopReason = escalationStopReasonDone
+		step.Action = escalationStopReasonDone
+		return nil, s.repo.RecordStepInTransaction(escalation, step, tx)
+	}
+
+	policyStep := s.steps[escalation.Level]
+	step.Action = string(policyStep.Action)
+
+	alert, err := s.alertRepo.GetByID(escalation.AlertID, "alert_id")
+	if err != nil {
+		return nil, err
+	}
+	if alert == nil {
+		escalation.NextEscalationAt = nil
+		escalation.StoppedAt = &now
+		escalation.StopReason = "deleted"
+		return nil, s.repo.RecordStepInTransaction(escalation, step, tx)
+	}
+	if alert.Status != string(enum.AlertStatusNew) && alert.Status != string(enum.AlertStatusEscalated) {
+		// The alert was handled since the escalation was selected
+		return nil, s.stopStaleEscalation(escalation, step, alert.Status, now, tx)
+	}
+
+	// Reaching beyond the devices of the alert moves it to Escalated, unless it was handled in the meantime
+	if policyStep.Action != enum.EscalationActionRenotify && alert.Status == string(enum.AlertStatusNew) {
+		transitioned, err := s.alertRepo.TransitionStatusInTransaction(alert.AlertID, alert.Status, alert.Version, map[string]interface{}{"status": string(enum.AlertStatusEscalated)}, &models.AlertEvent{
+			AlertID:    alert.AlertID,
+			FromStatus: alert.Status,
+			ToStatus:   string(enum.AlertStatusEscalated),
+			Note:       "Escalation step: " + string(policyStep.Action),
+		}, tx)
+		if err != nil {
+			return nil, err
+		}
+		if !transitioned {
+			current, err := s.alertRepo.GetByID(alert.AlertID, "alert_id")
+			if err != nil {
+				return nil, err
+			}
+			status := ""
+			if current != nil {
+				status = current.Status
+			}
+			return nil, s.stopStaleEscalation(escalation, step, status, now, tx)
+		}
+	}
+
+	eventPayload := map[string]interface{}{
+		"alert":  dto.MapAlertToDTO(alert),
+		"level":  escalation.Level,
+		"action": policyStep.Action,
+	}
+
+	var pushTokens []string
+	switch policyStep.Action {
+	case enum.EscalationActionRenotify:
+		pushTokens, err = s.phoneService.GetAlertTargetPushTokens(alert.PatientID)
+		if err != nil {
+			return nil, err
+		}
+	case enum.EscalationActionCareTeam:
+		doctors, err := s.doctorRepo.GetDoctorsByPatientID(alert.PatientID)
+		if err != nil {
+			return nil, err
+		}
+		doctorIDs := make([]uuid.UUID, 0, len(doctors))
+		userIDs := make([]uuid.UUID, 0, len(doctors))
+		for _, doctor := range doctors {
+			doctorIDs = append(doctorIDs, doctor.DoctorID)
+			userIDs = append(userIDs, doctor.UserID)
+		}
+		if err := s.alertRepo.AssignDoctorsInTransaction(alert.AlertID, doctorIDs, tx); err != nil {
+			return nil, err
+		}
+		pushTokens, err = s.phoneService.GetPushTokensByUserIDs(userIDs)
+		if err != nil {
+			return nil, err
+		}
+		step.TargetCount = len(doctorIDs)
+		eventPayload["target_doctor_ids"] = doctorIDs
+	case enum.EscalationActionAdmins:
+		userIDs, err := s.authRepo.GetUserIDsByRole(string(enums.Admin))
+		if err != nil {
+			return nil, err
+		}
+		pushTokens, err = s.phoneService.GetPushTokensByUserIDs(userIDs)
+		if err != nil {
+			return nil, err
+		}
+		step.TargetCount = len(userIDs)
+		eventPayload["target_user_ids"] = userIDs
+	}
+
+	// A step only reaches its own targets: without any phone among them, it is recorded and the next step widens
+	// the audience on schedule
+	if len(pushTokens) == 0 {
+		step.Error = escalationErrorNoReachableTarget
+		log.Printf("Escalation step %d (%s) of AlertID %s has no reachable target", step.Level, step.Action, alert.AlertID)
+	} else if err := s.enqueueEscalationNotification(alert, policyStep.Action, pushTokens, tx); err != nil {
+		return nil, err
+	}
+	step.NotifiedDevices = len(pushTokens)
+
+	s.advance(escalation, now)
+
+	if err := s.repo.RecordStepInTransaction(escalation, step, tx); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Escalation step %d (%s) executed for AlertID %s", step.Level, step.Action, alert.AlertID)
+	return &events.Event{
+		Type:    events.AlertEscalated,
+		Payload: eventPayload,
+	}, nil
+}
+
+// advance moves an escalation past the step executed at the given time and schedules the next step relative to
+// it, or completes the escalation after the last step
+func (s *escalationService) advance(escalation *models.AlertEscalation, now time.Time) {
+	executed := s.steps[escalation.Level]
+	escalation.Level++
+	if escalation.Level < len(s.steps) {
+		nextEscalationAt := now.Add(s.steps[escalation.Level].After - executed.After)
+		escalation.NextEscalationAt = &nextEscalationAt
+		return
+	}
+
+	escalation.NextEscalationAt = nil
+	escalation.StoppedAt = &now
+	escalation.StopReason = escalationStopReasonDone
+}
+
+// stopStaleEscalation ends the escalation of an alert that left New or Escalated after being selected, without
+// notifying anyone
+func (s *escalationService) stopStaleEscalation(escalation *models.AlertEscalation, step *models.AlertEscalationStep, status string, now time.Time, tx *gorm.DB) error {
+	reason, ok := escalationStopReasons[enum.AlertStatus(status)]
+	if !ok {
+		reason = escalationStopReasonStatusChanged
+	}
+
+	escalation.NextEscalationAt = nil
+	escalation.StoppedAt = &now
+	escalation.StopReason = reason
+	step.Action = reason
+	log.Printf("Escalation of AlertID %s stopped: alert is %s", escalation.AlertID, status)
+	return s.repo.RecordStepInTransaction(escalation, step, tx)
+}
+
+// enqueueEscalationNotification queues a reminder for an unattended alert
+func (s *escalationService) enqueueEscalationNotification(alert *models.Alert, action enum.EscalationAction, pushTokens []string, tx *gorm.DB) error {
+	diagnosis := ""
+	if alert.ComputerDiagnostic != nil {
+		diagnosis = alert.ComputerDiagnostic.Diagnosis
+	}
+	patientName, patientLocation := "", ""
+	if alert.Patient != nil {
+		patientName = alert.Patient.Name
+		patientLocation = alert.Patient.Location
+	}
+
+	notificationTitle := "Alerta sin atender: " + diagnosis
+	if action != enum.EscalationActionRenotify {
+		notificationTitle = "Alerta escalada: " + diagnosis
+	}
+
+	notification := &notifier.Notification{
+		Title:      notificationTitle,
+		Body:       "Paciente: " + patientName + ", Ubicación: " + patientLocation,
+		PushTokens: pushTokens,
+		Data:       map[string]interface{}{"alert_id": alert.AlertID, "escalation_action": action},
+	}
+	return s.notificationService.EnqueueInTransaction(notification, &alert.AlertID, tx)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"biometric-data-backend/config"
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"testing"
+	"time"
+)
+
+func TestEscalationAdvance(t *testing.T) {
+	steps := []config.EscalationStep{
+		{After: 2 * time.Minute, Action: enum.EscalationActionRenotify},
+		{After: 5 * time.Minute, Action: enum.EscalationActionCareTeam},
+		{After: 10 * time.Minute, Action: enum.EscalationActionAdmins},
+	}
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		level     int
+		wantLevel int
+		// wantNext is the delay until the next step, zero when the escalation is done
+		wantNext time.Duration
+	}{
+		{"first step schedules the second", 0, 1, 3 * time.Minute},
+		{"second step schedules the last", 1, 2, 5 * time.Minute},
+		{"last step completes the escalation", 2, 3, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &escalationService{steps: steps}
+			escalation := &models.AlertEscalation{Level: tt.level}
+
+			s.advance(escalation, now)
+
+			if escalation.Level != tt.wantLevel {
+				t.Errorf("level %d, want %d", escalation.Level, tt.wantLevel)
+			}
+			if tt.wantNext == 0 {
+				if escalation.NextEscalationAt != nil {
+					t.Errorf("next escalation scheduled at %v after the last step", escalation.NextEscalationAt)
+				}
+				if escalation.StoppedAt == nil || !escalation.StoppedAt.Equal(now) {
+					t.Errorf("stopped at %v, want %v", escalation.StoppedAt, now)
+				}
+				if escalation.StopReason != escalationStopReasonDone {
+					t.Errorf("stop reason %q, want %q", escalation.StopReason, escalationStopReasonDone)
+				}
+				return
+			}
+			if escalation.NextEscalationAt == nil || !escalation.NextEscalationAt.Equal(now.Add(tt.wantNext)) {
+				t.Errorf("next escalation at %v, want %v", escalation.NextEscalationAt, now.Add(tt.wantNext))
+			}
+			if escalation.StoppedAt != nil {
+				t.Errorf("escalation stopped before the last step")
+			}
+		})
+	}
+}
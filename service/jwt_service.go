@@ -1,34 +1,618 @@
 package service
 
 import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
 	"fmt"
-	"github.com/golang-jwt/jwt/v5"
-	"os"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// rsaSigningKeyBits is the size of the RS256 signing keys
+	rsaSigningKeyBits = 2048
+	// signingKeyReloadBackoff limits how often a token with an unknown kid triggers a reload of the keys
+	signingKeyReloadBackoff = 30 * time.Second
+	// encryptedPrivateKeyPrefix marks the private keys stored encrypted, as opposed to the PEM stored before
+	encryptedPrivateKeyPrefix = "aes-gcm:"
 )
 
-// GenerateToken generates a JWT token
-func GenerateToken(email string, roles []string, additionalClaims map[string]interface{}) (string, error) {
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	if secretKey == "" {
-		return "", fmt.Errorf("JWT_SECRET_KEY not set in environment")
+var (
+	ErrNoSigningKey         = errors.New("no signing key available")
+	ErrUnknownSigningKey    = errors.New("token signed with an unknown key")
+	ErrSigningKeyMismatch   = errors.New("token algorithm does not match its signing key")
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrNoEncryptionKey      = errors.New("no valid signing key encryption key configured")
+)
+
+// supportedSigningMethods are the only algorithms tokens are signed and accepted with
+var supportedSigningMethods = map[string]jwt.SigningMethod{
+	jwt.SigningMethodRS256.Alg(): jwt.SigningMethodRS256,
+	jwt.SigningMethodEdDSA.Alg(): jwt.SigningMethodEdDSA,
+}
+
+// JWTService signs the tokens with the current signing key and resolves the keys verifying them. The keys are
+// stored in the database so every instance signs with the same key, and are rotated on a schedule.
+type JWTService interface {
+	GenerateToken(email string, roles []string, expiresAt time.Time, additionalClaims map[string]interface{}) (string, error)
+	VerificationKey(token *jwt.Token) (interface{}, error)
+	ValidMethods() []string
+	Issuer() string
+	GetJWKS() *dto.JWKSDTO
+	GetSigningKeys() ([]*dto.SigningKeyDTO, error)
+	RotateKey(immediate bool) (*dto.SigningKeyDTO, error)
+	EnsureSigningKey() error
+	RunKeyRotation()
+}
+
+// loadedSigningKey is a signing key with its halves parsed
+type loadedSigningKey struct {
+	kid         string
+	method      jwt.SigningMethod
+	privateKey  crypto.PrivateKey
+	publicKey   crypto.PublicKey
+	activatesAt time.Time
+	expiresAt   *time.Time
+}
+
+type jwtService struct {
+	repo             repository.SigningKeyRepository
+	algorithm        string
+	issuer           string
+	rotationInterval time.Duration
+	publishLead      time.Duration
+	retention        time.Duration
+	checkInterval    time.Duration
+	// aead encrypts the private keys stored in the database, nil without a valid encryption key
+	aead cipher.AEAD
+
+	mu       sync.RWMutex
+	keys     map[string]*loadedSigningKey
+	loadedAt time.Time
+}
+
+func NewJWTService(
+	repo repository.SigningKeyRepository,
+	algorithm string,
+	issuer string,
+	rotationInterval time.Duration,
+	publishLead time.Duration,
+	retention time.Duration,
+	checkInterval time.Duration,
+	encryptionKey []byte,
+) JWTService {
+	var aead cipher.AEAD
+	if encryptionKey != nil {
+		block, err := aes.NewCipher(encryptionKey)
+		if err == nil {
+			aead, err = cipher.NewGCM(block)
+		}
+		if err != nil {
+			log.Printf("Invalid signing key encryption key: %v", err)
+		}
+	}
+
+	return &jwtService{
+		repo:             repo,
+		algorithm:        algorithm,
+		issuer:           issuer,
+		rotationInterval: rotationInterval,
+		publishLead:      publishLead,
+		retention:        retention,
+		checkInterval:    checkInterval,
+		aead:             aead,
+		keys:             make(map[string]*loadedSigningKey),
+	}
+}
+
+// GenerateToken generates a JWT token expiring at the given time, signed with the current signing key.
+// Every token gets a unique ID (jti) so it can be revoked on its own.
+func (s *jwtService) GenerateToken(email string, roles []string, expiresAt time.Time, additionalClaims map[string]interface{}) (string, error) {
+	now := time.Now()
+	key := s.currentKey(now)
+	if key == nil {
+		return "", ErrNoSigningKey
 	}
+
 	claims := jwt.MapClaims{
 		"email": email,
 		"roles": roles,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(), // 24 hrs to expire
+		"iss":   s.issuer,
+		"iat":   now.Unix(),
+		"exp":   expiresAt.Unix(),
+		"jti":   uuid.NewString(),
 	}
 
-	for key, value := range additionalClaims {
-		claims[key] = value
+	for claim, value := range additionalClaims {
+		claims[claim] = value
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
 
-	tokenString, err := token.SignedString([]byte(secretKey))
+	tokenString, err := token.SignedString(key.privateKey)
 	if err != nil {
 		return "", err
 	}
 
 	return tokenString, nil
 }
+
+// VerificationKey returns the public key a token must be verified with, found by the kid of its header.
+// The algorithm of the token must be the one of the key.
+func (s *jwtService) VerificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrUnknownSigningKey
+	}
+
+	key := s.publishedKey(kid, time.Now())
+	if key == nil && s.canReload() {
+		// The key may have just been created by another instance
+		if err := s.loadKeys(); err != nil {
+			log.Printf("Failed to reload signing keys: %v", err)
+		}
+		key = s.publishedKey(kid, time.Now())
+	}
+	if key == nil {
+		return nil, ErrUnknownSigningKey
+	}
+
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrSigningKeyMismatch
+	}
+	return key.publicKey, nil
+}
+
+// ValidMethods returns the algorithms tokens are accepted with
+func (s *jwtService) ValidMethods() []string {
+	methods := make([]string, 0, len(supportedSigningMethods))
+	for alg := range supportedSigningMethods {
+		methods = append(methods, alg)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Issuer returns the issuer (iss) of the tokens
+func (s *jwtService) Issuer() string {
+	return s.issuer
+}
+
+// GetJWKS returns the public keys of the published signing keys, including the ones not signing yet
+func (s *jwtService) GetJWKS() *dto.JWKSDTO {
+	now := time.Now()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := &dto.JWKSDTO{Keys: make([]dto.JWKDTO, 0, len(s.keys))}
+	for _, key := range s.keys {
+		if key.expiresAt != nil && !key.expiresAt.After(now) {
+			continue
+		}
+		jwk, err := mapPublicKeyToJWK(key)
+		if err != nil {
+			log.Printf("Failed to publish signing key %s: %v", key.kid, err)
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// GetSigningKeys returns all the signing keys with their status, without their private halves
+func (s *jwtService) GetSigningKeys() ([]*dto.SigningKeyDTO, error) {
+	keys, err := s.repo.GetSigningKeys()
+	if err != nil {
+		log.Printf("Error retrieving signing keys: %v", err)
+		return nil, err
+	}
+
+	now := time.Now()
+	activeKID := ""
+	if current := s.currentKey(now); current != nil {
+		activeKID = current.kid
+	}
+
+	keyDTOs := make([]*dto.SigningKeyDTO, 0, len(keys))
+	for _, key := range keys {
+		keyDTOs = append(keyDTOs, mapSigningKeyToDTO(key, activeKID, now))
+	}
+	return keyDTOs, nil
+}
+
+// RotateKey creates a new signing key. It is published right away and signs tokens once the services verifying
+// them had time to fetch it, unless the rotation is immediate. The previous keys stay published as long as the
+// tokens they signed live, or are dropped right away on an immediate rotation.
+func (s *jwtService) RotateKey(immediate bool) (*dto.SigningKeyDTO, error) {
+	tx := s.repo.BeginTransaction()
+	if _, err := s.repo.GetLatestKeyInTransaction(tx); err != nil {
+		tx.Rollback()
+		log.Printf("Error retrieving latest signing key: %v", err)
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	activatesAt, previousExpiresAt := now, now
+	if !immediate {
+		activatesAt = now.Add(s.publishLead)
+		previousExpiresAt = activatesAt.Add(s.retention)
+	}
+
+	key, err := s.createKeyInTransaction(activatesAt, previousExpiresAt, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Transaction commit failed: %v", err)
+		return nil, err
+	}
+
+	if err := s.loadKeys(); err != nil {
+		log.Printf("Failed to reload signing keys: %v", err)
+	}
+
+	activeKID := ""
+	if current := s.currentKey(now); current != nil {
+		activeKID = current.kid
+	}
+	return mapSigningKeyToDTO(key, activeKID, now), nil
+}
+
+// EnsureSigningKey loads the signing keys, creating the first one if needed. It must succeed before any token
+// is issued or verified.
+func (s *jwtService) EnsureSigningKey() error {
+	if s.aead == nil {
+		return ErrNoEncryptionKey
+	}
+	if err := s.rotateIfDue(); err != nil {
+		return err
+	}
+	if err := s.loadKeys(); err != nil {
+		return err
+	}
+	if s.currentKey(time.Now()) == nil {
+		return ErrNoSigningKey
+	}
+	return nil
+}
+
+// RunKeyRotation periodically rotates the signing key once it is due and reloads the keys, picking up the ones
+// created by other instances
+func (s *jwtService) RunKeyRotation() {
+	ticker := time.NewTicker(s.checkInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := s.rotateIfDue(); err != nil {
+			log.Printf("Failed to rotate signing key: %v", err)
+		}
+		if err := s.loadKeys(); err != nil {
+			log.Printf("Failed to reload signing keys: %v", err)
+		}
+	}
+}
+
+// rotateIfDue creates the next signing key once the latest one has signed for the rotation interval, minus the
+// publish lead so it starts signing right on time. Without any key, the first one signs right away.
+func (s *jwtService) rotateIfDue() error {
+	tx := s.repo.BeginTransaction()
+	latest, err := s.repo.GetLatestKeyInTransaction(tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error retrieving latest signing key: %v", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	if latest != nil && now.Before(latest.ActivatesAt.Add(s.rotationInterval-s.publishLead)) {
+		tx.Rollback()
+		return nil
+	}
+
+	activatesAt := now
+	if latest != nil {
+		activatesAt = now.Add(s.publishLead)
+	}
+	if _, err := s.createKeyInTransaction(activatesAt, activatesAt.Add(s.retention), tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (s *jwtService) createKeyInTransaction(activatesAt time.Time, previousExpiresAt time.Time, tx *gorm.DB) (*models.SigningKey, error) {
+	key, err := generateSigningKey(s.algorithm)
+	if err != nil {
+		log.Printf("Failed to generate signing key: %v", err)
+		return nil, err
+	}
+	key.ActivatesAt = activatesAt
+	if key.PrivateKey, err = s.encryptPrivateKey(key.KID, key.PrivateKey); err != nil {
+		log.Printf("Failed to encrypt signing key: %v", err)
+		return nil, err
+	}
+
+	if err := s.repo.ExpireKeysInTransaction(key.KID, previousExpiresAt, tx); err != nil {
+		log.Printf("Failed to expire previous signing keys: %v", err)
+		return nil, err
+	}
+	if err := s.repo.CreateInTransaction(key, tx); err != nil {
+		log.Printf("Failed to store signing key: %v", err)
+		return nil, err
+	}
+
+	log.Printf("Signing key %s (%s) created, signing from %s", key.KID, key.Algorithm, activatesAt.Format(time.RFC3339))
+	return key, nil
+}
+
+// loadKeys replaces the keys in memory with the published keys of the database
+func (s *jwtService) loadKeys() error {
+	now := time.Now()
+	keys, err := s.repo.GetPublishedKeys(now)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]*loadedSigningKey, len(keys))
+	for _, key := range keys {
+		privateKey, err := s.decryptPrivateKey(key)
+		if err != nil {
+			log.Printf("Skipping signing key %s that cannot be decrypted: %v", key.KID, err)
+			continue
+		}
+		loadedKey, err := parseSigningKey(key, privateKey)
+		if err != nil {
+			log.Printf("Skipping invalid signing key %s: %v", key.KID, err)
+			continue
+		}
+		if !strings.HasPrefix(key.PrivateKey, encryptedPrivateKeyPrefix) {
+			s.encryptStoredPrivateKey(key.KID, privateKey)
+		}
+		loaded[key.KID] = loadedKey
+	}
+
+	s.mu.Lock()
+	s.keys = loaded
+	s.loadedAt = now
+	s.mu.Unlock()
+	return nil
+}
+
+// encryptPrivateKey encrypts a PEM encoded private key with AES-GCM, bound to its kid so it cannot be moved to
+// another row. The random nonce is stored ahead of the ciphertext.
+func (s *jwtService) encryptPrivateKey(kid string, privateKey string) (string, error) {
+	if s.aead == nil {
+		return "", ErrNoEncryptionKey
+	}
+
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(privateKey), []byte(kid))
+	return encryptedPrivateKeyPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptPrivateKey returns the PEM encoded private half of a stored key. Keys stored before the private keys
+// were encrypted are returned as they are.
+func (s *jwtService) decryptPrivateKey(key *models.SigningKey) (string, error) {
+	encoded, encrypted := strings.CutPrefix(key.PrivateKey, encryptedPrivateKeyPrefix)
+	if !encrypted {
+		return key.PrivateKey, nil
+	}
+	if s.aead == nil {
+		return "", ErrNoEncryptionKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < s.aead.NonceSize() {
+		return "", errors.New("encrypted private key too short")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	privateKey, err := s.aead.Open(nil, nonce, ciphertext, []byte(key.KID))
+	if err != nil {
+		return "", err
+	}
+	return string(privateKey), nil
+}
+
+// encryptStoredPrivateKey replaces a private key stored as plain PEM with its encrypted form. A failure is only
+// logged, the key is encrypted on the next reload.
+func (s *jwtService) encryptStoredPrivateKey(kid string, privateKey string) {
+	encrypted, err := s.encryptPrivateKey(kid, privateKey)
+	if err != nil {
+		log.Printf("Failed to encrypt signing key %s: %v", kid, err)
+		return
+	}
+	if err := s.repo.UpdatePrivateKey(kid, encrypted); err != nil {
+		log.Printf("Failed to store encrypted signing key %s: %v", kid, err)
+		return
+	}
+	log.Printf("Signing key %s stored unencrypted, now encrypted", kid)
+}
+
+func (s *jwtService) canReload() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.loadedAt) >= signingKeyReloadBackoff
+}
+
+// currentKey returns the key signing tokens: the most recently activated key not expired yet
+func (s *jwtService) currentKey(now time.Time) *loadedSigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var current *loadedSigningKey
+	for _, key := range s.keys {
+		if key.activatesAt.After(now) || (key.expiresAt != nil && !key.expiresAt.After(now)) {
+			continue
+		}
+		if current == nil || key.activatesAt.After(current.activatesAt) {
+			current = key
+		}
+	}
+	return current
+}
+
+func (s *jwtService) publishedKey(kid string, now time.Time) *loadedSigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[kid]
+	if !ok || (key.expiresAt != nil && !key.expiresAt.After(now)) {
+		return nil
+	}
+	return key
+}
+
+// generateSigningKey generates a key pair of the given algorithm, PEM encoded
+func generateSigningKey(algorithm string) (*models.SigningKey, error) {
+	var privateKey crypto.PrivateKey
+	var publicKey crypto.PublicKey
+	switch algorithm {
+	case jwt.SigningMethodRS256.Alg():
+		rsaKey, err := rsa.GenerateKey(rand.Reader, rsaSigningKeyBits)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, publicKey = rsaKey, &rsaKey.PublicKey
+	case jwt.SigningMethodEdDSA.Alg():
+		edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, publicKey = edPrivateKey, edPublicKey
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := randomToken("", 16, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.SigningKey{
+		KID:        kid,
+		Algorithm:  algorithm,
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+	}, nil
+}
+
+// parseSigningKey parses the PEM encoded halves of a signing key, the private one already decrypted
+func parseSigningKey(key *models.SigningKey, privateKeyPEM string) (*loadedSigningKey, error) {
+	method, ok := supportedSigningMethods[key.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, key.Algorithm)
+	}
+
+	privateBlock, _ := pem.Decode([]byte(privateKeyPEM))
+	publicBlock, _ := pem.Decode([]byte(key.PublicKey))
+	if privateBlock == nil || publicBlock == nil {
+		return nil, errors.New("invalid PEM encoding")
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey(privateBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.ParsePKIXPublicKey(publicBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch publicKey.(type) {
+	case *rsa.PublicKey:
+		if method != jwt.SigningMethodRS256 {
+			return nil, ErrSigningKeyMismatch
+		}
+	case ed25519.PublicKey:
+		if method != jwt.SigningMethodEdDSA {
+			return nil, ErrSigningKeyMismatch
+		}
+	default:
+		return nil, ErrSigningKeyMismatch
+	}
+
+	return &loadedSigningKey{
+		kid:         key.KID,
+		method:      method,
+		privateKey:  privateKey,
+		publicKey:   publicKey,
+		activatesAt: key.ActivatesAt,
+		expiresAt:   key.ExpiresAt,
+	}, nil
+}
+
+// mapPublicKeyToJWK maps the public half of a signing key to a JSON Web Key (RFC 7518 and RFC 8037)
+func mapPublicKeyToJWK(key *loadedSigningKey) (dto.JWKDTO, error) {
+	jwk := dto.JWKDTO{Use: "sig", Alg: key.method.Alg(), Kid: key.kid}
+	switch publicKey := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return dto.JWKDTO{}, ErrUnsupportedAlgorithm
+	}
+	return jwk, nil
+}
+
+func mapSigningKeyToDTO(key *models.SigningKey, activeKID string, now time.Time) *dto.SigningKeyDTO {
+	status := "retired"
+	switch {
+	case key.ExpiresAt != nil && !key.ExpiresAt.After(now):
+		status = "expired"
+	case key.ActivatesAt.After(now):
+		status = "pending"
+	case key.KID == activeKID:
+		status = "active"
+	}
+
+	return &dto.SigningKeyDTO{
+		KID:         key.KID,
+		Algorithm:   key.Algorithm,
+		Status:      status,
+		ActivatesAt: key.ActivatesAt,
+		ExpiresAt:   key.ExpiresAt,
+		CreatedAt:   key.CreatedAt,
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// errAny matches any error in the table tests
+var errAny = errors.New("any error")
+
+func newTestJWTService(encryptionKey []byte) *jwtService {
+	return NewJWTService(nil, jwt.SigningMethodEdDSA.Alg(), "test", 0, 0, 0, 0, encryptionKey).(*jwtService)
+}
+
+func TestSigningKeyEncryption(t *testing.T) {
+	s := newTestJWTService(make([]byte, 32))
+	key, err := generateSigningKey(jwt.SigningMethodEdDSA.Alg())
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	plain := key.PrivateKey
+
+	encrypted, err := s.encryptPrivateKey(key.KID, plain)
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, encryptedPrivateKeyPrefix) || strings.Contains(encrypted, "PRIVATE KEY") {
+		t.Fatalf("private key not encrypted: %q", encrypted)
+	}
+
+	otherKey := make([]byte, 32)
+	otherKey[0] = 1
+
+	tests := []struct {
+		name    string
+		service *jwtService
+		kid     string
+		stored  string
+		want    string
+		wantErr error
+	}{
+		{name: "round trip", service: s, kid: key.KID, stored: encrypted, want: plain},
+		{name: "unencrypted key is returned as is", service: s, kid: key.KID, stored: plain, want: plain},
+		{name: "key moved to another kid", service: s, kid: "another", stored: encrypted, wantErr: errAny},
+		{name: "another encryption key", service: newTestJWTService(otherKey), kid: key.KID, stored: encrypted, wantErr: errAny},
+		{name: "no encryption key", service: newTestJWTService(nil), kid: key.KID, stored: encrypted, wantErr: ErrNoEncryptionKey},
+		{name: "truncated", service: s, kid: key.KID, stored: encryptedPrivateKeyPrefix + "AAAA", wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key.KID, key.PrivateKey = tt.kid, tt.stored
+			got, err := tt.service.decryptPrivateKey(key)
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decrypt: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("decrypted key differs from the original")
+			}
+		})
+	}
+}
+
+func TestEncryptPrivateKeyWithoutEncryptionKey(t *testing.T) {
+	for _, encryptionKey := range [][]byte{nil, []byte("too short")} {
+		s := newTestJWTService(encryptionKey)
+		if _, err := s.encryptPrivateKey("kid", "key"); !errors.Is(err, ErrNoEncryptionKey) {
+			t.Errorf("key of %d bytes: error %v, want ErrNoEncryptionKey", len(encryptionKey), err)
+		}
+		if err := s.EnsureSigningKey(); !errors.Is(err, ErrNoEncryptionKey) {
+			t.Errorf("key of %d bytes: EnsureSigningKey error %v, want ErrNoEncryptionKey", len(encryptionKey), err)
+		}
+	}
+}
+
+func TestParseSigningKey(t *testing.T) {
+	rsaKey, err := generateSigningKey(jwt.SigningMethodRS256.Alg())
+	if err != nil {
+		t.Fatalf("generate RS256: %v", err)
+	}
+	edKey, err := generateSigningKey(jwt.SigningMethodEdDSA.Alg())
+	if err != nil {
+		t.Fatalf("generate EdDSA: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		algorithm string
+		private   string
+		public    string
+		wantKty   string
+		wantErr   bool
+	}{
+		{name: "RS256", algorithm: "RS256", private: rsaKey.PrivateKey, public: rsaKey.PublicKey, wantKty: "RSA"},
+		{name: "EdDSA", algorithm: "EdDSA", private: edKey.PrivateKey, public: edKey.PublicKey, wantKty: "OKP"},
+		{name: "algorithm mismatch", algorithm: "RS256", private: edKey.PrivateKey, public: edKey.PublicKey, wantErr: true},
+		{name: "unsupported algorithm", algorithm: "HS256", private: edKey.PrivateKey, public: edKey.PublicKey, wantErr: true},
+		{name: "invalid PEM", algorithm: "EdDSA", private: "not a key", public: edKey.PublicKey, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := *edKey
+			stored.Algorithm, stored.PublicKey = tt.algorithm, tt.public
+
+			loaded, err := parseSigningKey(&stored, tt.private)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+
+			jwk, err := mapPublicKeyToJWK(loaded)
+			if err != nil {
+				t.Fatalf("jwk: %v", err)
+			}
+			if jwk.Kty != tt.wantKty || jwk.Alg != tt.algorithm || jwk.Kid != stored.KID {
+				t.Errorf("got jwk %+v", jwk)
+			}
+		})
+	}
+}
+
+func TestCurrentSigningKey(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration) *time.Time {
+		instant := now.Add(offset)
+		return &instant
+	}
+
+	tests := []struct {
+		name string
+		keys []*loadedSigningKey
+		want string
+	}{
+		{name: "no keys"},
+		{
+			name: "latest active key",
+			keys: []*loadedSigningKey{
+				{kid: "old", activatesAt: now.Add(-48 * time.Hour), expiresAt: at(time.Hour)},
+				{kid: "current", activatesAt: now.Add(-time.Hour)},
+			},
+			want: "current",
+		},
+		{
+			name: "published key not active yet",
+			keys: []*loadedSigningKey{
+				{kid: "current", activatesAt: now.Add(-time.Hour)},
+				{kid: "next", activatesAt: now.Add(time.Hour)},
+			},
+			want: "current",
+		},
+		{
+			name: "expired key",
+			keys: []*loadedSigningKey{
+				{kid: "expired", activatesAt: now.Add(-time.Hour), expiresAt: at(0)},
+				{kid: "previous", activatesAt: now.Add(-48 * time.Hour), expiresAt: at(time.Hour)},
+			},
+			want: "previous",
+		},
+		{
+			name: "only future keys",
+			keys: []*loadedSigningKey{{kid: "next", activatesAt: now.Add(time.Hour)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestJWTService(nil)
+			for _, key := range tt.keys {
+				s.keys[key.kid] = key
+			}
+
+			current := s.currentKey(now)
+			got := ""
+			if current != nil {
+				got = current.kid
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
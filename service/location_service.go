@@ -0,0 +1,235 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/repository"
+	"errors"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLocationParent = errors.New("invalid parent: buildings have none, wards belong to a building, rooms to a ward and beds to a room")
+	ErrLocationHasChildren   = errors.New("the location still contains other locations")
+	ErrLocationOccupied      = errors.New("the location has patients assigned to its beds")
+	ErrNotAWard              = errors.New("staff can only be assigned to wards")
+	ErrUnknownWardStaff      = errors.New("one or more doctors do not exist")
+)
+
+type LocationService interface {
+	CreateLocation(locationDTO *dto.LocationCreateDTO) (*dto.LocationDTO, error)
+	GetLocationByID(id uuid.UUID) (*dto.LocationDTO, error)
+	GetLocations(filters dto.LocationFilter) ([]*dto.LocationDTO, error)
+	UpdateLocation(id uuid.UUID, locationDTO *dto.LocationUpdateDTO, expectedVersion *int) error
+	DeleteLocation(id uuid.UUID) error
+	GetOccupancy(id uuid.UUID) (*dto.LocationOccupancyDTO, error)
+	SetWardStaff(id uuid.UUID, staffDTO *dto.WardStaffUpdateDTO) (*dto.LocationDTO, error)
+}
+
+type locationService struct {
+	repo       repository.LocationRepository
+	doctorRepo repository.DoctorRepository
+}
+
+func NewLocationService(repo repository.LocationRepository, doctorRepo repository.DoctorRepository) LocationService {
+	return &locationService{
+		repo:       repo,
+		doctorRepo: doctorRepo,
+	}
+}
+
+// CreateLocation creates a location under a parent of the type right above it in the hierarchy
+func (s *locationService) CreateLocation(locationDTO *dto.LocationCreateDTO) (*dto.LocationDTO, error) {
+	parentType := enum.LocationType(locationDTO.Type).ParentType()
+	if parentType == "" && locationDTO.ParentID != nil || parentType != "" && locationDTO.ParentID == nil {
+		return nil, ErrInvalidLocationParent
+	}
+	if locationDTO.ParentID != nil {
+		parent, err := s.repo.GetByID(*locationDTO.ParentID, "location_id")
+		if err != nil {
+			log.Printf("Error retrieving parent location: %v", err)
+			return nil, err
+		}
+		if parent == nil || enum.LocationType(parent.Type) != parentType {
+			return nil, ErrInvalidLocationParent
+		}
+	}
+
+	location := dto.MapCreateDTOToLocation(locationDTO)
+	err := s.repo.Create(location)
+	if err != nil {
+		log.Printf("Failed to create location: %v", err)
+		return nil, err
+	}
+	log.Println("Location created successfully with LocationID:", location.LocationID)
+
+	return dto.MapLocationToDTO(location), nil
+}
+
+func (s *locationService) GetLocationByID(id uuid.UUID) (*dto.LocationDTO, error) {
+	log.Println("Fetching location with LocationID:", id)
+	location, err := s.repo.GetByID(id, "location_id")
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		log.Println("No location found with LocationID:", id)
+		return nil, nil
+	}
+	return dto.MapLocationToDTO(location), nil
+}
+
+func (s *locationService) GetLocations(filters dto.LocationFilter) ([]*dto.LocationDTO, error) {
+	log.Println("Fetching locations")
+	locations, err := s.repo.GetLocations(filters)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapLocationsToDTOs(locations), nil
+}
+
+// UpdateLocation renames a location or takes it in or out of service, if it was not modified since it was read,
+// or since the expected version if given
+func (s *locationService) UpdateLocation(id uuid.UUID, locationDTO *dto.LocationUpdateDTO, expectedVersion *int) error {
+	log.Println("Updating location with LocationID:", id)
+
+	location, err := s.repo.GetByID(id, "location_id")
+	if err != nil {
+		log.Printf("Error retrieving location: %v", err)
+		return err
+	}
+	if location == nil {
+		log.Printf("Location not found with LocationID: %v", id)
+		return gorm.ErrRecordNotFound
+	}
+
+	version := location.Version
+	if expectedVersion != nil && *expectedVersion != version {
+		log.Printf("Rejected update of LocationID %s: version %d expected, found %d", id, *expectedVersion, version)
+		return repository.ErrVersionConflict
+	}
+
+	location.Name = locationDTO.Name
+	if locationDTO.OutOfService != nil {
+		location.OutOfService = *locationDTO.OutOfService
+	}
+	location.Version = version + 1
+	err = s.repo.UpdateWithVersion(location, "location_id", id, version, "name", "out_of_service")
+	if err != nil {
+		log.Printf("Failed to update location: %v", err)
+		return err
+	}
+	log.Println("Location updated successfully with LocationID:", id)
+	return nil
+}
+
+// DeleteLocation deletes a location that contains no other location and whose bed is not assigned to a patient
+func (s *locationService) DeleteLocation(id uuid.UUID) error {
+	log.Println("Deleting location with LocationID:", id)
+
+	children, err := s.repo.CountChildren(id)
+	if err != nil {
+		log.Printf("Error counting child locations: %v", err)
+		return err
+	}
+	if children > 0 {
+		return ErrLocationHasChildren
+	}
+
+	beds, err := s.repo.GetBedOccupancy(id)
+	if err != nil {
+		log.Printf("Error retrieving bed occupancy: %v", err)
+		return err
+	}
+	for _, bed := range beds {
+		if bed.MedicalVisitID != nil {
+			return ErrLocationOccupied
+		}
+	}
+
+	err = s.repo.Delete(id, "location_id")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Println("Location not found with LocationID:", id)
+			return nil
+		}
+		log.Printf("Failed to delete location: %v", err)
+		return err
+	}
+	log.Println("Location deleted successfully with LocationID:", id)
+	return nil
+}
+
+// GetOccupancy returns the beds contained in a location with their status, or nil if the location does not exist
+func (s *locationService) GetOccupancy(id uuid.UUID) (*dto.LocationOccupancyDTO, error) {
+	location, err := s.repo.GetByID(id, "location_id")
+	if err != nil {
+		log.Printf("Error retrieving location: %v", err)
+		return nil, err
+	}
+	if location == nil {
+		log.Println("No location found with LocationID:", id)
+		return nil, nil
+	}
+
+	beds, err := s.repo.GetBedOccupancy(id)
+	if err != nil {
+		log.Printf("Error retrieving bed occupancy: %v", err)
+		return nil, err
+	}
+	return dto.MapBedOccupancyToDTO(id, beds), nil
+}
+
+// SetWardStaff replaces the doctors of a ward, who are notified of the alerts of the patients in its beds
+func (s *locationService) SetWardStaff(id uuid.UUID, staffDTO *dto.WardStaffUpdateDTO) (*dto.LocationDTO, error) {
+	location, err := s.repo.GetByID(id, "location_id")
+	if err != nil {
+		log.Printf("Error retrieving location: %v", err)
+		return nil, err
+	}
+	if location == nil {
+		log.Println("No location found with LocationID:", id)
+		return nil, gorm.ErrRecordNotFound
+	}
+	if enum.LocationType(location.Type) != enum.LocationTypeWard {
+		return nil, ErrNotAWard
+	}
+
+	doctors := make([]*models.Doctor, 0)
+	if len(staffDTO.DoctorIDs) > 0 {
+		doctors, err = s.doctorRepo.GetDoctorsByIDs(staffDTO.DoctorIDs)
+		if err != nil {
+			log.Printf("Error retrieving doctors: %v", err)
+			return nil, err
+		}
+		if len(doctors) != len(uniqueUUIDs(staffDTO.DoctorIDs)) {
+			return nil, ErrUnknownWardStaff
+		}
+	}
+
+	if err := s.repo.SetWardStaff(id, doctors); err != nil {
+		log.Printf("Failed to set ward staff: %v", err)
+		return nil, err
+	}
+	log.Printf("Ward %s staffed with %d doctors", id, len(doctors))
+
+	location.Staff = doctors
+	return dto.MapLocationToDTO(location), nil
+}
+
+// uniqueUUIDs removes the repeated IDs of a list, keeping their order
+func uniqueUUIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,346 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/redis"
+	"biometric-data-backend/repository"
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPatientAlreadyAdmitted = errors.New("the patient already has an open visit")
+	ErrPatientNotAdmitted     = errors.New("the patient has no open visit")
+	ErrInvalidEntryDate       = errors.New("entry date cannot be in the future")
+	ErrInvalidDischargeDate   = errors.New("discharge date must be between the entry date and now")
+	ErrSameTransferLocation   = errors.New("the patient is already in that location")
+	ErrNotABed                = errors.New("bed_id must be a bed")
+	ErrBedOutOfService        = errors.New("the bed is out of service")
+	ErrBedOccupied            = errors.New("the bed is assigned to another patient")
+)
+
+// maxLocationLength is the size of the location of the patients and visits, longer bed labels are cut
+const maxLocationLength = 100
+
+type MedicalVisitService interface {
+	Admit(patientID uuid.UUID, admitDTO *dto.MedicalVisitAdmitDTO) (*dto.MedicalVisitDTO, error)
+	Discharge(patientID uuid.UUID, dischargeDTO *dto.MedicalVisitDischargeDTO) (*dto.MedicalVisitDischargeResponseDTO, error)
+	Transfer(patientID uuid.UUID, transferDTO *dto.MedicalVisitTransferCreateDTO) (*dto.MedicalVisitDTO, error)
+	GetVisitsByPatientID(patientID uuid.UUID) ([]*dto.MedicalVisitDTO, error)
+}
+
+type medicalVisitService struct {
+	repo         repository.MedicalVisitRepository
+	patientRepo  repository.PatientRepository
+	locationRepo repository.LocationRepository
+	cache        *redis.CacheManager
+}
+
+func NewMedicalVisitService(repo repository.MedicalVisitRepository, patientRepo repository.PatientRepository, locationRepo repository.LocationRepository, cache *redis.CacheManager) MedicalVisitService {
+	return &medicalVisitService{
+		repo:         repo,
+		patientRepo:  patientRepo,
+		locationRepo: locationRepo,
+		cache:        cache,
+	}
+}
+
+// Admit opens a visit for a patient that has none open, optionally in a free bed, and moves the patient to the
+// location of the visit
+func (s *medicalVisitService) Admit(patientID uuid.UUID, admitDTO *dto.MedicalVisitAdmitDTO) (*dto.MedicalVisitDTO, error) {
+	now := time.Now()
+	if admitDTO.EntryDate != nil && admitDTO.EntryDate.After(now) {
+		return nil, ErrInvalidEntryDate
+	}
+
+	patient, err := s.getPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	location := admitDTO.Location
+	if admitDTO.BedID != nil {
+		location, err = s.bedLabel(*admitDTO.BedID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if location == "" {
+		location = patient.Location
+	}
+	entryDate := now
+	if admitDTO.EntryDate != nil {
+		entryDate = *admitDTO.EntryDate
+	}
+
+	visit := &models.MedicalVisit{
+		PatientID: patientID,
+		Reason:    admitDTO.Reason,
+		Diagnosis: admitDTO.Diagnosis,
+		Treatment: admitDTO.Treatment,
+		Location:  location,
+		BedID:     admitDTO.BedID,
+		EntryDate: &entryDate,
+	}
+
+	err = s.inTransaction(func(tx *gorm.DB) error {
+		openVisit, err := s.repo.GetOpenVisitInTransaction(patientID, tx)
+		if err != nil {
+			return err
+		}
+		if openVisit != nil {
+			return ErrPatientAlreadyAdmitted
+		}
+		if visit.BedID != nil {
+			if err := s.checkBedFreeInTransaction(*visit.BedID, tx); err != nil {
+				return err
+			}
+		}
+
+		if err := s.repo.CreateInTransaction(visit, tx); err != nil {
+			return err
+		}
+		if location != patient.Location {
+			return s.repo.SetPatientLocationInTransaction(patientID, location, tx)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to admit patient %s: %v", patientID, err)
+		return nil, err
+	}
+	log.Println("Patient admitted with MedicalVisitID:", visit.MedicalVisitID)
+
+	s.invalidatePatient(patient)
+	return dto.MapMedicalVisitToDTO(visit), nil
+}
+
+// Discharge closes the open visit of a patient, which frees its bed, clears the location of the patient and frees
+// its monitoring device
+func (s *medicalVisitService) Discharge(patientID uuid.UUID, dischargeDTO *dto.MedicalVisitDischargeDTO) (*dto.MedicalVisitDischargeResponseDTO, error) {
+	patient, err := s.getPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var visit *models.MedicalVisit
+	var releasedDeviceID string
+	err = s.inTransaction(func(tx *gorm.DB) error {
+		visit, err = s.repo.GetOpenVisitInTransaction(patientID, tx)
+		if err != nil {
+			return err
+		}
+		if visit == nil {
+			return ErrPatientNotAdmitted
+		}
+
+		now := time.Now()
+		dischargeDate := now
+		if dischargeDTO.DischargeDate != nil {
+			dischargeDate = *dischargeDTO.DischargeDate
+		}
+		if dischargeDate.After(now) || (visit.EntryDate != nil && dischargeDate.Before(*visit.EntryDate)) {
+			return ErrInvalidDischargeDate
+		}
+
+		visit.DischargeDate = &dischargeDate
+		if dischargeDTO.Diagnosis != "" {
+			visit.Diagnosis = dischargeDTO.Diagnosis
+		}
+		if dischargeDTO.Treatment != "" {
+			visit.Treatment = dischargeDTO.Treatment
+		}
+		if err := s.repo.DischargeInTransaction(visit, tx); err != nil {
+			return err
+		}
+
+		releasedDeviceID, err = s.repo.ReleasePatientDeviceInTransaction(patientID, tx)
+		if err != nil {
+			return err
+		}
+		return s.repo.SetPatientLocationInTransaction(patientID, "", tx)
+	})
+	if err != nil {
+		log.Printf("Failed to discharge patient %s: %v", patientID, err)
+		return nil, err
+	}
+	log.Println("Patient discharged from MedicalVisitID:", visit.MedicalVisitID)
+
+	s.invalidatePatient(patient)
+	if releasedDeviceID != "" {
+		log.Printf("Monitoring device %s released from patient %s", releasedDeviceID, patientID)
+		_ = s.cache.Delete(context.Background(), "monitoring_device:"+releasedDeviceID, "monitoring_devices:all")
+	}
+	return &dto.MedicalVisitDischargeResponseDTO{
+		Visit:            dto.MapMedicalVisitToDTO(visit),
+		ReleasedDeviceID: releasedDeviceID,
+	}, nil
+}
+
+// Transfer moves an admitted patient to another bed or location and records the move in its open visit
+func (s *medicalVisitService) Transfer(patientID uuid.UUID, transferDTO *dto.MedicalVisitTransferCreateDTO) (*dto.MedicalVisitDTO, error) {
+	patient, err := s.getPatient(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	toLocation := transferDTO.Location
+	if transferDTO.BedID != nil {
+		toLocation, err = s.bedLabel(*transferDTO.BedID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var visit *models.MedicalVisit
+	err = s.inTransaction(func(tx *gorm.DB) error {
+		visit, err = s.repo.GetOpenVisitInTransaction(patientID, tx)
+		if err != nil {
+			return err
+		}
+		if visit == nil {
+			return ErrPatientNotAdmitted
+		}
+
+		fromLocation := visit.Location
+		if fromLocation == "" {
+			fromLocation = patient.Location
+		}
+		if transferDTO.BedID != nil {
+			if visit.BedID != nil && *visit.BedID == *transferDTO.BedID {
+				return ErrSameTransferLocation
+			}
+			if err := s.checkBedFreeInTransaction(*transferDTO.BedID, tx); err != nil {
+				return err
+			}
+		} else if fromLocation == toLocation {
+			return ErrSameTransferLocation
+		}
+
+		transfer := &models.MedicalVisitTransfer{
+			FromLocation:  fromLocation,
+			ToLocation:    toLocation,
+			FromBedID:     visit.BedID,
+			ToBedID:       transferDTO.BedID,
+			Reason:        transferDTO.Reason,
+			TransferredAt: time.Now(),
+		}
+		if err := s.repo.TransferInTransaction(visit, transfer, tx); err != nil {
+			return err
+		}
+		visit.Location = transfer.ToLocation
+		visit.BedID = transfer.ToBedID
+		visit.Transfers = append(visit.Transfers, transfer)
+		return s.repo.SetPatientLocationInTransaction(patientID, transfer.ToLocation, tx)
+	})
+	if err != nil {
+		log.Printf("Failed to transfer patient %s: %v", patientID, err)
+		return nil, err
+	}
+	log.Printf("Patient %s transferred to %s", patientID, toLocation)
+
+	s.invalidatePatient(patient)
+	return dto.MapMedicalVisitToDTO(visit), nil
+}
+
+// GetVisitsByPatientID returns the visit history of a patient, most recent first, or nil if the patient does not exist
+func (s *medicalVisitService) GetVisitsByPatientID(patientID uuid.UUID) ([]*dto.MedicalVisitDTO, error) {
+	exists, err := s.patientRepo.ExistsByID(patientID)
+	if err != nil {
+		log.Printf("Error checking patient: %v", err)
+		return nil, err
+	}
+	if !exists {
+		log.Println("No patient found with PatientID:", patientID)
+		return nil, nil
+	}
+
+	visits, err := s.repo.GetVisitsByPatientID(patientID)
+	if err != nil {
+		log.Printf("Error retrieving medical visits: %v", err)
+		return nil, err
+	}
+	return dto.MapMedicalVisitsToDTOs(visits), nil
+}
+
+// bedLabel checks that a location is a bed in service and returns its full name, e.g. "Main / ICU / 101 / A"
+func (s *medicalVisitService) bedLabel(bedID uuid.UUID) (string, error) {
+	ancestors, err := s.locationRepo.GetAncestors(bedID)
+	if err != nil {
+		log.Printf("Error retrieving bed: %v", err)
+		return "", err
+	}
+	if len(ancestors) == 0 || enum.LocationType(ancestors[len(ancestors)-1].Type) != enum.LocationTypeBed {
+		return "", ErrNotABed
+	}
+	if ancestors[len(ancestors)-1].OutOfService {
+		return "", ErrBedOutOfService
+	}
+
+	label := []rune(dto.LocationLabel(ancestors))
+	if len(label) > maxLocationLength {
+		label = label[:maxLocationLength]
+	}
+	return string(label), nil
+}
+
+// checkBedFreeInTransaction locks a bed for the transaction and checks that no open visit is assigned to it
+func (s *medicalVisitService) checkBedFreeInTransaction(bedID uuid.UUID, tx *gorm.DB) error {
+	occupant, err := s.repo.GetOpenVisitByBedInTransaction(bedID, tx)
+	if err != nil {
+		return err
+	}
+	if occupant != nil {
+		return ErrBedOccupied
+	}
+	return nil
+}
+
+// getPatient retrieves a patient, returning gorm.ErrRecordNotFound when it does not exist
+func (s *medicalVisitService) getPatient(patientID uuid.UUID) (*models.Patient, error) {
+	patient, err := s.patientRepo.GetByID(patientID, "patient_id")
+	if err != nil {
+		log.Printf("Error retrieving patient: %v", err)
+		return nil, err
+	}
+	if patient == nil {
+		log.Println("No patient found with PatientID:", patientID)
+		return nil, gorm.ErrRecordNotFound
+	}
+	return patient, nil
+}
+
+// inTransaction runs fn in a transaction, committed only when fn succeeds
+func (s *medicalVisitService) inTransaction(fn func(tx *gorm.DB) error) error {
+	tx := s.repo.BeginTransaction()
+	if tx.Error != nil {
+		log.Printf("Failed to start transaction: %v", tx.Error)
+		return tx.Error
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			log.Printf("Transaction rolled back due to panic: %v", r)
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// invalidatePatient drops the cached patient, whose visits, location or device changed
+func (s *medicalVisitService) invalidatePatient(patient *models.Patient) {
+	_ = s.cache.Delete(context.Background(), "patient:"+patient.PatientID.String(), "patient:dni:"+patient.DNI, "patients:all")
+}
@@ -16,6 +16,7 @@ type MedicationService interface {
 	CreateMedication(medicationDTO *dto.MedicationCreateDTO) error
 	GetMedicationByID(id uuid.UUID) (*dto.MedicationDTO, error)
 	GetAllMedications() ([]*dto.MedicationDTO, error)
+	GetMedicationsPage(request dto.PageRequest) (*dto.PageDTO[dto.MedicationDTO], error)
 	UpdateMedication(id uuid.UUID, medicationDTO *dto.MedicationUpdateDTO) error
 	DeleteMedication(id uuid.UUID) error
 }
@@ -172,3 +173,13 @@ func (s *medicationService) DeleteMedication(id uuid.UUID) error {
 	_ = s.cache.Delete(context.Background(), "medication:"+id.String(), "medications:all")
 	return nil
 }
+
+// GetMedicationsPage returns a cursor paginated page of the medications
+func (s *medicationService) GetMedicationsPage(request dto.PageRequest) (*dto.PageDTO[dto.MedicationDTO], error) {
+	page, err := s.repo.GetMedicationsPage(request)
+	if err != nil {
+		log.Printf("Error retrieving page of medications: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapMedicationsToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
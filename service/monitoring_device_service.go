@@ -3,13 +3,28 @@ package service
 import (
 	"biometric-data-backend/models"
 	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
 	"biometric-data-backend/redis"
 	"biometric-data-backend/repository"
 	"context"
 	"errors"
 	"fmt"
-	"gorm.io/gorm"
 	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// connectingSweepBatchSize bounds the number of timed out devices freed on each sweep
+const connectingSweepBatchSize = 100
+
+var (
+	ErrInvalidDeviceStatus     = errors.New("invalid status: must be 'Free', 'Connecting', 'In Use' or 'Unavailable'")
+	ErrInvalidDeviceTransition = errors.New("monitoring device status transition not allowed")
+	ErrDevicePatientRequired   = errors.New("patient_id is required to pair a monitoring device")
+	ErrDevicePatientMismatch   = errors.New("monitoring device is linked to another patient")
+	ErrPatientAlreadyHasDevice = errors.New("patient is already linked to a monitoring device")
 )
 
 type MonitoringDeviceService interface {
@@ -17,17 +32,46 @@ type MonitoringDeviceService interface {
 	GetMonitoringDeviceByID(id string) (*dto.MonitoringDeviceDTO, error)
 	GetAllMonitoringDevices(page int, limit int, filters dto.MonitoringDeviceFilter) ([]*dto.MonitoringDeviceDTO, int, error)
 	GetAllMonitoringDevicesByStatus(status string) ([]*dto.MonitoringDeviceDTO, int, error)
-	UpdateMonitoringDevice(id string, deviceDTO *dto.MonitoringDeviceUpdateDTO) error
+	GetMonitoringDevicesPage(request dto.PageRequest, filters dto.MonitoringDeviceFilter) (*dto.PageDTO[dto.MonitoringDeviceDTO], error)
+	UpdateMonitoringDevice(id string, deviceDTO *dto.MonitoringDeviceUpdateDTO, expectedVersion *int) error
 	DeleteMonitoringDevice(id string) error
+	PairDevice(id string, pairDTO *dto.MonitoringDevicePairDTO, userID *uuid.UUID, expectedVersion *int) (*dto.MonitoringDeviceDTO, error)
+	UnpairDevice(id string, userID *uuid.UUID, expectedVersion *int) (*dto.MonitoringDeviceDTO, error)
+	MarkDeviceUnavailable(id string, expectedVersion *int) (*dto.MonitoringDeviceDTO, error)
+	MarkDeviceAvailable(id string, expectedVersion *int) (*dto.MonitoringDeviceDTO, error)
+	MarkDeviceConnected(id string) error
+	GetDeviceAssignments(id string) ([]*dto.DeviceAssignmentDTO, error)
+	RunConnectingSweeper()
 }
 
 type monitoringDeviceService struct {
-	repo  repository.MonitoringDeviceRepository
-	cache *redis.CacheManager
+	repo              repository.MonitoringDeviceRepository
+	assignmentRepo    repository.DeviceAssignmentRepository
+	patientRepo       repository.PatientRepository
+	doctorRepo        repository.DoctorRepository
+	cache             *redis.CacheManager
+	connectingTimeout time.Duration
+	sweepInterval     time.Duration
 }
 
-func NewMonitoringDeviceService(repo repository.MonitoringDeviceRepository, cache *redis.CacheManager) MonitoringDeviceService {
-	return &monitoringDeviceService{repo: repo, cache: cache}
+func NewMonitoringDeviceService(
+	repo repository.MonitoringDeviceRepository,
+	assignmentRepo repository.DeviceAssignmentRepository,
+	patientRepo repository.PatientRepository,
+	doctorRepo repository.DoctorRepository,
+	cache *redis.CacheManager,
+	connectingTimeout time.Duration,
+	sweepInterval time.Duration,
+) MonitoringDeviceService {
+	return &monitoringDeviceService{
+		repo:              repo,
+		assignmentRepo:    assignmentRepo,
+		patientRepo:       patientRepo,
+		doctorRepo:        doctorRepo,
+		cache:             cache,
+		connectingTimeout: connectingTimeout,
+		sweepInterval:     sweepInterval,
+	}
 }
 
 func (s *monitoringDeviceService) CreateMonitoringDevice(deviceDTO *dto.MonitoringDeviceCreateDTO) error {
@@ -157,33 +201,63 @@ func (s *monitoringDeviceService) GetAllMonitoringDevicesByStatus(status string)
 	return devices, len(devices), nil
 }
 
-func (s *monitoringDeviceService) UpdateMonitoringDevice(id string, deviceDTO *dto.MonitoringDeviceUpdateDTO) error {
+// UpdateMonitoringDevice moves a device to the given status if it was not modified since it was read, or since the
+// expected version if given. Only the transitions of the pairing workflow are allowed: pairing a free device with
+// a patient, confirming a connecting device, unpairing it, and taking a free device out of use and back.
+func (s *monitoringDeviceService) UpdateMonitoringDevice(id string, deviceDTO *dto.MonitoringDeviceUpdateDTO, expectedVersion *int) error {
 	log.Println("Updating monitoring device with DeviceID:", id)
 
-	device, err := s.repo.GetMonitoringDeviceByID(id)
-	if err != nil {
-		log.Printf("Error retrieving monitoring device: %v", err)
-		return err
+	target := enum.DeviceStatus(deviceDTO.Status)
+	if !target.IsValid() {
+		return ErrInvalidDeviceStatus
 	}
-	if device == nil {
-		log.Printf("Monitoring device not found with DeviceID: %v", id)
-		return gorm.ErrRecordNotFound
+	if target == enum.DeviceStatusConnecting && deviceDTO.PatientID != nil {
+		exists, err := s.patientRepo.ExistsByID(*deviceDTO.PatientID)
+		if err != nil {
+			log.Printf("Failed to fetch patient: %v", err)
+			return err
+		}
+		if !exists {
+			return ErrPatientNotFound
+		}
 	}
 
-	// Update fields
-	device.Status = deviceDTO.Status
-	device.LinkedByID = deviceDTO.LinkedByID
-	device.PatientID = deviceDTO.PatientID
+	_, err := s.changeDevice(id, expectedVersion, func(device *models.MonitoringDevice, now time.Time, tx *gorm.DB) error {
+		current := enum.DeviceStatus(device.Status)
+		if current == target {
+			if !sameUUID(device.PatientID, deviceDTO.PatientID) {
+				return ErrDevicePatientMismatch
+			}
+			return nil
+		}
 
-	err = s.repo.UpdateMonitoringDevice(device)
+		switch target {
+		case enum.DeviceStatusConnecting:
+			if deviceDTO.PatientID == nil {
+				return ErrDevicePatientRequired
+			}
+			return s.pairInTransaction(device, *deviceDTO.PatientID, deviceDTO.LinkedByID, nil, now, tx)
+		case enum.DeviceStatusInUse:
+			if !current.CanTransitionTo(target) {
+				return ErrInvalidDeviceTransition
+			}
+			if !sameUUID(device.PatientID, deviceDTO.PatientID) {
+				return ErrDevicePatientMismatch
+			}
+			device.Status = string(target)
+			return s.assignmentRepo.MarkConnectedInTransaction(device.DeviceID, now, tx)
+		case enum.DeviceStatusFree:
+			if current.IsLinked() {
+				return s.unpairInTransaction(device, nil, enum.DeviceUnlinkReasonUnpaired, now, tx)
+			}
+		}
+		return setDeviceStatus(device, target)
+	})
 	if err != nil {
 		log.Printf("Failed to update monitoring device: %v", err)
 		return err
 	}
-	log.Println("Monitoring device updated successfully with DeviceID:", device.DeviceID)
-
-	// Invalidate cache
-	_ = s.cache.Delete(context.Background(), "monitoring_device:"+id, "monitoring_devices:all")
+	log.Println("Monitoring device updated successfully with DeviceID:", id)
 	return nil
 }
 
@@ -204,3 +278,289 @@ func (s *monitoringDeviceService) DeleteMonitoringDevice(id string) error {
 	_ = s.cache.Delete(context.Background(), "monitoring_device:"+id, "monitoring_devices:all")
 	return nil
 }
+
+// GetMonitoringDevicesPage returns a cursor paginated page of the monitoring devices matching the filters
+func (s *monitoringDeviceService) GetMonitoringDevicesPage(request dto.PageRequest, filters dto.MonitoringDeviceFilter) (*dto.PageDTO[dto.MonitoringDeviceDTO], error) {
+	page, err := s.repo.GetMonitoringDevicesPage(request, filters)
+	if err != nil {
+		log.Printf("Error retrieving page of monitoring devices: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapMonitoringDevicesToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
+
+// PairDevice links a free device to a patient on behalf of the given doctor, or of the doctor of the current user.
+// The device stays Connecting until it sends its first readings.
+func (s *monitoringDeviceService) PairDevice(id string, pairDTO *dto.MonitoringDevicePairDTO, userID *uuid.UUID, expectedVersion *int) (*dto.MonitoringDeviceDTO, error) {
+	exists, err := s.patientRepo.ExistsByID(pairDTO.PatientID)
+	if err != nil {
+		log.Printf("Failed to fetch patient: %v", err)
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPatientNotFound
+	}
+
+	linkedByID, err := s.resolveLinkingDoctor(pairDTO.DoctorID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	device, err := s.changeDevice(id, expectedVersion, func(device *models.MonitoringDevice, now time.Time, tx *gorm.DB) error {
+		return s.pairInTransaction(device, pairDTO.PatientID, linkedByID, userID, now, tx)
+	})
+	if err != nil {
+		log.Printf("Failed to pair DeviceID %s with PatientID %s: %v", id, pairDTO.PatientID, err)
+		return nil, err
+	}
+	log.Printf("DeviceID %s paired with PatientID %s", id, pairDTO.PatientID)
+	return dto.MapMonitoringDeviceToDTO(device), nil
+}
+
+// UnpairDevice unlinks a device from its patient and frees it
+func (s *monitoringDeviceService) UnpairDevice(id string, userID *uuid.UUID, expectedVersion *int) (*dto.MonitoringDeviceDTO, error) {
+	device, err := s.changeDevice(id, expectedVersion, func(device *models.MonitoringDevice, now time.Time, tx *gorm.DB) error {
+		if !enum.DeviceStatus(device.Status).IsLinked() {
+			return ErrInvalidDeviceTransition
+		}
+		return s.unpairInTransaction(device, userID, enum.DeviceUnlinkReasonUnpaired, now, tx)
+	})
+	if err != nil {
+		log.Printf("Failed to unpair DeviceID %s: %v", id, err)
+		return nil, err
+	}
+	log.Println("Monitoring device unpaired with DeviceID:", id)
+	return dto.MapMonitoringDeviceToDTO(device), nil
+}
+
+// MarkDeviceUnavailable takes a free device out of use, e.g. for maintenance
+func (s *monitoringDeviceService) MarkDeviceUnavailable(id string, expectedVersion *int) (*dto.MonitoringDeviceDTO, error) {
+	return s.changeDeviceStatus(id, enum.DeviceStatusUnavailable, expectedVersion)
+}
+
+// MarkDeviceAvailable puts an unavailable device back in use
+func (s *monitoringDeviceService) MarkDeviceAvailable(id string, expectedVersion *int) (*dto.MonitoringDeviceDTO, error) {
+	return s.changeDeviceStatus(id, enum.DeviceStatusFree, expectedVersion)
+}
+
+func (s *monitoringDeviceService) changeDeviceStatus(id string, target enum.DeviceStatus, expectedVersion *int) (*dto.MonitoringDeviceDTO, error) {
+	device, err := s.changeDevice(id, expectedVersion, func(device *models.MonitoringDevice, now time.Time, tx *gorm.DB) error {
+		if enum.DeviceStatus(device.Status).IsLinked() {
+			return ErrInvalidDeviceTransition
+		}
+		return setDeviceStatus(device, target)
+	})
+	if err != nil {
+		log.Printf("Failed to mark DeviceID %s as %s: %v", id, target, err)
+		return nil, err
+	}
+	log.Printf("DeviceID %s marked as %s", id, target)
+	return dto.MapMonitoringDeviceToDTO(device), nil
+}
+
+// MarkDeviceConnected moves a connecting device to In Use once it sends its first readings
+func (s *monitoringDeviceService) MarkDeviceConnected(id string) error {
+	connected, err := s.repo.MarkConnected(id, time.Now().UTC())
+	if err != nil {
+		log.Printf("Failed to mark DeviceID %s as connected: %v", id, err)
+		return err
+	}
+	if connected {
+		log.Println("Monitoring device connected with DeviceID:", id)
+		s.invalidateDevice(id, enum.DeviceStatusConnecting, enum.DeviceStatusInUse)
+	}
+	return nil
+}
+
+// GetDeviceAssignments returns the patients a device was linked to, most recent first
+func (s *monitoringDeviceService) GetDeviceAssignments(id string) ([]*dto.DeviceAssignmentDTO, error) {
+	if _, err := s.repo.GetMonitoringDeviceByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		log.Printf("Error retrieving monitoring device: %v", err)
+		return nil, err
+	}
+
+	assignments, err := s.assignmentRepo.GetAssignmentsByDeviceID(id)
+	if err != nil {
+		log.Printf("Error retrieving assignments of DeviceID %s: %v", id, err)
+		return nil, err
+	}
+	return dto.MapDeviceAssignmentsToDTOs(assignments), nil
+}
+
+// RunConnectingSweeper periodically frees the paired devices that never sent readings within the connecting timeout
+func (s *monitoringDeviceService) RunConnectingSweeper() {
+	log.Printf("Device connecting sweeper started, checking every %s", s.sweepInterval)
+	ticker := time.NewTicker(s.sweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.freeTimedOutDevices()
+	}
+}
+
+func (s *monitoringDeviceService) freeTimedOutDevices() {
+	devices, err := s.repo.GetStaleConnectingDevices(time.Now().UTC().Add(-s.connectingTimeout), connectingSweepBatchSize)
+	if err != nil {
+		log.Printf("Failed to fetch connecting devices: %v", err)
+		return
+	}
+
+	for _, stale := range devices {
+		// The version skips devices that connected or were changed since they were fetched
+		_, err := s.changeDevice(stale.DeviceID, &stale.Version, func(device *models.MonitoringDevice, now time.Time, tx *gorm.DB) error {
+			return s.unpairInTransaction(device, nil, enum.DeviceUnlinkReasonTimeout, now, tx)
+		})
+		if err != nil {
+			if !errors.Is(err, repository.ErrVersionConflict) {
+				log.Printf("Failed to free timed out DeviceID %s: %v", stale.DeviceID, err)
+			}
+			continue
+		}
+		log.Printf("DeviceID %s freed after not connecting within %s", stale.DeviceID, s.connectingTimeout)
+	}
+}
+
+// changeDevice applies a change to a device inside a transaction holding its lock, saves it with a version check
+// and invalidates the cached devices and patients it affects
+func (s *monitoringDeviceService) changeDevice(id string, expectedVersion *int, change func(device *models.MonitoringDevice, now time.Time, tx *gorm.DB) error) (*models.MonitoringDevice, error) {
+	tx := s.repo.BeginTransaction()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	device, err := s.repo.GetMonitoringDeviceInTransaction(id, tx)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		return nil, err
+	}
+
+	if expectedVersion != nil && *expectedVersion != device.Version {
+		tx.Rollback()
+		return nil, repository.ErrVersionConflict
+	}
+
+	previousStatus := enum.DeviceStatus(device.Status)
+	previousPatientID := device.PatientID
+	if err := change(device, time.Now().UTC(), tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := s.repo.UpdateMonitoringDeviceInTransaction(device, tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	s.invalidateDevice(device.DeviceID, previousStatus, enum.DeviceStatus(device.Status))
+	s.invalidatePatients(previousPatientID, device.PatientID)
+	return device, nil
+}
+
+// pairInTransaction links a free device to a patient without a device and opens its assignment
+func (s *monitoringDeviceService) pairInTransaction(device *models.MonitoringDevice, patientID uuid.UUID, linkedByID *uuid.UUID, userID *uuid.UUID, now time.Time, tx *gorm.DB) error {
+	if !enum.DeviceStatus(device.Status).CanTransitionTo(enum.DeviceStatusConnecting) {
+		return ErrInvalidDeviceTransition
+	}
+
+	linked, err := s.repo.GetDeviceByPatientIDInTransaction(patientID, tx)
+	if err != nil {
+		return err
+	}
+	if linked != nil {
+		return ErrPatientAlreadyHasDevice
+	}
+
+	device.Status = string(enum.DeviceStatusConnecting)
+	device.PatientID = &patientID
+	device.LinkedByID = linkedByID
+	device.LinkedAt = &now
+
+	return s.assignmentRepo.CreateInTransaction(&models.DeviceAssignment{
+		DeviceID:       device.DeviceID,
+		PatientID:      patientID,
+		LinkedByID:     linkedByID,
+		LinkedByUserID: userID,
+		LinkedAt:       now,
+	}, tx)
+}
+
+// unpairInTransaction unlinks a device from its patient, frees it and closes its assignment
+func (s *monitoringDeviceService) unpairInTransaction(device *models.MonitoringDevice, userID *uuid.UUID, reason enum.DeviceUnlinkReason, now time.Time, tx *gorm.DB) error {
+	if err := setDeviceStatus(device, enum.DeviceStatusFree); err != nil {
+		return err
+	}
+	device.PatientID = nil
+	device.LinkedByID = nil
+	device.LinkedAt = nil
+
+	return s.assignmentRepo.CloseOpenAssignmentInTransaction(device.DeviceID, now, userID, reason, tx)
+}
+
+// resolveLinkingDoctor returns the given doctor, or the doctor linked to the current user if any
+func (s *monitoringDeviceService) resolveLinkingDoctor(doctorID *uuid.UUID, userID *uuid.UUID) (*uuid.UUID, error) {
+	if doctorID != nil && *doctorID != uuid.Nil {
+		return doctorID, nil
+	}
+	if userID == nil {
+		return nil, nil
+	}
+
+	doctor, err := s.doctorRepo.GetDoctorByUserID(*userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		log.Printf("Failed to fetch doctor of UserID %s: %v", userID, err)
+		return nil, err
+	}
+	if doctor == nil {
+		return nil, nil
+	}
+	return &doctor.DoctorID, nil
+}
+
+// invalidateDevice drops the cached device and the cached lists of the statuses it moved between
+func (s *monitoringDeviceService) invalidateDevice(id string, statuses ...enum.DeviceStatus) {
+	keys := []string{"monitoring_device:" + id, "monitoring_devices:all"}
+	for _, status := range statuses {
+		keys = append(keys, "monitoring_devices:status:"+string(status))
+	}
+	_ = s.cache.Delete(context.Background(), keys...)
+}
+
+// invalidatePatients drops the cached patients whose device changed
+func (s *monitoringDeviceService) invalidatePatients(patientIDs ...*uuid.UUID) {
+	keys := []string{"patients:all"}
+	for _, patientID := range patientIDs {
+		if patientID != nil {
+			keys = append(keys, "patient:"+patientID.String())
+		}
+	}
+	_ = s.cache.Delete(context.Background(), keys...)
+}
+
+// setDeviceStatus moves a device to the given status if the transition is allowed
+func setDeviceStatus(device *models.MonitoringDevice, target enum.DeviceStatus) error {
+	if !enum.DeviceStatus(device.Status).CanTransitionTo(target) {
+		return ErrInvalidDeviceTransition
+	}
+	device.Status = string(target)
+	return nil
+}
+
+// sameUUID reports whether two optional IDs are equal, a missing ID only being equal to another missing one
+func sameUUID(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
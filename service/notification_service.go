@@ -0,0 +1,447 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/notifier"
+	"biometric-data-backend/repository"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	outboxBatchSize      = 50
+	outboxBaseRetryDelay = 10 * time.Second
+	outboxMaxRetryDelay  = 30 * time.Minute
+	// outboxLeaseDuration is how long a claimed entry is left to its dispatcher before another one may send it,
+	// longer than the sends of a whole batch
+	outboxLeaseDuration = 10 * time.Minute
+
+	receiptBatchSize = 1000
+	// Push receipts are kept by the push service for a day; tickets older than that will never get one
+	receiptExpiration = 24 * time.Hour
+)
+
+type NotificationService interface {
+	EnqueueInTransaction(notification *notifier.Notification, alertID *uuid.UUID, tx *gorm.DB) error
+	GetAlertDeliveries(alertID uuid.UUID) (*dto.AlertDeliveriesDTO, error)
+	RunDispatcher()
+	RunReceiptPoller()
+}
+
+type notificationService struct {
+	outboxRepo      repository.NotificationOutboxRepository
+	pushTicketRepo  repository.PushTicketRepository
+	phoneRepo       repository.PhoneRepository
+	notifiers       map[string]notifier.Notifier
+	interval        time.Duration
+	maxAttempts     int
+	receiptInterval time.Duration
+	receiptDelay    time.Duration
+}
+
+func NewNotificationService(
+	outboxRepo repository.NotificationOutboxRepository,
+	pushTicketRepo repository.PushTicketRepository,
+	phoneRepo repository.PhoneRepository,
+	notifiers []notifier.Notifier,
+	interval time.Duration,
+	maxAttempts int,
+	receiptInterval time.Duration,
+	receiptDelay time.Duration,
+) NotificationService {
+	notifiersByChannel := make(map[string]notifier.Notifier, len(notifiers))
+	for _, n := range notifiers {
+		notifiersByChannel[n.Channel()] = n
+	}
+	return &notificationService{
+		outboxRepo:      outboxRepo,
+		pushTicketRepo:  pushTicketRepo,
+		phoneRepo:       phoneRepo,
+		notifiers:       notifiersByChannel,
+		interval:        interval,
+		maxAttempts:     maxAttempts,
+		receiptInterval: receiptInterval,
+		receiptDelay:    receiptDelay,
+	}
+}
+
+// EnqueueInTransaction stores the notification in the outbox, once per configured channel.
+// The notification is delivered asynchronously by the dispatcher after the transaction commits.
+func (s *notificationService) EnqueueInTransaction(notification *notifier.Notification, alertID *uuid.UUID, tx *gorm.DB) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for channel := range s.notifiers {
+		entry := &models.NotificationOutbox{
+			AlertID:       alertID,
+			Channel:       channel,
+			Payload:       string(payload),
+			Status:        string(enum.OutboxStatusPending),
+			NextAttemptAt: now,
+		}
+		if err := s.outboxRepo.CreateInTransaction(entry, tx); err != nil {
+			log.Printf("Failed to enqueue %s notification: %v", channel, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RunDispatcher periodically delivers the pending notifications of the outbox. It blocks forever.
+func (s *notificationService) RunDispatcher() {
+	if len(s.notifiers) == 0 || s.interval <= 0 {
+		log.Println("No notification channels configured. Notification dispatcher disabled.")
+		return
+	}
+
+	log.Printf("Notification dispatcher started, checking every %s", s.interval)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.dispatchPendingNotifications()
+	}
+}
+
+// dispatchPendingNotifications claims the due entries of the outbox, then sends them outside of any transaction
+// and records the outcome of every entry in its own transaction, so a slow channel holds no lock and a failure
+// to record one entry does not send the others again
+func (s *notificationService) dispatchPendingNotifications() {
+	entries, err := s.claimDueNotifications()
+	if err != nil {
+		log.Printf("Failed to claim pending notifications: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		tickets := s.deliver(entry)
+		if err := s.recordAttempt(entry, tickets); err != nil {
+			log.Printf("Failed to record notification attempt for OutboxID %s: %v", entry.OutboxID, err)
+		}
+	}
+}
+
+// claimDueNotifications locks the due entries of the outbox and marks them as being sent
+func (s *notificationService) claimDueNotifications() ([]*models.NotificationOutbox, error) {
+	tx := s.outboxRepo.BeginTransaction()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	now := time.Now().UTC()
+	entries, err := s.outboxRepo.GetDueInTransaction(now, outboxBatchSize, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(entries) == 0 {
+		tx.Rollback()
+		return nil, nil
+	}
+
+	if err := s.outboxRepo.ClaimInTransaction(entries, now.Add(outboxLeaseDuration), tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// recordAttempt stores the outcome of the delivery of a claimed entry and its push tickets
+func (s *notificationService) recordAttempt(entry *models.NotificationOutbox, tickets []*notifier.PushTicket) error {
+	tx := s.outboxRepo.BeginTransaction()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	claimed, err := s.outboxRepo.RecordAttemptInTransaction(entry, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if !claimed {
+		// The lease expired and another dispatcher took the entry over
+		tx.Rollback()
+		log.Printf("Notification OutboxID %s was no longer claimed, attempt not recorded", entry.OutboxID)
+		return nil
+	}
+	if err := s.recordPushTickets(entry, tickets, tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// deliver sends an outbox entry through its channel and updates its status, scheduling a retry on failure.
+// It returns the push tickets issued for the delivery, if the channel issues any. When only part of the push tokens
+// were delivered, they are removed from the entry so a retry does not notify them again.
+func (s *notificationService) deliver(entry *models.NotificationOutbox) []*notifier.PushTicket {
+	now := time.Now().UTC()
+	entry.Attempts++
+
+	tickets, err := s.send(entry)
+	if err == nil {
+		entry.Status = string(enum.OutboxStatusSent)
+		entry.SentAt = &now
+		entry.LastError = ""
+		return tickets
+	}
+
+	log.Printf("Failed to deliver %s notification (attempt %d) for OutboxID %s: %v", entry.Channel, entry.Attempts, entry.OutboxID, err)
+	entry.LastError = truncate(err.Error(), 255)
+	if len(tickets) > 0 {
+		if err := removeDeliveredTokens(entry, tickets); err != nil {
+			log.Printf("Failed to remove delivered push tokens from OutboxID %s: %v", entry.OutboxID, err)
+		}
+	}
+
+	if entry.Attempts >= s.maxAttempts {
+		entry.Status = string(enum.OutboxStatusFailed)
+		return tickets
+	}
+	entry.Status = string(enum.OutboxStatusPending)
+	entry.NextAttemptAt = now.Add(retryDelay(entry.Attempts))
+	return tickets
+}
+
+// removeDeliveredTokens keeps in the payload of an outbox entry only the push tokens without a ticket
+func removeDeliveredTokens(entry *models.NotificationOutbox, tickets []*notifier.PushTicket) error {
+	var notification notifier.Notification
+	if err := json.Unmarshal([]byte(entry.Payload), &notification); err != nil {
+		return fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+
+	delivered := make(map[string]bool, len(tickets))
+	for _, ticket := range tickets {
+		delivered[ticket.PushToken] = true
+	}
+	pending := make([]string, 0, len(notification.PushTokens))
+	for _, token := range notification.PushTokens {
+		if !delivered[token] {
+			pending = append(pending, token)
+		}
+	}
+	notification.PushTokens = pending
+
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+	entry.Payload = string(payload)
+	return nil
+}
+
+func (s *notificationService) send(entry *models.NotificationOutbox) ([]*notifier.PushTicket, error) {
+	n, ok := s.notifiers[entry.Channel]
+	if !ok {
+		return nil, fmt.Errorf("notification channel %q is not configured", entry.Channel)
+	}
+
+	var notification notifier.Notification
+	if err := json.Unmarshal([]byte(entry.Payload), &notification); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notification: %w", err)
+	}
+
+	if ticketNotifier, ok := n.(notifier.TicketNotifier); ok {
+		return ticketNotifier.SendWithTickets(&notification)
+	}
+	return nil, n.Send(&notification)
+}
+
+// recordPushTickets stores the tickets of a delivered outbox entry and deactivates the tokens rejected right away
+func (s *notificationService) recordPushTickets(entry *models.NotificationOutbox, tickets []*notifier.PushTicket, tx *gorm.DB) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	pushTickets := make([]*models.PushTicket, 0, len(tickets))
+	var unregisteredTokens []string
+	for _, ticket := range tickets {
+		pushTicket := &models.PushTicket{
+			OutboxID:     entry.OutboxID,
+			AlertID:      entry.AlertID,
+			PushToken:    ticket.PushToken,
+			ExpoTicketID: ticket.TicketID,
+			Status:       string(enum.PushTicketStatusPending),
+		}
+		if ticket.Status != notifier.PushStatusOK {
+			pushTicket.Status = string(enum.PushTicketStatusFailed)
+			pushTicket.ErrorCode = ticket.Error
+			pushTicket.ErrorMessage = truncate(ticket.Message, 255)
+		}
+		if ticket.Error == notifier.ErrorDeviceNotRegistered {
+			unregisteredTokens = append(unregisteredTokens, ticket.PushToken)
+		}
+		pushTickets = append(pushTickets, pushTicket)
+	}
+
+	if err := s.pushTicketRepo.CreateBatchInTransaction(pushTickets, tx); err != nil {
+		return err
+	}
+	return s.deactivatePushTokens(unregisteredTokens)
+}
+
+// RunReceiptPoller periodically checks the receipts of the issued push tickets. It blocks forever.
+func (s *notificationService) RunReceiptPoller() {
+	checker := s.receiptChecker()
+	if checker == nil || s.receiptInterval <= 0 {
+		log.Println("No push channel issuing receipts configured. Push receipt poller disabled.")
+		return
+	}
+
+	log.Printf("Push receipt poller started, checking every %s", s.receiptInterval)
+	ticker := time.NewTicker(s.receiptInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.checkPushReceipts(checker)
+	}
+}
+
+func (s *notificationService) receiptChecker() notifier.ReceiptChecker {
+	for _, n := range s.notifiers {
+		if checker, ok := n.(notifier.ReceiptChecker); ok {
+			return checker
+		}
+	}
+	return nil
+}
+
+func (s *notificationService) checkPushReceipts(checker notifier.ReceiptChecker) {
+	tx := s.pushTicketRepo.BeginTransaction()
+	if tx.Error != nil {
+		log.Printf("Failed to start push receipt transaction: %v", tx.Error)
+		return
+	}
+
+	now := time.Now().UTC()
+	tickets, err := s.pushTicketRepo.GetPendingReceiptsInTransaction(now.Add(-s.receiptDelay), receiptBatchSize, tx)
+	if err != nil {
+		log.Printf("Failed to fetch pending push receipts: %v", err)
+		tx.Rollback()
+		return
+	}
+	if len(tickets) == 0 {
+		tx.Rollback()
+		return
+	}
+
+	ticketIDs := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		ticketIDs = append(ticketIDs, ticket.ExpoTicketID)
+	}
+
+	receipts, err := checker.GetReceipts(ticketIDs)
+	if err != nil {
+		log.Printf("Failed to fetch push receipts: %v", err)
+		tx.Rollback()
+		return
+	}
+
+	var unregisteredTokens []string
+	for _, ticket := range tickets {
+		receipt, ok := receipts[ticket.ExpoTicketID]
+		switch {
+		case ok && receipt.Status == notifier.PushStatusOK:
+			ticket.Status = string(enum.PushTicketStatusDelivered)
+		case ok:
+			ticket.Status = string(enum.PushTicketStatusFailed)
+			ticket.ErrorCode = receipt.Error
+			ticket.ErrorMessage = truncate(receipt.Message, 255)
+			if receipt.Error == notifier.ErrorDeviceNotRegistered {
+				unregisteredTokens = append(unregisteredTokens, ticket.PushToken)
+			}
+		case now.Sub(ticket.CreatedAt) > receiptExpiration:
+			ticket.Status = string(enum.PushTicketStatusFailed)
+			ticket.ErrorCode = "ReceiptExpired"
+		default:
+			// The receipt is not ready yet
+			continue
+		}
+
+		ticket.ReceiptCheckedAt = &now
+		if err := s.pushTicketRepo.UpdateReceiptInTransaction(ticket, tx); err != nil {
+			log.Printf("Failed to update push ticket %s: %v", ticket.PushTicketID, err)
+			tx.Rollback()
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Failed to commit push receipt transaction: %v", err)
+		return
+	}
+
+	if err := s.deactivatePushTokens(unregisteredTokens); err != nil {
+		log.Printf("Failed to deactivate unregistered push tokens: %v", err)
+	}
+}
+
+func (s *notificationService) deactivatePushTokens(tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	if err := s.phoneRepo.DeactivateByExponentPushTokens(tokens); err != nil {
+		return err
+	}
+	log.Printf("Deactivated %d phones whose push token is no longer registered", len(tokens))
+	return nil
+}
+
+// GetAlertDeliveries retrieves the delivery status of every notification sent for an alert
+func (s *notificationService) GetAlertDeliveries(alertID uuid.UUID) (*dto.AlertDeliveriesDTO, error) {
+	entries, err := s.outboxRepo.GetOutboxByAlertID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving notifications: %v", err)
+		return nil, err
+	}
+
+	tickets, err := s.pushTicketRepo.GetPushTicketsByAlertID(alertID)
+	if err != nil {
+		log.Printf("Error retrieving push tickets: %v", err)
+		return nil, err
+	}
+
+	tokens := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		tokens = append(tokens, ticket.PushToken)
+	}
+	userIDs, err := s.phoneRepo.GetUserIDsByExponentPushTokens(tokens)
+	if err != nil {
+		log.Printf("Error retrieving phone owners: %v", err)
+		return nil, err
+	}
+
+	return dto.MapAlertDeliveriesToDTO(alertID, entries, tickets, userIDs), nil
+}
+
+// retryDelay returns an exponential backoff delay for the given number of attempts
+func retryDelay(attempts int) time.Duration {
+	delay := outboxBaseRetryDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= outboxMaxRetryDelay {
+			return outboxMaxRetryDelay
+		}
+	}
+	return delay
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
@@ -19,7 +19,8 @@ type PatientService interface {
 	GetPatientByID(id uuid.UUID) (*dto.PatientDTO, error)
 	GetPatientByDNI(dni string) (*dto.PatientDTO, error)
 	GetAllPatients(page int, limit int, filters dto.PatientFilter) ([]*dto.PatientDTO, int, error)
-	UpdatePatient(id uuid.UUID, patientDTO *dto.PatientUpdateDTO) error
+	GetPatientsPage(request dto.PageRequest, filters dto.PatientFilter) (*dto.PageDTO[dto.PatientDTO], error)
+	UpdatePatient(id uuid.UUID, patientDTO *dto.PatientUpdateDTO, expectedVersion *int) error
 	DeletePatient(id uuid.UUID) error
 }
 
@@ -157,7 +158,8 @@ func (s *patientService) GetAllPatients(page int, limit int, filters dto.Patient
 	return patients, totalCount, nil
 }
 
-func (s *patientService) UpdatePatient(id uuid.UUID, patientDTO *dto.PatientUpdateDTO) error {
+// UpdatePatient updates a patient if it was not modified since it was read, or since the expected version if given
+func (s *patientService) UpdatePatient(id uuid.UUID, patientDTO *dto.PatientUpdateDTO, expectedVersion *int) error {
 	log.Println("Updating patient with PatientID:", id)
 
 	patient, err := s.repo.GetByID(id, "patient_id")
@@ -170,8 +172,15 @@ func (s *patientService) UpdatePatient(id uuid.UUID, patientDTO *dto.PatientUpda
 		return gorm.ErrRecordNotFound
 	}
 
+	version := patient.Version
+	if expectedVersion != nil && *expectedVersion != version {
+		log.Printf("Rejected update of PatientID %s: version %d expected, found %d", id, *expectedVersion, version)
+		return repository.ErrVersionConflict
+	}
+
 	patient = dto.MapUpdateDTOToPatient(patientDTO, patient)
-	err = s.repo.Update(patient, "patient_id", id)
+	patient.Version = version + 1
+	err = s.repo.UpdateWithVersion(patient, "patient_id", id, version)
 	if err != nil {
 		log.Printf("Failed to update patient: %v", err)
 		return err
@@ -200,3 +209,13 @@ func (s *patientService) DeletePatient(id uuid.UUID) error {
 	_ = s.cache.Delete(context.Background(), "patient:"+id.String(), "patients:all")
 	return nil
 }
+
+// GetPatientsPage returns a cursor paginated page of the patients matching the filters
+func (s *patientService) GetPatientsPage(request dto.PageRequest, filters dto.PatientFilter) (*dto.PageDTO[dto.PatientDTO], error) {
+	page, err := s.repo.GetPatientsPage(request, filters)
+	if err != nil {
+		log.Printf("Error retrieving page of patients: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapPatientsToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
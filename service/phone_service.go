@@ -4,43 +4,115 @@ import (
 	"biometric-data-backend/models/dto"
 	"biometric-data-backend/repository"
 	"log"
+
+	"github.com/google/uuid"
 )
 
 type PhoneService interface {
-	CreatePhone(phoneDTO *dto.PhoneCreateDTO) error
+	RegisterPhone(phoneDTO *dto.PhoneCreateDTO, userID uuid.UUID) error
+	UnregisterPhone(phoneDTO *dto.PhoneCreateDTO, userID uuid.UUID) error
+	GetAlertPushTokens(patientID uuid.UUID) ([]string, error)
+	GetAlertTargetPushTokens(patientID uuid.UUID) ([]string, error)
+	GetPushTokensByUserIDs(userIDs []uuid.UUID) ([]string, error)
 }
 
 type phoneService struct {
-	phoneRepo repository.PhoneRepository
+	phoneRepo  repository.PhoneRepository
+	doctorRepo repository.DoctorRepository
 }
 
-func NewPhoneService(phoneRepo repository.PhoneRepository) PhoneService {
+func NewPhoneService(phoneRepo repository.PhoneRepository, doctorRepo repository.DoctorRepository) PhoneService {
 	return &phoneService{
-		phoneRepo: phoneRepo,
+		phoneRepo:  phoneRepo,
+		doctorRepo: doctorRepo,
 	}
 }
 
-func (s *phoneService) CreatePhone(phoneDTO *dto.PhoneCreateDTO) error {
-	// Check if ExponentPushToken already exists
-	exists, err := s.phoneRepo.ExistsByExponentPushToken(phoneDTO.ExponentPushToken)
+// RegisterPhone links a push token to the authenticated user
+func (s *phoneService) RegisterPhone(phoneDTO *dto.PhoneCreateDTO, userID uuid.UUID) error {
+	phone := dto.MapCreateDTOToPhone(phoneDTO)
+	phone.UserID = &userID
+
+	err := s.phoneRepo.Register(phone)
 	if err != nil {
-		log.Printf("Failed to check ExponentPushToken: %v", err)
+		log.Printf("Failed to register phone: %v", err)
 		return err
 	}
 
-	if exists {
-		log.Println("Phone with the same ExponentPushToken already exists, skipping creation.")
-		return nil
-	}
+	log.Println("Phone registered successfully for UserID:", userID)
+	return nil
+}
 
-	// Map DTO to model and create the phone
-	phone := dto.MapCreateDTOToPhone(phoneDTO)
-	err = s.phoneRepo.Create(phone)
+// UnregisterPhone stops sending notifications to a push token of the authenticated user
+func (s *phoneService) UnregisterPhone(phoneDTO *dto.PhoneCreateDTO, userID uuid.UUID) error {
+	unregistered, err := s.phoneRepo.Unregister(phoneDTO.ExponentPushToken, userID)
 	if err != nil {
-		log.Printf("Failed to create phone: %v", err)
+		log.Printf("Failed to unregister phone: %v", err)
 		return err
 	}
 
-	log.Println("Phone created successfully with PhoneID:", phone.PhoneID)
+	if !unregistered {
+		log.Println("No active phone found with the given ExponentPushToken for UserID:", userID)
+		return nil
+	}
+
+	log.Println("Phone unregistered successfully for UserID:", userID)
 	return nil
 }
+
+// GetAlertPushTokens retrieves the push tokens a new alert of a patient is sent to, those of
+// GetAlertTargetPushTokens. If none of them has a registered phone, the tokens of every active phone are returned
+// so the alert reaches someone.
+func (s *phoneService) GetAlertPushTokens(patientID uuid.UUID) ([]string, error) {
+	tokens, err := s.GetAlertTargetPushTokens(patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) > 0 {
+		return tokens, nil
+	}
+
+	log.Println("No registered phones for the targeted users, broadcasting to every phone")
+	return s.phoneRepo.GetPushTokens()
+}
+
+// GetAlertTargetPushTokens retrieves the push tokens of the care team of a patient, of the staff of its ward and of
+// the on-call doctors
+func (s *phoneService) GetAlertTargetPushTokens(patientID uuid.UUID) ([]string, error) {
+	careTeam, err := s.doctorRepo.GetDoctorsByPatientID(patientID)
+	if err != nil {
+		log.Printf("Failed to fetch care team: %v", err)
+		return nil, err
+	}
+
+	wardStaff, err := s.doctorRepo.GetWardStaffByPatientID(patientID)
+	if err != nil {
+		log.Printf("Failed to fetch ward staff: %v", err)
+		return nil, err
+	}
+
+	onCall, err := s.doctorRepo.GetOnCallDoctors()
+	if err != nil {
+		log.Printf("Failed to fetch on-call doctors: %v", err)
+		return nil, err
+	}
+
+	doctors := append(append(careTeam, wardStaff...), onCall...)
+	userIDs := make([]uuid.UUID, 0, len(doctors))
+	for _, doctor := range doctors {
+		userIDs = append(userIDs, doctor.UserID)
+	}
+
+	return s.GetPushTokensByUserIDs(userIDs)
+}
+
+// GetPushTokensByUserIDs retrieves the push tokens of the active phones registered by the given users
+func (s *phoneService) GetPushTokensByUserIDs(userIDs []uuid.UUID) ([]string, error) {
+	tokens, err := s.phoneRepo.GetPushTokensByUserIDs(userIDs)
+	if err != nil {
+		log.Printf("Failed to fetch push tokens: %v", err)
+		return nil, err
+	}
+	return tokens, nil
+}
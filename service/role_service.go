@@ -17,6 +17,7 @@ type RoleService interface {
 	CreateRole(roleDTO *dto.RoleCreateDTO) error
 	GetRoleByID(id uuid.UUID) (*dto.RoleDTO, error)
 	GetAllRoles() ([]*dto.RoleDTO, error)
+	GetRolesPage(request dto.PageRequest) (*dto.PageDTO[dto.RoleDTO], error)
 	UpdateRole(id uuid.UUID, roleDTO *dto.RoleUpdateDTO) error
 	DeleteRole(id uuid.UUID) error
 	GetRolesByNames(roleNames []string) ([]*dto.RoleDTO, error)
@@ -186,3 +187,13 @@ func (s *roleService) GetRolesByNames(roleNames []string) ([]*dto.RoleDTO, error
 	log.Println("Roles fetched successfully by names")
 	return roles, nil
 }
+
+// GetRolesPage returns a cursor paginated page of the roles
+func (s *roleService) GetRolesPage(request dto.PageRequest) (*dto.PageDTO[dto.RoleDTO], error) {
+	page, err := s.repo.GetPage(request, nil)
+	if err != nil {
+		log.Printf("Error retrieving page of roles: %v", err)
+		return nil, err
+	}
+	return dto.NewPageDTO(dto.MapRolesToDTOs(page.Items), page.NextCursor, page.Total), nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ruleStateKey struct {
+	ruleID    uuid.UUID
+	patientID uuid.UUID
+}
+
+// ruleState tracks a rule for a single patient between batches of readings
+type ruleState struct {
+	// breachStart is when the current uninterrupted breach started, zero if the metric is within range
+	breachStart time.Time
+	// active is set once the rule fires and cleared when the metric recovers beyond the hysteresis band
+	active      bool
+	lastFiredAt time.Time
+	lastSeenAt  time.Time
+}
+
+// ruleEngine evaluates threshold rules against the readings of each patient.
+// A rule fires when the metric stays beyond the threshold for the rule duration (debounce). It cannot fire again
+// for that patient until the metric recovers past the threshold plus the hysteresis and the cooldown has elapsed.
+// The state lives in memory: it is restored from the stored readings and alerts when a patient is first seen, and
+// forgotten once the patient sends no reading for a while.
+type ruleEngine struct {
+	mu          sync.Mutex
+	states      map[ruleStateKey]*ruleState
+	lastEvicted time.Time
+}
+
+func newRuleEngine() *ruleEngine {
+	return &ruleEngine{
+		states: make(map[ruleStateKey]*ruleState),
+	}
+}
+
+// evaluate feeds a reading to a rule and reports whether the rule fires.
+// Readings must be fed in chronological order; older readings than the last one seen are ignored, and so are
+// readings without the metric of the rule.
+func (e *ruleEngine) evaluate(rule *models.ThresholdRule, reading *models.VitalReading) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.state(rule.RuleID, reading.PatientID).feed(rule, reading, true)
+}
+
+// hasState reports whether the state of a rule for a patient is in memory
+func (e *ruleEngine) hasState(ruleID uuid.UUID, patientID uuid.UUID) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, ok := e.states[ruleStateKey{ruleID: ruleID, patientID: patientID}]
+	return ok
+}
+
+// restore rebuilds the state of a rule for a patient from the time the rule last fired for the patient, zero if
+// never, and the readings that came before the ones about to be evaluated. From the time it fired, the rule is
+// active until the readings show the metric recovered. Replayed readings never fire the rule.
+func (e *ruleEngine) restore(rule *models.ThresholdRule, patientID uuid.UUID, lastFiredAt time.Time, readings []*models.VitalReading) {
+	state := &ruleState{lastFiredAt: lastFiredAt}
+	for _, reading := range readings {
+		state.markFired(reading.RecordedAt)
+		state.feed(rule, reading, false)
+	}
+	state.markFired(time.Now())
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.states[ruleStateKey{ruleID: rule.RuleID, patientID: patientID}] = state
+}
+
+// evictIdle forgets the states of the patients without any reading since the given time, e.g. discharged patients
+// or rules no longer enabled. It runs at most once per interval.
+func (e *ruleEngine) evictIdle(idleSince time.Time, interval time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(e.lastEvicted) < interval {
+		return
+	}
+	e.lastEvicted = now
+
+	for key, state := range e.states {
+		if state.lastSeenAt.Before(idleSince) {
+			delete(e.states, key)
+		}
+	}
+}
+
+// reset forgets the state of a rule, e.g. after it was updated or deleted
+func (e *ruleEngine) reset(ruleID uuid.UUID) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for key := range e.states {
+		if key.ruleID == ruleID {
+			delete(e.states, key)
+		}
+	}
+}
+
+func (e *ruleEngine) state(ruleID uuid.UUID, patientID uuid.UUID) *ruleState {
+	key := ruleStateKey{ruleID: ruleID, patientID: patientID}
+	state, ok := e.states[key]
+	if !ok {
+		state = &ruleState{}
+		e.states[key] = state
+	}
+	return state
+}
+
+// markFired activates a restored rule once the replay reaches the time it last fired
+func (state *ruleState) markFired(now time.Time) {
+	if !state.lastFiredAt.IsZero() && state.lastSeenAt.Before(state.lastFiredAt) && !now.Before(state.lastFiredAt) {
+		state.active = true
+		state.breachStart = time.Time{}
+	}
+}
+
+// feed advances the state with a reading and reports whether the rule fires. Without canFire, a reading that
+// would fire the rule leaves it breached, so the next reading fires it.
+func (state *ruleState) feed(rule *models.ThresholdRule, reading *models.VitalReading, canFire bool) bool {
+	value, ok := ruleMetricValue(rule, reading)
+	if !ok {
+		return false
+	}
+
+	if !reading.RecordedAt.After(state.lastSeenAt) {
+		return false
+	}
+	state.lastSeenAt = reading.RecordedAt
+
+	if state.active {
+		if ruleRecovered(rule, value) {
+			state.active = false
+			state.breachStart = time.Time{}
+		}
+		return false
+	}
+
+	if !ruleBreached(rule, value) {
+		state.breachStart = time.Time{}
+		return false
+	}
+
+	if state.breachStart.IsZero() {
+		state.breachStart = reading.RecordedAt
+	}
+	if reading.RecordedAt.Sub(state.breachStart) < time.Duration(rule.DurationSeconds)*time.Second {
+		return false
+	}
+	if !state.lastFiredAt.IsZero() && reading.RecordedAt.Sub(state.lastFiredAt) < time.Duration(rule.CooldownSeconds)*time.Second {
+		return false
+	}
+	if !canFire {
+		return false
+	}
+
+	state.active = true
+	state.lastFiredAt = reading.RecordedAt
+	return true
+}
+
+// applicableRules returns the rules evaluated for a patient: its own rules, and the global rules of the metrics
+// without a rule of its own, which the patient specific rules override
+func applicableRules(rules []*models.ThresholdRule, patientID uuid.UUID) []*models.ThresholdRule {
+	ownMetrics := make(map[string]bool)
+	for _, rule := range rules {
+		if rule.PatientID != nil && *rule.PatientID == patientID {
+			ownMetrics[rule.Metric] = true
+		}
+	}
+
+	applicable := make([]*models.ThresholdRule, 0, len(rules))
+	for _, rule := range rules {
+		switch {
+		case rule.PatientID != nil && *rule.PatientID == patientID:
+			applicable = append(applicable, rule)
+		case rule.PatientID == nil && !ownMetrics[rule.Metric]:
+			applicable = append(applicable, rule)
+		}
+	}
+	return applicable
+}
+
+// ruleMetricValue returns the value of the metric of a rule in a reading, if the reading carries it
+func ruleMetricValue(rule *models.ThresholdRule, reading *models.VitalReading) (float64, bool) {
+	value := reading.O2Saturation
+	if enum.RuleMetric(rule.Metric) == enum.RuleMetricHeartRate {
+		value = reading.HeartRate
+	}
+	if value == nil {
+		return 0, false
+	}
+	return *value, true
+}
+
+func ruleBreached(rule *models.ThresholdRule, value float64) bool {
+	switch enum.RuleOperator(rule.Operator) {
+	case enum.RuleOperatorLessThan:
+		return value < rule.Threshold
+	case enum.RuleOperatorLessOrEqual:
+		return value <= rule.Threshold
+	case enum.RuleOperatorGreaterThan:
+		return value > rule.Threshold
+	case enum.RuleOperatorGreaterOrEqual:
+		return value >= rule.Threshold
+	}
+	return false
+}
+
+// ruleRecovered reports whether the value moved back past the threshold by at least the hysteresis
+func ruleRecovered(rule *models.ThresholdRule, value float64) bool {
+	switch enum.RuleOperator(rule.Operator) {
+	case enum.RuleOperatorLessThan, enum.RuleOperatorLessOrEqual:
+		return value >= rule.Threshold+rule.Hysteresis && !ruleBreached(rule, value)
+	case enum.RuleOperatorGreaterThan, enum.RuleOperatorGreaterOrEqual:
+		return value <= rule.Threshold-rule.Hysteresis && !ruleBreached(rule, value)
+	}
+	return true
+}
@@ -0,0 +1,369 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/enum"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ruleTestStart = time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// ruleTestReading is an O2 saturation reading taken the given number of seconds after ruleTestStart
+type ruleTestReading struct {
+	second int
+	value  float64
+}
+
+func newO2Reading(patientID uuid.UUID, second int, value float64) *models.VitalReading {
+	return &models.VitalReading{
+		PatientID:    patientID,
+		RecordedAt:   ruleTestStart.Add(time.Duration(second) * time.Second),
+		O2Saturation: &value,
+	}
+}
+
+func newLowO2Rule(durationSeconds int, cooldownSeconds int, hysteresis float64) *models.ThresholdRule {
+	return &models.ThresholdRule{
+		RuleID:          uuid.New(),
+		Metric:          string(enum.RuleMetricO2Saturation),
+		Operator:        string(enum.RuleOperatorLessThan),
+		Threshold:       90,
+		Hysteresis:      hysteresis,
+		DurationSeconds: durationSeconds,
+		CooldownSeconds: cooldownSeconds,
+	}
+}
+
+func TestRuleEngineEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     *models.ThresholdRule
+		readings []ruleTestReading
+		// fired lists the seconds of the readings that fire the rule
+		fired []int
+	}{
+		{
+			name:     "fires on the first breach without duration",
+			rule:     newLowO2Rule(0, 0, 0),
+			readings: []ruleTestReading{{0, 95}, {10, 88}},
+			fired:    []int{10},
+		},
+		{
+			name:     "never fires within range",
+			rule:     newLowO2Rule(0, 0, 0),
+			readings: []ruleTestReading{{0, 95}, {10, 90}, {20, 91}},
+		},
+		{
+			name:     "waits for the breach to last the duration",
+			rule:     newLowO2Rule(30, 0, 0),
+			readings: []ruleTestReading{{0, 88}, {10, 87}, {29, 86}, {30, 86}},
+			fired:    []int{30},
+		},
+		{
+			name:     "an interrupted breach restarts the duration",
+			rule:     newLowO2Rule(30, 0, 0),
+			readings: []ruleTestReading{{0, 88}, {20, 92}, {25, 88}, {50, 88}, {55, 88}},
+			fired:    []int{55},
+		},
+		{
+			name:     "fires once per breach",
+			rule:     newLowO2Rule(0, 0, 0),
+			readings: []ruleTestReading{{0, 88}, {10, 85}, {20, 80}},
+			fired:    []int{0},
+		},
+		{
+			name:     "recovery within the hysteresis does not rearm the rule",
+			rule:     newLowO2Rule(0, 0, 3),
+			readings: []ruleTestReading{{0, 88}, {10, 91}, {20, 88}},
+			fired:    []int{0},
+		},
+		{
+			name:     "recovery beyond the hysteresis rearms the rule",
+			rule:     newLowO2Rule(0, 0, 3),
+			readings: []ruleTestReading{{0, 88}, {10, 93}, {20, 88}},
+			fired:    []int{0, 20},
+		},
+		{
+			name:     "cooldown holds back a new breach",
+			rule:     newLowO2Rule(0, 60, 0),
+			readings: []ruleTestReading{{0, 88}, {10, 95}, {20, 88}, {59, 88}, {60, 88}},
+			fired:    []int{0, 60},
+		},
+		{
+			name:     "older readings are ignored",
+			rule:     newLowO2Rule(0, 0, 0),
+			readings: []ruleTestReading{{0, 95}, {40, 95}, {35, 88}, {40, 88}, {50, 88}},
+			fired:    []int{50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRuleEngine()
+			patientID := uuid.New()
+
+			var fired []int
+			for _, reading := range tt.readings {
+				if engine.evaluate(tt.rule, newO2Reading(patientID, reading.second, reading.value)) {
+					fired = append(fired, reading.second)
+				}
+			}
+
+			if !equalInts(fired, tt.fired) {
+				t.Errorf("fired at %v, want %v", fired, tt.fired)
+			}
+		})
+	}
+}
+
+func TestRuleEngineIgnoresReadingsWithoutTheMetric(t *testing.T) {
+	engine := newRuleEngine()
+	rule := newLowO2Rule(0, 0, 0)
+	rule.Metric = string(enum.RuleMetricHeartRate)
+	rule.Operator = string(enum.RuleOperatorGreaterThan)
+	rule.Threshold = 120
+
+	patientID := uuid.New()
+	if engine.evaluate(rule, newO2Reading(patientID, 0, 50)) {
+		t.Fatalf("fired on a reading without heart rate")
+	}
+
+	heartRate := 130.0
+	reading := &models.VitalReading{PatientID: patientID, RecordedAt: ruleTestStart.Add(time.Second), HeartRate: &heartRate}
+	if !engine.evaluate(rule, reading) {
+		t.Errorf("did not fire on a heart rate above the threshold")
+	}
+}
+
+func TestRuleEngineKeepsPatientsApart(t *testing.T) {
+	engine := newRuleEngine()
+	rule := newLowO2Rule(0, 0, 0)
+
+	first, second := uuid.New(), uuid.New()
+	if !engine.evaluate(rule, newO2Reading(first, 0, 88)) {
+		t.Fatalf("did not fire for the first patient")
+	}
+	if !engine.evaluate(rule, newO2Reading(second, 0, 88)) {
+		t.Errorf("did not fire for the second patient while the first one is active")
+	}
+}
+
+func TestRuleOperators(t *testing.T) {
+	tests := []struct {
+		operator  enum.RuleOperator
+		value     float64
+		breached  bool
+		recovered bool
+	}{
+		{enum.RuleOperatorLessThan, 89, true, false},
+		{enum.RuleOperatorLessThan, 90, false, false},
+		{enum.RuleOperatorLessThan, 92, false, true},
+		{enum.RuleOperatorLessOrEqual, 90, true, false},
+		{enum.RuleOperatorLessOrEqual, 92, false, true},
+		{enum.RuleOperatorGreaterThan, 91, true, false},
+		{enum.RuleOperatorGreaterThan, 90, false, false},
+		{enum.RuleOperatorGreaterThan, 88, false, true},
+		{enum.RuleOperatorGreaterOrEqual, 90, true, false},
+		{enum.RuleOperatorGreaterOrEqual, 88, false, true},
+	}
+
+	for _, tt := range tests {
+		rule := newLowO2Rule(0, 0, 2)
+		rule.Operator = string(tt.operator)
+
+		if got := ruleBreached(rule, tt.value); got != tt.breached {
+			t.Errorf("%s %v: breached %v, want %v", tt.operator, tt.value, got, tt.breached)
+		}
+		if got := ruleRecovered(rule, tt.value); got != tt.recovered {
+			t.Errorf("%s %v: recovered %v, want %v", tt.operator, tt.value, got, tt.recovered)
+		}
+	}
+}
+
+func TestRuleEngineRestore(t *testing.T) {
+	tests := []struct {
+		name string
+		rule *models.ThresholdRule
+		// lastFired is the second the rule last fired, negative if never
+		lastFired int
+		history   []ruleTestReading
+		next      ruleTestReading
+		wantFired bool
+	}{
+		{
+			name:      "a breach in the history is not fired again",
+			rule:      newLowO2Rule(0, 0, 0),
+			lastFired: 10,
+			history:   []ruleTestReading{{0, 95}, {10, 88}},
+			next:      ruleTestReading{20, 88},
+		},
+		{
+			name:      "a recovery after the last alert rearms the rule",
+			rule:      newLowO2Rule(0, 0, 0),
+			lastFired: 10,
+			history:   []ruleTestReading{{10, 88}, {20, 95}},
+			next:      ruleTestReading{30, 88},
+			wantFired: true,
+		},
+		{
+			name:      "the duration counts the breach in the history",
+			rule:      newLowO2Rule(30, 0, 0),
+			lastFired: -1,
+			history:   []ruleTestReading{{0, 88}, {20, 88}},
+			next:      ruleTestReading{30, 88},
+			wantFired: true,
+		},
+		{
+			name:      "a breach that would have fired during the replay fires on the next reading",
+			rule:      newLowO2Rule(0, 0, 0),
+			lastFired: -1,
+			history:   []ruleTestReading{{0, 88}},
+			next:      ruleTestReading{10, 88},
+			wantFired: true,
+		},
+		{
+			name:      "the cooldown counts from the last alert",
+			rule:      newLowO2Rule(0, 60, 0),
+			lastFired: 10,
+			history:   []ruleTestReading{{10, 88}, {20, 95}},
+			next:      ruleTestReading{30, 88},
+		},
+		{
+			name:      "an alert without history keeps the rule active",
+			rule:      newLowO2Rule(0, 0, 0),
+			lastFired: 10,
+			next:      ruleTestReading{20, 88},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newRuleEngine()
+			patientID := uuid.New()
+
+			var lastFiredAt time.Time
+			if tt.lastFired >= 0 {
+				lastFiredAt = ruleTestStart.Add(time.Duration(tt.lastFired) * time.Second)
+			}
+			history := make([]*models.VitalReading, 0, len(tt.history))
+			for _, reading := range tt.history {
+				history = append(history, newO2Reading(patientID, reading.second, reading.value))
+			}
+
+			engine.restore(tt.rule, patientID, lastFiredAt, history)
+			if !engine.hasState(tt.rule.RuleID, patientID) {
+				t.Fatalf("state not restored")
+			}
+
+			if got := engine.evaluate(tt.rule, newO2Reading(patientID, tt.next.second, tt.next.value)); got != tt.wantFired {
+				t.Errorf("fired %v, want %v", got, tt.wantFired)
+			}
+		})
+	}
+}
+
+func TestRuleEngineEvictIdle(t *testing.T) {
+	engine := newRuleEngine()
+	rule := newLowO2Rule(0, 0, 0)
+
+	idle, recent := uuid.New(), uuid.New()
+	engine.evaluate(rule, newO2Reading(idle, 0, 95))
+	engine.evaluate(rule, newO2Reading(recent, 100, 95))
+
+	engine.evictIdle(ruleTestStart.Add(50*time.Second), time.Hour)
+	if engine.hasState(rule.RuleID, idle) {
+		t.Errorf("idle patient not evicted")
+	}
+	if !engine.hasState(rule.RuleID, recent) {
+		t.Errorf("recent patient evicted")
+	}
+
+	// A second eviction within the interval is skipped
+	engine.evictIdle(ruleTestStart.Add(200*time.Second), time.Hour)
+	if !engine.hasState(rule.RuleID, recent) {
+		t.Errorf("eviction ran again within the interval")
+	}
+}
+
+func TestRuleEngineReset(t *testing.T) {
+	engine := newRuleEngine()
+	rule, other := newLowO2Rule(0, 0, 0), newLowO2Rule(0, 0, 0)
+	patientID := uuid.New()
+
+	engine.evaluate(rule, newO2Reading(patientID, 0, 88))
+	engine.evaluate(other, newO2Reading(patientID, 0, 88))
+	engine.reset(rule.RuleID)
+
+	if engine.hasState(rule.RuleID, patientID) {
+		t.Errorf("state of the reset rule kept")
+	}
+	if !engine.hasState(other.RuleID, patientID) {
+		t.Errorf("state of another rule forgotten")
+	}
+	if !engine.evaluate(rule, newO2Reading(patientID, 10, 88)) {
+		t.Errorf("reset rule did not fire again")
+	}
+}
+
+func TestApplicableRules(t *testing.T) {
+	patientID, otherPatientID := uuid.New(), uuid.New()
+
+	newRule := func(metric enum.RuleMetric, patientID *uuid.UUID) *models.ThresholdRule {
+		return &models.ThresholdRule{RuleID: uuid.New(), Metric: string(metric), PatientID: patientID}
+	}
+	globalO2 := newRule(enum.RuleMetricO2Saturation, nil)
+	globalHeartRate := newRule(enum.RuleMetricHeartRate, nil)
+	patientO2 := newRule(enum.RuleMetricO2Saturation, &patientID)
+	otherPatientHeartRate := newRule(enum.RuleMetricHeartRate, &otherPatientID)
+
+	tests := []struct {
+		name  string
+		rules []*models.ThresholdRule
+		want  []*models.ThresholdRule
+	}{
+		{
+			name:  "global rules only",
+			rules: []*models.ThresholdRule{globalO2, globalHeartRate},
+			want:  []*models.ThresholdRule{globalO2, globalHeartRate},
+		},
+		{
+			name:  "patient rule overrides the global rule of its metric",
+			rules: []*models.ThresholdRule{globalO2, globalHeartRate, patientO2},
+			want:  []*models.ThresholdRule{globalHeartRate, patientO2},
+		},
+		{
+			name:  "rules of other patients are skipped and do not override",
+			rules: []*models.ThresholdRule{globalO2, globalHeartRate, otherPatientHeartRate},
+			want:  []*models.ThresholdRule{globalO2, globalHeartRate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applicableRules(tt.rules, patientID)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d rules, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("rule %d: got %s, want %s", i, got[i].RuleID, tt.want[i].RuleID)
+				}
+			}
+		})
+	}
+}
+
+func equalInts(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,372 @@
+package service
+
+import (
+	"biometric-data-backend/enums"
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/redis"
+	"biometric-data-backend/repository"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// serviceAccountClientIDPrefix starts every client ID, so service account tokens are easy to recognize
+	serviceAccountClientIDPrefix = "sa_"
+	// clientCredentialsGrantType is the only OAuth 2.0 grant type supported for service accounts
+	clientCredentialsGrantType = "client_credentials"
+)
+
+var (
+	ErrServiceAccountNotFound      = errors.New("service account not found")
+	ErrServiceAccountRevoked       = errors.New("service account is revoked")
+	ErrInvalidServiceAccountScope  = errors.New("invalid scopes: must be known roles")
+	ErrInvalidServiceAccountExpiry = errors.New("invalid expires_at: must be in the future")
+	// OAuth 2.0 token errors (RFC 6749, section 5.2), their message is the error code
+	ErrInvalidClient        = errors.New("invalid_client")
+	ErrInvalidScope         = errors.New("invalid_scope")
+	ErrUnsupportedGrantType = errors.New("unsupported_grant_type")
+	ErrInvalidTokenRequest  = errors.New("invalid_request")
+)
+
+type ServiceAccountService interface {
+	CreateServiceAccount(accountDTO *dto.ServiceAccountCreateDTO, userID *uuid.UUID) (*dto.ServiceAccountSecretDTO, error)
+	GetServiceAccounts() ([]*dto.ServiceAccountDTO, error)
+	GetServiceAccountByID(id uuid.UUID) (*dto.ServiceAccountDTO, error)
+	RotateSecret(id uuid.UUID) (*dto.ServiceAccountSecretDTO, error)
+	RevokeServiceAccount(id uuid.UUID) error
+	IssueToken(request *dto.ServiceTokenRequestDTO) (*dto.ServiceTokenDTO, error)
+	IsTokenRevoked(claims jwt.MapClaims) (bool, error)
+}
+
+type serviceAccountService struct {
+	repo       repository.ServiceAccountRepository
+	jwtService JWTService
+	cache      *redis.CacheManager
+	tokenTTL   time.Duration
+}
+
+func NewServiceAccountService(repo repository.ServiceAccountRepository, jwtService JWTService, cache *redis.CacheManager, tokenTTL time.Duration) ServiceAccountService {
+	return &serviceAccountService{repo: repo, jwtService: jwtService, cache: cache, tokenTTL: tokenTTL}
+}
+
+// serviceAccountStatus is the part of a service account cached to check its tokens on every request
+type serviceAccountStatus struct {
+	ExpiresAt        *time.Time `json:"expires_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+	TokensValidAfter time.Time  `json:"tokens_valid_after"`
+}
+
+// CreateServiceAccount creates a service account with fixed scopes. The client secret is only returned here.
+func (s *serviceAccountService) CreateServiceAccount(accountDTO *dto.ServiceAccountCreateDTO, userID *uuid.UUID) (*dto.ServiceAccountSecretDTO, error) {
+	scopes, ok := normalizeScopes(accountDTO.Scopes)
+	if !ok {
+		return nil, ErrInvalidServiceAccountScope
+	}
+	if accountDTO.ExpiresAt != nil && !accountDTO.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidServiceAccountExpiry
+	}
+
+	clientID, err := randomToken(serviceAccountClientIDPrefix, 12, hex.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	secret, secretHash, err := newClientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.ServiceAccount{
+		Name:             accountDTO.Name,
+		Description:      accountDTO.Description,
+		ClientID:         clientID,
+		ClientSecretHash: secretHash,
+		Scopes:           strings.Join(scopes, " "),
+		CreatedByID:      userID,
+		ExpiresAt:        accountDTO.ExpiresAt,
+		TokensValidAfter: time.Now().UTC(),
+	}
+	if err := s.repo.Create(account); err != nil {
+		log.Printf("Failed to create service account: %v", err)
+		return nil, err
+	}
+	log.Printf("Service account %s created with ClientID %s", account.Name, account.ClientID)
+
+	return &dto.ServiceAccountSecretDTO{ServiceAccountDTO: *dto.MapServiceAccountToDTO(account), ClientSecret: secret}, nil
+}
+
+// GetServiceAccounts returns all the service accounts, without their secrets
+func (s *serviceAccountService) GetServiceAccounts() ([]*dto.ServiceAccountDTO, error) {
+	accounts, err := s.repo.GetServiceAccounts()
+	if err != nil {
+		log.Printf("Error retrieving service accounts: %v", err)
+		return nil, err
+	}
+	return dto.MapServiceAccountsToDTOs(accounts), nil
+}
+
+// GetServiceAccountByID returns a service account, without its secret
+func (s *serviceAccountService) GetServiceAccountByID(id uuid.UUID) (*dto.ServiceAccountDTO, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapServiceAccountToDTO(account), nil
+}
+
+// RotateSecret replaces the client secret of a service account. The tokens issued with the previous secret stop
+// working right away.
+func (s *serviceAccountService) RotateSecret(id uuid.UUID) (*dto.ServiceAccountSecretDTO, error) {
+	account, err := s.getServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if account.RevokedAt != nil {
+		return nil, ErrServiceAccountRevoked
+	}
+
+	secret, secretHash, err := newClientSecret()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if err := s.repo.UpdateSecret(id, secretHash, now); err != nil {
+		log.Printf("Failed to rotate secret of service account %s: %v", id, err)
+		return nil, err
+	}
+	s.invalidateServiceAccount(id)
+	log.Println("Secret rotated for service account with ServiceAccountID:", id)
+
+	account.TokensValidAfter = now
+	return &dto.ServiceAccountSecretDTO{ServiceAccountDTO: *dto.MapServiceAccountToDTO(account), ClientSecret: secret}, nil
+}
+
+// RevokeServiceAccount revokes a service account: it can no longer get tokens and the issued ones stop working.
+// Revoking a revoked account does nothing.
+func (s *serviceAccountService) RevokeServiceAccount(id uuid.UUID) error {
+	if _, err := s.getServiceAccount(id); err != nil {
+		return err
+	}
+
+	if err := s.repo.Revoke(id, time.Now().UTC()); err != nil {
+		log.Printf("Failed to revoke service account %s: %v", id, err)
+		return err
+	}
+	s.invalidateServiceAccount(id)
+	log.Println("Service account revoked with ServiceAccountID:", id)
+	return nil
+}
+
+// IssueToken exchanges the client credentials of a service account for a token carrying its scopes, or the
+// requested subset of them
+func (s *serviceAccountService) IssueToken(request *dto.ServiceTokenRequestDTO) (*dto.ServiceTokenDTO, error) {
+	if request.GrantType != clientCredentialsGrantType {
+		if request.GrantType == "" {
+			return nil, ErrInvalidTokenRequest
+		}
+		return nil, ErrUnsupportedGrantType
+	}
+	if request.ClientID == "" || request.ClientSecret == "" {
+		return nil, ErrInvalidClient
+	}
+
+	account, err := s.repo.GetByClientID(request.ClientID)
+	if err != nil {
+		log.Printf("Error retrieving service account: %v", err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrInvalidClient
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(account.ClientSecretHash), []byte(request.ClientSecret)); err != nil {
+		log.Println("Incorrect secret for service account:", request.ClientID)
+		return nil, ErrInvalidClient
+	}
+
+	now := time.Now().UTC()
+	if account.RevokedAt != nil || (account.ExpiresAt != nil && !account.ExpiresAt.After(now)) {
+		log.Println("Token requested for inactive service account:", request.ClientID)
+		return nil, ErrInvalidClient
+	}
+
+	scopes := strings.Fields(account.Scopes)
+	if request.Scope != "" {
+		scopes, err = restrictScopes(scopes, strings.Fields(request.Scope))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expiresAt := now.Add(s.tokenTTL)
+	if account.ExpiresAt != nil && account.ExpiresAt.Before(expiresAt) {
+		expiresAt = *account.ExpiresAt
+	}
+
+	token, err := s.jwtService.GenerateToken(account.Name, scopes, expiresAt, map[string]interface{}{
+		"sub":                account.ClientID,
+		"service_account_id": account.ServiceAccountID,
+	})
+	if err != nil {
+		log.Printf("Failed to generate token: %v", err)
+		return nil, err
+	}
+
+	if err := s.repo.TouchLastToken(account.ServiceAccountID, now); err != nil {
+		log.Printf("Failed to record token of service account %s: %v", account.ServiceAccountID, err)
+	}
+	log.Println("Token issued to service account:", account.ClientID)
+
+	return &dto.ServiceTokenDTO{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(expiresAt.Sub(now).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// IsTokenRevoked reports whether a service account token belongs to a revoked or expired account, or was issued
+// before its secret was rotated. Tokens of users are never considered revoked here.
+func (s *serviceAccountService) IsTokenRevoked(claims jwt.MapClaims) (bool, error) {
+	rawID, ok := claims["service_account_id"].(string)
+	if !ok {
+		return false, nil
+	}
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return true, nil
+	}
+
+	status, err := s.getServiceAccountStatus(id)
+	if err != nil {
+		return false, err
+	}
+	if status == nil {
+		return true, nil
+	}
+
+	now := time.Now()
+	if status.RevokedAt != nil || (status.ExpiresAt != nil && !status.ExpiresAt.After(now)) {
+		return true, nil
+	}
+
+	issuedAt, err := claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		return true, nil
+	}
+	return issuedAt.Unix() < status.TokensValidAfter.Unix(), nil
+}
+
+func (s *serviceAccountService) getServiceAccountStatus(id uuid.UUID) (*serviceAccountStatus, error) {
+	ctx := context.Background()
+	cacheKey := "service_account:" + id.String()
+
+	var status serviceAccountStatus
+	found, err := s.cache.Get(ctx, cacheKey, &status)
+	if err != nil {
+		log.Printf("Error accessing cache for ServiceAccountID %s: %v", id, err)
+	}
+	if found {
+		return &status, nil
+	}
+
+	account, err := s.repo.GetByID(id, "service_account_id")
+	if err != nil {
+		log.Printf("Error retrieving service account: %v", err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, nil
+	}
+
+	status = serviceAccountStatus{
+		ExpiresAt:        account.ExpiresAt,
+		RevokedAt:        account.RevokedAt,
+		TokensValidAfter: account.TokensValidAfter,
+	}
+	if err := s.cache.Set(ctx, cacheKey, status); err != nil {
+		log.Printf("Failed to cache service account: %v", err)
+	}
+	return &status, nil
+}
+
+func (s *serviceAccountService) getServiceAccount(id uuid.UUID) (*models.ServiceAccount, error) {
+	account, err := s.repo.GetByID(id, "service_account_id")
+	if err != nil {
+		log.Printf("Error retrieving service account: %v", err)
+		return nil, err
+	}
+	if account == nil {
+		return nil, ErrServiceAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *serviceAccountService) invalidateServiceAccount(id uuid.UUID) {
+	_ = s.cache.Delete(context.Background(), "service_account:"+id.String())
+}
+
+// normalizeScopes checks the scopes are known roles and removes duplicates
+func normalizeScopes(scopes []string) ([]string, bool) {
+	seen := make(map[string]bool, len(scopes))
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !enums.RoleEnum(scope).IsValid() {
+			return nil, false
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, len(normalized) > 0
+}
+
+// restrictScopes returns the requested scopes if they were all granted
+func restrictScopes(granted []string, requested []string) ([]string, error) {
+	allowed := make(map[string]bool, len(granted))
+	for _, scope := range granted {
+		allowed[scope] = true
+	}
+
+	restricted := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		if !allowed[scope] {
+			return nil, ErrInvalidScope
+		}
+		restricted = append(restricted, scope)
+	}
+	return restricted, nil
+}
+
+// newClientSecret generates a random client secret and its bcrypt hash
+func newClientSecret() (string, string, error) {
+	secret, err := randomToken("", 32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", "", err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", "", err
+	}
+	return secret, string(hash), nil
+}
+
+// randomToken returns the given prefix followed by size random bytes in the given encoding
+func randomToken(prefix string, size int, encode func([]byte) string) (string, error) {
+	buffer := make([]byte, size)
+	if _, err := rand.Read(buffer); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %w", err)
+	}
+	return prefix + encode(buffer), nil
+}
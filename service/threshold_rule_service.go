@@ -0,0 +1,244 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/repository"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// ruleCacheTTL is how long the enabled rules are kept in memory before being reloaded
+	ruleCacheTTL = 30 * time.Second
+	// ruleStateLookback is how far back the readings are replayed to restore the state of a rule for a patient,
+	// and how long the state of a patient without readings is kept
+	ruleStateLookback = time.Hour
+	// ruleStateEvictionInterval is how often the states of idle patients are forgotten
+	ruleStateEvictionInterval = 5 * time.Minute
+)
+
+type ThresholdRuleService interface {
+	CreateThresholdRule(ruleDTO *dto.ThresholdRuleCreateDTO) (*dto.ThresholdRuleDTO, error)
+	GetThresholdRuleByID(id uuid.UUID) (*dto.ThresholdRuleDTO, error)
+	GetAllThresholdRules() ([]*dto.ThresholdRuleDTO, error)
+	UpdateThresholdRule(id uuid.UUID, ruleDTO *dto.ThresholdRuleUpdateDTO) error
+	DeleteThresholdRule(id uuid.UUID) error
+	EvaluateReadings(deviceID string, readings []*models.VitalReading)
+}
+
+type thresholdRuleService struct {
+	repo             repository.ThresholdRuleRepository
+	alertRepo        repository.AlertRepository
+	vitalReadingRepo repository.VitalReadingRepository
+	alertService     AlertService
+	engine           *ruleEngine
+
+	mu            sync.Mutex
+	rules         []*models.ThresholdRule
+	rulesLoadedAt time.Time
+}
+
+func NewThresholdRuleService(
+	repo repository.ThresholdRuleRepository,
+	alertRepo repository.AlertRepository,
+	vitalReadingRepo repository.VitalReadingRepository,
+	alertService AlertService,
+) ThresholdRuleService {
+	return &thresholdRuleService{
+		repo:             repo,
+		alertRepo:        alertRepo,
+		vitalReadingRepo: vitalReadingRepo,
+		alertService:     alertService,
+		engine:           newRuleEngine(),
+	}
+}
+
+func (s *thresholdRuleService) CreateThresholdRule(ruleDTO *dto.ThresholdRuleCreateDTO) (*dto.ThresholdRuleDTO, error) {
+	rule := dto.MapCreateDTOToThresholdRule(ruleDTO)
+
+	err := s.repo.Create(rule)
+	if err != nil {
+		log.Printf("Failed to create threshold rule: %v", err)
+		return nil, err
+	}
+	log.Println("Threshold rule created successfully with RuleID:", rule.RuleID)
+
+	s.invalidateRules()
+	return dto.MapThresholdRuleToDTO(rule), nil
+}
+
+func (s *thresholdRuleService) GetThresholdRuleByID(id uuid.UUID) (*dto.ThresholdRuleDTO, error) {
+	log.Println("Fetching threshold rule with RuleID:", id)
+	rule, err := s.repo.GetByID(id, "rule_id")
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		log.Println("No threshold rule found with RuleID:", id)
+		return nil, nil
+	}
+	return dto.MapThresholdRuleToDTO(rule), nil
+}
+
+func (s *thresholdRuleService) GetAllThresholdRules() ([]*dto.ThresholdRuleDTO, error) {
+	log.Println("Fetching all threshold rules")
+	rules, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return dto.MapThresholdRulesToDTOs(rules), nil
+}
+
+func (s *thresholdRuleService) UpdateThresholdRule(id uuid.UUID, ruleDTO *dto.ThresholdRuleUpdateDTO) error {
+	log.Println("Updating threshold rule with RuleID:", id)
+
+	rule, err := s.repo.GetByID(id, "rule_id")
+	if err != nil {
+		log.Printf("Error retrieving threshold rule: %v", err)
+		return err
+	}
+	if rule == nil {
+		log.Printf("Threshold rule not found with RuleID: %v", id)
+		return gorm.ErrRecordNotFound
+	}
+
+	rule = dto.MapUpdateDTOToThresholdRule(ruleDTO, rule)
+
+	err = s.repo.UpdateRule(rule)
+	if err != nil {
+		log.Printf("Failed to update threshold rule: %v", err)
+		return err
+	}
+	log.Println("Threshold rule updated successfully with RuleID:", rule.RuleID)
+
+	s.engine.reset(id)
+	s.invalidateRules()
+	return nil
+}
+
+func (s *thresholdRuleService) DeleteThresholdRule(id uuid.UUID) error {
+	log.Println("Deleting threshold rule with RuleID:", id)
+	err := s.repo.Delete(id, "rule_id")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Println("Threshold rule not found with RuleID:", id)
+			return nil
+		}
+		log.Printf("Failed to delete threshold rule: %v", err)
+		return err
+	}
+	log.Println("Threshold rule deleted successfully with RuleID:", id)
+
+	s.engine.reset(id)
+	s.invalidateRules()
+	return nil
+}
+
+// EvaluateReadings runs the rules of each patient against the readings of a device and raises an alert for every
+// rule that fires. The rules of a patient override the global rules of the same metric.
+func (s *thresholdRuleService) EvaluateReadings(deviceID string, readings []*models.VitalReading) {
+	if len(readings) == 0 {
+		return
+	}
+
+	rules, err := s.getEnabledRules()
+	if err != nil {
+		log.Printf("Failed to load threshold rules: %v", err)
+		return
+	}
+
+	s.engine.evictIdle(time.Now().Add(-ruleStateLookback), ruleStateEvictionInterval)
+
+	sorted := make([]*models.VitalReading, len(readings))
+	copy(sorted, readings)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].RecordedAt.Before(sorted[j].RecordedAt)
+	})
+
+	var patientIDs []uuid.UUID
+	readingsByPatient := make(map[uuid.UUID][]*models.VitalReading)
+	for _, reading := range sorted {
+		if _, ok := readingsByPatient[reading.PatientID]; !ok {
+			patientIDs = append(patientIDs, reading.PatientID)
+		}
+		readingsByPatient[reading.PatientID] = append(readingsByPatient[reading.PatientID], reading)
+	}
+
+	for _, patientID := range patientIDs {
+		patientReadings := readingsByPatient[patientID]
+		for _, rule := range applicableRules(rules, patientID) {
+			if !s.engine.hasState(rule.RuleID, patientID) {
+				s.restoreRuleState(rule, patientID, patientReadings[0].RecordedAt)
+			}
+
+			for _, reading := range patientReadings {
+				if !s.engine.evaluate(rule, reading) {
+					continue
+				}
+
+				log.Printf("Threshold rule %s fired for PatientID %s", rule.Name, patientID)
+				if _, err := s.alertService.CreateAlertFromRule(rule, deviceID, reading.O2Saturation, reading.HeartRate); err != nil {
+					log.Printf("Failed to create alert for threshold rule %s: %v", rule.RuleID, err)
+				}
+			}
+		}
+	}
+}
+
+// restoreRuleState rebuilds the state of a rule for a patient seen for the first time since a restart, or since it
+// was forgotten, from the last alert the rule raised for the patient and the readings stored before the given time.
+// It starts from scratch when they cannot be read.
+func (s *thresholdRuleService) restoreRuleState(rule *models.ThresholdRule, patientID uuid.UUID, before time.Time) {
+	var lastFiredAt time.Time
+	firedAt, err := s.alertRepo.GetLatestRuleAlertTimestamp(rule.RuleID, patientID)
+	if err != nil {
+		log.Printf("Failed to fetch the last alert of threshold rule %s: %v", rule.RuleID, err)
+	} else if firedAt != nil {
+		lastFiredAt = firedAt.UTC()
+	}
+
+	from := before.Add(-max(ruleStateLookback, time.Duration(rule.DurationSeconds)*time.Second))
+	stored, err := s.vitalReadingRepo.GetReadings(patientID, from, before)
+	if err != nil {
+		log.Printf("Failed to fetch the readings of PatientID %s: %v", patientID, err)
+	}
+
+	// The readings being evaluated are already stored
+	previous := make([]*models.VitalReading, 0, len(stored))
+	for _, reading := range stored {
+		if reading.RecordedAt.Before(before) {
+			previous = append(previous, reading)
+		}
+	}
+	s.engine.restore(rule, patientID, lastFiredAt, previous)
+}
+
+func (s *thresholdRuleService) getEnabledRules() ([]*models.ThresholdRule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.rules != nil && time.Since(s.rulesLoadedAt) < ruleCacheTTL {
+		return s.rules, nil
+	}
+
+	rules, err := s.repo.GetEnabledRules()
+	if err != nil {
+		return nil, err
+	}
+	s.rules = rules
+	s.rulesLoadedAt = time.Now()
+	return s.rules, nil
+}
+
+func (s *thresholdRuleService) invalidateRules() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = nil
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"biometric-data-backend/models"
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/redis"
+	"biometric-data-backend/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token already used, the session has been revoked")
+)
+
+// TokenService issues the access and refresh tokens of users and revokes them
+type TokenService interface {
+	IssueTokens(user *models.User) (*dto.AuthTokensDTO, error)
+	RefreshTokens(refreshToken string) (*dto.AuthTokensDTO, error)
+	Logout(claims jwt.MapClaims, allSessions bool) error
+	IssueStreamToken(claims jwt.MapClaims) (*dto.StreamTokenDTO, error)
+	RevokeUserTokens(userID uuid.UUID) error
+	IsTokenRevoked(claims jwt.MapClaims) (bool, error)
+	RunPurge()
+}
+
+type tokenService struct {
+	refreshRepo     repository.RefreshTokenRepository
+	revokedRepo     repository.RevokedTokenRepository
+	userRepo        repository.AuthorizationRepository
+	doctorRepo      repository.DoctorRepository
+	jwtService      JWTService
+	cache           *redis.CacheManager
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	streamTokenTTL  time.Duration
+	purgeInterval   time.Duration
+}
+
+func NewTokenService(
+	refreshRepo repository.RefreshTokenRepository,
+	revokedRepo repository.RevokedTokenRepository,
+	userRepo repository.AuthorizationRepository,
+	doctorRepo repository.DoctorRepository,
+	jwtService JWTService,
+	cache *redis.CacheManager,
+	accessTokenTTL time.Duration,
+	refreshTokenTTL time.Duration,
+	streamTokenTTL time.Duration,
+	purgeInterval time.Duration,
+) TokenService {
+	return &tokenService{
+		refreshRepo:     refreshRepo,
+		revokedRepo:     revokedRepo,
+		userRepo:        userRepo,
+		doctorRepo:      doctorRepo,
+		jwtService:      jwtService,
+		cache:           cache,
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+		streamTokenTTL:  streamTokenTTL,
+		purgeInterval:   purgeInterval,
+	}
+}
+
+// IssueTokens starts a new session for a user who just logged in
+func (s *tokenService) IssueTokens(user *models.User) (*dto.AuthTokensDTO, error) {
+	tx := s.refreshRepo.BeginTransaction()
+	tokens, err := s.issueTokensInTransaction(user, uuid.New(), tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Transaction commit failed: %v", err)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new access token and a new refresh token of the same session.
+// A refresh token can only be used once: using it again revokes the whole session, as either the user or an
+// attacker holds a stolen copy.
+func (s *tokenService) RefreshTokens(refreshToken string) (*dto.AuthTokensDTO, error) {
+	now := time.Now().UTC()
+	tx := s.refreshRepo.BeginTransaction()
+
+	token, err := s.refreshRepo.GetByTokenHashInTransaction(hashRefreshToken(refreshToken), tx)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error retrieving refresh token: %v", err)
+		return nil, err
+	}
+	if token == nil || token.RevokedAt != nil || !token.ExpiresAt.After(now) {
+		tx.Rollback()
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if token.UsedAt != nil {
+		if err := s.refreshRepo.RevokeFamilyInTransaction(token.FamilyID, now, tx); err != nil {
+			tx.Rollback()
+			log.Printf("Failed to revoke refresh token family %s: %v", token.FamilyID, err)
+			return nil, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			log.Printf("Transaction commit failed: %v", err)
+			return nil, err
+		}
+		log.Printf("Refresh token reused for UserID %s, session %s revoked", token.UserID, token.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.GetUserByID(token.UserID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		log.Printf("Error retrieving user: %v", err)
+		return nil, err
+	}
+
+	if err := s.refreshRepo.MarkUsedInTransaction(token.RefreshTokenID, now, tx); err != nil {
+		tx.Rollback()
+		log.Printf("Failed to mark refresh token as used: %v", err)
+		return nil, err
+	}
+	tokens, err := s.issueTokensInTransaction(user, token.FamilyID, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("Transaction commit failed: %v", err)
+		return nil, err
+	}
+
+	log.Println("Tokens refreshed for UserID:", user.UserID)
+	return tokens, nil
+}
+
+// streamTokenClaims are the claims of an access token carried over to the stream tokens issued for it, so they
+// identify the same caller and are revoked along with it
+var streamTokenClaims = []string{"user_id", "doctor_id", "service_account_id", "sid", "tv"}
+
+// IssueStreamToken issues a short-lived token for the caller of the given access token, only accepted to open the
+// alert stream. It never outlives the access token.
+func (s *tokenService) IssueStreamToken(claims jwt.MapClaims) (*dto.StreamTokenDTO, error) {
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.streamTokenTTL)
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil && exp.Before(expiresAt) {
+		expiresAt = exp.Time
+	}
+
+	var roles []string
+	if claimedRoles, ok := claims["roles"].([]interface{}); ok {
+		for _, role := range claimedRoles {
+			if name, ok := role.(string); ok {
+				roles = append(roles, name)
+			}
+		}
+	}
+	email, _ := claims["email"].(string)
+
+	streamClaims := map[string]interface{}{"use": dto.StreamTokenUse}
+	for _, claim := range streamTokenClaims {
+		if value, ok := claims[claim]; ok {
+			streamClaims[claim] = value
+		}
+	}
+
+	token, err := s.jwtService.GenerateToken(email, roles, expiresAt, streamClaims)
+	if err != nil {
+		log.Printf("Failed to generate stream token: %v", err)
+		return nil, err
+	}
+	return &dto.StreamTokenDTO{
+		Token:     token,
+		ExpiresIn: int64(expiresAt.Sub(now).Seconds()),
+	}, nil
+}
+
+// Logout revokes the access token of the request and the refresh tokens of its session, or every token of the
+// user when allSessions is set
+func (s *tokenService) Logout(claims jwt.MapClaims, allSessions bool) error {
+	userID, hasUser := userIDFromClaims(claims)
+
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		expiresAt, err := claims.GetExpirationTime()
+		if err != nil || expiresAt == nil {
+			expiresAt = jwt.NewNumericDate(time.Now().Add(s.accessTokenTTL))
+		}
+		revoked := &models.RevokedToken{JTI: jti, ExpiresAt: expiresAt.UTC()}
+		if hasUser {
+			revoked.UserID = &userID
+		}
+		if err := s.revokedRepo.Revoke(revoked); err != nil {
+			log.Printf("Failed to revoke token %s: %v", jti, err)
+			return err
+		}
+	}
+
+	if sid, ok := claims["sid"].(string); ok {
+		if familyID, err := uuid.Parse(sid); err == nil {
+			if err := s.refreshRepo.RevokeFamily(familyID, time.Now().UTC()); err != nil {
+				log.Printf("Failed to revoke refresh token family %s: %v", familyID, err)
+				return err
+			}
+		}
+	}
+
+	if allSessions && hasUser {
+		return s.RevokeUserTokens(userID)
+	}
+	return nil
+}
+
+// RevokeUserTokens revokes every access and refresh token of a user, e.g. once the password or roles changed
+func (s *tokenService) RevokeUserTokens(userID uuid.UUID) error {
+	if err := s.userRepo.IncrementTokenVersion(userID); err != nil {
+		log.Printf("Failed to revoke access tokens of UserID %s: %v", userID, err)
+		return err
+	}
+	_ = s.cache.Delete(context.Background(), "user_tokens:"+userID.String())
+
+	if err := s.refreshRepo.RevokeUserTokens(userID, time.Now().UTC()); err != nil {
+		log.Printf("Failed to revoke refresh tokens of UserID %s: %v", userID, err)
+		return err
+	}
+
+	log.Println("Tokens revoked for UserID:", userID)
+	return nil
+}
+
+// IsTokenRevoked reports whether a token was revoked at logout, or is a user token issued before the tokens of
+// the user were revoked. Tokens of deleted users are revoked too.
+func (s *tokenService) IsTokenRevoked(claims jwt.MapClaims) (bool, error) {
+	if jti, ok := claims["jti"].(string); ok && jti != "" {
+		revoked, err := s.revokedRepo.IsRevoked(jti)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	userID, ok := userIDFromClaims(claims)
+	if !ok {
+		return false, nil
+	}
+
+	tokenVersion, found, err := s.getTokenVersion(userID)
+	if err != nil {
+		return false, err
+	}
+	if !found {
+		return true, nil
+	}
+
+	// Tokens issued before token versions existed count as version 0
+	claimedVersion, _ := claims["tv"].(float64)
+	return int(claimedVersion) < tokenVersion, nil
+}
+
+// RunPurge periodically deletes the expired refresh tokens and revoked token IDs, they are of no use anymore
+func (s *tokenService) RunPurge() {
+	ticker := time.NewTicker(s.purgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now().UTC()
+		if count, err := s.refreshRepo.DeleteExpired(now); err != nil {
+			log.Printf("Failed to purge expired refresh tokens: %v", err)
+		} else if count > 0 {
+			log.Printf("Purged %d expired refresh tokens", count)
+		}
+		if count, err := s.revokedRepo.DeleteExpired(now); err != nil {
+			log.Printf("Failed to purge expired revoked tokens: %v", err)
+		} else if count > 0 {
+			log.Printf("Purged %d expired revoked tokens", count)
+		}
+	}
+}
+
+func (s *tokenService) issueTokensInTransaction(user *models.User, familyID uuid.UUID, tx *gorm.DB) (*dto.AuthTokensDTO, error) {
+	now := time.Now().UTC()
+
+	refreshToken, err := randomToken("", 32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshRepo.CreateInTransaction(&models.RefreshToken{
+		UserID:    user.UserID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(refreshToken),
+		ExpiresAt: now.Add(s.refreshTokenTTL),
+	}, tx); err != nil {
+		log.Printf("Failed to store refresh token: %v", err)
+		return nil, err
+	}
+
+	claims := map[string]interface{}{
+		"user_id": user.UserID,
+		"sid":     familyID,
+		"tv":      user.TokenVersion,
+	}
+	// Doctors act as themselves, e.g. when attending alerts
+	doctor, err := s.doctorRepo.GetDoctorByUserID(user.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to fetch doctor of UserID %s: %v", user.UserID, err)
+		return nil, err
+	}
+	if doctor != nil {
+		claims["doctor_id"] = doctor.DoctorID
+	}
+
+	accessToken, err := s.jwtService.GenerateToken(user.Username, dto.MapRolesToNames(user.Roles), now.Add(s.accessTokenTTL), claims)
+	if err != nil {
+		log.Printf("Failed to generate token: %v", err)
+		return nil, err
+	}
+
+	return &dto.AuthTokensDTO{
+		Token:        accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+// getTokenVersion returns the current token version of a user, found is false when the user does not exist
+func (s *tokenService) getTokenVersion(userID uuid.UUID) (int, bool, error) {
+	ctx := context.Background()
+	cacheKey := "user_tokens:" + userID.String()
+
+	var tokenVersion int
+	found, err := s.cache.Get(ctx, cacheKey, &tokenVersion)
+	if err != nil {
+		log.Printf("Error accessing cache for UserID %s: %v", userID, err)
+	}
+	if found {
+		return tokenVersion, true, nil
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, nil
+		}
+		log.Printf("Error retrieving user: %v", err)
+		return 0, false, err
+	}
+
+	if err := s.cache.Set(ctx, cacheKey, user.TokenVersion); err != nil {
+		log.Printf("Failed to cache token version: %v", err)
+	}
+	return user.TokenVersion, true, nil
+}
+
+func userIDFromClaims(claims jwt.MapClaims) (uuid.UUID, bool) {
+	rawID, ok := claims["user_id"].(string)
+	if !ok {
+		return uuid.Nil, false
+	}
+	userID, err := uuid.Parse(rawID)
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+func hashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"biometric-data-backend/models/dto"
+	"biometric-data-backend/models/enum"
+	"biometric-data-backend/repository"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxReadingClockSkew is how far in the future a reading timestamp may be before the batch is rejected
+const maxReadingClockSkew = time.Minute
+
+const (
+	// defaultVitalsRange is the time range queried when no start is given
+	defaultVitalsRange = 6 * time.Hour
+	// maxVitalsRange bounds the time range of a single vitals query
+	maxVitalsRange = 31 * 24 * time.Hour
+	// targetVitalsPoints is the number of points aimed at when no bucket size is given
+	targetVitalsPoints = 300
+	// maxVitalsPoints bounds the number of buckets of a single vitals query
+	maxVitalsPoints = 5000
+)
+
+// vitalsBucketSizes are the bucket sizes picked from when no bucket size is given
+var vitalsBucketSizes = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second,
+	time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+var (
+	ErrDeviceNotFound       = errors.New("monitoring device not found")
+	ErrDeviceNotInUse       = errors.New("monitoring device is not in use nor connecting")
+	ErrDeviceWithoutPatient = errors.New("monitoring device is not linked to a patient")
+	ErrReadingInFuture      = errors.New("reading timestamp is in the future")
+	ErrPatientNotFound      = errors.New("patient not found")
+	ErrInvalidVitalsRange   = errors.New("invalid time range: 'from' must be before 'to' and span at most 31 days")
+	ErrInvalidVitalsMetric  = errors.New("invalid metric: must be 'o2_saturation' or 'heart_rate'")
+	ErrInvalidVitalsBucket  = errors.New("invalid bucket: must be a whole number of seconds yielding at most 5000 buckets")
+)
+
+type VitalReadingService interface {
+	CreateReadings(deviceID string, batchDTO *dto.VitalReadingBatchCreateDTO) (*dto.VitalReadingBatchResponseDTO, error)
+	GetPatientVitals(patientID uuid.UUID, query dto.VitalsQuery) (*dto.PatientVitalsDTO, error)
+}
+
+type vitalReadingService struct {
+	repo                 repository.VitalReadingRepository
+	monitoringDeviceRepo repository.MonitoringDeviceRepository
+	deviceService        MonitoringDeviceService
+	deviceHealthService  DeviceHealthService
+	patientRepo          repository.PatientRepository
+	thresholdRuleService ThresholdRuleService
+}
+
+func NewVitalReadingService(repo repository.VitalReadingRepository, monitoringDeviceRepo repository.MonitoringDeviceRepository, deviceService MonitoringDeviceService, deviceHealthService DeviceHealthService, patientRepo repository.PatientRepository, thresholdRuleService ThresholdRuleService) VitalReadingService {
+	return &vitalReadingService{
+		repo:                 repo,
+		monitoringDeviceRepo: monitoringDeviceRepo,
+		deviceService:        deviceService,
+		deviceHealthService:  deviceHealthService,
+		patientRepo:          patientRepo,
+		thresholdRuleService: thresholdRuleService,
+	}
+}
+
+// CreateReadings stores a batch of readings sent by a device against the patient it is currently linked to
+func (s *vitalReadingService) CreateReadings(deviceID string, batchDTO *dto.VitalReadingBatchCreateDTO) (*dto.VitalReadingBatchResponseDTO, error) {
+	device, err := s.monitoringDeviceRepo.GetMonitoringDeviceByID(deviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDeviceNotFound
+		}
+		log.Printf("Failed to fetch monitoring device: %v", err)
+		return nil, err
+	}
+
+	status := enum.DeviceStatus(device.Status)
+	if status != enum.DeviceStatusInUse && status != enum.DeviceStatusConnecting {
+		log.Printf("Rejected readings from DeviceID %s with status %s", deviceID, device.Status)
+		return nil, ErrDeviceNotInUse
+	}
+	if device.PatientID == nil {
+		log.Printf("Rejected readings from DeviceID %s without patient", deviceID)
+		return nil, ErrDeviceWithoutPatient
+	}
+
+	latestAllowed := time.Now().UTC().Add(maxReadingClockSkew)
+	for _, reading := range batchDTO.Readings {
+		if reading.RecordedAt.After(latestAllowed) {
+			return nil, ErrReadingInFuture
+		}
+	}
+
+	readings := dto.MapCreateDTOsToVitalReadings(batchDTO.Readings, device.DeviceID, *device.PatientID)
+	stored, err := s.repo.CreateReadings(readings)
+	if err != nil {
+		log.Printf("Failed to store readings: %v", err)
+		return nil, err
+	}
+
+	// Readings prove the device is alive as much as heartbeats do
+	if err := s.deviceHealthService.RecordActivity(device.DeviceID); err != nil {
+		log.Printf("Failed to record activity of DeviceID %s: %v", device.DeviceID, err)
+	}
+
+	// The first readings of a paired device confirm it is connected
+	if status == enum.DeviceStatusConnecting {
+		if err := s.deviceService.MarkDeviceConnected(device.DeviceID); err != nil {
+			return nil, err
+		}
+	}
+
+	// Rule evaluation raises its own alerts and must not fail the ingestion of the batch
+	s.thresholdRuleService.EvaluateReadings(device.DeviceID, readings)
+
+	return &dto.VitalReadingBatchResponseDTO{
+		DeviceID:  device.DeviceID,
+		PatientID: *device.PatientID,
+		Received:  len(readings),
+		Stored:    stored,
+	}, nil
+}
+
+// GetPatientVitals returns the min/avg/max series of the vitals of a patient, downsampled into time buckets.
+// Missing parameters default to the last 6 hours, both metrics and a bucket size yielding about 300 points.
+func (s *vitalReadingService) GetPatientVitals(patientID uuid.UUID, query dto.VitalsQuery) (*dto.PatientVitalsDTO, error) {
+	to := query.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := query.From
+	if from.IsZero() {
+		from = to.Add(-defaultVitalsRange)
+	}
+	if !from.Before(to) || to.Sub(from) > maxVitalsRange {
+		return nil, ErrInvalidVitalsRange
+	}
+
+	metrics := []string{string(enum.RuleMetricO2Saturation), string(enum.RuleMetricHeartRate)}
+	if query.Metric != "" {
+		if query.Metric != string(enum.RuleMetricO2Saturation) && query.Metric != string(enum.RuleMetricHeartRate) {
+			return nil, ErrInvalidVitalsMetric
+		}
+		metrics = []string{query.Metric}
+	}
+
+	bucket := query.Bucket
+	if bucket == 0 {
+		bucket = vitalsBucketSize(to.Sub(from))
+	}
+	if bucket < time.Second || bucket%time.Second != 0 || int64(to.Sub(from)/bucket) > maxVitalsPoints {
+		return nil, ErrInvalidVitalsBucket
+	}
+
+	exists, err := s.patientRepo.ExistsByID(patientID)
+	if err != nil {
+		log.Printf("Failed to fetch patient: %v", err)
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrPatientNotFound
+	}
+
+	log.Printf("Fetching vitals of PatientID %s from %s to %s in buckets of %s", patientID, from, to, bucket)
+	buckets, err := s.repo.GetBuckets(patientID, from, to, bucket)
+	if err != nil {
+		log.Printf("Failed to fetch vitals: %v", err)
+		return nil, err
+	}
+
+	return &dto.PatientVitalsDTO{
+		PatientID:     patientID,
+		From:          from.UTC(),
+		To:            to.UTC(),
+		BucketSeconds: int64(bucket / time.Second),
+		Series:        dto.MapVitalReadingBucketsToSeries(buckets, metrics),
+	}, nil
+}
+
+// vitalsBucketSize picks the smallest bucket size that keeps the time range within the target number of points
+func vitalsBucketSize(timeRange time.Duration) time.Duration {
+	for _, size := range vitalsBucketSizes {
+		if timeRange/size <= targetVitalsPoints {
+			return size
+		}
+	}
+	return vitalsBucketSizes[len(vitalsBucketSizes)-1]
+}